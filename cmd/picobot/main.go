@@ -12,12 +12,15 @@ import (
 	"github.com/spf13/cobra"
 
 	"path/filepath"
+	"reflect"
+	"slices"
 	"strings"
 
 	"log"
 
 	"github.com/local/picobot/internal/agent"
 	"github.com/local/picobot/internal/agent/memory"
+	"github.com/local/picobot/internal/agent/tools"
 	"github.com/local/picobot/internal/channels"
 	"github.com/local/picobot/internal/chat"
 	"github.com/local/picobot/internal/config"
@@ -81,14 +84,16 @@ func NewRootCmd() *cobra.Command {
 			choice, _ := reader.ReadString('\n')
 			choice = strings.TrimSpace(strings.ToLower(choice))
 
-			cfg, err := config.LoadConfig()
+			cfgPath, _, err := config.ResolveDefaultPaths()
 			if err != nil {
-				fmt.Fprintf(os.Stderr, "failed to load config: %v\n", err)
+				fmt.Fprintf(os.Stderr, "failed to resolve config path: %v\n", err)
 				return
 			}
-			cfgPath, _, err := config.ResolveDefaultPaths()
+			// Read the file as written, so ${VAR} references and environment
+			// overrides aren't saved into it.
+			cfg, err := config.ReadConfigFile(cfgPath)
 			if err != nil {
-				fmt.Fprintf(os.Stderr, "failed to resolve config path: %v\n", err)
+				fmt.Fprintf(os.Stderr, "failed to load config: %v\n", err)
 				return
 			}
 
@@ -116,13 +121,18 @@ func NewRootCmd() *cobra.Command {
 		Run: func(cmd *cobra.Command, args []string) {
 			msg, _ := cmd.Flags().GetString("message")
 			modelFlag, _ := cmd.Flags().GetString("model")
+			timeout, _ := cmd.Flags().GetDuration("timeout")
 			if msg == "" {
 				fmt.Println("Specify a message with -m \"your message\"")
 				return
 			}
 
 			hub := chat.NewHub(100)
-			cfg, _ := config.LoadConfig()
+			cfg, err := config.LoadConfig()
+			if err != nil {
+				fmt.Fprintln(os.Stderr, err)
+				os.Exit(1)
+			}
 			provider := providers.NewProviderFromConfig(cfg)
 
 			// choose model: flag > config default > provider default
@@ -140,20 +150,20 @@ func NewRootCmd() *cobra.Command {
 			}
 			ag := agent.NewAgentLoop(hub, provider, model, maxIter, cfg.Agents.Defaults.Workspace, nil, cfg.MCPServers)
 			defer ag.Close()
-			if cfg.Agents.Defaults.EnableToolActivityIndicator != nil && !*cfg.Agents.Defaults.EnableToolActivityIndicator {
-				ag.SetToolActivityIndicator(false)
-			}
+			configureAgent(ag, cfg)
 
-			resp, err := ag.ProcessDirect(msg, 60*time.Second)
+			resp, err := ag.ProcessDirect(msg, timeout)
 			if err != nil {
 				fmt.Fprintln(cmd.ErrOrStderr(), "error:", err)
-				return
+				ag.Close()
+				os.Exit(1)
 			}
 			fmt.Fprintln(cmd.OutOrStdout(), resp)
 		},
 	}
 	agentCmd.Flags().StringP("message", "m", "", "Message to send to the agent")
 	agentCmd.Flags().StringP("model", "M", "", "Model to use (overrides config/provider default)")
+	agentCmd.Flags().Duration("timeout", 60*time.Second, "Maximum time for the whole query, including tool calls")
 	rootCmd.AddCommand(agentCmd)
 
 	gatewayCmd := &cobra.Command{
@@ -161,8 +171,23 @@ func NewRootCmd() *cobra.Command {
 		Short: "Start long-running gateway (agent, channels, heartbeat)",
 		Run: func(cmd *cobra.Command, args []string) {
 			hub := chat.NewHub(200)
-			cfg, _ := config.LoadConfig()
+			cfg, err := config.LoadConfig()
+			if err != nil {
+				fmt.Fprintln(os.Stderr, err)
+				os.Exit(1)
+			}
+			if q := cfg.Channels.Queue; q != nil {
+				hub.SetQueuePolicy(chat.QueuePolicy{Timeout: time.Duration(q.TimeoutS) * time.Second, Drop: q.Drop})
+			}
+			hub.SetFooter("", cfg.Channels.Footer)
+			for ch, footer := range cfg.Channels.Footers {
+				hub.SetFooter(ch, footer)
+			}
 			provider := providers.NewProviderFromConfig(cfg)
+			if err := checkProvider(cfg, provider); err != nil {
+				fmt.Fprintln(os.Stderr, err)
+				os.Exit(1)
+			}
 
 			// choose model: flag > config > provider default
 			modelFlag, _ := cmd.Flags().GetString("model")
@@ -174,28 +199,60 @@ func NewRootCmd() *cobra.Command {
 				model = provider.GetDefaultModel()
 			}
 
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
 			// create scheduler with fire callback that routes back through the agent loop, so the LLM can process the reminder and respond naturally to the user.
+			// Digest jobs skip the conversational turn: the agent fetches, summarizes and posts directly.
+			var ag *agent.AgentLoop
 			scheduler := cron.NewScheduler(func(job cron.Job) {
+				if job.Kind == cron.KindDigest {
+					go func() {
+						if err := ag.RunDigest(ctx, job); err != nil {
+							log.Printf("cron: %v", err)
+						}
+					}()
+					return
+				}
 				log.Printf("cron fired: %s — %s", job.Name, job.Message)
-				hub.In <- chat.Inbound{
+				if err := hub.Receive(ctx, chat.Inbound{
 					Channel:  job.Channel,
 					SenderID: "cron",
 					ChatID:   job.ChatID,
 					Content:  fmt.Sprintf("[Scheduled reminder fired] %s — Please relay this to the user in a friendly way.", job.Message),
+				}); err != nil {
+					log.Printf("cron: dropping reminder %q: %v", job.Name, err)
 				}
 			})
 
+			if qh := cfg.Agents.Defaults.QuietHours; qh != nil {
+				q, err := cron.ParseQuietHours(qh.Start, qh.End, qh.Timezone)
+				if err != nil {
+					fmt.Fprintf(os.Stderr, "ignoring quietHours: %v\n", err)
+				} else {
+					q.Drop = qh.Action == "drop"
+					scheduler.SetQuietHours(q)
+				}
+			}
+
 			maxIter := cfg.Agents.Defaults.MaxToolIterations
 			if maxIter <= 0 {
 				maxIter = 100
 			}
-			ag := agent.NewAgentLoop(hub, provider, model, maxIter, cfg.Agents.Defaults.Workspace, scheduler, cfg.MCPServers)
+			ag = agent.NewAgentLoop(hub, provider, model, maxIter, cfg.Agents.Defaults.Workspace, scheduler, cfg.MCPServers)
 			defer ag.Close()
-			if cfg.Agents.Defaults.EnableToolActivityIndicator != nil && !*cfg.Agents.Defaults.EnableToolActivityIndicator {
-				ag.SetToolActivityIndicator(false)
+			configureAgent(ag, cfg)
+			if t := cfg.Agents.Defaults.TurnTimeoutS; t > 0 {
+				ag.SetTurnTimeout(time.Duration(t) * time.Second)
+			}
+			ag.SetAskUserTimeout(time.Duration(cfg.Agents.Defaults.AskUserTimeoutS) * time.Second)
+			if sm := cfg.Agents.Defaults.Summarize; sm != nil {
+				ag.SetSummaryPolicy(agent.SummaryPolicy{EveryTurns: sm.EveryTurns, MaxHistoryTokens: sm.MaxHistoryTokens, Model: sm.Model})
+			}
+			ag.SetReplyToMessage(replyToChannels(cfg.Channels))
+			if ev := cfg.Agents.Defaults.Events; ev != nil {
+				ag.SetEventSink(agent.NewWebhookSink(ctx, ev.Webhook, ev.Headers), ev.Redact == nil || *ev.Redact)
 			}
-			ctx, cancel := context.WithCancel(context.Background())
-			defer cancel()
 
 			// start agent loop
 			go ag.Run(ctx)
@@ -219,14 +276,16 @@ func NewRootCmd() *cobra.Command {
 
 			// start discord if enabled
 			if cfg.Channels.Discord.Enabled {
-				if err := channels.StartDiscord(ctx, hub, cfg.Channels.Discord.Token, cfg.Channels.Discord.AllowFrom); err != nil {
+				if err := channels.StartDiscord(ctx, hub, cfg.Channels.Discord.Token, cfg.Channels.Discord.AllowFrom, channels.NewMediaGuard(cfg.Channels.InboundMedia),
+					discordTrigger(cfg.Channels.Discord)); err != nil {
 					fmt.Fprintf(os.Stderr, "failed to start discord: %v\n", err)
 				}
 			}
 
 			// start slack if enabled
 			if cfg.Channels.Slack.Enabled {
-				if err := channels.StartSlack(ctx, hub, cfg.Channels.Slack.AppToken, cfg.Channels.Slack.BotToken, cfg.Channels.Slack.AllowUsers, cfg.Channels.Slack.AllowChannels); err != nil {
+				if err := channels.StartSlack(ctx, hub, cfg.Channels.Slack.AppToken, cfg.Channels.Slack.BotToken, cfg.Channels.Slack.AllowUsers, cfg.Channels.Slack.AllowChannels, channels.NewMediaGuard(cfg.Channels.InboundMedia),
+					channels.Trigger{RequireInDMs: cfg.Channels.Slack.RequireMentionInDMs, Words: cfg.Channels.Slack.TriggerWords}); err != nil {
 					fmt.Fprintf(os.Stderr, "failed to start slack: %v\n", err)
 				}
 			}
@@ -247,12 +306,26 @@ func NewRootCmd() *cobra.Command {
 				}
 			}
 
+			// start email if enabled
+			if cfg.Channels.Email.Enabled {
+				if err := channels.StartEmail(ctx, hub, cfg.Channels.Email); err != nil {
+					fmt.Fprintf(os.Stderr, "failed to start email: %v\n", err)
+				}
+			}
+
 			// start hub router after all channels have subscribed.
 			// This routes outbound messages from hub.Out to each channel's
 			// dedicated queue, preventing competing reads when multiple channels
 			// are active simultaneously.
 			hub.StartRouter(ctx)
 
+			// apply config file edits that are safe to change while running
+			if cfgPath, _, err := config.ResolveDefaultPaths(); err == nil {
+				go config.Watch(ctx, cfgPath, cfg, 2*time.Second, func(old, cfg config.Config) {
+					applyConfigReload(ag, provider, modelFlag, old, cfg)
+				})
+			}
+
 			// wait for signal
 			sigCh := make(chan os.Signal, 1)
 			signal.Notify(sigCh, syscall.SIGINT, syscall.SIGTERM)
@@ -675,3 +748,191 @@ func setupWhatsAppInteractive(cfg config.Config, cfgPath string) {
 
 	fmt.Println("\nWhatsApp setup complete! Run 'picobot gateway' to start.")
 }
+
+// checkProvider verifies at startup that the configured provider is reachable
+// and accepts its credentials, so a bad key surfaces now rather than on the
+// first message. It returns an error only for providers marked required.
+func checkProvider(cfg config.Config, provider providers.LLMProvider) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	err := providers.CheckConnectivity(ctx, provider)
+	if err == nil {
+		log.Println("provider check: OK")
+		return nil
+	}
+	pc := cfg.Providers.OpenAI
+	if cfg.Providers.Ollama != nil {
+		pc = cfg.Providers.Ollama
+	}
+	if pc != nil && pc.Required {
+		return fmt.Errorf("provider check failed: %w", err)
+	}
+	log.Printf("provider check failed (continuing): %v", err)
+	return nil
+}
+
+// channelToolPolicies converts the channelTools config into agent tool policies.
+func channelToolPolicies(cfg map[string]config.ToolPolicyConfig) map[string]*tools.Policy {
+	if len(cfg) == 0 {
+		return nil
+	}
+	out := make(map[string]*tools.Policy, len(cfg))
+	for ch, p := range cfg {
+		out[ch] = &tools.Policy{Allow: p.Allow, Deny: p.Deny, Message: p.Message}
+	}
+	return out
+}
+
+// applyConfigReload applies the settings of a reloaded config that can
+// change while the gateway runs: the model (unless set with --model),
+// maxTokens, the channels' allow lists and the MCP servers. Other changes
+// are logged as needing a restart.
+func applyConfigReload(ag *agent.AgentLoop, provider providers.LLMProvider, modelFlag string, old, cfg config.Config) {
+	d, od := cfg.Agents.Defaults, old.Agents.Defaults
+	if modelFlag == "" && d.Model != od.Model {
+		model := d.Model
+		if model == "" {
+			model = provider.GetDefaultModel()
+		}
+		ag.SetModel(model)
+		log.Printf("config: model is now %s", model)
+	}
+	if d.MaxTokens != od.MaxTokens {
+		ag.SetMaxTokens(d.MaxTokens)
+		log.Printf("config: maxTokens is now %d", d.MaxTokens)
+	}
+	if !slices.Equal(d.FallbackModels, od.FallbackModels) || d.LongContextModel != od.LongContextModel {
+		ag.SetFallbackModels(d.FallbackModels, d.LongContextModel)
+		log.Printf("config: fallback models are now %v (long context: %q)", d.FallbackModels, d.LongContextModel)
+	}
+	if d.SystemPrompt != od.SystemPrompt || d.SystemPromptFile != od.SystemPromptFile {
+		applySystemPrompt(ag, d)
+		log.Printf("config: system prompt updated")
+	}
+	if d.Temperature != od.Temperature {
+		log.Printf("config: temperature changed, but it is not sent to the provider")
+	}
+	ch := cfg.Channels
+	for name, ids := range map[string][]string{
+		"telegram":       ch.Telegram.AllowFrom,
+		"discord":        ch.Discord.AllowFrom,
+		"slack":          ch.Slack.AllowUsers,
+		"slack:channels": ch.Slack.AllowChannels,
+		"whatsapp":       ch.WhatsApp.AllowFrom,
+		"email":          ch.Email.AllowFrom,
+	} {
+		channels.UpdateAllowList(name, ids)
+	}
+	applyMCPServers(ag, old.MCPServers, cfg.MCPServers)
+	ag.SetConfig(cfg)
+	if sections := config.RestartRequired(old, cfg); len(sections) > 0 {
+		log.Printf("config: restart picobot to apply changes to %s", strings.Join(sections, ", "))
+	}
+}
+
+// applySystemPrompt sets the agent's system prompt from systemPrompt or the
+// file named by systemPromptFile. On error the current prompt is kept.
+func applySystemPrompt(ag *agent.AgentLoop, d config.AgentDefaults) {
+	prompt := d.SystemPrompt
+	if file := d.SystemPromptFile; file != "" {
+		if strings.HasPrefix(file, "~/") {
+			home, _ := os.UserHomeDir()
+			file = filepath.Join(home, file[2:])
+		}
+		b, err := os.ReadFile(file)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "failed to load system prompt: %v\n", err)
+			return
+		}
+		prompt = string(b)
+	}
+	if err := ag.SetSystemPrompt(prompt); err != nil {
+		fmt.Fprintf(os.Stderr, "failed to load system prompt: %v\n", err)
+	}
+}
+
+// discordTrigger builds the Discord channel's Trigger from its config.
+func discordTrigger(dc config.DiscordConfig) channels.Trigger {
+	t := channels.Trigger{RequireInDMs: dc.RequireMentionInDMs, Words: dc.TriggerWords}
+	if dc.RespondToBareMention {
+		t.BareReply = dc.BareMentionReply
+		if t.BareReply == "" {
+			t.BareReply = channels.DefaultBareReply
+		}
+	}
+	return t
+}
+
+// replyToChannels lists the channels configured to reply to the triggering
+// message.
+func replyToChannels(cfg config.ChannelsConfig) []string {
+	var out []string
+	if cfg.Discord.ReplyToMessage {
+		out = append(out, "discord")
+	}
+	if cfg.Telegram.ReplyToMessage {
+		out = append(out, "telegram")
+	}
+	if cfg.Slack.ReplyToMessage {
+		out = append(out, "slack")
+	}
+	return out
+}
+
+// configureAgent applies the agent settings shared by the agent and
+// gateway commands.
+func configureAgent(ag *agent.AgentLoop, cfg config.Config) {
+	if cfg.Agents.Defaults.EnableToolActivityIndicator != nil && !*cfg.Agents.Defaults.EnableToolActivityIndicator {
+		ag.SetToolActivityIndicator(false)
+	}
+	ag.SetChannelToolPolicies(channelToolPolicies(cfg.Agents.Defaults.ChannelTools))
+	ag.SetChannelMaxIterations(cfg.Agents.Defaults.ChannelMaxToolIterations)
+	ag.SetMaxUnknownToolCalls(cfg.Agents.Defaults.MaxUnknownToolCalls)
+	ag.SetGreeting(cfg.Agents.Defaults.Greeting)
+	ag.SetStopSequences(cfg.Agents.Defaults.Stop)
+	ag.SetWebAllowPrivateNetworks(cfg.Agents.Defaults.WebAllowPrivateNetworks)
+	ag.SetExecAllowedPrograms(cfg.Agents.Defaults.ExecAllowedPrograms)
+	ag.SetConfig(cfg)
+	ag.SetFallbackModels(cfg.Agents.Defaults.FallbackModels, cfg.Agents.Defaults.LongContextModel)
+	applySystemPrompt(ag, cfg.Agents.Defaults)
+	ag.SetToolSelection(tools.ToolSelection{Max: cfg.Agents.Defaults.MaxTools, Priority: cfg.Agents.Defaults.ToolPriority})
+	if su := cfg.Agents.Defaults.StatusUpdates; su != nil {
+		ag.SetStatusUpdateTarget(su.Channel, su.ChatID)
+	}
+	if sc := cfg.Agents.Defaults.Secrets; sc != nil {
+		if store, err := tools.LoadSecrets(sc.Env, sc.File); err != nil {
+			fmt.Fprintf(os.Stderr, "failed to load secrets: %v\n", err)
+		} else {
+			ag.SetSecrets(store)
+		}
+	}
+	if d := cfg.Agents.Defaults.Docker; d != nil {
+		ag.SetDockerPolicy(tools.DockerPolicy{Enabled: d.Enabled, AllowedImages: d.AllowedImages, AllowedFlags: d.AllowedFlags, Timeout: time.Duration(d.TimeoutS) * time.Second})
+	}
+}
+
+// applyMCPServers connects MCP servers added or changed between old and
+// cfg and disconnects those removed. A server that fails to connect is
+// reported as unavailable, as at startup.
+func applyMCPServers(ag *agent.AgentLoop, old, cfg map[string]config.MCPServerConfig) {
+	for name := range old {
+		if _, ok := cfg[name]; ok {
+			continue
+		}
+		if err := ag.DisconnectMCPServer(name); err != nil {
+			log.Printf("config: disconnecting MCP server %s: %v", name, err)
+		} else {
+			log.Printf("config: disconnected MCP server %s", name)
+		}
+	}
+	for name, sc := range cfg {
+		if prev, ok := old[name]; ok && reflect.DeepEqual(prev, sc) {
+			continue
+		}
+		if err := ag.ConnectMCPServer(name, sc); err != nil {
+			log.Printf("config: connecting MCP server %s: %v", name, err)
+		} else {
+			log.Printf("config: connected MCP server %s", name)
+		}
+	}
+}
@@ -2,6 +2,8 @@ package main
 
 import (
 	"bytes"
+	"net/http"
+	"net/http/httptest"
 	"os"
 	"path/filepath"
 	"strings"
@@ -9,6 +11,7 @@ import (
 
 	"github.com/local/picobot/internal/agent/memory"
 	"github.com/local/picobot/internal/config"
+	"github.com/local/picobot/internal/providers"
 )
 
 func TestMemoryCLI_ReadAppendWriteRecent(t *testing.T) {
@@ -148,3 +151,23 @@ func TestAgentCLI_ModelFlag(t *testing.T) {
 		t.Fatalf("expected stub echo output, got: %q", out)
 	}
 }
+
+func TestCheckProviderRequired(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+	}))
+	defer srv.Close()
+
+	cfg := config.Config{}
+	cfg.Providers.OpenAI = &config.ProviderConfig{APIKey: "bad", APIBase: srv.URL}
+	provider := providers.NewProviderFromConfig(cfg)
+
+	if err := checkProvider(cfg, provider); err != nil {
+		t.Fatalf("optional provider should only log, got %v", err)
+	}
+	cfg.Providers.OpenAI.Required = true
+	err := checkProvider(cfg, provider)
+	if err == nil || !strings.Contains(err.Error(), "rejected the API key") {
+		t.Fatalf("expected startup error for required provider, got %v", err)
+	}
+}
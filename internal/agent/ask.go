@@ -0,0 +1,64 @@
+package agent
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/local/picobot/internal/chat"
+)
+
+// defaultAskTimeout is how long ask_user waits for the user's answer.
+const defaultAskTimeout = 5 * time.Minute
+
+// SetAskUserTimeout sets how long ask_user waits for an answer before the
+// turn carries on without one. d <= 0 keeps the default of 5 minutes. The
+// turn timeout, if set, still bounds the whole turn.
+func (a *AgentLoop) SetAskUserTimeout(d time.Duration) {
+	if d > 0 {
+		a.askTimeout = d
+	}
+}
+
+// askUser sends question to a conversation and waits for its next message,
+// which is the answer. It runs inside a turn on Run's goroutine, so it reads
+// the inbound queue itself; messages for other conversations are set aside
+// and handled, in order, once the turn is over.
+func (a *AgentLoop) askUser(ctx context.Context, channel, chatID, question string) (string, error) {
+	if channel == "" || isSystemChannel(channel) || (channel == "cli" && chatID == "direct") {
+		return "", fmt.Errorf("nobody can answer questions here; make a reasonable assumption and say what it was")
+	}
+	if err := a.hub.Send(ctx, chat.Outbound{Channel: channel, ChatID: chatID, Content: question}); err != nil {
+		return "", err
+	}
+	timer := time.NewTimer(a.askTimeout)
+	defer timer.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return "", ctx.Err()
+		case <-timer.C:
+			return "", fmt.Errorf("no answer within %s; carry on with a reasonable assumption and say what it was", a.askTimeout)
+		case msg, ok := <-a.hub.In:
+			if !ok {
+				return "", fmt.Errorf("inbound channel closed")
+			}
+			if msg.Channel == channel && msg.ChatID == chatID {
+				return msg.Content, nil
+			}
+			a.deferred = append(a.deferred, msg)
+		}
+	}
+}
+
+// next returns the channel Run takes its next message from: messages set
+// aside while a turn waited on ask_user come first.
+func (a *AgentLoop) next() <-chan chat.Inbound {
+	if len(a.deferred) == 0 {
+		return a.hub.In
+	}
+	ch := make(chan chat.Inbound, 1)
+	ch <- a.deferred[0]
+	a.deferred = a.deferred[1:]
+	return ch
+}
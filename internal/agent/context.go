@@ -6,6 +6,9 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
+	"sync"
+	"text/template"
+	"time"
 
 	"github.com/local/picobot/internal/agent/memory"
 	"github.com/local/picobot/internal/agent/skills"
@@ -18,6 +21,26 @@ type ContextBuilder struct {
 	ranker       memory.Ranker
 	topK         int
 	skillsLoader *skills.Loader
+	// unavailable lists MCP servers that failed to connect, as "name: reason".
+	// Guarded by mu since servers can be (re)connected at runtime.
+	mu          sync.Mutex
+	unavailable []string
+	// prompt replaces defaultSystemPrompt when set; guarded by mu.
+	prompt *template.Template
+}
+
+// defaultSystemPrompt opens the system message unless SetSystemPrompt
+// replaced it.
+const defaultSystemPrompt = "You are Picobot, a helpful assistant."
+
+// promptData is what a custom system prompt's template can refer to, e.g.
+// "Today is {{.Weekday}}, {{.Date}}. You are chatting on {{.Channel}}."
+type promptData struct {
+	Date    string // 2006-01-02
+	Time    string // 15:04
+	Weekday string
+	Channel string
+	ChatID  string
 }
 
 func NewContextBuilder(workspace string, r memory.Ranker, topK int) *ContextBuilder {
@@ -29,13 +52,64 @@ func NewContextBuilder(workspace string, r memory.Ranker, topK int) *ContextBuil
 	}
 }
 
+// SetUnavailableServers tells the model which MCP servers failed to connect,
+// so it can explain why their tools are missing instead of guessing.
+func (cb *ContextBuilder) SetUnavailableServers(servers []string) {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+	cb.unavailable = servers
+}
+
+// SetSystemPrompt replaces the opening line of the system message with
+// prompt, a text/template over promptData. The workspace files, memory and
+// skills still follow it. An empty prompt restores the default.
+func (cb *ContextBuilder) SetSystemPrompt(prompt string) error {
+	var t *template.Template
+	if strings.TrimSpace(prompt) != "" {
+		var err error
+		t, err = template.New("systemPrompt").Option("missingkey=error").Parse(prompt)
+		if err != nil {
+			return fmt.Errorf("system prompt: %w", err)
+		}
+	}
+	cb.mu.Lock()
+	cb.prompt = t
+	cb.mu.Unlock()
+	return nil
+}
+
+// systemPrompt renders the system prompt for a conversation, falling back to
+// the default if the template fails.
+func (cb *ContextBuilder) systemPrompt(channel, chatID string) string {
+	cb.mu.Lock()
+	t := cb.prompt
+	cb.mu.Unlock()
+	if t == nil {
+		return defaultSystemPrompt
+	}
+	now := time.Now()
+	var sb strings.Builder
+	err := t.Execute(&sb, promptData{
+		Date:    now.Format("2006-01-02"),
+		Time:    now.Format("15:04"),
+		Weekday: now.Weekday().String(),
+		Channel: channel,
+		ChatID:  chatID,
+	})
+	if err != nil {
+		log.Printf("error rendering system prompt, using the default: %v", err)
+		return defaultSystemPrompt
+	}
+	return strings.TrimSpace(sb.String())
+}
+
 func (cb *ContextBuilder) BuildMessages(history []string, currentMessage string, channel, chatID string, memoryContext string, memories []memory.MemoryItem) []providers.Message {
 	msgs := make([]providers.Message, 0, len(history)+2)
 
 	// Combine all system instructions into one message at position 0 to avoid errors in strict chat templates (e.g. llama.cpp)
 	var sysParts []string
 
-	sysParts = append(sysParts, "You are Picobot, a helpful assistant.")
+	sysParts = append(sysParts, cb.systemPrompt(channel, chatID))
 
 	// Load workspace bootstrap files
 	bootstrapFiles := []string{"SOUL.md", "AGENTS.md", "USER.md", "TOOLS.md"}
@@ -59,16 +133,33 @@ func (cb *ContextBuilder) BuildMessages(history []string, currentMessage string,
 	// Memory tool instruction
 	sysParts = append(sysParts, "If you decide something should be remembered, call the tool 'write_memory' with JSON arguments: {\"target\": \"today\"|\"long\", \"content\": \"...\", \"append\": true|false}. Use a tool call rather than plain chat text when writing memory.")
 
-	// Skills context
+	cb.mu.Lock()
+	unavailable := cb.unavailable
+	cb.mu.Unlock()
+	if len(unavailable) > 0 {
+		sysParts = append(sysParts, "These MCP servers failed to connect, so their tools are unavailable:\n- "+
+			strings.Join(unavailable, "\n- ")+
+			"\nIf the user's request needs one of them, tell them that server is unavailable rather than attempting a workaround silently.")
+	}
+
+	// Skills context. Skills are read from disk for every message, so ones
+	// created or edited at runtime apply from the next turn.
 	loadedSkills, err := cb.skillsLoader.LoadAll()
 	if err != nil {
 		log.Printf("error loading skills: %v", err)
 	}
 	if len(loadedSkills) > 0 {
 		var sb strings.Builder
+		var suggested []string
 		sb.WriteString("Available Skills:\n")
 		for _, skill := range loadedSkills {
 			fmt.Fprintf(&sb, "\n## %s\n%s\n\n%s\n", skill.Name, skill.Description, skill.Content)
+			if skill.Matches(currentMessage) {
+				suggested = append(suggested, skill.Name)
+			}
+		}
+		if len(suggested) > 0 {
+			fmt.Fprintf(&sb, "\nThe current message matches the tags or triggers of these skills; follow them if they apply: %s\n", strings.Join(suggested, ", "))
 		}
 		sysParts = append(sysParts, sb.String())
 	}
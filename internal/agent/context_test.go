@@ -1,6 +1,8 @@
 package agent
 
 import (
+	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 
@@ -39,3 +41,25 @@ func TestBuildMessagesIncludesMemories(t *testing.T) {
 		t.Fatalf("expected memory summary to be present in messages: %v", msgs)
 	}
 }
+
+func TestBuildMessagesSuggestsMatchingSkills(t *testing.T) {
+	ws := t.TempDir()
+	dir := filepath.Join(ws, "skills", "release")
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	skill := "---\nname: release\ndescription: Cut a release\ntags: [versioning]\ntrigger: [ship it]\n---\n\nSteps"
+	if err := os.WriteFile(filepath.Join(dir, "SKILL.md"), []byte(skill), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	cb := NewContextBuilder(ws, nil, 5)
+
+	msgs := cb.BuildMessages(nil, "ok, ship it", "cli", "direct", "", nil)
+	if !strings.Contains(msgs[0].Content, "follow them if they apply: release") {
+		t.Fatalf("expected release to be suggested, got %q", msgs[0].Content)
+	}
+	msgs = cb.BuildMessages(nil, "what's the weather", "cli", "direct", "", nil)
+	if strings.Contains(msgs[0].Content, "follow them if they apply") {
+		t.Fatalf("no skill should be suggested, got %q", msgs[0].Content)
+	}
+}
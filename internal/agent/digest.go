@@ -0,0 +1,74 @@
+package agent
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/local/picobot/internal/cron"
+	"github.com/local/picobot/internal/providers"
+)
+
+// maxDigestSource caps how much fetched text is handed to the summarizer.
+const maxDigestSource = 32 * 1024
+
+const digestSystemPrompt = "You write short digests for a chat. Summarize the source text you are given, following the user's instructions. Reply with the digest only."
+
+// RunDigest runs one firing of a digest job: it fetches the job's source,
+// summarizes it with the provider, and posts the result to the job's chat.
+// Each step is separate so other scheduled task types can reuse them.
+func (a *AgentLoop) RunDigest(ctx context.Context, job cron.Job) error {
+	text, err := a.fetchDigestSource(ctx, job.Source)
+	if err != nil {
+		return fmt.Errorf("digest %q: fetching %s: %w", job.Name, job.Source, err)
+	}
+	summary, err := a.summarize(ctx, a.currentModel(), job.Message, text)
+	if err != nil {
+		return fmt.Errorf("digest %q: summarizing: %w", job.Name, err)
+	}
+	sendChannelNotification(a.hub, job.Channel, job.ChatID, fmt.Sprintf("Digest: %s\n\n%s", job.Name, summary))
+	return nil
+}
+
+// fetchDigestSource returns the text of a URL (through the web tool, so the
+// fetch is subject to the same limits as the model's own fetches) or the
+// recent history of a "chat:<channel>:<chatID>" session.
+func (a *AgentLoop) fetchDigestSource(ctx context.Context, source string) (string, error) {
+	if key, ok := strings.CutPrefix(source, "chat:"); ok {
+		history := a.sessions.GetOrCreate(key).GetHistory()
+		if len(history) == 0 {
+			return "", fmt.Errorf("no messages in %s", key)
+		}
+		text := strings.Join(history, "\n")
+		if len(text) > maxDigestSource {
+			text = text[len(text)-maxDigestSource:] // keep the most recent messages
+		}
+		return text, nil
+	}
+	text, err := a.tools.Execute(ctx, "web", map[string]interface{}{"url": source})
+	if err != nil {
+		return "", err
+	}
+	if len(text) > maxDigestSource {
+		text = text[:maxDigestSource]
+	}
+	return text, nil
+}
+
+// summarize asks the provider, without tools, to condense text according to
+// instructions using model.
+func (a *AgentLoop) summarize(ctx context.Context, model, instructions, text string) (string, error) {
+	messages := []providers.Message{
+		{Role: "system", Content: digestSystemPrompt},
+		{Role: "user", Content: instructions + "\n\nSource:\n" + text},
+	}
+	resp, err := a.provider.Chat(providers.WithStop(ctx, a.stop), messages, nil, model)
+	if err != nil {
+		return "", err
+	}
+	summary := sanitizeContent(resp.Content)
+	if summary == "" {
+		return "", fmt.Errorf("empty summary")
+	}
+	return summary, nil
+}
@@ -0,0 +1,193 @@
+package agent
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/local/picobot/internal/chat"
+	"github.com/local/picobot/internal/session"
+)
+
+// Turn event types.
+const (
+	EventTurnStarted   = "turn_started"
+	EventToolCalled    = "tool_called"
+	EventTurnCompleted = "turn_completed"
+	EventTurnError     = "turn_error"
+)
+
+// TurnEvent is a step in the life of a turn, published to an EventSink so
+// picobot can be integrated into a larger system.
+type TurnEvent struct {
+	Type     string    `json:"type"`
+	Time     time.Time `json:"time"`
+	TurnID   string    `json:"turnId"`
+	Channel  string    `json:"channel"`
+	ChatID   string    `json:"chatId"`
+	SenderID string    `json:"senderId,omitempty"`
+	// Content is the user's message for turn_started and the reply for
+	// turn_completed.
+	Content string `json:"content,omitempty"`
+	// Tool, Args and ElapsedMs describe a tool_called event.
+	Tool      string                 `json:"tool,omitempty"`
+	Args      map[string]interface{} `json:"args,omitempty"`
+	ElapsedMs int64                  `json:"elapsedMs,omitempty"`
+	// Status is "finished" or "failed" for tool_called, and "ok", "timeout"
+	// or "cancelled" for turn_completed.
+	Status string `json:"status,omitempty"`
+	// Iterations is how many model calls a completed turn took.
+	Iterations int    `json:"iterations,omitempty"`
+	Error      string `json:"error,omitempty"`
+}
+
+// EventSink receives turn events. Emit is called from the agent loop and
+// must not block; sinks that do slow work should queue it.
+type EventSink interface {
+	Emit(e TurnEvent)
+}
+
+// ChannelSink publishes events to an in-process channel. Events are dropped
+// when the channel is full.
+type ChannelSink chan TurnEvent
+
+func (s ChannelSink) Emit(e TurnEvent) {
+	select {
+	case s <- e:
+	default:
+		log.Println("turn event channel full, dropping event")
+	}
+}
+
+// WebhookSink POSTs each event as JSON to a URL, one at a time in the
+// background. Events are dropped when more than 100 are waiting.
+type WebhookSink struct {
+	url     string
+	headers map[string]string
+	client  *http.Client
+	queue   chan TurnEvent
+}
+
+// NewWebhookSink starts a sink posting to url with the given extra headers
+// until ctx is done.
+func NewWebhookSink(ctx context.Context, url string, headers map[string]string) *WebhookSink {
+	s := &WebhookSink{url: url, headers: headers, client: &http.Client{Timeout: 10 * time.Second}, queue: make(chan TurnEvent, 100)}
+	go s.run(ctx)
+	return s
+}
+
+func (s *WebhookSink) Emit(e TurnEvent) {
+	select {
+	case s.queue <- e:
+	default:
+		log.Println("turn event webhook is behind, dropping event")
+	}
+}
+
+func (s *WebhookSink) run(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case e := <-s.queue:
+			if err := s.post(ctx, e); err != nil {
+				log.Printf("turn event webhook: %v", err)
+			}
+		}
+	}
+}
+
+func (s *WebhookSink) post(ctx context.Context, e TurnEvent) error {
+	b, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, "POST", s.url, bytes.NewReader(b))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range s.headers {
+		req.Header.Set(k, v)
+	}
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("posting %s to %s: %s", e.Type, s.url, resp.Status)
+	}
+	return nil
+}
+
+// secretArgWords mark tool arguments whose values are never published.
+var secretArgWords = []string{"password", "secret", "token", "apikey", "api_key", "authorization", "credential"}
+
+// RedactEvent masks emails, API keys and phone or account numbers in an
+// event's content, error and string arguments, and replaces arguments whose
+// names suggest a secret (e.g. "password") entirely.
+func RedactEvent(e TurnEvent) TurnEvent {
+	e.Content = session.Redact(e.Content)
+	e.Error = session.Redact(e.Error)
+	if e.Args != nil {
+		args := make(map[string]interface{}, len(e.Args))
+		for k, v := range e.Args {
+			args[k] = redactArg(k, v)
+		}
+		e.Args = args
+	}
+	return e
+}
+
+func redactArg(name string, v interface{}) interface{} {
+	lower := strings.ToLower(name)
+	for _, w := range secretArgWords {
+		if strings.Contains(lower, w) {
+			return "[secret]"
+		}
+	}
+	switch v := v.(type) {
+	case string:
+		return session.Redact(v)
+	case map[string]interface{}:
+		m := make(map[string]interface{}, len(v))
+		for k, x := range v {
+			m[k] = redactArg(k, x)
+		}
+		return m
+	case []interface{}:
+		l := make([]interface{}, len(v))
+		for i, x := range v {
+			l[i] = redactArg("", x)
+		}
+		return l
+	}
+	return v
+}
+
+// SetEventSink publishes turn events to sink (nil to stop). With redact set,
+// events pass through RedactEvent first.
+func (a *AgentLoop) SetEventSink(sink EventSink, redact bool) {
+	a.events = sink
+	a.redactEvents = redact
+}
+
+// emit fills in the time and conversation of e and publishes it.
+func (a *AgentLoop) emit(turnID string, msg chat.Inbound, e TurnEvent) {
+	if a.events == nil {
+		return
+	}
+	e.Time = time.Now()
+	e.TurnID = turnID
+	e.Channel, e.ChatID, e.SenderID = msg.Channel, msg.ChatID, msg.SenderID
+	if a.redactEvents {
+		e = RedactEvent(e)
+	}
+	a.events.Emit(e)
+}
@@ -0,0 +1,122 @@
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/local/picobot/internal/chat"
+)
+
+// inflightTurn is persisted under sessions/inflight/ while a conversation's
+// turn runs and removed once its reply is sent. A marker still present at
+// startup means the process stopped mid-turn.
+type inflightTurn struct {
+	Channel   string    `json:"channel"`
+	ChatID    string    `json:"chatId"`
+	MessageID string    `json:"messageId,omitempty"`
+	Content   string    `json:"content"`
+	Started   time.Time `json:"started"`
+}
+
+// interruptedReply tells a user their message was not finished before a
+// restart.
+const interruptedReply = "Sorry, I was restarted while working on your message %q and didn't finish it. Please send it again if you still need it."
+
+func (a *AgentLoop) inflightDir() string {
+	return filepath.Join(a.workspace, "sessions", "inflight")
+}
+
+func (a *AgentLoop) inflightPath(msg chat.Inbound) string {
+	return filepath.Join(a.inflightDir(), msg.Channel+":"+msg.ChatID+".json")
+}
+
+// inboundID identifies a platform message for deduplication, or is empty if
+// the channel doesn't report message IDs.
+func inboundID(msg chat.Inbound) string {
+	id, _ := msg.Metadata["message_id"].(string)
+	if id == "" {
+		return ""
+	}
+	return msg.Channel + ":" + msg.ChatID + ":" + id
+}
+
+// markInflight records that a turn for msg's conversation has started.
+func (a *AgentLoop) markInflight(msg chat.Inbound) {
+	id, _ := msg.Metadata["message_id"].(string)
+	b, err := json.Marshal(inflightTurn{Channel: msg.Channel, ChatID: msg.ChatID, MessageID: id, Content: msg.Content, Started: time.Now()})
+	if err == nil {
+		err = os.MkdirAll(a.inflightDir(), 0o755)
+	}
+	if err == nil {
+		err = os.WriteFile(a.inflightPath(msg), b, 0o644)
+	}
+	if err != nil {
+		log.Printf("error recording turn for %s:%s: %v", msg.Channel, msg.ChatID, err)
+	}
+}
+
+// clearInflight records that the turn for msg's conversation is done.
+func (a *AgentLoop) clearInflight(msg chat.Inbound) {
+	if err := os.Remove(a.inflightPath(msg)); err != nil && !os.IsNotExist(err) {
+		log.Printf("error clearing turn for %s:%s: %v", msg.Channel, msg.ChatID, err)
+	}
+}
+
+// recoverInterrupted handles turns left unfinished by a previous run. Each
+// is aborted rather than redone: the user is told, and if the platform
+// delivers the same message again it is skipped (see skipRedelivered), so a
+// message is never processed twice.
+func (a *AgentLoop) recoverInterrupted(ctx context.Context) {
+	entries, err := os.ReadDir(a.inflightDir())
+	if err != nil {
+		return
+	}
+	for _, e := range entries {
+		path := filepath.Join(a.inflightDir(), e.Name())
+		b, err := os.ReadFile(path)
+		var t inflightTurn
+		if err == nil {
+			err = json.Unmarshal(b, &t)
+		}
+		if err != nil {
+			log.Printf("ignoring unreadable turn marker %s: %v", e.Name(), err)
+			_ = os.Remove(path)
+			continue
+		}
+		log.Printf("turn for %s:%s started %s was interrupted by a restart", t.Channel, t.ChatID, t.Started.Format(time.RFC3339))
+		msg := chat.Inbound{Channel: t.Channel, ChatID: t.ChatID, Metadata: map[string]interface{}{"message_id": t.MessageID}}
+		if id := inboundID(msg); id != "" {
+			a.interrupted[id] = true
+		}
+		out := chat.Outbound{Channel: t.Channel, ChatID: t.ChatID, Content: interruptedNotice(t.Content)}
+		if err := a.hub.Send(ctx, out); err != nil {
+			log.Printf("dropping interrupted-turn notice to %s:%s: %v", t.Channel, t.ChatID, err)
+		}
+		_ = os.Remove(path)
+	}
+}
+
+func interruptedNotice(content string) string {
+	content = strings.Join(strings.Fields(content), " ")
+	if r := []rune(content); len(r) > 60 {
+		content = string(r[:57]) + "..."
+	}
+	return fmt.Sprintf(interruptedReply, content)
+}
+
+// skipRedelivered reports whether msg is a redelivery of a message whose
+// turn was interrupted by a restart, which must not be processed again.
+func (a *AgentLoop) skipRedelivered(msg chat.Inbound) bool {
+	id := inboundID(msg)
+	if id == "" || !a.interrupted[id] {
+		return false
+	}
+	delete(a.interrupted, id)
+	return true
+}
@@ -3,11 +3,13 @@ package agent
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
 	"os"
 	"regexp"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/local/picobot/internal/agent/memory"
@@ -22,14 +24,66 @@ import (
 
 var rememberRE = regexp.MustCompile(`(?i)^remember(?:\s+to)?\s+(.+)$`)
 
+// thinkBlockRE matches reasoning blocks some models emit inline in their
+// content, e.g. <think>...</think>.
+var thinkBlockRE = regexp.MustCompile(`(?is)<think>(.*?)</think>|<thinking>(.*?)</thinking>`)
+
+// sanitizeContent strips inline reasoning from model output.
+func sanitizeContent(s string) string {
+	answer, _ := splitThinking(s)
+	return answer
+}
+
+// splitThinking separates inline reasoning from the answer. It removes
+// complete <think> blocks, anything before a stray closing tag (models that
+// omit the opening tag), and an unterminated trailing block, returning the
+// removed reasoning separately.
+func splitThinking(s string) (answer, thinking string) {
+	var parts []string
+	keep := func(t string) {
+		if t = strings.TrimSpace(t); t != "" {
+			parts = append(parts, t)
+		}
+	}
+	for _, m := range thinkBlockRE.FindAllStringSubmatch(s, -1) {
+		keep(m[1] + m[2])
+	}
+	s = thinkBlockRE.ReplaceAllString(s, "")
+	lower := strings.ToLower(s)
+	for _, tag := range []string{"</think>", "</thinking>"} {
+		if i := strings.LastIndex(lower, tag); i >= 0 {
+			keep(s[:i])
+			s = s[i+len(tag):]
+			lower = lower[i+len(tag):]
+		}
+	}
+	for _, tag := range []string{"<think>", "<thinking>"} {
+		if i := strings.Index(lower, tag); i >= 0 {
+			keep(s[i+len(tag):])
+			s = s[:i]
+			lower = lower[:i]
+		}
+	}
+	return strings.TrimSpace(s), strings.Join(parts, "\n\n")
+}
+
 // sendChannelNotification delivers a non-blocking status message back to the
 // originating channel so the user can see tool progress in real time.
 // It is a no-op for system channels (heartbeat, cron) that have no user-facing chat.
 func sendChannelNotification(hub *chat.Hub, channel, chatID, content string) {
-	if isSystemChannel(channel) {
+	sendNotification(hub, chat.Outbound{Channel: channel, ChatID: chatID, Content: content})
+}
+
+// sendToolActivity is sendChannelNotification for tool progress lines,
+// which channels may skip (see chat.Outbound.Progress).
+func sendToolActivity(hub *chat.Hub, channel, chatID, content string) {
+	sendNotification(hub, chat.Outbound{Channel: channel, ChatID: chatID, Content: content, Progress: true})
+}
+
+func sendNotification(hub *chat.Hub, out chat.Outbound) {
+	if isSystemChannel(out.Channel) {
 		return
 	}
-	out := chat.Outbound{Channel: channel, ChatID: chatID, Content: content}
 	select {
 	case hub.Out <- out:
 	default:
@@ -60,13 +114,49 @@ type AgentLoop struct {
 	sessions           *session.SessionManager
 	context            *ContextBuilder
 	memory             *memory.MemoryStore
+	settingsMu         sync.RWMutex // guards the model settings below, which can change at runtime
 	model              string
+	maxTokens          int      // overrides the provider's limit when > 0
+	fallbackModels     []string // tried in order when the model is overloaded
+	longContextModel   string   // tried when a request exceeds the model's context
+	configInfo         *tools.ConfigInfoTool
 	maxIterations      int
 	running            bool
-	mcpClients         []*mcp.Client
+	mcpManager         *mcp.Manager
+	mcpMu              sync.Mutex
+	mcpTools           map[string]bool // registered MCP tool names
 	enableToolActivity bool
+	turnTimeout        time.Duration
+	ops                *tools.Operations
+	pages              *tools.PageStore
+	channelPolicies    map[string]*tools.Policy
+	channelMaxIter     map[string]int
+	maxUnknownTools    int
+	greeting           string
+	stop               []string
+	summary            SummaryPolicy
+	toolSelection      tools.ToolSelection
+	askTimeout         time.Duration
+	replyToMessage     map[string]bool // channels that reply to the triggering message
+	deferred           []chat.Inbound  // messages set aside while waiting on ask_user
+	turnsSinceSummary  map[string]int  // per conversation key
+	turns              sync.Map        // conversation key -> operation ID of its running turn
+	workspace          string
+	interrupted        map[string]bool // inbound IDs whose turns a restart cut short
+	events             EventSink       // nil unless SetEventSink was called
+	redactEvents       bool
+	usageMu            sync.Mutex
+	usage              map[string]providers.Usage // tokens per conversation key
 }
 
+// defaultMaxUnknownToolCalls is how many calls to non-existent tools a turn
+// may make before it is stopped.
+const defaultMaxUnknownToolCalls = 3
+
+// unknownToolsReply is sent when a turn is stopped for calling non-existent
+// tools too often.
+const unknownToolsReply = "Sorry, I kept trying to use tools that don't exist, so I stopped. Please try rephrasing your request."
+
 // NewAgentLoop creates a new AgentLoop with the given provider.
 func NewAgentLoop(b *chat.Hub, provider providers.LLMProvider, model string, maxIterations int, workspace string, scheduler *cron.Scheduler, mcpServers map[string]config.MCPServerConfig) *AgentLoop {
 	if model == "" {
@@ -78,6 +168,7 @@ func NewAgentLoop(b *chat.Hub, provider providers.LLMProvider, model string, max
 	reg := tools.NewRegistry()
 	// register default tools
 	reg.Register(tools.NewMessageTool(b))
+	reg.Register(tools.NewStatusUpdateTool(b, "", ""))
 
 	// Open an os.Root anchored at the workspace for kernel-enforced sandboxing.
 	root, err := os.OpenRoot(workspace)
@@ -92,6 +183,7 @@ func NewAgentLoop(b *chat.Hub, provider providers.LLMProvider, model string, max
 	reg.Register(fsTool)
 
 	reg.Register(tools.NewExecTool(60))
+	reg.Register(tools.NewDockerTool(tools.DockerPolicy{}))
 	reg.Register(tools.NewWebTool())
 	reg.Register(tools.NewWebSearchTool())
 	reg.Register(tools.NewSpawnTool())
@@ -100,48 +192,105 @@ func NewAgentLoop(b *chat.Hub, provider providers.LLMProvider, model string, max
 	}
 
 	sm := session.NewSessionManager(workspace)
+	reg.Register(tools.NewExportTranscriptTool(sm, root))
 	ctx := NewContextBuilder(workspace, memory.NewLLMRanker(provider, model), 5)
 	mem := memory.NewMemoryStoreWithWorkspace(workspace, 100)
 	// register memory tools (all share the same store instance)
 	reg.Register(tools.NewWriteMemoryTool(mem))
 	reg.Register(tools.NewListMemoryTool(mem))
 	reg.Register(tools.NewReadMemoryTool(mem))
+	reg.Register(tools.NewSearchMemoryTool(mem))
 	reg.Register(tools.NewEditMemoryTool(mem))
 	reg.Register(tools.NewDeleteMemoryTool(mem))
 
 	// register skill management tools (share the same os.Root)
 	skillMgr := tools.NewSkillManager(root)
 	reg.Register(tools.NewCreateSkillTool(skillMgr))
+	reg.Register(tools.NewUpdateSkillTool(skillMgr))
 	reg.Register(tools.NewListSkillsTool(skillMgr))
+	reg.Register(tools.NewSearchSkillsTool(skillMgr))
 	reg.Register(tools.NewReadSkillTool(skillMgr))
 	reg.Register(tools.NewDeleteSkillTool(skillMgr))
 
-	// Connect to configured MCP servers and register their tools.
-	var mcpClients []*mcp.Client
-	for name, cfg := range mcpServers {
-		var client *mcp.Client
-		var err error
-		switch {
-		case cfg.Command != "":
-			client, err = mcp.NewStdioClient(name, cfg.Command, cfg.Args)
-		case cfg.URL != "":
-			client, err = mcp.NewHTTPClient(name, cfg.URL, cfg.Headers)
-		default:
-			log.Printf("MCP server %q: no command or url configured, skipping", name)
-			continue
-		}
-		if err != nil {
-			log.Printf("MCP server %q: failed to connect: %v", name, err)
-			continue
+	var embed tools.EmbedFunc
+	if e, ok := provider.(providers.Embedder); ok {
+		embed = e.Embed
+	}
+	reg.Register(tools.NewNotesTool(root, embed))
+
+	reg.Register(tools.NewValidateTool(root))
+	reg.Register(tools.NewReplaceTool(root))
+	reg.Register(tools.NewCompressTool(root))
+	reg.Register(tools.NewQRCodeTool(root))
+	reg.Register(tools.NewTailTool(root))
+
+	// Connect to configured MCP servers; their tools are registered below.
+	mcpMgr := mcp.NewManager()
+	mcpMgr.InitializeServers(mcpServers)
+
+	ops := tools.NewOperations()
+	reg.Register(tools.NewOperationsTool(ops))
+	pages := tools.NewPageStore(tools.DefaultPageSize)
+	reg.Register(tools.NewGetPageTool(pages))
+
+	a := &AgentLoop{hub: b, provider: provider, tools: reg, sessions: sm, context: ctx, memory: mem, model: model, maxIterations: maxIterations, mcpManager: mcpMgr, enableToolActivity: true, ops: ops, pages: pages, maxUnknownTools: defaultMaxUnknownToolCalls, askTimeout: defaultAskTimeout, workspace: workspace, interrupted: make(map[string]bool), usage: make(map[string]providers.Usage)}
+	reg.Register(tools.NewAskUserTool(a.askUser))
+	a.configInfo = tools.NewConfigInfoTool(a.currentModel, func() []string {
+		var names []string
+		for _, d := range reg.Definitions() {
+			names = append(names, d.Name)
 		}
-		mcpClients = append(mcpClients, client)
-		for _, tool := range client.Tools() {
-			reg.Register(tools.NewMCPTool(client, name, tool))
+		return names
+	})
+	reg.Register(a.configInfo)
+	reg.Register(tools.NewUsageStatsTool(a.usageTotals))
+	mcpMgr.OnToolsChanged(a.syncMCPTools)
+	a.syncMCPTools()
+	return a
+}
+
+// ConnectMCPServer connects (or reconnects) an MCP server while the agent is
+// running and registers its tools.
+func (a *AgentLoop) ConnectMCPServer(name string, cfg config.MCPServerConfig) error {
+	_, err := a.mcpManager.ConnectServer(name, cfg)
+	a.syncMCPTools()
+	return err
+}
+
+// DisconnectMCPServer closes an MCP server connection and unregisters its tools.
+func (a *AgentLoop) DisconnectMCPServer(name string) error {
+	err := a.mcpManager.DisconnectServer(name)
+	a.syncMCPTools()
+	return err
+}
+
+// syncMCPTools brings the tool registry, and the system note about
+// unavailable servers, in line with the MCP manager's current connections.
+func (a *AgentLoop) syncMCPTools() {
+	a.mcpMu.Lock()
+	defer a.mcpMu.Unlock()
+	current := make(map[string]bool)
+	for _, t := range a.mcpManager.GetAllTools() {
+		tool := tools.NewMCPTool(t.Client, t.Client.Name(), t.Tool)
+		a.tools.Register(tool)
+		current[tool.Name()] = true
+	}
+	for name := range a.mcpTools {
+		if !current[name] {
+			a.tools.Unregister(name)
 		}
-		log.Printf("MCP server %q: registered %d tools", name, len(client.Tools()))
 	}
-
-	return &AgentLoop{hub: b, provider: provider, tools: reg, sessions: sm, context: ctx, memory: mem, model: model, maxIterations: maxIterations, mcpClients: mcpClients, enableToolActivity: true}
+	a.mcpTools = current
+	if a.mcpManager.HasResources() {
+		a.tools.Register(tools.NewReadResourceTool(a.mcpManager))
+	} else {
+		a.tools.Unregister("read_resource")
+	}
+	var unavailable []string
+	for _, f := range a.mcpManager.Failures() {
+		unavailable = append(unavailable, fmt.Sprintf("%s: %v", f.Server, f.Err))
+	}
+	a.context.SetUnavailableServers(unavailable)
 }
 
 // SetToolActivityIndicator controls whether the feedback of tool progress
@@ -149,17 +298,186 @@ func (a *AgentLoop) SetToolActivityIndicator(enabled bool) {
 	a.enableToolActivity = enabled
 }
 
+// SetTurnTimeout bounds how long a single turn (all provider and tool calls
+// for one inbound message) may run. When it expires, outstanding calls are
+// cancelled and the user gets an apology with any partial reply. Zero means
+// no limit.
+func (a *AgentLoop) SetTurnTimeout(d time.Duration) {
+	a.turnTimeout = d
+}
+
+// SetWebAllowPrivateNetworks lets the web tool reach loopback and
+// private-network addresses, which it refuses by default.
+func (a *AgentLoop) SetWebAllowPrivateNetworks(allow bool) {
+	if wt, ok := a.tools.Get("web").(*tools.WebTool); ok {
+		wt.SetAllowPrivateNetworks(allow)
+	}
+}
+
+// SetExecAllowedPrograms restricts the exec tool to the given programs. The
+// built-in blacklist (rm, sudo, ...) still applies.
+func (a *AgentLoop) SetExecAllowedPrograms(progs []string) {
+	if len(progs) > 0 {
+		a.tools.Register(tools.NewExecTool(60, progs...))
+	}
+}
+
+// SetSecrets makes named secrets usable in web request headers and registers
+// the secrets tool, which lists their names. Values are never shown to the
+// model.
+func (a *AgentLoop) SetSecrets(store *tools.SecretStore) {
+	if wt, ok := a.tools.Get("web").(*tools.WebTool); ok {
+		wt.SetSecrets(store)
+	}
+	a.tools.Register(tools.NewSecretsTool(store))
+}
+
+// SetStatusUpdateTarget sets where the status_update tool posts, e.g. an
+// incident channel. Until it is set the tool refuses to post.
+func (a *AgentLoop) SetStatusUpdateTarget(channel, chatID string) {
+	a.tools.Register(tools.NewStatusUpdateTool(a.hub, channel, chatID))
+}
+
+// SetDockerPolicy enables and restricts the docker tool, which is registered
+// disabled by default.
+func (a *AgentLoop) SetDockerPolicy(p tools.DockerPolicy) {
+	a.tools.Register(tools.NewDockerTool(p))
+}
+
+// SetChannelToolPolicies restricts which tools each channel (e.g. "discord")
+// may use. Channels without an entry may use every tool.
+func (a *AgentLoop) SetChannelToolPolicies(policies map[string]*tools.Policy) {
+	a.channelPolicies = policies
+}
+
+// SetToolSelection caps how many tools are offered to the model per turn
+// and which are kept; see tools.ToolSelection. The zero value offers all.
+func (a *AgentLoop) SetToolSelection(s tools.ToolSelection) {
+	a.toolSelection = s
+}
+
+// SetModel changes the model used from the next provider request on. It is
+// safe to call while the loop runs, e.g. when the config is reloaded.
+func (a *AgentLoop) SetModel(model string) {
+	if model == "" {
+		return
+	}
+	a.settingsMu.Lock()
+	a.model = model
+	a.settingsMu.Unlock()
+}
+
+// SetFallbackModels sets the models tried, in order, when the model is
+// overloaded or unavailable, and the model tried when a request is too long
+// for the model's context window ("" for none).
+func (a *AgentLoop) SetFallbackModels(models []string, longContext string) {
+	a.settingsMu.Lock()
+	a.fallbackModels = models
+	a.longContextModel = longContext
+	a.settingsMu.Unlock()
+}
+
+// SetSystemPrompt replaces the default opening of the system prompt; see
+// ContextBuilder.SetSystemPrompt. It returns an error if the template doesn't
+// parse, leaving the prompt unchanged.
+func (a *AgentLoop) SetSystemPrompt(prompt string) error {
+	return a.context.SetSystemPrompt(prompt)
+}
+
+// SetMaxTokens limits the length of replies from the next turn on,
+// overriding the provider's configured limit; n <= 0 restores it. It is safe
+// to call while the loop runs.
+func (a *AgentLoop) SetMaxTokens(n int) {
+	a.settingsMu.Lock()
+	a.maxTokens = n
+	a.settingsMu.Unlock()
+}
+
+func (a *AgentLoop) currentModel() string {
+	a.settingsMu.RLock()
+	defer a.settingsMu.RUnlock()
+	return a.model
+}
+
+func (a *AgentLoop) currentMaxTokens() int {
+	a.settingsMu.RLock()
+	defer a.settingsMu.RUnlock()
+	return a.maxTokens
+}
+
+// SetConfig gives the config_info tool the config to report. Only
+// non-secret fields are ever shown to the model.
+func (a *AgentLoop) SetConfig(cfg config.Config) {
+	a.configInfo.SetConfig(cfg)
+}
+
+// SetReplyToMessage makes replies on the given channels reference the
+// message that triggered them (a Discord or Telegram reply, a Slack thread),
+// using the "message_id" the channel put in the inbound metadata. Other
+// channels send standalone messages.
+func (a *AgentLoop) SetReplyToMessage(channels []string) {
+	a.replyToMessage = make(map[string]bool, len(channels))
+	for _, ch := range channels {
+		a.replyToMessage[ch] = true
+	}
+}
+
+// SetGreeting sets a message sent once, before the first reply, to each new
+// conversation. Empty disables it.
+func (a *AgentLoop) SetGreeting(greeting string) {
+	a.greeting = strings.TrimSpace(greeting)
+}
+
+// SetStopSequences sets stop sequences passed to the provider on every
+// request, for providers that support them.
+func (a *AgentLoop) SetStopSequences(stop []string) {
+	a.stop = stop
+}
+
+// SetChannelMaxIterations overrides the tool iteration cap for individual
+// channels (e.g. a lower one for "discord"). Other channels use the cap
+// given to NewAgentLoop.
+func (a *AgentLoop) SetChannelMaxIterations(limits map[string]int) {
+	a.channelMaxIter = limits
+}
+
+// SetMaxUnknownToolCalls sets how many calls to tools that don't exist one
+// turn may make before it is stopped. Each such call gets an error listing
+// the real tools, so the model can usually correct itself first. n <= 0
+// keeps the default of 3.
+func (a *AgentLoop) SetMaxUnknownToolCalls(n int) {
+	if n > 0 {
+		a.maxUnknownTools = n
+	}
+}
+
+// CancelTurn stops the turn currently running for a conversation, e.g. when
+// a user presses stop in a UI. The turn's provider call and tool calls are
+// cancelled and the user is told the request was stopped. It reports false if
+// no turn is running for that conversation.
+func (a *AgentLoop) CancelTurn(channel, chatID string) bool {
+	id, ok := a.turns.Load(channel + ":" + chatID)
+	return ok && a.ops.Cancel(id.(string))
+}
+
+// maxIterationsFor returns the tool iteration cap for a channel.
+func (a *AgentLoop) maxIterationsFor(channel string) int {
+	if n := a.channelMaxIter[channel]; n > 0 {
+		return n
+	}
+	return a.maxIterations
+}
+
 // Close shuts down all MCP server connections.
 func (a *AgentLoop) Close() {
-	for _, c := range a.mcpClients {
-		_ = c.Close()
-	}
+	a.mcpManager.Close()
 }
 
 // Run starts processing inbound messages. This is a blocking call until context is canceled.
 func (a *AgentLoop) Run(ctx context.Context) {
 	a.running = true
 	log.Println("Agent loop started")
+	a.recoverInterrupted(ctx)
 
 	for a.running {
 		select {
@@ -167,7 +485,7 @@ func (a *AgentLoop) Run(ctx context.Context) {
 			log.Println("Agent loop received shutdown signal")
 			a.running = false
 			return
-		case msg, ok := <-a.hub.In:
+		case msg, ok := <-a.next():
 			if !ok {
 				log.Println("Inbound channel closed, stopping agent loop")
 				a.running = false
@@ -175,6 +493,14 @@ func (a *AgentLoop) Run(ctx context.Context) {
 			}
 
 			log.Printf("Processing message from %s:%s\n", msg.Channel, msg.SenderID)
+			if a.skipRedelivered(msg) {
+				log.Printf("skipping redelivered message in %s:%s; its turn was interrupted by a restart", msg.Channel, msg.ChatID)
+				continue
+			}
+
+			if a.greeting != "" && !isSystemChannel(msg.Channel) && !a.sessions.Known(msg.Channel+":"+msg.ChatID) {
+				sendChannelNotification(a.hub, msg.Channel, msg.ChatID, a.greeting)
+			}
 
 			// Quick heuristic: if user asks the agent to remember something explicitly,
 			// store it in today's note and reply immediately without calling the LLM.
@@ -203,17 +529,8 @@ func (a *AgentLoop) Run(ctx context.Context) {
 				continue
 			}
 
-			// Set tool context (so message tool knows channel+chat)
-			if mt := a.tools.Get("message"); mt != nil {
-				if mtool, ok := mt.(interface{ SetContext(string, string) }); ok {
-					mtool.SetContext(msg.Channel, msg.ChatID)
-				}
-			}
-			if ct := a.tools.Get("cron"); ct != nil {
-				if ctool, ok := ct.(interface{ SetContext(string, string) }); ok {
-					ctool.SetContext(msg.Channel, msg.ChatID)
-				}
-			}
+			// Set tool context (so message, cron and similar tools know channel+chat)
+			a.tools.SetContext(msg.Channel, msg.ChatID)
 
 			// Build messages from session, long-term memory, and recent memory.
 			// System channels (heartbeat, cron) get a blank ephemeral session so
@@ -226,65 +543,201 @@ func (a *AgentLoop) Run(ctx context.Context) {
 			}
 			// get file-backed memory context (long-term + today)
 			memCtx, _ := a.memory.GetMemoryContext()
+			if !isSystemChannel(msg.Channel) {
+				memCtx = a.withSummary(memCtx, sess.Key)
+			}
 			memories := a.memory.Recent(5)
 			messages := a.context.BuildMessages(sess.GetHistory(), msg.Content, msg.Channel, msg.ChatID, memCtx, memories)
 
+			// "/prompt <server>_<name> [key=value ...]" replaces the raw command
+			// with a prompt template fetched from an MCP server.
+			if id, args, ok := parsePromptCommand(msg.Content); ok {
+				seeded, reply := a.loadPrompt(ctx, id, args)
+				if reply != "" {
+					sendChannelNotification(a.hub, msg.Channel, msg.ChatID, reply)
+					continue
+				}
+				messages = append(messages[:len(messages)-1], seeded...)
+			}
+
+			// Persist that this conversation has a turn in progress, so a
+			// restart before the reply is sent is noticed; see inflight.go.
+			if !isSystemChannel(msg.Channel) {
+				a.markInflight(msg)
+			}
+
+			// Identify the sender to the provider by a stable hash for abuse monitoring.
+			turnCtx := providers.WithUser(ctx, providers.HashUser(msg.Channel, msg.SenderID))
+			turnCtx = providers.WithStop(turnCtx, a.stop)
+			turnCtx = providers.WithMaxTokens(turnCtx, a.currentMaxTokens())
+			// Collect images returned by tools so they reach the user too.
+			media := &tools.MediaCollector{}
+			turnCtx = tools.WithMediaCollector(turnCtx, media)
+			policy := a.channelPolicies[msg.Channel]
+			turnCtx = tools.WithPolicy(turnCtx, policy)
+			turnCtx, turnID, endTurn := a.ops.Start(turnCtx, "turn", fmt.Sprintf("reply to %s:%s", msg.Channel, msg.ChatID))
+			a.turns.Store(msg.Channel+":"+msg.ChatID, turnID)
+			a.emit(turnID, msg, TurnEvent{Type: EventTurnStarted, Content: msg.Content})
+			cancelTurn := context.CancelFunc(func() {})
+			if a.turnTimeout > 0 {
+				turnCtx, cancelTurn = context.WithTimeout(turnCtx, a.turnTimeout)
+			}
+
 			iteration := 0
 			finalContent := ""
+			reasoning := "" // provider-reported reasoning for the final answer
+			servedBy := ""  // the model that gave the last reply
 			lastToolResult := ""
-			toolDefs := a.tools.Definitions()
-			for iteration < a.maxIterations {
+			partial := "" // assistant text sent alongside tool calls so far
+			timedOut, cancelled := false, false
+			toolDefs := a.toolSelection.Select(a.tools.DefinitionsFor(policy), msg.Content)
+			maxIterations := a.maxIterationsFor(msg.Channel)
+			unknownCalls := 0
+			refused := false // a call was refused by a policy with a Message
+			for iteration < maxIterations {
 				iteration++
-				resp, err := a.provider.Chat(ctx, messages, toolDefs, a.model)
+				resp, model, err := a.chat(turnCtx, msg, messages, toolDefs)
+				a.addUsage(msg.Channel+":"+msg.ChatID, resp.Usage)
+				if errors.Is(turnCtx.Err(), context.DeadlineExceeded) {
+					// Keep whatever the user already saw streamed.
+					if c := sanitizeContent(resp.Content); c != "" {
+						partial = strings.TrimSpace(partial + "\n\n" + c)
+					}
+					timedOut = true
+					break
+				}
+				if turnCtx.Err() != nil {
+					cancelled = true
+					break
+				}
 				if err != nil {
 					log.Printf("provider error: %v", err)
+					a.emit(turnID, msg, TurnEvent{Type: EventTurnError, Error: err.Error()})
 					finalContent = "Sorry, I encountered an error while processing your request."
 					break
 				}
+				servedBy = model
 
 				if resp.HasToolCalls {
+					if c := sanitizeContent(resp.Content); c != "" {
+						partial = strings.TrimSpace(partial + "\n\n" + c)
+					}
 					// append assistant message with tool_calls attached
 					messages = append(messages, providers.Message{Role: "assistant", Content: resp.Content, ToolCalls: resp.ToolCalls})
 					// execute each tool call and return results with "tool" role
 					for _, tc := range resp.ToolCalls {
 						argsJSON, _ := json.Marshal(tc.Arguments)
+						a.sendToolEvent(msg, tc.Name, "started", 0)
 						if a.enableToolActivity {
-							sendChannelNotification(a.hub, msg.Channel, msg.ChatID,
+							sendToolActivity(a.hub, msg.Channel, msg.ChatID,
 								fmt.Sprintf("🤖 Running: %s %s", tc.Name, argsJSON))
 						}
 
 						start := time.Now()
-						res, err := a.tools.Execute(ctx, tc.Name, tc.Arguments)
+						res, err := a.executeTool(turnCtx, tc.Name, tc.Arguments)
 						elapsed := time.Since(start).Round(time.Millisecond)
 
 						if err != nil {
+							if errors.Is(err, tools.ErrUnknownTool) {
+								unknownCalls++
+							}
+							if errors.Is(err, tools.ErrNotPermitted) && policy != nil && policy.Message != "" {
+								refused = true
+							}
+							a.sendToolEvent(msg, tc.Name, "failed", elapsed)
 							if a.enableToolActivity {
-								sendChannelNotification(a.hub, msg.Channel, msg.ChatID,
+								sendToolActivity(a.hub, msg.Channel, msg.ChatID,
 									fmt.Sprintf("📢 %s failed (%s): %v", tc.Name, elapsed, err))
 							}
 							res = "(tool error) " + err.Error()
 						} else {
+							a.sendToolEvent(msg, tc.Name, "finished", elapsed)
 							if a.enableToolActivity {
-								sendChannelNotification(a.hub, msg.Channel, msg.ChatID,
+								sendToolActivity(a.hub, msg.Channel, msg.ChatID,
 									fmt.Sprintf("📢 %s done (%s)", tc.Name, elapsed))
 							}
 						}
+						ev := TurnEvent{Type: EventToolCalled, Tool: tc.Name, Args: tc.Arguments, Status: "finished", ElapsedMs: elapsed.Milliseconds()}
+						if err != nil {
+							ev.Status, ev.Error = "failed", err.Error()
+						}
+						a.emit(turnID, msg, ev)
 						lastToolResult = res
 						messages = append(messages, providers.Message{Role: "tool", Content: res, ToolCallID: tc.ID})
 					}
+					if errors.Is(turnCtx.Err(), context.DeadlineExceeded) {
+						timedOut = true
+						break
+					}
+					if turnCtx.Err() != nil {
+						cancelled = true
+						break
+					}
+					if unknownCalls >= a.maxUnknownTools {
+						log.Printf("turn for %s:%s stopped after %d calls to unknown tools", msg.Channel, msg.ChatID, unknownCalls)
+						finalContent = unknownToolsReply
+						break
+					}
+					if refused {
+						log.Printf("turn for %s:%s stopped after a tool call refused by the channel policy", msg.Channel, msg.ChatID)
+						finalContent = policy.Message
+						break
+					}
 					// loop again
 					continue
 				} else {
 					finalContent = resp.Content
+					if resp.Incomplete {
+						finalContent = markIncomplete(finalContent)
+					}
+					reasoning = resp.Reasoning
 					break
 				}
 			}
 
-			if finalContent == "" && lastToolResult != "" {
+			cancelTurn()
+			a.turns.Delete(msg.Channel + ":" + msg.ChatID)
+			endTurn()
+
+			// Strip reasoning before it reaches the user or the stored history,
+			// so replayed history stays clean.
+			// Reasoning the provider returned separately is treated the same way
+			// as <think> blocks.
+			finalContent, thinking := splitThinking(finalContent)
+			thinking = strings.TrimSpace(strings.TrimSpace(reasoning) + "\n\n" + thinking)
+			if thinking != "" && !isSystemChannel(msg.Channel) {
+				// Channels only get the answer; UIs may subscribe to the reasoning.
+				select {
+				case a.hub.Out <- chat.Outbound{Channel: msg.Channel, ChatID: msg.ChatID, Content: thinking, Kind: chat.KindThinking}:
+				default:
+					log.Println("Outbound channel full, dropping reasoning")
+				}
+			}
+			if timedOut {
+				log.Printf("turn for %s:%s timed out after %v", msg.Channel, msg.ChatID, a.turnTimeout)
+				finalContent = timeoutReply(partial)
+			} else if cancelled {
+				finalContent = "Stopped: this request was cancelled."
+			} else if finalContent == "" && lastToolResult != "" {
 				finalContent = lastToolResult
 			} else if finalContent == "" {
 				finalContent = "I've completed processing but have no response to give."
 			}
+			done := TurnEvent{Type: EventTurnCompleted, Content: finalContent, Status: "ok", Iterations: iteration}
+			if timedOut {
+				done.Status = "timeout"
+			} else if cancelled {
+				done.Status = "cancelled"
+			}
+			a.emit(turnID, msg, done)
+
+			// A turn cancelled because the agent is shutting down is left
+			// unanswered and its marker kept, so the next run tells the user
+			// it was interrupted instead of recording a reply nobody saw.
+			if cancelled && ctx.Err() != nil {
+				log.Printf("turn for %s:%s interrupted by shutdown", msg.Channel, msg.ChatID)
+				continue
+			}
 
 			// Save session for interactive channels only.
 			// System channels (heartbeat, cron) are stateless triggers — their
@@ -297,11 +750,25 @@ func (a *AgentLoop) Run(ctx context.Context) {
 				}
 			}
 
-			out := chat.Outbound{Channel: msg.Channel, ChatID: msg.ChatID, Content: finalContent}
-			select {
-			case a.hub.Out <- out:
-			default:
-				log.Println("Outbound channel full, dropping message")
+			out := chat.Outbound{Channel: msg.Channel, ChatID: msg.ChatID, Content: finalContent, Media: media.Items()}
+			if servedBy != "" {
+				out.Metadata = map[string]interface{}{"model": servedBy}
+				if servedBy != a.currentModel() {
+					log.Printf("reply to %s:%s served by fallback model %s", msg.Channel, msg.ChatID, servedBy)
+				}
+			}
+			if a.replyToMessage[msg.Channel] {
+				out.ReplyTo, _ = msg.Metadata["message_id"].(string)
+			}
+			if err := a.hub.Send(ctx, out); err != nil {
+				log.Printf("dropping reply to %s:%s: %v", msg.Channel, msg.ChatID, err)
+			} else if !isSystemChannel(msg.Channel) {
+				a.clearInflight(msg)
+			}
+
+			// Summarize long conversations into memory once the reply is out.
+			if !isSystemChannel(msg.Channel) && !timedOut && !cancelled {
+				a.maybeSummarize(ctx, sess)
 			}
 		default:
 			// idle tick
@@ -310,24 +777,201 @@ func (a *AgentLoop) Run(ctx context.Context) {
 	}
 }
 
+// incompleteMarker is appended to a reply the provider cut off before the
+// model finished, so the user knows it is partial.
+const incompleteMarker = "[incomplete: the reply was cut off]"
+
+func markIncomplete(content string) string {
+	return strings.TrimSpace(content + "\n\n" + incompleteMarker)
+}
+
+// timeoutReply is sent when a turn exceeds its timeout, carrying whatever the
+// model had said before it was cut off.
+func timeoutReply(partial string) string {
+	const apology = "Sorry, that took longer than I'm allowed, so I had to stop."
+	if partial == "" {
+		return apology
+	}
+	return apology + " Here's what I had so far:\n\n" + partial
+}
+
+// sendToolEvent reports tool activity in msg's conversation as a
+// chat.KindTool event. Channels ignore these; subscribers that asked for them
+// (e.g. a UI) can show progress. Nothing is sent if no one asked, and events
+// are dropped if the queue is full.
+func (a *AgentLoop) sendToolEvent(msg chat.Inbound, name, status string, elapsed time.Duration) {
+	if isSystemChannel(msg.Channel) || !a.hub.Wants(chat.KindTool) {
+		return
+	}
+	content := name + " " + status
+	meta := map[string]interface{}{"tool": name, "status": status}
+	if status != "started" {
+		content += " (" + elapsed.String() + ")"
+		meta["elapsed_ms"] = elapsed.Milliseconds()
+	}
+	select {
+	case a.hub.Out <- chat.Outbound{Channel: msg.Channel, ChatID: msg.ChatID, Content: content, Kind: chat.KindTool, Metadata: meta}:
+	default:
+		log.Println("Outbound channel full, dropping tool event")
+	}
+}
+
+// deltaInterval is the least time between two KindDelta events of a reply,
+// so channels that edit a message in place stay within rate limits.
+const deltaInterval = 500 * time.Millisecond
+
+// chat asks the provider for the next reply of msg's turn and returns the
+// model that gave it. If the model is overloaded or unavailable, the
+// fallback models are tried in turn; if the request was too long for its
+// context window, the long-context model is, if one is set.
+func (a *AgentLoop) chat(ctx context.Context, msg chat.Inbound, messages []providers.Message, toolDefs []providers.ToolDefinition) (providers.LLMResponse, string, error) {
+	a.settingsMu.RLock()
+	fallbacks, longContext := a.fallbackModels, a.longContextModel
+	a.settingsMu.RUnlock()
+
+	model := a.currentModel()
+	resp, err := a.chatModel(ctx, msg, messages, toolDefs, model)
+	for err != nil && ctx.Err() == nil {
+		var next string
+		switch {
+		case providers.IsContextTooLong(err) && longContext != "" && model != longContext:
+			next = longContext
+		case providers.IsOverloaded(err) && len(fallbacks) > 0:
+			next, fallbacks = fallbacks[0], fallbacks[1:]
+		default:
+			return resp, model, err
+		}
+		log.Printf("model %s failed (%v), trying %s", model, err, next)
+		model = next
+		resp, err = a.chatModel(ctx, msg, messages, toolDefs, model)
+	}
+	return resp, model, err
+}
+
+// chatModel asks model for the next reply of msg's turn. When the provider
+// can stream and a subscriber wants KindDelta events, the reply is streamed
+// and its text forwarded as it grows. If the stream fails, the response
+// carries the text received before the failure.
+func (a *AgentLoop) chatModel(ctx context.Context, msg chat.Inbound, messages []providers.Message, toolDefs []providers.ToolDefinition, model string) (providers.LLMResponse, error) {
+	s, ok := a.provider.(providers.Streamer)
+	if !ok || isSystemChannel(msg.Channel) || !a.hub.Wants(chat.KindDelta) {
+		return a.provider.Chat(ctx, messages, toolDefs, model)
+	}
+	ch, err := s.ChatStream(ctx, messages, toolDefs, model)
+	if err != nil {
+		return providers.LLMResponse{}, err
+	}
+	var text strings.Builder
+	var last time.Time
+	resp, err := providers.CollectStream(ch, func(d providers.Delta) {
+		text.WriteString(d.Content)
+		if d.Content == "" || time.Since(last) < deltaInterval {
+			return
+		}
+		answer, _ := splitThinking(text.String())
+		if answer == "" {
+			return
+		}
+		last = time.Now()
+		select {
+		case a.hub.Out <- chat.Outbound{Channel: msg.Channel, ChatID: msg.ChatID, Content: answer, Kind: chat.KindDelta}:
+		default:
+			log.Println("Outbound channel full, dropping reply delta")
+		}
+	})
+	if err != nil && resp.Content == "" {
+		resp.Content = text.String()
+	}
+	return resp, err
+}
+
+// executeTool runs a registered tool. Calls to tools of an MCP server that
+// failed to connect get an error naming the server, so the model can relay it.
+func (a *AgentLoop) executeTool(ctx context.Context, name string, args map[string]interface{}) (string, error) {
+	if a.tools.Get(name) == nil {
+		if f, ok := a.mcpManager.UnavailableTool(name); ok {
+			return "", fmt.Errorf("MCP server %q is unavailable (%v), so %s cannot be used", f.Server, f.Err, name)
+		}
+	}
+	ctx, _, done := a.ops.Start(ctx, "tool", name)
+	defer done()
+	res, err := a.tools.Execute(ctx, name, args)
+	if err != nil {
+		return res, err
+	}
+	// Large results go to the model a page at a time; see get_page.
+	return a.pages.Paginate(res), nil
+}
+
+// parsePromptCommand parses "/prompt <id> [key=value ...]". An empty id means
+// the user asked for the list of available prompts.
+func parsePromptCommand(content string) (id string, args map[string]string, ok bool) {
+	fields := strings.Fields(content)
+	if len(fields) == 0 || fields[0] != "/prompt" {
+		return "", nil, false
+	}
+	if len(fields) > 1 {
+		id = fields[1]
+	}
+	args = make(map[string]string)
+	for _, f := range fields[min(2, len(fields)):] {
+		if k, v, found := strings.Cut(f, "="); found {
+			args[k] = v
+		}
+	}
+	return id, args, true
+}
+
+// loadPrompt fetches an MCP prompt's messages. When there is nothing to seed
+// (listing, unknown prompt, server error) it returns a reply for the user instead.
+func (a *AgentLoop) loadPrompt(ctx context.Context, id string, args map[string]string) ([]providers.Message, string) {
+	if id == "" {
+		prompts := a.mcpManager.GetAllPrompts(ctx)
+		if len(prompts) == 0 {
+			return nil, "No MCP prompts are available."
+		}
+		var sb strings.Builder
+		sb.WriteString("Available prompts:\n")
+		for _, p := range prompts {
+			fmt.Fprintf(&sb, "- %s", p.ID)
+			if p.Description != "" {
+				fmt.Fprintf(&sb, ": %s", p.Description)
+			}
+			for _, arg := range p.Arguments {
+				if arg.Required {
+					fmt.Fprintf(&sb, " %s=…", arg.Name)
+				}
+			}
+			sb.WriteString("\n")
+		}
+		return nil, sb.String()
+	}
+	msgs, err := a.mcpManager.GetPrompt(ctx, id, args)
+	if err != nil {
+		return nil, fmt.Sprintf("Couldn't load prompt %s: %v", id, err)
+	}
+	if len(msgs) == 0 {
+		return nil, fmt.Sprintf("Prompt %s is empty.", id)
+	}
+	return msgs, ""
+}
+
 // ProcessDirect sends a message directly to the provider and returns the response.
-// It supports tool calling - if the model requests tools, they will be executed.
+// It runs the full tool-calling loop - if the model requests tools, they will be
+// executed and their results fed back until the model gives a final answer.
+// The whole turn, tools included, must finish within timeout.
 func (a *AgentLoop) ProcessDirect(content string, timeout time.Duration) (string, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), timeout)
 	defer cancel()
+	ctx = providers.WithUser(ctx, providers.HashUser("cli", "direct"))
+	ctx = providers.WithStop(ctx, a.stop)
+	ctx = providers.WithMaxTokens(ctx, a.currentMaxTokens())
+	policy := a.channelPolicies["cli"]
+	ctx = tools.WithPolicy(ctx, policy)
 
 	// Set tool context so message/cron tools know the originating channel,
 	// matching what Run() does for hub-based messages.
-	if mt := a.tools.Get("message"); mt != nil {
-		if mtool, ok := mt.(interface{ SetContext(string, string) }); ok {
-			mtool.SetContext("cli", "direct")
-		}
-	}
-	if ct := a.tools.Get("cron"); ct != nil {
-		if ctool, ok := ct.(interface{ SetContext(string, string) }); ok {
-			ctool.SetContext("cli", "direct")
-		}
-	}
+	a.tools.SetContext("cli", "direct")
 
 	// Build full context (bootstrap files, skills, memory) just like the main loop
 	memCtx, _ := a.memory.GetMemoryContext()
@@ -335,34 +979,57 @@ func (a *AgentLoop) ProcessDirect(content string, timeout time.Duration) (string
 	messages := a.context.BuildMessages(nil, content, "cli", "direct", memCtx, memories)
 
 	// Support tool calling iterations (similar to main loop)
+	toolDefs := a.toolSelection.Select(a.tools.DefinitionsFor(policy), content)
 	var lastToolResult string
-	for iteration := 0; iteration < a.maxIterations; iteration++ {
-		resp, err := a.provider.Chat(ctx, messages, a.tools.Definitions(), a.model)
+	unknownCalls := 0
+	for iteration := 0; iteration < a.maxIterationsFor("cli"); iteration++ {
+		if ctx.Err() != nil {
+			return "", fmt.Errorf("no final response within %s", timeout)
+		}
+		resp, _, err := a.chat(ctx, chat.Inbound{Channel: "cli", ChatID: "direct"}, messages, toolDefs)
+		a.addUsage("cli:direct", resp.Usage)
 		if err != nil {
+			if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+				return "", fmt.Errorf("no final response within %s: %w", timeout, err)
+			}
 			return "", err
 		}
 
 		if !resp.HasToolCalls {
 			// No tool calls, return the response (fall back to last tool result if empty)
-			if resp.Content != "" {
-				return resp.Content, nil
-			}
-			if lastToolResult != "" {
+			content := sanitizeContent(resp.Content)
+			if content == "" && lastToolResult != "" {
 				return lastToolResult, nil
 			}
-			return resp.Content, nil
+			if resp.Incomplete {
+				content = markIncomplete(content)
+			}
+			return content, nil
 		}
 
 		// Execute tool calls
 		messages = append(messages, providers.Message{Role: "assistant", Content: resp.Content, ToolCalls: resp.ToolCalls})
+		refused := false
 		for _, tc := range resp.ToolCalls {
-			result, err := a.tools.Execute(ctx, tc.Name, tc.Arguments)
+			result, err := a.executeTool(ctx, tc.Name, tc.Arguments)
 			if err != nil {
+				if errors.Is(err, tools.ErrUnknownTool) {
+					unknownCalls++
+				}
+				if errors.Is(err, tools.ErrNotPermitted) && policy != nil && policy.Message != "" {
+					refused = true
+				}
 				result = "(tool error) " + err.Error()
 			}
 			lastToolResult = result
 			messages = append(messages, providers.Message{Role: "tool", Content: result, ToolCallID: tc.ID})
 		}
+		if unknownCalls >= a.maxUnknownTools {
+			return unknownToolsReply, nil
+		}
+		if refused {
+			return policy.Message, nil
+		}
 	}
 
 	return "Max iterations reached without final response", nil
@@ -0,0 +1,88 @@
+package agent
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/local/picobot/internal/chat"
+	"github.com/local/picobot/internal/providers"
+)
+
+// askingProvider asks which city the user means, then answers with the
+// tool result. Messages other than the weather request are echoed.
+type askingProvider struct{}
+
+func (p *askingProvider) Chat(ctx context.Context, messages []providers.Message, defs []providers.ToolDefinition, model string) (providers.LLMResponse, error) {
+	last := messages[len(messages)-1]
+	switch {
+	case last.Role == "tool":
+		return providers.LLMResponse{Content: "Forecast: " + last.Content}, nil
+	case strings.Contains(last.Content, "weather"):
+		return providers.LLMResponse{
+			HasToolCalls: true,
+			ToolCalls:    []providers.ToolCall{{ID: "1", Name: "ask_user", Arguments: map[string]interface{}{"question": "Which city?"}}},
+		}, nil
+	}
+	return providers.LLMResponse{Content: "echo: " + last.Content}, nil
+}
+func (p *askingProvider) GetDefaultModel() string { return "ask" }
+
+func TestAskUserSuspendsAndResumesTurn(t *testing.T) {
+	b := chat.NewHub(10)
+	ag := NewAgentLoop(b, &askingProvider{}, "ask", 3, t.TempDir(), nil, nil)
+	ag.SetToolActivityIndicator(false)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	go ag.Run(ctx)
+
+	recv := func() chat.Outbound {
+		select {
+		case out := <-b.Out:
+			return out
+		case <-ctx.Done():
+			t.Fatal("timeout waiting for outbound")
+			return chat.Outbound{}
+		}
+	}
+
+	b.In <- chat.Inbound{Channel: "telegram", SenderID: "u", ChatID: "1", Content: "what's the weather?"}
+	if q := recv(); q.Content != "Which city?" || q.ChatID != "1" {
+		t.Fatalf("expected the question, got %+v", q)
+	}
+
+	// A message from another chat while waiting is handled after the turn.
+	b.In <- chat.Inbound{Channel: "telegram", SenderID: "v", ChatID: "2", Content: "hi"}
+	b.In <- chat.Inbound{Channel: "telegram", SenderID: "u", ChatID: "1", Content: "Paris"}
+
+	if out := recv(); out.ChatID != "1" || out.Content != "Forecast: The user answered: Paris" {
+		t.Fatalf("expected the turn to resume with the answer, got %+v", out)
+	}
+	if out := recv(); out.ChatID != "2" || out.Content != "echo: hi" {
+		t.Fatalf("expected the other chat's message next, got %+v", out)
+	}
+}
+
+func TestAskUserTimesOut(t *testing.T) {
+	b := chat.NewHub(10)
+	ag := NewAgentLoop(b, &askingProvider{}, "ask", 3, t.TempDir(), nil, nil)
+	ag.SetToolActivityIndicator(false)
+	ag.SetAskUserTimeout(20 * time.Millisecond)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	go ag.Run(ctx)
+
+	b.In <- chat.Inbound{Channel: "telegram", SenderID: "u", ChatID: "1", Content: "what's the weather?"}
+	<-b.Out // the question
+	select {
+	case out := <-b.Out:
+		if !strings.Contains(out.Content, "no answer within") {
+			t.Fatalf("expected the timeout to reach the model, got %q", out.Content)
+		}
+	case <-ctx.Done():
+		t.Fatal("turn did not resume after the ask timeout")
+	}
+}
@@ -0,0 +1,76 @@
+package agent
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/local/picobot/internal/chat"
+	"github.com/local/picobot/internal/cron"
+	"github.com/local/picobot/internal/providers"
+)
+
+// summarizingProvider records what it was asked to summarize and returns a
+// fixed digest.
+type summarizingProvider struct {
+	prompt chan string
+}
+
+func (p *summarizingProvider) Chat(ctx context.Context, messages []providers.Message, tools []providers.ToolDefinition, model string) (providers.LLMResponse, error) {
+	select {
+	case p.prompt <- messages[len(messages)-1].Content:
+	default: // later runs of the recurring job
+	}
+	return providers.LLMResponse{Content: "Two stories today."}, nil
+}
+func (p *summarizingProvider) GetDefaultModel() string { return "stub" }
+
+func TestDigestJobFetchesSummarizesAndPosts(t *testing.T) {
+	feed := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		w.Write([]byte("<h1>News</h1><p>Go 2 released</p><p>Picobot ships digests</p>"))
+	}))
+	defer feed.Close()
+
+	b := chat.NewHub(10)
+	p := &summarizingProvider{prompt: make(chan string, 1)}
+	var ag *AgentLoop
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	scheduler := cron.NewScheduler(func(job cron.Job) {
+		if err := ag.RunDigest(ctx, job); err != nil {
+			t.Errorf("RunDigest: %v", err)
+		}
+	})
+	ag = NewAgentLoop(b, p, p.GetDefaultModel(), 5, t.TempDir(), scheduler, nil)
+	ag.SetWebAllowPrivateNetworks(true) // httptest listens on loopback
+	scheduler.AddDigest("news", feed.URL, "List the headlines.", 50*time.Millisecond, "telegram", "42")
+	go scheduler.Start(ctx.Done())
+
+	select {
+	case prompt := <-p.prompt:
+		if !strings.HasPrefix(prompt, "List the headlines.") || !strings.Contains(prompt, "Picobot ships digests") {
+			t.Fatalf("summarizer got unexpected prompt %q", prompt)
+		}
+		if strings.Contains(prompt, "<p>") {
+			t.Fatalf("expected HTML converted to text, got %q", prompt)
+		}
+	case <-ctx.Done():
+		t.Fatal("digest job never fired")
+	}
+
+	select {
+	case out := <-b.Out:
+		if out.Channel != "telegram" || out.ChatID != "42" {
+			t.Fatalf("digest posted to %s:%s, want telegram:42", out.Channel, out.ChatID)
+		}
+		if out.Content != "Digest: news\n\nTwo stories today." {
+			t.Fatalf("unexpected digest %q", out.Content)
+		}
+	case <-ctx.Done():
+		t.Fatal("digest was not posted")
+	}
+}
@@ -0,0 +1,162 @@
+package agent
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/local/picobot/internal/chat"
+	"github.com/local/picobot/internal/providers"
+)
+
+// listOnceProvider lists the workspace once, then answers.
+type listOnceProvider struct{}
+
+func (listOnceProvider) Chat(ctx context.Context, messages []providers.Message, tools []providers.ToolDefinition, model string) (providers.LLMResponse, error) {
+	if messages[len(messages)-1].Role == "tool" {
+		return providers.LLMResponse{Content: "done"}, nil
+	}
+	tc := providers.ToolCall{ID: "l", Name: "filesystem", Arguments: map[string]interface{}{"action": "list", "path": "."}}
+	return providers.LLMResponse{HasToolCalls: true, ToolCalls: []providers.ToolCall{tc}}, nil
+}
+func (listOnceProvider) GetDefaultModel() string { return "test" }
+
+func TestToolEventsReachSubscribersThatAskedForThem(t *testing.T) {
+	b := chat.NewHub(10)
+	ui := b.Subscribe("web", chat.KindTool)
+	ag := NewAgentLoop(b, listOnceProvider{}, "test", 3, t.TempDir(), nil, nil)
+	ag.SetToolActivityIndicator(false)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	b.StartRouter(ctx)
+	go ag.Run(ctx)
+
+	b.In <- chat.Inbound{Channel: "web", SenderID: "user", ChatID: "s1", Content: "what's here?"}
+
+	var statuses []string
+	for {
+		select {
+		case out := <-ui:
+			if out.Kind == "" {
+				if out.Content != "done" {
+					t.Fatalf("unexpected reply %q", out.Content)
+				}
+				if len(statuses) != 2 || statuses[0] != "filesystem started" || statuses[1] != "filesystem finished" {
+					t.Fatalf("tool events = %v, want started then finished", statuses)
+				}
+				return
+			}
+			if out.Kind != chat.KindTool || out.ChatID != "s1" || out.Metadata["tool"] != "filesystem" {
+				t.Fatalf("unexpected event %+v", out)
+			}
+			statuses = append(statuses, out.Metadata["tool"].(string)+" "+out.Metadata["status"].(string))
+		case <-time.After(2 * time.Second):
+			t.Fatalf("timeout waiting for reply, got events %v", statuses)
+		}
+	}
+}
+
+// streamingProvider streams its answer in two pieces.
+type streamingProvider struct{}
+
+func (streamingProvider) Chat(ctx context.Context, messages []providers.Message, tools []providers.ToolDefinition, model string) (providers.LLMResponse, error) {
+	return providers.LLMResponse{Content: "Hello there"}, nil
+}
+func (streamingProvider) GetDefaultModel() string { return "test" }
+func (streamingProvider) ChatStream(ctx context.Context, messages []providers.Message, tools []providers.ToolDefinition, model string) (<-chan providers.Delta, error) {
+	ch := make(chan providers.Delta, 3)
+	ch <- providers.Delta{Content: "Hello"}
+	ch <- providers.Delta{Content: " there"}
+	ch <- providers.Delta{Done: true, Response: providers.LLMResponse{Content: "Hello there"}}
+	close(ch)
+	return ch, nil
+}
+
+func TestRepliesStreamToSubscribersThatAskedForDeltas(t *testing.T) {
+	b := chat.NewHub(10)
+	ui := b.Subscribe("web", chat.KindDelta)
+	ag := NewAgentLoop(b, streamingProvider{}, "test", 3, t.TempDir(), nil, nil)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	b.StartRouter(ctx)
+	go ag.Run(ctx)
+
+	b.In <- chat.Inbound{Channel: "web", SenderID: "user", ChatID: "s1", Content: "hi"}
+
+	var deltas []string
+	for {
+		select {
+		case out := <-ui:
+			if out.Kind == chat.KindDelta {
+				deltas = append(deltas, out.Content)
+				continue
+			}
+			if out.Content != "Hello there" {
+				t.Fatalf("unexpected reply %q", out.Content)
+			}
+			// The second piece arrives within deltaInterval of the first.
+			if len(deltas) != 1 || deltas[0] != "Hello" {
+				t.Fatalf("deltas = %q, want the first piece only", deltas)
+			}
+			return
+		case <-time.After(2 * time.Second):
+			t.Fatalf("timeout waiting for reply, got deltas %q", deltas)
+		}
+	}
+}
+
+func TestTurnEventsReachTheSink(t *testing.T) {
+	b := chat.NewHub(10)
+	ag := NewAgentLoop(b, listOnceProvider{}, "test", 3, t.TempDir(), nil, nil)
+	ag.SetToolActivityIndicator(false)
+	sink := make(ChannelSink, 10)
+	ag.SetEventSink(sink, true)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	b.StartRouter(ctx)
+	go ag.Run(ctx)
+
+	b.In <- chat.Inbound{Channel: "web", SenderID: "user", ChatID: "s1", Content: "what's here? mail bob@example.com"}
+
+	var events []TurnEvent
+	for len(events) < 3 {
+		select {
+		case e := <-sink:
+			events = append(events, e)
+		case <-time.After(2 * time.Second):
+			t.Fatalf("timeout waiting for events, got %+v", events)
+		}
+	}
+	started, tool, done := events[0], events[1], events[2]
+	if started.Type != EventTurnStarted || started.Content != "what's here? mail [email]" || started.ChatID != "s1" || started.TurnID == "" {
+		t.Fatalf("unexpected turn_started %+v", started)
+	}
+	if tool.Type != EventToolCalled || tool.Tool != "filesystem" || tool.Status != "finished" || tool.Args["action"] != "list" {
+		t.Fatalf("unexpected tool_called %+v", tool)
+	}
+	if done.Type != EventTurnCompleted || done.Content != "done" || done.Status != "ok" || done.Iterations != 2 {
+		t.Fatalf("unexpected turn_completed %+v", done)
+	}
+	for _, e := range events {
+		if e.TurnID != started.TurnID || e.Time.IsZero() {
+			t.Fatalf("event %s not tied to the turn: %+v", e.Type, e)
+		}
+	}
+}
+
+func TestRedactEventMasksSecretArgs(t *testing.T) {
+	e := RedactEvent(TurnEvent{Args: map[string]interface{}{
+		"url":     "https://example.com",
+		"headers": map[string]interface{}{"Authorization": "Bearer abc"},
+		"api_key": "sk-1234567890abcdef",
+	}})
+	if e.Args["url"] != "https://example.com" || e.Args["api_key"] != "[secret]" {
+		t.Fatalf("unexpected args %+v", e.Args)
+	}
+	if h := e.Args["headers"].(map[string]interface{}); h["Authorization"] != "[secret]" {
+		t.Fatalf("nested secret not masked: %+v", h)
+	}
+}
@@ -0,0 +1,83 @@
+package agent
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/local/picobot/internal/chat"
+	"github.com/local/picobot/internal/providers"
+)
+
+// modelErrProvider fails requests for the models in errs and answers the
+// rest with the model's name.
+type modelErrProvider struct {
+	errs map[string]error
+	mu   sync.Mutex
+	used []string
+}
+
+func (p *modelErrProvider) Chat(ctx context.Context, messages []providers.Message, tools []providers.ToolDefinition, model string) (providers.LLMResponse, error) {
+	p.mu.Lock()
+	p.used = append(p.used, model)
+	p.mu.Unlock()
+	if err := p.errs[model]; err != nil {
+		return providers.LLMResponse{}, err
+	}
+	return providers.LLMResponse{Content: "from " + model}, nil
+}
+func (p *modelErrProvider) GetDefaultModel() string { return "primary" }
+
+func TestModelFallback(t *testing.T) {
+	overloaded := &providers.StatusError{Provider: "OpenAI", StatusCode: 503, Status: "503 Service Unavailable"}
+	tooLong := &providers.StatusError{Provider: "OpenAI", StatusCode: 400, Status: "400 Bad Request",
+		Body: `{"error":{"code":"context_length_exceeded","message":"This model's maximum context length is 8192 tokens"}}`}
+
+	tests := []struct {
+		name        string
+		errs        map[string]error
+		longContext string
+		want        string // reply
+		wantModel   string // Metadata["model"], "" for none
+		wantUsed    []string
+	}{
+		{"overloaded falls through the list", map[string]error{"primary": overloaded, "backup1": overloaded}, "", "from backup2", "backup2", []string{"primary", "backup1", "backup2"}},
+		{"context too long without long model", map[string]error{"primary": tooLong}, "", "Sorry, I encountered an error while processing your request.", "", []string{"primary"}},
+		{"context too long with long model", map[string]error{"primary": tooLong}, "big", "from big", "big", []string{"primary", "big"}},
+		{"no fallback needed", nil, "", "from primary", "primary", []string{"primary"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := chat.NewHub(10)
+			p := &modelErrProvider{errs: tt.errs}
+			ag := NewAgentLoop(b, p, "primary", 3, t.TempDir(), nil, nil)
+			ag.SetFallbackModels([]string{"backup1", "backup2"}, tt.longContext)
+
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+			go ag.Run(ctx)
+
+			b.In <- chat.Inbound{Channel: "telegram", SenderID: "user", ChatID: "c1", Content: "hello"}
+			select {
+			case out := <-b.Out:
+				model, _ := out.Metadata["model"].(string)
+				if out.Content != tt.want || model != tt.wantModel {
+					t.Fatalf("got %q from model %q, want %q from %q", out.Content, model, tt.want, tt.wantModel)
+				}
+			case <-time.After(2 * time.Second):
+				t.Fatal("timeout waiting for reply")
+			}
+			p.mu.Lock()
+			defer p.mu.Unlock()
+			if len(p.used) != len(tt.wantUsed) {
+				t.Fatalf("models tried = %v, want %v", p.used, tt.wantUsed)
+			}
+			for i := range p.used {
+				if p.used[i] != tt.wantUsed[i] {
+					t.Fatalf("models tried = %v, want %v", p.used, tt.wantUsed)
+				}
+			}
+		})
+	}
+}
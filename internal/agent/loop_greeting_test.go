@@ -0,0 +1,55 @@
+package agent
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/local/picobot/internal/chat"
+	"github.com/local/picobot/internal/providers"
+)
+
+type replyProvider struct{}
+
+func (replyProvider) Chat(ctx context.Context, messages []providers.Message, tools []providers.ToolDefinition, model string) (providers.LLMResponse, error) {
+	return providers.LLMResponse{Content: "reply"}, nil
+}
+func (replyProvider) GetDefaultModel() string { return "test" }
+
+func TestGreetingSentOncePerConversation(t *testing.T) {
+	b := chat.NewHub(10)
+	ag := NewAgentLoop(b, replyProvider{}, "test", 3, t.TempDir(), nil, nil)
+	ag.SetGreeting("Hi! I can search the web, manage files, and set reminders.")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	go ag.Run(ctx)
+
+	// send returns the outbound messages up to and including the reply.
+	send := func(chatID string) []string {
+		b.In <- chat.Inbound{Channel: "telegram", SenderID: "user", ChatID: chatID, Content: "hello"}
+		var got []string
+		for {
+			select {
+			case out := <-b.Out:
+				got = append(got, out.Content)
+				if out.Content == "reply" {
+					return got
+				}
+			case <-time.After(2 * time.Second):
+				t.Fatalf("timeout waiting for reply in %s, got %v", chatID, got)
+				return nil
+			}
+		}
+	}
+
+	if got := send("one"); len(got) != 2 || got[0] != "Hi! I can search the web, manage files, and set reminders." {
+		t.Fatalf("first message should get the greeting then the reply, got %v", got)
+	}
+	if got := send("one"); len(got) != 1 {
+		t.Fatalf("second message should not be greeted again, got %v", got)
+	}
+	if got := send("two"); len(got) != 2 {
+		t.Fatalf("a new conversation should be greeted, got %v", got)
+	}
+}
@@ -0,0 +1,172 @@
+package agent
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/local/picobot/internal/chat"
+	"github.com/local/picobot/internal/providers"
+)
+
+// stuckProvider never answers until release is closed, standing in for a
+// process that dies mid-turn.
+type stuckProvider struct{ release chan struct{} }
+
+func (p stuckProvider) Chat(ctx context.Context, messages []providers.Message, tools []providers.ToolDefinition, model string) (providers.LLMResponse, error) {
+	<-p.release
+	return providers.LLMResponse{Content: "too late"}, nil
+}
+func (stuckProvider) GetDefaultModel() string { return "test" }
+
+// countingProvider counts the turns it is asked to answer.
+type countingProvider struct{ calls atomic.Int32 }
+
+func (p *countingProvider) Chat(ctx context.Context, messages []providers.Message, tools []providers.ToolDefinition, model string) (providers.LLMResponse, error) {
+	p.calls.Add(1)
+	return providers.LLMResponse{Content: "reply"}, nil
+}
+func (*countingProvider) GetDefaultModel() string { return "test" }
+
+func TestRestartAbortsInterruptedTurnWithoutReprocessing(t *testing.T) {
+	workspace := t.TempDir()
+	msg := chat.Inbound{Channel: "telegram", SenderID: "u", ChatID: "42", Content: "summarize my inbox",
+		Metadata: map[string]interface{}{"message_id": "1001"}}
+
+	// First run: the turn starts and never finishes.
+	hung := stuckProvider{release: make(chan struct{})}
+	first := NewAgentLoop(chat.NewHub(10), hung, "test", 3, workspace, nil, nil)
+	ctx1, cancel1 := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	t.Cleanup(func() {
+		// Let the abandoned loop finish before the workspace is removed.
+		cancel1()
+		close(hung.release)
+		<-done
+	})
+	first.hub.In <- msg
+	go func() {
+		first.Run(ctx1)
+		close(done)
+	}()
+	marker := filepath.Join(workspace, "sessions", "inflight", "telegram:42.json")
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		if _, err := os.Stat(marker); err == nil {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("no in-progress marker was written")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	// Restart on the same workspace, as if the first process had crashed.
+	b := chat.NewHub(10)
+	p := &countingProvider{}
+	second := NewAgentLoop(b, p, "test", 3, workspace, nil, nil)
+	ctx2, cancel2 := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel2()
+	go second.Run(ctx2)
+
+	recv := func() chat.Outbound {
+		select {
+		case out := <-b.Out:
+			return out
+		case <-time.After(2 * time.Second):
+			t.Fatal("timeout waiting for outbound")
+			return chat.Outbound{}
+		}
+	}
+	if out := recv(); out.ChatID != "42" || !strings.Contains(out.Content, "restarted") || !strings.Contains(out.Content, "summarize my inbox") {
+		t.Fatalf("expected an interrupted-turn notice, got %+v", out)
+	}
+
+	// The platform redelivers the same message: it must not start a turn.
+	b.In <- msg
+	next := msg
+	next.Content = "try again please"
+	next.Metadata = map[string]interface{}{"message_id": "1002"}
+	b.In <- next
+	if out := recv(); out.Content != "reply" {
+		t.Fatalf("expected the reply to the new message, got %q", out.Content)
+	}
+	if n := p.calls.Load(); n != 1 {
+		t.Fatalf("provider asked %d times, want 1 (the redelivery must be skipped)", n)
+	}
+	if _, err := os.Stat(marker); !os.IsNotExist(err) {
+		t.Fatalf("marker should be cleared after the reply, stat err = %v", err)
+	}
+}
+
+// waitProvider blocks until its context is cancelled.
+type waitProvider struct{}
+
+func (waitProvider) Chat(ctx context.Context, messages []providers.Message, tools []providers.ToolDefinition, model string) (providers.LLMResponse, error) {
+	<-ctx.Done()
+	return providers.LLMResponse{}, ctx.Err()
+}
+func (waitProvider) GetDefaultModel() string { return "test" }
+
+func TestShutdownMidTurnKeepsMarker(t *testing.T) {
+	workspace := t.TempDir()
+	ag := NewAgentLoop(chat.NewHub(10), waitProvider{}, "test", 3, workspace, nil, nil)
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	ag.hub.In <- chat.Inbound{Channel: "telegram", SenderID: "u", ChatID: "42", Content: "summarize my inbox"}
+	go func() {
+		ag.Run(ctx)
+		close(done)
+	}()
+	marker := filepath.Join(workspace, "sessions", "inflight", "telegram:42.json")
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		if _, err := os.Stat(marker); err == nil {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("no in-progress marker was written")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	cancel()
+	<-done
+
+	if _, err := os.Stat(marker); err != nil {
+		t.Fatalf("marker should survive a shutdown mid-turn, stat err = %v", err)
+	}
+	if s := ag.sessions.GetOrCreate("telegram:42"); len(s.GetHistory()) != 0 {
+		t.Fatalf("a turn cut off by shutdown must not be saved, got history %+v", s.GetHistory())
+	}
+}
+
+func TestPromptCommandReplyLeavesNoMarker(t *testing.T) {
+	workspace := t.TempDir()
+	b := chat.NewHub(10)
+	p := &countingProvider{}
+	ag := NewAgentLoop(b, p, "test", 3, workspace, nil, nil)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	go ag.Run(ctx)
+
+	b.In <- chat.Inbound{Channel: "telegram", SenderID: "u", ChatID: "42", Content: "/prompt nosuch_prompt"}
+	select {
+	case out := <-b.Out:
+		if !strings.Contains(out.Content, "nosuch_prompt") {
+			t.Fatalf("expected a prompt error reply, got %q", out.Content)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("timeout waiting for outbound")
+	}
+	if n := p.calls.Load(); n != 0 {
+		t.Fatalf("provider asked %d times, want 0", n)
+	}
+	marker := filepath.Join(workspace, "sessions", "inflight", "telegram:42.json")
+	if _, err := os.Stat(marker); !os.IsNotExist(err) {
+		t.Fatalf("no marker should be left after a /prompt reply, stat err = %v", err)
+	}
+}
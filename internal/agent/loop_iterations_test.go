@@ -0,0 +1,63 @@
+package agent
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/local/picobot/internal/chat"
+	"github.com/local/picobot/internal/providers"
+)
+
+// provider that never stops asking for tools
+type loopingProvider struct {
+	mu    sync.Mutex
+	calls int
+}
+
+func (p *loopingProvider) Chat(ctx context.Context, messages []providers.Message, tools []providers.ToolDefinition, model string) (providers.LLMResponse, error) {
+	p.mu.Lock()
+	p.calls++
+	p.mu.Unlock()
+	tc := providers.ToolCall{ID: "l", Name: "filesystem", Arguments: map[string]interface{}{"action": "list", "path": "."}}
+	return providers.LLMResponse{HasToolCalls: true, ToolCalls: []providers.ToolCall{tc}}, nil
+}
+func (p *loopingProvider) GetDefaultModel() string { return "test" }
+
+func (p *loopingProvider) takeCalls() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	n := p.calls
+	p.calls = 0
+	return n
+}
+
+func TestChannelMaxIterations(t *testing.T) {
+	b := chat.NewHub(10)
+	p := &loopingProvider{}
+	ag := NewAgentLoop(b, p, p.GetDefaultModel(), 8, t.TempDir(), nil, nil)
+	ag.SetToolActivityIndicator(false)
+	ag.SetChannelMaxIterations(map[string]int{"discord": 2})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	go ag.Run(ctx)
+
+	turn := func(channel string) int {
+		b.In <- chat.Inbound{Channel: channel, SenderID: "user", ChatID: "one", Content: "loop"}
+		select {
+		case <-b.Out:
+		case <-time.After(3 * time.Second):
+			t.Fatalf("timeout waiting for %s reply", channel)
+		}
+		return p.takeCalls()
+	}
+
+	if got := turn("discord"); got != 2 {
+		t.Fatalf("discord turn made %d provider calls, want 2", got)
+	}
+	if got := turn("web"); got != 8 {
+		t.Fatalf("web turn made %d provider calls, want the default 8", got)
+	}
+}
@@ -0,0 +1,110 @@
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/local/picobot/internal/chat"
+	"github.com/local/picobot/internal/config"
+	"github.com/local/picobot/internal/providers"
+)
+
+// mcpCallingProvider calls a tool from an MCP server, then echoes the tool
+// result back as its final answer. It records the system prompt it was given.
+type mcpCallingProvider struct {
+	calls  int
+	system string
+}
+
+func (p *mcpCallingProvider) Chat(ctx context.Context, messages []providers.Message, tools []providers.ToolDefinition, model string) (providers.LLMResponse, error) {
+	p.calls++
+	if p.calls == 1 {
+		p.system = messages[0].Content
+		tc := providers.ToolCall{ID: "1", Name: "mcp_search_query", Arguments: map[string]interface{}{"q": "go"}}
+		return providers.LLMResponse{HasToolCalls: true, ToolCalls: []providers.ToolCall{tc}}, nil
+	}
+	return providers.LLMResponse{Content: messages[len(messages)-1].Content}, nil
+}
+func (p *mcpCallingProvider) GetDefaultModel() string { return "test" }
+
+func TestUnavailableMCPServerIsReported(t *testing.T) {
+	p := &mcpCallingProvider{}
+	ag := NewAgentLoop(chat.NewHub(10), p, p.GetDefaultModel(), 5, t.TempDir(), nil,
+		map[string]config.MCPServerConfig{"search": {Command: "/nonexistent/mcp-search"}})
+	defer ag.Close()
+
+	resp, err := ag.ProcessDirect("search for go", 2*time.Second)
+	if err != nil {
+		t.Fatalf("ProcessDirect: %v", err)
+	}
+	if !strings.Contains(resp, `MCP server "search" is unavailable`) {
+		t.Fatalf("expected unavailable server in tool result, got %q", resp)
+	}
+	if !strings.Contains(p.system, "failed to connect") || !strings.Contains(p.system, "- search: ") {
+		t.Fatalf("expected system prompt to list the failed server, got %q", p.system)
+	}
+}
+
+// newSearchMCPServer serves an MCP server over HTTP with a single "query" tool.
+func newSearchMCPServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			ID     *int64 `json:"id"`
+			Method string `json:"method"`
+		}
+		_ = json.NewDecoder(r.Body).Decode(&req)
+		var result string
+		switch req.Method {
+		case "initialize":
+			result = `{"capabilities":{}}`
+		case "tools/list":
+			result = `{"tools":[{"name":"query"}]}`
+		case "tools/call":
+			result = `{"content":[{"type":"text","text":"found it"}]}`
+		default:
+			w.WriteHeader(http.StatusAccepted)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{"jsonrpc": "2.0", "id": req.ID, "result": json.RawMessage(result)})
+	}))
+}
+
+func TestConnectAndDisconnectMCPServerAtRuntime(t *testing.T) {
+	srv := newSearchMCPServer(t)
+	defer srv.Close()
+
+	p := &mcpCallingProvider{}
+	ag := NewAgentLoop(chat.NewHub(10), p, p.GetDefaultModel(), 5, t.TempDir(), nil, nil)
+	defer ag.Close()
+	if ag.tools.Get("mcp_search_query") != nil {
+		t.Fatal("tool registered before the server was connected")
+	}
+
+	if err := ag.ConnectMCPServer("search", config.MCPServerConfig{URL: srv.URL}); err != nil {
+		t.Fatalf("ConnectMCPServer: %v", err)
+	}
+	resp, err := ag.ProcessDirect("search for go", 2*time.Second)
+	if err != nil {
+		t.Fatalf("ProcessDirect: %v", err)
+	}
+	if resp != "found it" {
+		t.Fatalf("expected tool result from live-connected server, got %q", resp)
+	}
+
+	if err := ag.DisconnectMCPServer("search"); err != nil {
+		t.Fatalf("DisconnectMCPServer: %v", err)
+	}
+	if ag.tools.Get("mcp_search_query") != nil {
+		t.Fatal("tool still registered after disconnect")
+	}
+	if err := ag.DisconnectMCPServer("search"); err == nil {
+		t.Fatal("expected error disconnecting an unknown server")
+	}
+}
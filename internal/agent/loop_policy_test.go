@@ -0,0 +1,126 @@
+package agent
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/local/picobot/internal/agent/tools"
+	"github.com/local/picobot/internal/chat"
+	"github.com/local/picobot/internal/providers"
+)
+
+// execProvider asks to run "echo hi" and then replies with the tool result,
+// recording whether exec was offered.
+type execProvider struct {
+	offered []bool
+}
+
+func (p *execProvider) Chat(ctx context.Context, messages []providers.Message, defs []providers.ToolDefinition, model string) (providers.LLMResponse, error) {
+	last := messages[len(messages)-1]
+	if last.Role == "tool" {
+		return providers.LLMResponse{Content: "result: " + last.Content}, nil
+	}
+	offered := false
+	for _, d := range defs {
+		if d.Name == "exec" {
+			offered = true
+		}
+	}
+	p.offered = append(p.offered, offered)
+	return providers.LLMResponse{
+		HasToolCalls: true,
+		ToolCalls:    []providers.ToolCall{{ID: "1", Name: "exec", Arguments: map[string]interface{}{"cmd": []interface{}{"echo", "hi"}}}},
+	}, nil
+}
+func (p *execProvider) GetDefaultModel() string { return "exec" }
+
+func TestChannelToolPolicy(t *testing.T) {
+	b := chat.NewHub(10)
+	p := &execProvider{}
+	ag := NewAgentLoop(b, p, p.GetDefaultModel(), 3, t.TempDir(), nil, nil)
+	ag.SetToolActivityIndicator(false)
+	ag.SetChannelToolPolicies(map[string]*tools.Policy{
+		"discord": {Deny: []string{"exec", "filesystem:write"}},
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	go ag.Run(ctx)
+
+	reply := func(channel string) string {
+		b.In <- chat.Inbound{Channel: channel, SenderID: "user", ChatID: "one", Content: "run it"}
+		select {
+		case out := <-b.Out:
+			return out.Content
+		case <-time.After(3 * time.Second):
+			t.Fatalf("timeout waiting for %s reply", channel)
+			return ""
+		}
+	}
+
+	if got := reply("discord"); !strings.Contains(got, "not permitted") {
+		t.Fatalf("discord should not be able to run exec, got %q", got)
+	}
+	if got := reply("web"); !strings.Contains(got, "hi") || strings.Contains(got, "not permitted") {
+		t.Fatalf("web should be able to run exec, got %q", got)
+	}
+	if len(p.offered) != 2 || p.offered[0] || !p.offered[1] {
+		t.Fatalf("exec should be hidden from discord only, offered = %v", p.offered)
+	}
+}
+
+func TestPolicyMessageRepliesWhenToolRefused(t *testing.T) {
+	b := chat.NewHub(10)
+	p := &execProvider{}
+	ag := NewAgentLoop(b, p, p.GetDefaultModel(), 3, t.TempDir(), nil, nil)
+	ag.SetToolActivityIndicator(false)
+	ag.SetChannelToolPolicies(map[string]*tools.Policy{
+		"discord": {Deny: []string{"exec"}, Message: "I can't run commands in this channel."},
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	go ag.Run(ctx)
+
+	b.In <- chat.Inbound{Channel: "discord", SenderID: "user", ChatID: "one", Content: "run echo hi"}
+	select {
+	case out := <-b.Out:
+		if out.Content != "I can't run commands in this channel." {
+			t.Fatalf("expected the policy's explanation, got %q", out.Content)
+		}
+	case <-time.After(3 * time.Second):
+		t.Fatal("timeout waiting for reply")
+	}
+}
+
+// defsRecordingProvider records the tool names offered on each call.
+type defsRecordingProvider struct {
+	offered [][]string
+}
+
+func (p *defsRecordingProvider) Chat(ctx context.Context, messages []providers.Message, defs []providers.ToolDefinition, model string) (providers.LLMResponse, error) {
+	var names []string
+	for _, d := range defs {
+		names = append(names, d.Name)
+	}
+	p.offered = append(p.offered, names)
+	return providers.LLMResponse{Content: "ok"}, nil
+}
+func (p *defsRecordingProvider) GetDefaultModel() string { return "defs" }
+
+func TestToolSelectionCapsOfferedTools(t *testing.T) {
+	b := chat.NewHub(10)
+	p := &defsRecordingProvider{}
+	ag := NewAgentLoop(b, p, p.GetDefaultModel(), 3, t.TempDir(), nil, nil)
+	ag.SetToolSelection(tools.ToolSelection{Max: 3, Priority: []string{"exec", "web*"}})
+
+	if _, err := ag.ProcessDirect("hello", time.Second); err != nil {
+		t.Fatal(err)
+	}
+	got := strings.Join(p.offered[0], ",")
+	if got != "exec,web,web_search" {
+		t.Fatalf("expected the 3 priority tools, got %s", got)
+	}
+}
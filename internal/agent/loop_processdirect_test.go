@@ -2,6 +2,8 @@ package agent
 
 import (
 	"context"
+	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 	"time"
@@ -63,3 +65,59 @@ func TestProcessDirectExecutesToolCall(t *testing.T) {
 }
 
 func contains(s, sub string) bool { return strings.Contains(s, sub) }
+
+// provider that calls the filesystem tool, then answers using the tool result
+type readThenAnswerProvider struct {
+	calls int
+}
+
+func (p *readThenAnswerProvider) Chat(ctx context.Context, messages []providers.Message, tools []providers.ToolDefinition, model string) (providers.LLMResponse, error) {
+	p.calls++
+	if p.calls == 1 {
+		tc := providers.ToolCall{ID: "r1", Name: "filesystem", Arguments: map[string]interface{}{"action": "read", "path": "answer.txt"}}
+		return providers.LLMResponse{HasToolCalls: true, ToolCalls: []providers.ToolCall{tc}}, nil
+	}
+	last := messages[len(messages)-1]
+	if last.Role != "tool" || last.ToolCallID != "r1" {
+		return providers.LLMResponse{Content: "tool result missing"}, nil
+	}
+	return providers.LLMResponse{Content: "The file says: " + last.Content}, nil
+}
+func (p *readThenAnswerProvider) GetDefaultModel() string { return "test" }
+
+func TestProcessDirectReturnsPostToolAnswer(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "answer.txt"), []byte("42"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	prov := &readThenAnswerProvider{}
+	ag := NewAgentLoop(chat.NewHub(10), prov, prov.GetDefaultModel(), 5, dir, nil, nil)
+	defer ag.Close()
+
+	resp, err := ag.ProcessDirect("what does answer.txt say?", 2*time.Second)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if resp != "The file says: 42" || prov.calls != 2 {
+		t.Fatalf("got %q after %d calls, want the post-tool answer after 2", resp, prov.calls)
+	}
+}
+
+// provider that blocks until the request is cancelled
+type hangingProvider struct{}
+
+func (hangingProvider) Chat(ctx context.Context, messages []providers.Message, tools []providers.ToolDefinition, model string) (providers.LLMResponse, error) {
+	<-ctx.Done()
+	return providers.LLMResponse{}, ctx.Err()
+}
+func (hangingProvider) GetDefaultModel() string { return "test" }
+
+func TestProcessDirectTimesOut(t *testing.T) {
+	ag := NewAgentLoop(chat.NewHub(10), hangingProvider{}, "test", 5, t.TempDir(), nil, nil)
+	defer ag.Close()
+
+	_, err := ag.ProcessDirect("hello", 50*time.Millisecond)
+	if err == nil || !strings.Contains(err.Error(), "no final response within 50ms") {
+		t.Fatalf("expected a timeout error, got %v", err)
+	}
+}
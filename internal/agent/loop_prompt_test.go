@@ -0,0 +1,20 @@
+package agent
+
+import "testing"
+
+func TestParsePromptCommand(t *testing.T) {
+	id, args, ok := parsePromptCommand("/prompt code_review lang=Go focus=errors")
+	if !ok || id != "code_review" {
+		t.Fatalf("unexpected parse: %q %v", id, ok)
+	}
+	if args["lang"] != "Go" || args["focus"] != "errors" {
+		t.Fatalf("unexpected args: %v", args)
+	}
+
+	if id, _, ok := parsePromptCommand("/prompt"); !ok || id != "" {
+		t.Fatalf("bare /prompt should list prompts, got %q %v", id, ok)
+	}
+	if _, _, ok := parsePromptCommand("please /prompt x"); ok {
+		t.Fatal("command must be at the start of the message")
+	}
+}
@@ -0,0 +1,35 @@
+package agent
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/local/picobot/internal/chat"
+)
+
+func TestReplyToMessageFollowsChannelToggle(t *testing.T) {
+	b := chat.NewHub(10)
+	ag := NewAgentLoop(b, replyProvider{}, "test", 3, t.TempDir(), nil, nil)
+	ag.SetReplyToMessage([]string{"discord"})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	go ag.Run(ctx)
+
+	for _, tc := range []struct{ channel, want string }{
+		{"discord", "m1"},
+		{"telegram", ""},
+	} {
+		b.In <- chat.Inbound{Channel: tc.channel, SenderID: "user", ChatID: "c1", Content: "hello",
+			Metadata: map[string]interface{}{"message_id": "m1"}}
+		select {
+		case out := <-b.Out:
+			if out.ReplyTo != tc.want {
+				t.Errorf("%s: ReplyTo = %q, want %q", tc.channel, out.ReplyTo, tc.want)
+			}
+		case <-time.After(2 * time.Second):
+			t.Fatalf("%s: timeout waiting for reply", tc.channel)
+		}
+	}
+}
@@ -0,0 +1,50 @@
+package agent
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/local/picobot/internal/chat"
+	"github.com/local/picobot/internal/providers"
+)
+
+// systemPromptProvider records the system prompt of every request.
+type systemPromptProvider struct {
+	systems []string
+}
+
+func (p *systemPromptProvider) Chat(ctx context.Context, messages []providers.Message, tools []providers.ToolDefinition, model string) (providers.LLMResponse, error) {
+	p.systems = append(p.systems, messages[0].Content)
+	return providers.LLMResponse{Content: "ok"}, nil
+}
+func (p *systemPromptProvider) GetDefaultModel() string { return "test" }
+
+func TestNewSkillAppearsWithoutRestart(t *testing.T) {
+	p := &systemPromptProvider{}
+	ag := NewAgentLoop(chat.NewHub(10), p, p.GetDefaultModel(), 3, t.TempDir(), nil, nil)
+
+	if _, err := ag.ProcessDirect("hello", time.Second); err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(p.systems[0], "brew-coffee") {
+		t.Fatal("skill should not exist yet")
+	}
+
+	_, err := ag.tools.Execute(context.Background(), "create_skill", map[string]interface{}{
+		"name":        "brew-coffee",
+		"description": "How to brew coffee",
+		"content":     "Grind, pour, wait.",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := ag.ProcessDirect("hello again", time.Second); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(p.systems[1], "## brew-coffee") || !strings.Contains(p.systems[1], "Grind, pour, wait.") {
+		t.Fatalf("expected the new skill in the next turn's context, got:\n%s", p.systems[1])
+	}
+}
@@ -0,0 +1,99 @@
+package agent
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/local/picobot/internal/chat"
+	"github.com/local/picobot/internal/providers"
+)
+
+// summaryProvider answers summary requests (made with the "small" model)
+// with a fixed summary and records the system prompts of normal turns.
+type summaryProvider struct {
+	mu        sync.Mutex
+	summaries int
+	systems   []string
+}
+
+func (p *summaryProvider) Chat(ctx context.Context, messages []providers.Message, tools []providers.ToolDefinition, model string) (providers.LLMResponse, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if model == "small" {
+		p.summaries++
+		return providers.LLMResponse{Content: "- the user likes tea"}, nil
+	}
+	p.systems = append(p.systems, messages[0].Content)
+	return providers.LLMResponse{Content: "ok"}, nil
+}
+func (p *summaryProvider) GetDefaultModel() string { return "big" }
+
+func TestConversationIsSummarizedIntoMemory(t *testing.T) {
+	b := chat.NewHub(10)
+	p := &summaryProvider{}
+	ag := NewAgentLoop(b, p, "big", 3, t.TempDir(), nil, nil)
+	ag.SetToolActivityIndicator(false)
+	ag.SetSummaryPolicy(SummaryPolicy{EveryTurns: 3, Model: "small"})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	go ag.Run(ctx)
+
+	for i := 0; i < 4; i++ {
+		b.In <- chat.Inbound{Channel: "telegram", SenderID: "u", ChatID: "c", Content: "I like tea"}
+		select {
+		case <-b.Out:
+		case <-ctx.Done():
+			t.Fatal("timeout waiting for reply")
+		}
+	}
+	time.Sleep(50 * time.Millisecond)
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.summaries != 1 {
+		t.Fatalf("expected 1 summary after 3 turns, got %d", p.summaries)
+	}
+	got, err := ag.memory.LatestSummary("telegram:c")
+	if err != nil || got != "- the user likes tea" {
+		t.Fatalf("summary not stored for the chat: %q, %v", got, err)
+	}
+	if n := len(ag.sessions.GetOrCreate("telegram:c").GetHistory()); n != keepAfterSummary+2 {
+		t.Fatalf("expected summarized history to be trimmed, got %d messages", n)
+	}
+	last := p.systems[len(p.systems)-1]
+	if !strings.Contains(last, "the user likes tea") {
+		t.Fatalf("expected the summary in the next turn's context, got:\n%s", last)
+	}
+}
+
+func TestSummaryTriggeredByHistorySize(t *testing.T) {
+	b := chat.NewHub(10)
+	p := &summaryProvider{}
+	ag := NewAgentLoop(b, p, "big", 3, t.TempDir(), nil, nil)
+	ag.SetToolActivityIndicator(false)
+	ag.SetSummaryPolicy(SummaryPolicy{MaxHistoryTokens: 100, Model: "small"})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	go ag.Run(ctx)
+
+	for _, content := range []string{"hi", "hi", strings.Repeat("long message ", 50)} {
+		b.In <- chat.Inbound{Channel: "telegram", SenderID: "u", ChatID: "c", Content: content}
+		select {
+		case <-b.Out:
+		case <-ctx.Done():
+			t.Fatal("timeout waiting for reply")
+		}
+	}
+	time.Sleep(50 * time.Millisecond)
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.summaries != 1 {
+		t.Fatalf("expected a summary once the history grew past the budget, got %d", p.summaries)
+	}
+}
@@ -0,0 +1,40 @@
+package agent
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/local/picobot/internal/chat"
+)
+
+func TestCustomSystemPromptOpensFirstMessage(t *testing.T) {
+	p := &systemPromptProvider{}
+	ag := NewAgentLoop(chat.NewHub(10), p, p.GetDefaultModel(), 3, t.TempDir(), nil, nil)
+
+	if err := ag.SetSystemPrompt("You are Ada, a terse ops assistant on {{.Channel}}. Today is {{.Date}}."); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ag.ProcessDirect("hello", time.Second); err != nil {
+		t.Fatal(err)
+	}
+	want := "You are Ada, a terse ops assistant on cli. Today is " + time.Now().Format("2006-01-02") + "."
+	if !strings.HasPrefix(p.systems[0], want) || strings.Contains(p.systems[0], defaultSystemPrompt) {
+		t.Fatalf("system prompt should open with the custom prompt, got:\n%s", p.systems[0])
+	}
+
+	// A template that doesn't parse is rejected and the prompt kept.
+	if err := ag.SetSystemPrompt("Hi {{.Channel"); err == nil {
+		t.Fatal("want an error for a broken template")
+	}
+	// Empty restores the default.
+	if err := ag.SetSystemPrompt(""); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ag.ProcessDirect("hello", time.Second); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(p.systems[1], defaultSystemPrompt) {
+		t.Fatalf("want the default prompt back, got:\n%s", p.systems[1])
+	}
+}
@@ -1,6 +1,8 @@
 package agent
 
 import (
+	"context"
+	"strings"
 	"testing"
 	"time"
 
@@ -22,3 +24,74 @@ func TestProcessDirectWithStub(t *testing.T) {
 		t.Fatalf("expected response, got empty string")
 	}
 }
+
+// provider that records the stop sequences it was asked to use
+type stopRecordingProvider struct {
+	got [][]string
+}
+
+func (p *stopRecordingProvider) Chat(ctx context.Context, messages []providers.Message, tools []providers.ToolDefinition, model string) (providers.LLMResponse, error) {
+	p.got = append(p.got, providers.StopFromContext(ctx))
+	return providers.LLMResponse{Content: "ok"}, nil
+}
+func (p *stopRecordingProvider) GetDefaultModel() string { return "test" }
+
+func TestStopSequencesForwarded(t *testing.T) {
+	b := chat.NewHub(10)
+	p := &stopRecordingProvider{}
+	ag := NewAgentLoop(b, p, p.GetDefaultModel(), 3, t.TempDir(), nil, nil)
+	ag.SetStopSequences([]string{"\nUser:", "<|end|>"})
+
+	if _, err := ag.ProcessDirect("hello", time.Second); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	go ag.Run(ctx)
+	b.In <- chat.Inbound{Channel: "telegram", SenderID: "u", ChatID: "c", Content: "hello"}
+	select {
+	case <-b.Out:
+	case <-ctx.Done():
+		t.Fatal("timeout waiting for reply")
+	}
+
+	if len(p.got) != 2 {
+		t.Fatalf("expected 2 provider calls, got %d", len(p.got))
+	}
+	for _, stop := range p.got {
+		if len(stop) != 2 || stop[0] != "\nUser:" || stop[1] != "<|end|>" {
+			t.Fatalf("stop sequences not forwarded: %q", stop)
+		}
+	}
+}
+
+type cutOffProvider struct{}
+
+func (cutOffProvider) Chat(ctx context.Context, messages []providers.Message, tools []providers.ToolDefinition, model string) (providers.LLMResponse, error) {
+	return providers.LLMResponse{Content: "The first step is", Incomplete: true}, nil
+}
+func (cutOffProvider) GetDefaultModel() string { return "test" }
+
+func TestIncompleteReplyIsMarked(t *testing.T) {
+	b := chat.NewHub(10)
+	ag := NewAgentLoop(b, cutOffProvider{}, "test", 3, t.TempDir(), nil, nil)
+
+	got, err := ag.ProcessDirect("how?", time.Second)
+	if err != nil || !strings.HasPrefix(got, "The first step is") || !strings.HasSuffix(got, incompleteMarker) {
+		t.Fatalf("ProcessDirect = %q, %v; want partial content with the incomplete marker", got, err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	go ag.Run(ctx)
+	b.In <- chat.Inbound{Channel: "telegram", SenderID: "u", ChatID: "c", Content: "how?"}
+	select {
+	case out := <-b.Out:
+		if !strings.HasPrefix(out.Content, "The first step is") || !strings.HasSuffix(out.Content, incompleteMarker) {
+			t.Fatalf("got %q, want partial content with the incomplete marker", out.Content)
+		}
+	case <-ctx.Done():
+		t.Fatal("timeout waiting for reply")
+	}
+}
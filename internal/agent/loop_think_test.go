@@ -0,0 +1,124 @@
+package agent
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/local/picobot/internal/chat"
+	"github.com/local/picobot/internal/providers"
+)
+
+// thinkingProvider returns a reply with inline reasoning.
+type thinkingProvider struct{}
+
+func (p *thinkingProvider) Chat(ctx context.Context, messages []providers.Message, tools []providers.ToolDefinition, model string) (providers.LLMResponse, error) {
+	return providers.LLMResponse{Content: "<think>The user greeted me, so greet back.</think>\nHello there!"}, nil
+}
+func (p *thinkingProvider) GetDefaultModel() string { return "thinking" }
+
+func TestAgentStripsThinkTagsFromHistory(t *testing.T) {
+	b := chat.NewHub(10)
+	p := &thinkingProvider{}
+	ag := NewAgentLoop(b, p, p.GetDefaultModel(), 5, t.TempDir(), nil, nil)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	go ag.Run(ctx)
+
+	b.In <- chat.Inbound{Channel: "cli", SenderID: "user", ChatID: "one", Content: "hi"}
+
+	var thinking, answer *chat.Outbound
+	for answer == nil {
+		select {
+		case out := <-b.Out:
+			switch out.Kind {
+			case chat.KindThinking:
+				thinking = &out
+			case "":
+				answer = &out
+			}
+		case <-time.After(time.Second):
+			t.Fatal("timeout waiting for reply")
+		}
+	}
+	if answer.Content != "Hello there!" {
+		t.Fatalf("expected sanitized reply, got %q", answer.Content)
+	}
+	if thinking == nil || thinking.Content != "The user greeted me, so greet back." {
+		t.Fatalf("expected reasoning as a separate thinking event, got %+v", thinking)
+	}
+
+	history := ag.sessions.GetOrCreate("cli:one").GetHistory()
+	if len(history) != 2 {
+		t.Fatalf("expected 2 history entries, got %v", history)
+	}
+	for _, h := range history {
+		if strings.Contains(h, "<think>") || strings.Contains(h, "greet back") {
+			t.Fatalf("history contains reasoning: %q", h)
+		}
+	}
+	if history[1] != "assistant: Hello there!" {
+		t.Fatalf("unexpected assistant history: %q", history[1])
+	}
+}
+
+// reasoningFieldProvider returns its reasoning in a separate field, as some
+// OpenAI-compatible servers do.
+type reasoningFieldProvider struct{}
+
+func (p *reasoningFieldProvider) Chat(ctx context.Context, messages []providers.Message, tools []providers.ToolDefinition, model string) (providers.LLMResponse, error) {
+	return providers.LLMResponse{Content: "Hello there!", Reasoning: "A greeting deserves a greeting."}, nil
+}
+func (p *reasoningFieldProvider) GetDefaultModel() string { return "reasoning" }
+
+func TestAgentHandlesSeparateReasoningLikeThinkTags(t *testing.T) {
+	b := chat.NewHub(10)
+	p := &reasoningFieldProvider{}
+	ag := NewAgentLoop(b, p, p.GetDefaultModel(), 5, t.TempDir(), nil, nil)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	go ag.Run(ctx)
+
+	b.In <- chat.Inbound{Channel: "cli", SenderID: "user", ChatID: "one", Content: "hi"}
+
+	var got []chat.Outbound
+	for len(got) < 2 {
+		select {
+		case out := <-b.Out:
+			got = append(got, out)
+		case <-time.After(time.Second):
+			t.Fatalf("timeout waiting for reply, got %+v", got)
+		}
+	}
+	if got[0].Kind != chat.KindThinking || got[0].Content != "A greeting deserves a greeting." {
+		t.Fatalf("expected the reasoning field as a thinking event, got %+v", got[0])
+	}
+	if got[1].Kind != "" || got[1].Content != "Hello there!" {
+		t.Fatalf("expected the plain answer, got %+v", got[1])
+	}
+	for _, h := range ag.sessions.GetOrCreate("cli:one").GetHistory() {
+		if strings.Contains(h, "greeting deserves") {
+			t.Fatalf("history contains reasoning: %q", h)
+		}
+	}
+}
+
+func TestSanitizeContent(t *testing.T) {
+	tests := []struct {
+		in, want string
+	}{
+		{"plain answer", "plain answer"},
+		{"<think>a</think>answer", "answer"},
+		{"<THINKING>a\nb</THINKING>\n\nanswer", "answer"},
+		{"reasoning without opening tag</think>answer", "answer"},
+		{"answer<think>cut off mid-thought", "answer"},
+	}
+	for _, tt := range tests {
+		if got := sanitizeContent(tt.in); got != tt.want {
+			t.Errorf("sanitizeContent(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,159 @@
+package agent
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/local/picobot/internal/chat"
+	"github.com/local/picobot/internal/providers"
+)
+
+// slowProvider says something and calls a tool on the first request, then
+// blocks until its context is cancelled.
+type slowProvider struct {
+	calls     int
+	cancelled chan struct{}
+}
+
+func (p *slowProvider) Chat(ctx context.Context, messages []providers.Message, tools []providers.ToolDefinition, model string) (providers.LLMResponse, error) {
+	p.calls++
+	if p.calls == 1 {
+		return providers.LLMResponse{
+			Content:      "Let me look that up.",
+			HasToolCalls: true,
+			ToolCalls:    []providers.ToolCall{{ID: "1", Name: "noop", Arguments: map[string]interface{}{}}},
+		}, nil
+	}
+	<-ctx.Done()
+	close(p.cancelled)
+	return providers.LLMResponse{}, ctx.Err()
+}
+func (p *slowProvider) GetDefaultModel() string { return "slow" }
+
+func TestAgentTurnTimeoutSendsApology(t *testing.T) {
+	b := chat.NewHub(10)
+	p := &slowProvider{cancelled: make(chan struct{})}
+	ag := NewAgentLoop(b, p, p.GetDefaultModel(), 5, t.TempDir(), nil, nil)
+	ag.SetToolActivityIndicator(false)
+	ag.SetTurnTimeout(100 * time.Millisecond)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+	go ag.Run(ctx)
+
+	b.In <- chat.Inbound{Channel: "cli", SenderID: "user", ChatID: "one", Content: "weather?"}
+
+	select {
+	case out := <-b.Out:
+		if !strings.HasPrefix(out.Content, "Sorry, that took longer") {
+			t.Fatalf("expected apology, got %q", out.Content)
+		}
+		if !strings.Contains(out.Content, "Let me look that up.") {
+			t.Fatalf("expected partial content in reply, got %q", out.Content)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("no reply after turn timeout")
+	}
+
+	select {
+	case <-p.cancelled:
+	default:
+		t.Fatal("provider call was not cancelled")
+	}
+}
+
+// slowStreamProvider streams the start of a reply, then stalls until its
+// context is cancelled.
+type slowStreamProvider struct{}
+
+func (slowStreamProvider) Chat(ctx context.Context, messages []providers.Message, tools []providers.ToolDefinition, model string) (providers.LLMResponse, error) {
+	<-ctx.Done()
+	return providers.LLMResponse{}, ctx.Err()
+}
+func (slowStreamProvider) GetDefaultModel() string { return "slow" }
+func (slowStreamProvider) ChatStream(ctx context.Context, messages []providers.Message, tools []providers.ToolDefinition, model string) (<-chan providers.Delta, error) {
+	ch := make(chan providers.Delta, 1)
+	go func() {
+		defer close(ch)
+		ch <- providers.Delta{Content: "Tomorrow looks sunny"}
+		<-ctx.Done()
+		ch <- providers.Delta{Done: true, Err: ctx.Err()}
+	}()
+	return ch, nil
+}
+
+func TestAgentTurnTimeoutKeepsStreamedText(t *testing.T) {
+	b := chat.NewHub(10)
+	ui := b.Subscribe("web", chat.KindDelta)
+	ag := NewAgentLoop(b, slowStreamProvider{}, "slow", 5, t.TempDir(), nil, nil)
+	ag.SetTurnTimeout(100 * time.Millisecond)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+	b.StartRouter(ctx)
+	go ag.Run(ctx)
+
+	b.In <- chat.Inbound{Channel: "web", SenderID: "user", ChatID: "one", Content: "weather?"}
+
+	for {
+		select {
+		case out := <-ui:
+			if out.Kind != "" {
+				continue
+			}
+			if !strings.HasPrefix(out.Content, "Sorry, that took longer") || !strings.Contains(out.Content, "Tomorrow looks sunny") {
+				t.Fatalf("expected apology with the streamed text, got %q", out.Content)
+			}
+			return
+		case <-time.After(2 * time.Second):
+			t.Fatal("no reply after turn timeout")
+		}
+	}
+}
+
+func TestCancelTurnStopsInFlightRequest(t *testing.T) {
+	b := chat.NewHub(10)
+	p := &slowProvider{cancelled: make(chan struct{})}
+	ag := NewAgentLoop(b, p, p.GetDefaultModel(), 5, t.TempDir(), nil, nil)
+	ag.SetToolActivityIndicator(false)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+	go ag.Run(ctx)
+
+	if ag.CancelTurn("cli", "one") {
+		t.Fatal("nothing is in flight yet, CancelTurn should report false")
+	}
+	b.In <- chat.Inbound{Channel: "cli", SenderID: "user", ChatID: "one", Content: "weather?"}
+
+	// The turn registers once it starts; retry until it does.
+	deadline := time.Now().Add(2 * time.Second)
+	for !ag.CancelTurn("cli", "one") {
+		if time.Now().After(deadline) {
+			t.Fatal("turn never became cancellable")
+		}
+		if ag.CancelTurn("cli", "other") {
+			t.Fatal("cancelled a conversation with nothing in flight")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	select {
+	case <-p.cancelled:
+	case <-time.After(time.Second):
+		t.Fatal("provider call was not cancelled")
+	}
+	select {
+	case out := <-b.Out:
+		if !strings.Contains(out.Content, "cancelled") {
+			t.Fatalf("expected a stopped notice, got %q", out.Content)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("no reply after cancelling")
+	}
+	if ag.CancelTurn("cli", "one") {
+		t.Fatal("finished turn should no longer be cancellable")
+	}
+}
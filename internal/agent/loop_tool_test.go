@@ -2,6 +2,7 @@ package agent
 
 import (
 	"context"
+	"strings"
 	"testing"
 	"time"
 
@@ -59,3 +60,61 @@ func TestAgentExecutesToolCall(t *testing.T) {
 		}
 	}
 }
+
+// provider that calls a tool that doesn't exist; it corrects itself if
+// fixAfterError is set, otherwise it keeps trying.
+type unknownToolProvider struct {
+	fixAfterError bool
+	calls         int
+	toolResults   []string
+}
+
+func (p *unknownToolProvider) Chat(ctx context.Context, messages []providers.Message, tools []providers.ToolDefinition, model string) (providers.LLMResponse, error) {
+	p.calls++
+	if last := messages[len(messages)-1]; last.Role == "tool" {
+		p.toolResults = append(p.toolResults, last.Content)
+		if p.fixAfterError {
+			return providers.LLMResponse{Content: "Corrected"}, nil
+		}
+	}
+	tc := providers.ToolCall{ID: "x", Name: "read_file", Arguments: map[string]interface{}{"path": "a.txt"}}
+	return providers.LLMResponse{HasToolCalls: true, ToolCalls: []providers.ToolCall{tc}}, nil
+}
+func (p *unknownToolProvider) GetDefaultModel() string { return "fake" }
+
+func TestUnknownToolCallIsFedBackAndCapped(t *testing.T) {
+	b := chat.NewHub(10)
+	p := &unknownToolProvider{fixAfterError: true}
+	ag := NewAgentLoop(b, p, p.GetDefaultModel(), 20, t.TempDir(), nil, nil)
+	ag.SetToolActivityIndicator(false)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+	go ag.Run(ctx)
+
+	reply := func() string {
+		b.In <- chat.Inbound{Channel: "cli", SenderID: "user", ChatID: "one", Content: "read a.txt"}
+		select {
+		case out := <-b.Out:
+			return out.Content
+		case <-time.After(2 * time.Second):
+			t.Fatal("timeout waiting for reply")
+			return ""
+		}
+	}
+
+	if got := reply(); got != "Corrected" {
+		t.Fatalf("expected the model to recover, got %q", got)
+	}
+	if len(p.toolResults) != 1 || !strings.Contains(p.toolResults[0], `unknown tool "read_file"`) || !strings.Contains(p.toolResults[0], "filesystem") {
+		t.Fatalf("expected a corrective tool result naming the real tools, got %v", p.toolResults)
+	}
+
+	p.fixAfterError, p.calls = false, 0
+	if got := reply(); got != unknownToolsReply {
+		t.Fatalf("expected the turn to be stopped, got %q", got)
+	}
+	if p.calls != defaultMaxUnknownToolCalls {
+		t.Fatalf("provider called %d times, want the turn stopped after %d unknown tool calls", p.calls, defaultMaxUnknownToolCalls)
+	}
+}
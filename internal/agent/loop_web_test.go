@@ -53,6 +53,7 @@ func TestAgentExecutesWebToolCall(t *testing.T) {
 	b := chat.NewHub(10)
 	p := &webCallingProvider{server: h.URL}
 	ag := NewAgentLoop(b, p, p.GetDefaultModel(), 5, "", nil, nil)
+	ag.SetWebAllowPrivateNetworks(true) // httptest listens on loopback
 
 	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
 	defer cancel()
@@ -192,6 +192,68 @@ func (s *MemoryStore) GetRecentMemories(days int) (string, error) {
 	return strings.Join(parts, "\n---\n"), nil
 }
 
+// Limits for SearchNotes, so a search stays cheap however many daily notes
+// have accumulated.
+const (
+	maxSearchDays    = 365
+	maxSearchMatches = 20
+	maxExcerptLen    = 300
+)
+
+// NoteMatch is a daily-note line found by SearchNotes.
+type NoteMatch struct {
+	Date    string // YYYY-MM-DD
+	Excerpt string
+}
+
+// SearchNotes looks through the daily notes of the last sinceDays days
+// (including today; 0 means the maximum of 365) for lines containing every
+// word of query, ignoring case. Matches are returned newest first, at most
+// 20 of them.
+func (s *MemoryStore) SearchNotes(query string, sinceDays int) ([]NoteMatch, error) {
+	terms := strings.Fields(strings.ToLower(query))
+	if len(terms) == 0 {
+		return nil, fmt.Errorf("search query is empty")
+	}
+	if sinceDays <= 0 || sinceDays > maxSearchDays {
+		sinceDays = maxSearchDays
+	}
+	var matches []NoteMatch
+	for i := 0; i < sinceDays && len(matches) < maxSearchMatches; i++ {
+		date := time.Now().UTC().AddDate(0, 0, -i).Format("2006-01-02")
+		b, err := os.ReadFile(filepath.Join(s.memoryDir, date+".md"))
+		if err != nil {
+			if os.IsNotExist(err) {
+				continue
+			}
+			return nil, err
+		}
+		for _, line := range strings.Split(string(b), "\n") {
+			if !containsAll(strings.ToLower(line), terms) {
+				continue
+			}
+			excerpt := strings.TrimSpace(line)
+			if len(excerpt) > maxExcerptLen {
+				excerpt = strings.ToValidUTF8(excerpt[:maxExcerptLen], "") + "..."
+			}
+			matches = append(matches, NoteMatch{Date: date, Excerpt: excerpt})
+			if len(matches) == maxSearchMatches {
+				break
+			}
+		}
+	}
+	return matches, nil
+}
+
+func containsAll(s string, terms []string) bool {
+	for _, t := range terms {
+		if !strings.Contains(s, t) {
+			return false
+		}
+	}
+	return true
+}
+
 // isValidMemoryFile reports whether name is a safe, recognised memory filename
 // (either "MEMORY.md" or a date file "YYYY-MM-DD.md").
 func isValidMemoryFile(name string) bool {
@@ -293,3 +355,49 @@ func (s *MemoryStore) GetMemoryContext() (string, error) {
 	}
 	return lt + "\n\n---\n\n" + td, nil
 }
+
+// summaryPath returns the file holding a conversation's summaries, under
+// memory/summaries/. key is "channel:chatID".
+func (s *MemoryStore) summaryPath(key string) string {
+	name := strings.NewReplacer(":", "-", "/", "-", "\\", "-").Replace(key) + ".md"
+	return filepath.Join(s.memoryDir, "summaries", name)
+}
+
+// AppendSummary records a summary of the conversation key ("channel:chatID").
+// Summaries are kept per conversation and never overwritten.
+func (s *MemoryStore) AppendSummary(key, summary string) error {
+	path := s.summaryPath(key)
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = f.Close() }()
+	_, err = fmt.Fprintf(f, "## %s\n%s\n\n", time.Now().UTC().Format(time.RFC3339), strings.TrimSpace(summary))
+	return err
+}
+
+// LatestSummary returns the most recent summary of the conversation key, or
+// "" if there is none.
+func (s *MemoryStore) LatestSummary(key string) (string, error) {
+	b, err := os.ReadFile(s.summaryPath(key))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return "", nil
+		}
+		return "", err
+	}
+	// Each entry starts with a "## <timestamp>" line; headings inside a
+	// summary don't parse as timestamps.
+	lines := strings.Split(string(b), "\n")
+	for i := len(lines) - 1; i >= 0; i-- {
+		if ts, ok := strings.CutPrefix(lines[i], "## "); ok {
+			if _, err := time.Parse(time.RFC3339, ts); err == nil {
+				return strings.TrimSpace(strings.Join(lines[i+1:], "\n")), nil
+			}
+		}
+	}
+	return strings.TrimSpace(string(b)), nil
+}
@@ -3,7 +3,9 @@ package memory
 import (
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
+	"time"
 )
 
 func TestMemoryPersistence_ReadWriteLongAndToday(t *testing.T) {
@@ -58,3 +60,72 @@ func TestMemoryPersistence_ReadWriteLongAndToday(t *testing.T) {
 		t.Fatalf("expected memory context, got empty")
 	}
 }
+
+func TestSearchNotes(t *testing.T) {
+	s := NewMemoryStoreWithWorkspace(t.TempDir(), 10)
+	day := func(ago int) string { return time.Now().UTC().AddDate(0, 0, -ago).Format("2006-01-02") }
+	for ago, content := range map[int]string{
+		0:  "[10:00] Talked about the garden\n[11:00] Ordered tomato seeds\n",
+		6:  "[09:00] Discussed the Garden layout with Sam\n[09:30] Budget review\n",
+		40: "[08:00] First garden idea\n",
+	} {
+		if err := s.WriteFile(day(ago)+".md", content); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	got, err := s.SearchNotes("GARDEN", 30)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 2 || got[0].Date != day(0) || got[1].Date != day(6) || got[1].Excerpt != "[09:00] Discussed the Garden layout with Sam" {
+		t.Fatalf("unexpected matches: %+v", got)
+	}
+	if got, _ := s.SearchNotes("garden sam", 30); len(got) != 1 {
+		t.Fatalf("every word must match, got %+v", got)
+	}
+	if got, _ := s.SearchNotes("garden", 0); len(got) != 3 {
+		t.Fatalf("0 days should search the whole window, got %+v", got)
+	}
+	if _, err := s.SearchNotes("  ", 7); err == nil {
+		t.Fatal("expected an error for an empty query")
+	}
+}
+
+func TestSearchNotesCapsMatches(t *testing.T) {
+	s := NewMemoryStoreWithWorkspace(t.TempDir(), 10)
+	if err := s.WriteFile(time.Now().UTC().Format("2006-01-02")+".md", strings.Repeat("coffee again\n", 50)); err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := s.SearchNotes("coffee", 1); len(got) != maxSearchMatches {
+		t.Fatalf("got %d matches, want the cap of %d", len(got), maxSearchMatches)
+	}
+}
+
+func TestSummariesAreKeptPerConversation(t *testing.T) {
+	s := NewMemoryStoreWithWorkspace(t.TempDir(), 10)
+
+	if got, err := s.LatestSummary("telegram:1"); err != nil || got != "" {
+		t.Fatalf("expected no summary yet, got %q, %v", got, err)
+	}
+	if err := s.AppendSummary("telegram:1", "first"); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.AppendSummary("telegram:1", "## Decisions\n- second"); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.AppendSummary("discord:2", "other chat"); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := s.LatestSummary("telegram:1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != "## Decisions\n- second" {
+		t.Fatalf("unexpected latest summary: %q", got)
+	}
+	if got, _ := s.LatestSummary("discord:2"); got != "other chat" {
+		t.Fatalf("unexpected summary for other chat: %q", got)
+	}
+}
@@ -4,14 +4,118 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"regexp"
+	"strconv"
 	"strings"
+	"unicode"
 )
 
 // Skill represents a loaded skill with its metadata and content.
 type Skill struct {
 	Name        string
 	Description string
-	Content     string
+	// Tags are topic keywords ("tags: [git, release]" in the frontmatter).
+	Tags []string
+	// Triggers are case-insensitive regular expressions, or plain keywords,
+	// that mark a message as relevant ("trigger: [deploy, 'roll ?back']").
+	Triggers []string
+	Content  string
+}
+
+// Matches reports whether message looks relevant to the skill: a trigger
+// matches it, or it contains one of the tags as a whole word.
+func (s Skill) Matches(message string) bool {
+	return Relevant(s.Tags, s.Triggers, message)
+}
+
+// Relevant reports whether message matches any trigger or contains any tag
+// as a whole word, ignoring case. A trigger that isn't a valid regular
+// expression is matched as plain text.
+func Relevant(tags, triggers []string, message string) bool {
+	lower := strings.ToLower(message)
+	for _, tr := range triggers {
+		if re, err := regexp.Compile("(?i)" + tr); err == nil {
+			if re.MatchString(message) {
+				return true
+			}
+		} else if tr != "" && strings.Contains(lower, strings.ToLower(tr)) {
+			return true
+		}
+	}
+	words := strings.FieldsFunc(lower, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '-' && r != '_'
+	})
+	for _, tag := range tags {
+		for _, w := range words {
+			if w == strings.ToLower(tag) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// ParseList parses a frontmatter list value: "[a, 'b c', \"d,e\"]" or a
+// bare comma-separated "a, b". Items may be quoted to contain commas.
+func ParseList(value string) []string {
+	value = strings.TrimSpace(value)
+	if strings.HasPrefix(value, "[") && strings.HasSuffix(value, "]") {
+		value = value[1 : len(value)-1]
+	}
+	var items []string
+	var cur strings.Builder
+	var quote rune
+	escaped := false
+	flush := func() {
+		if item := strings.TrimSpace(cur.String()); item != "" {
+			items = append(items, unquote(item))
+		}
+		cur.Reset()
+	}
+	for _, r := range value {
+		switch {
+		case escaped:
+			escaped = false
+		case quote == '"' && r == '\\':
+			escaped = true
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			}
+		case r == '"' || r == '\'':
+			quote = r
+		case r == ',':
+			flush()
+			continue
+		}
+		cur.WriteRune(r)
+	}
+	flush()
+	return items
+}
+
+func unquote(s string) string {
+	if len(s) >= 2 && s[0] == '\'' && s[len(s)-1] == '\'' {
+		return s[1 : len(s)-1]
+	}
+	if s[0] == '"' {
+		if u, err := strconv.Unquote(s); err == nil {
+			return u
+		}
+	}
+	return s
+}
+
+// FormatList renders items as a frontmatter list that ParseList reads back.
+func FormatList(items []string) string {
+	quoted := make([]string, len(items))
+	for i, item := range items {
+		if item != strings.TrimSpace(item) || strings.ContainsAny(item, ",[]'\"") {
+			item = strconv.Quote(item)
+		}
+		quoted[i] = item
+	}
+	return "[" + strings.Join(quoted, ", ") + "]"
 }
 
 // Loader handles loading skills from the skills directory.
@@ -92,6 +196,10 @@ func (l *Loader) loadSkill(skillPath string) (Skill, error) {
 			skill.Name = value
 		case "description":
 			skill.Description = value
+		case "tags":
+			skill.Tags = ParseList(value)
+		case "trigger", "triggers":
+			skill.Triggers = ParseList(value)
 		}
 	}
 
@@ -91,3 +91,45 @@ func TestLoader_LoadByName(t *testing.T) {
 		t.Errorf("expected content to contain 'Test content', got '%s'", skill.Content)
 	}
 }
+
+func TestParseListRoundTrip(t *testing.T) {
+	items := []string{"git", "roll ?back", `deploy(ment)?\b`, "a, b", `say "hi"`, "it's"}
+	got := ParseList(FormatList(items))
+	if strings.Join(got, "|") != strings.Join(items, "|") {
+		t.Fatalf("round trip got %q, want %q", got, items)
+	}
+	if got := ParseList("git, release"); len(got) != 2 || got[1] != "release" {
+		t.Fatalf("bare list got %q", got)
+	}
+}
+
+func TestLoader_TagsAndTriggers(t *testing.T) {
+	tmpDir := t.TempDir()
+	skillDir := filepath.Join(tmpDir, "skills", "release")
+	if err := os.MkdirAll(skillDir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	content := "---\nname: release\ndescription: Cut a release\ntags: [git, versioning]\ntrigger: ['ship it', \"roll ?back\"]\n---\n\nSteps"
+	if err := os.WriteFile(filepath.Join(skillDir, "SKILL.md"), []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	skill, err := NewLoader(tmpDir).LoadByName("release")
+	if err != nil {
+		t.Fatalf("LoadByName failed: %v", err)
+	}
+	if strings.Join(skill.Tags, ",") != "git,versioning" || strings.Join(skill.Triggers, ",") != "ship it,roll ?back" {
+		t.Fatalf("got tags %q triggers %q", skill.Tags, skill.Triggers)
+	}
+	for msg, want := range map[string]bool{
+		"Can you SHIP IT today?":    true,
+		"we need to rollback":       true,
+		"what's in the git log":     true,
+		"digit counting":            false,
+		"tell me about the weather": false,
+	} {
+		if got := skill.Matches(msg); got != want {
+			t.Errorf("Matches(%q) = %v, want %v", msg, got, want)
+		}
+	}
+}
@@ -0,0 +1,108 @@
+package agent
+
+import (
+	"context"
+	"log"
+	"strings"
+
+	"github.com/local/picobot/internal/session"
+)
+
+// SummaryPolicy configures automatic conversation summaries. A conversation
+// is summarized once EveryTurns turns have passed since the last summary, or
+// once its history is estimated to exceed MaxHistoryTokens, whichever comes
+// first; zero disables that trigger. Model, if set, is used instead of the
+// agent's model, e.g. a cheaper one.
+type SummaryPolicy struct {
+	EveryTurns       int
+	MaxHistoryTokens int
+	Model            string
+}
+
+func (p SummaryPolicy) enabled() bool { return p.EveryTurns > 0 || p.MaxHistoryTokens > 0 }
+
+// keepAfterSummary is how many of the most recent messages stay in the
+// session history once the rest has been summarized.
+const keepAfterSummary = 4
+
+const summaryInstructions = "Summarize this conversation for your own long-term memory: who the user is, what was discussed and decided, open tasks, and facts worth remembering. Fold in the previous summary, if any, so the result stands on its own. Use short bullet points."
+
+// SetSummaryPolicy enables automatic summaries of long conversations. Each
+// summary is appended to the conversation's file under memory/summaries/, the
+// latest one is added to that conversation's context, and the summarized
+// messages are dropped from the session history.
+func (a *AgentLoop) SetSummaryPolicy(p SummaryPolicy) {
+	a.summary = p
+	a.turnsSinceSummary = make(map[string]int)
+}
+
+// estimateTokens roughly counts the tokens in history, at four characters
+// per token.
+func estimateTokens(history []string) int {
+	n := 0
+	for _, m := range history {
+		n += len(m)
+	}
+	return n / 4
+}
+
+// maybeSummarize summarizes sess after a turn if the summary policy says it
+// is due. Failures are logged and retried after the next turn.
+func (a *AgentLoop) maybeSummarize(ctx context.Context, sess *session.Session) {
+	if !a.summary.enabled() {
+		return
+	}
+	a.turnsSinceSummary[sess.Key]++
+	history := sess.GetHistory()
+	due := (a.summary.EveryTurns > 0 && a.turnsSinceSummary[sess.Key] >= a.summary.EveryTurns) ||
+		(a.summary.MaxHistoryTokens > 0 && estimateTokens(history) > a.summary.MaxHistoryTokens)
+	if !due || len(history) <= keepAfterSummary {
+		return
+	}
+
+	previous, err := a.memory.LatestSummary(sess.Key)
+	if err != nil {
+		log.Printf("summary: reading previous summary of %s: %v", sess.Key, err)
+	}
+	text := strings.Join(history, "\n")
+	if previous != "" {
+		text = "Previous summary:\n" + previous + "\n\nConversation since then:\n" + text
+	}
+	if len(text) > maxDigestSource {
+		text = text[len(text)-maxDigestSource:]
+	}
+	model := a.summary.Model
+	if model == "" {
+		model = a.currentModel()
+	}
+	summary, err := a.summarize(ctx, model, summaryInstructions, text)
+	if err != nil {
+		log.Printf("summary: summarizing %s: %v", sess.Key, err)
+		return
+	}
+	if err := a.memory.AppendSummary(sess.Key, summary); err != nil {
+		log.Printf("summary: saving summary of %s: %v", sess.Key, err)
+		return
+	}
+	a.turnsSinceSummary[sess.Key] = 0
+	sess.KeepLast(keepAfterSummary)
+	if err := a.sessions.Save(sess); err != nil {
+		log.Printf("error saving session: %v", err)
+	}
+}
+
+// withSummary adds the latest summary of the conversation key to memCtx.
+func (a *AgentLoop) withSummary(memCtx, key string) string {
+	summary, err := a.memory.LatestSummary(key)
+	if err != nil {
+		log.Printf("summary: reading summary of %s: %v", key, err)
+	}
+	if summary == "" {
+		return memCtx
+	}
+	summary = "Summary of this conversation so far:\n" + summary
+	if memCtx == "" {
+		return summary
+	}
+	return memCtx + "\n\n---\n\n" + summary
+}
@@ -0,0 +1,62 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// AskFunc sends question to the user of a conversation and waits for their
+// reply.
+type AskFunc func(ctx context.Context, channel, chatID, question string) (string, error)
+
+// AskUserTool lets the model ask the user a clarifying question in the middle
+// of a turn. The turn pauses until the user's next message in the same
+// conversation, which becomes the tool result.
+type AskUserTool struct {
+	ask     AskFunc
+	channel string
+	chatID  string
+}
+
+// NewAskUserTool creates an ask_user tool that asks through ask.
+func NewAskUserTool(ask AskFunc) *AskUserTool {
+	return &AskUserTool{ask: ask}
+}
+
+func (t *AskUserTool) Name() string { return "ask_user" }
+func (t *AskUserTool) Description() string {
+	return "Ask the user a clarifying question and wait for their answer before continuing. Use only when the request is ambiguous and guessing would likely be wrong."
+}
+
+func (t *AskUserTool) Parameters() map[string]interface{} {
+	return map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"question": map[string]interface{}{
+				"type":        "string",
+				"description": "The question to ask, e.g. offering the options you are choosing between",
+			},
+		},
+		"required": []string{"question"},
+	}
+}
+
+// SetContext sets the conversation the question is asked in.
+func (t *AskUserTool) SetContext(channel, chatID string) {
+	t.channel = channel
+	t.chatID = chatID
+}
+
+func (t *AskUserTool) Execute(ctx context.Context, args map[string]interface{}) (string, error) {
+	question, _ := args["question"].(string)
+	question = strings.TrimSpace(question)
+	if question == "" {
+		return "", fmt.Errorf("ask_user: 'question' is required")
+	}
+	answer, err := t.ask(ctx, t.channel, t.chatID, question)
+	if err != nil {
+		return "", fmt.Errorf("ask_user: %w", err)
+	}
+	return "The user answered: " + answer, nil
+}
@@ -0,0 +1,325 @@
+package tools
+
+import (
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// Limits for the compress tool, so a small archive can't fill the disk
+// (a "zip bomb") and a single call stays bounded.
+const (
+	maxCompressBytes = 100 << 20 // total bytes read or written per call
+	maxZipEntries    = 10000
+)
+
+var errTooLarge = fmt.Errorf("exceeds the %d MB limit", maxCompressBytes>>20)
+
+// CompressTool gzips and gunzips single files or strings, and creates and
+// extracts zip archives, all inside the workspace via os.Root.
+type CompressTool struct {
+	root *os.Root
+}
+
+// NewCompressTool creates a compress tool working in root.
+func NewCompressTool(root *os.Root) *CompressTool {
+	return &CompressTool{root: root}
+}
+
+func (t *CompressTool) Name() string { return "compress" }
+
+func (t *CompressTool) Description() string {
+	return "Gzip or gunzip a workspace file (or gzip a string), or create or extract a zip archive of workspace paths"
+}
+
+func (t *CompressTool) Parameters() map[string]interface{} {
+	return map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"action": map[string]interface{}{
+				"type":        "string",
+				"description": "gzip, gunzip, zip, or unzip",
+				"enum":        []string{"gzip", "gunzip", "zip", "unzip"},
+			},
+			"path": map[string]interface{}{
+				"type":        "string",
+				"description": "Workspace file to gzip, gunzip, or unzip",
+			},
+			"content": map[string]interface{}{
+				"type":        "string",
+				"description": "Text to gzip instead of a file (gzip; requires dest)",
+			},
+			"paths": map[string]interface{}{
+				"type":        "array",
+				"description": "Workspace files and directories to put in the archive (zip)",
+				"items":       map[string]interface{}{"type": "string"},
+			},
+			"dest": map[string]interface{}{
+				"type":        "string",
+				"description": "Output file (gzip, gunzip, zip) or directory (unzip). Defaults: path + .gz for gzip, path without .gz for gunzip, the current directory for unzip.",
+			},
+		},
+		"required": []string{"action"},
+	}
+}
+
+func (t *CompressTool) Execute(ctx context.Context, args map[string]interface{}) (string, error) {
+	action, _ := args["action"].(string)
+	src, _ := args["path"].(string)
+	dest, _ := args["dest"].(string)
+	var (
+		res string
+		err error
+	)
+	switch action {
+	case "gzip":
+		res, err = t.gzip(args, src, dest)
+	case "gunzip":
+		res, err = t.gunzip(src, dest)
+	case "zip":
+		res, err = t.zip(args, dest)
+	case "unzip":
+		res, err = t.unzip(src, dest)
+	default:
+		return "", fmt.Errorf("compress: unknown action %q (use gzip, gunzip, zip, or unzip)", action)
+	}
+	if err != nil {
+		return "", fmt.Errorf("compress %s: %w", action, err)
+	}
+	return res, nil
+}
+
+func (t *CompressTool) gzip(args map[string]interface{}, src, dest string) (string, error) {
+	var data []byte
+	if content, ok := args["content"].(string); ok && src == "" {
+		if dest == "" {
+			return "", fmt.Errorf("'dest' is required when compressing content")
+		}
+		data = []byte(content)
+	} else {
+		if src == "" {
+			return "", fmt.Errorf("'path' or 'content' is required")
+		}
+		b, err := t.readCapped(src)
+		if err != nil {
+			return "", err
+		}
+		data = b
+		if dest == "" {
+			dest = src + ".gz"
+		}
+	}
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	if _, err := zw.Write(data); err != nil {
+		return "", err
+	}
+	if err := zw.Close(); err != nil {
+		return "", err
+	}
+	if err := t.root.WriteFile(filepath.Clean(dest), buf.Bytes(), 0o644); err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("Compressed %d bytes to %d bytes in %s", len(data), buf.Len(), dest), nil
+}
+
+func (t *CompressTool) gunzip(src, dest string) (string, error) {
+	if src == "" {
+		return "", fmt.Errorf("'path' is required")
+	}
+	if dest == "" {
+		if !strings.HasSuffix(src, ".gz") {
+			return "", fmt.Errorf("'dest' is required when path doesn't end in .gz")
+		}
+		dest = strings.TrimSuffix(src, ".gz")
+	}
+	f, err := t.root.Open(filepath.Clean(src))
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	zr, err := gzip.NewReader(f)
+	if err != nil {
+		return "", err
+	}
+	data, err := io.ReadAll(io.LimitReader(zr, maxCompressBytes+1))
+	if err != nil {
+		return "", err
+	}
+	if len(data) > maxCompressBytes {
+		return "", fmt.Errorf("decompressed data %w", errTooLarge)
+	}
+	if err := t.root.WriteFile(filepath.Clean(dest), data, 0o644); err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("Decompressed %s to %s (%d bytes)", src, dest, len(data)), nil
+}
+
+func (t *CompressTool) zip(args map[string]interface{}, dest string) (string, error) {
+	paths, err := stringList(args["paths"])
+	if err != nil {
+		return "", fmt.Errorf("'paths' %v", err)
+	}
+	if len(paths) == 0 {
+		return "", fmt.Errorf("'paths' is required")
+	}
+	if dest == "" {
+		return "", fmt.Errorf("'dest' is required")
+	}
+	dest = filepath.Clean(dest)
+
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	files, total := 0, 0
+	fsys := t.root.FS()
+	for _, p := range paths {
+		p = path.Clean(filepath.ToSlash(p))
+		err := fs.WalkDir(fsys, p, func(name string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if !d.Type().IsRegular() || name == filepath.ToSlash(dest) {
+				return nil // directories are implied; skip symlinks and the archive itself
+			}
+			b, err := fs.ReadFile(fsys, name)
+			if err != nil {
+				return err
+			}
+			if total += len(b); total > maxCompressBytes {
+				return fmt.Errorf("input %w", errTooLarge)
+			}
+			if files++; files > maxZipEntries {
+				return fmt.Errorf("more than %d files", maxZipEntries)
+			}
+			w, err := zw.Create(name)
+			if err != nil {
+				return err
+			}
+			_, err = w.Write(b)
+			return err
+		})
+		if err != nil {
+			return "", err
+		}
+	}
+	if err := zw.Close(); err != nil {
+		return "", err
+	}
+	if err := t.root.WriteFile(dest, buf.Bytes(), 0o644); err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("Archived %d files (%d bytes) to %s (%d bytes)", files, total, dest, buf.Len()), nil
+}
+
+func (t *CompressTool) unzip(src, dest string) (string, error) {
+	if src == "" {
+		return "", fmt.Errorf("'path' is required")
+	}
+	if dest == "" {
+		dest = "."
+	}
+	data, err := t.readCapped(src)
+	if err != nil {
+		return "", err
+	}
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return "", err
+	}
+	if len(zr.File) > maxZipEntries {
+		return "", fmt.Errorf("archive has more than %d entries", maxZipEntries)
+	}
+	// Check every entry before writing anything, so a bad archive leaves
+	// nothing half-extracted.
+	var total uint64
+	for _, f := range zr.File {
+		if _, err := zipEntryPath(dest, f.Name); err != nil {
+			return "", err
+		}
+		if f.Mode()&fs.ModeSymlink != 0 {
+			return "", fmt.Errorf("entry %q is a symlink, which is not allowed", f.Name)
+		}
+		if total += f.UncompressedSize64; total > maxCompressBytes {
+			return "", fmt.Errorf("extracted size %w", errTooLarge)
+		}
+	}
+
+	written, files := int64(0), 0
+	for _, f := range zr.File {
+		name, _ := zipEntryPath(dest, f.Name)
+		if f.FileInfo().IsDir() {
+			if err := t.root.MkdirAll(name, 0o755); err != nil {
+				return "", err
+			}
+			continue
+		}
+		if err := t.root.MkdirAll(filepath.Dir(name), 0o755); err != nil {
+			return "", err
+		}
+		// The sizes in the header can lie, so the limit is enforced while
+		// copying too.
+		n, err := t.extractFile(f, name, maxCompressBytes-written)
+		written += n
+		if err != nil {
+			return "", fmt.Errorf("%s: %w", f.Name, err)
+		}
+		files++
+	}
+	return fmt.Sprintf("Extracted %d files (%d bytes) from %s into %s", files, written, src, dest), nil
+}
+
+func (t *CompressTool) extractFile(f *zip.File, name string, limit int64) (int64, error) {
+	rc, err := f.Open()
+	if err != nil {
+		return 0, err
+	}
+	defer rc.Close()
+	out, err := t.root.OpenFile(name, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0o644)
+	if err != nil {
+		return 0, err
+	}
+	n, err := io.Copy(out, io.LimitReader(rc, limit+1))
+	if cerr := out.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil && n > limit {
+		err = fmt.Errorf("extracted size %w", errTooLarge)
+	}
+	return n, err
+}
+
+// zipEntryPath returns where an archive entry is extracted under dest, and
+// rejects names that would land outside it ("zip slip"). os.Root would
+// refuse those writes anyway; checking first gives a clear error.
+func zipEntryPath(dest, name string) (string, error) {
+	clean := path.Clean(strings.ReplaceAll(name, "\\", "/"))
+	if name == "" || path.IsAbs(clean) || clean == ".." || strings.HasPrefix(clean, "../") || filepath.VolumeName(clean) != "" {
+		return "", fmt.Errorf("entry %q would be extracted outside the destination", name)
+	}
+	return filepath.Join(filepath.Clean(dest), filepath.FromSlash(clean)), nil
+}
+
+// readCapped reads a workspace file of at most maxCompressBytes.
+func (t *CompressTool) readCapped(name string) ([]byte, error) {
+	f, err := t.root.Open(filepath.Clean(name))
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	data, err := io.ReadAll(io.LimitReader(f, maxCompressBytes+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(data) > maxCompressBytes {
+		return nil, fmt.Errorf("%s %w", name, errTooLarge)
+	}
+	return data, nil
+}
@@ -0,0 +1,98 @@
+package tools
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func newCompressWorkspace(t *testing.T) (string, *CompressTool) {
+	t.Helper()
+	d := t.TempDir()
+	root, err := os.OpenRoot(d)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = root.Close() })
+	return d, NewCompressTool(root)
+}
+
+func TestCompressGzipRoundTrip(t *testing.T) {
+	d, tool := newCompressWorkspace(t)
+	ctx := context.Background()
+	text := strings.Repeat("picobot compresses well. ", 100)
+
+	if _, err := tool.Execute(ctx, map[string]interface{}{"action": "gzip", "content": text, "dest": "data.txt.gz"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := tool.Execute(ctx, map[string]interface{}{"action": "gunzip", "path": "data.txt.gz"}); err != nil {
+		t.Fatal(err)
+	}
+	got, err := os.ReadFile(filepath.Join(d, "data.txt"))
+	if err != nil || string(got) != text {
+		t.Fatalf("round trip mismatch (%v): got %d bytes", err, len(got))
+	}
+
+	// A file gzips next to itself by default.
+	if _, err := tool.Execute(ctx, map[string]interface{}{"action": "gzip", "path": "data.txt"}); err != nil {
+		t.Fatal(err)
+	}
+	if fi, err := os.Stat(filepath.Join(d, "data.txt.gz")); err != nil || fi.Size() >= int64(len(text)) {
+		t.Fatalf("expected a smaller data.txt.gz, got %v, %v", fi, err)
+	}
+}
+
+func TestCompressZipAndExtract(t *testing.T) {
+	d, tool := newCompressWorkspace(t)
+	ctx := context.Background()
+	os.MkdirAll(filepath.Join(d, "docs", "sub"), 0o755)
+	os.WriteFile(filepath.Join(d, "docs", "a.md"), []byte("alpha"), 0o644)
+	os.WriteFile(filepath.Join(d, "docs", "sub", "b.md"), []byte("beta"), 0o644)
+	os.WriteFile(filepath.Join(d, "top.txt"), []byte("top"), 0o644)
+
+	res, err := tool.Execute(ctx, map[string]interface{}{"action": "zip", "paths": []interface{}{"docs", "top.txt"}, "dest": "out.zip"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(res, "Archived 3 files") {
+		t.Fatalf("unexpected result %q", res)
+	}
+	if _, err := tool.Execute(ctx, map[string]interface{}{"action": "unzip", "path": "out.zip", "dest": "restored"}); err != nil {
+		t.Fatal(err)
+	}
+	for name, want := range map[string]string{"docs/a.md": "alpha", "docs/sub/b.md": "beta", "top.txt": "top"} {
+		got, err := os.ReadFile(filepath.Join(d, "restored", name))
+		if err != nil || string(got) != want {
+			t.Fatalf("%s: got %q, %v", name, got, err)
+		}
+	}
+}
+
+func TestCompressUnzipRejectsTraversal(t *testing.T) {
+	d, tool := newCompressWorkspace(t)
+	for _, name := range []string{"../evil.txt", "/etc/evil.txt", "a/../../evil.txt", `..\evil.txt`} {
+		var buf bytes.Buffer
+		zw := zip.NewWriter(&buf)
+		w, _ := zw.Create("ok.txt")
+		w.Write([]byte("fine"))
+		w, _ = zw.Create(name)
+		w.Write([]byte("pwned"))
+		zw.Close()
+		os.WriteFile(filepath.Join(d, "bad.zip"), buf.Bytes(), 0o644)
+
+		_, err := tool.Execute(context.Background(), map[string]interface{}{"action": "unzip", "path": "bad.zip", "dest": "out"})
+		if err == nil || !strings.Contains(err.Error(), "outside the destination") {
+			t.Fatalf("%s: expected traversal to be rejected, got %v", name, err)
+		}
+		if _, err := os.Stat(filepath.Join(d, "out", "ok.txt")); !os.IsNotExist(err) {
+			t.Fatalf("%s: nothing should be extracted from a bad archive", name)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(filepath.Dir(d), "evil.txt")); !os.IsNotExist(err) {
+		t.Fatal("file written outside the workspace")
+	}
+}
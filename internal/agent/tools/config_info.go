@@ -0,0 +1,113 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"net/url"
+	"sort"
+	"sync"
+
+	"github.com/local/picobot/internal/config"
+)
+
+// ConfigInfoTool reports the agent's own settings (model, limits, enabled
+// channels, tools) so it can answer questions such as "which model are
+// you?". It only ever copies non-secret fields: tokens, API keys, passwords
+// and MCP headers are never read.
+type ConfigInfoTool struct {
+	model func() string
+	tools func() []string
+
+	mu  sync.RWMutex
+	cfg config.Config
+}
+
+// NewConfigInfoTool creates a config_info tool. model and tools return the
+// current model and tool names, which can change while the agent runs.
+func NewConfigInfoTool(model func() string, tools func() []string) *ConfigInfoTool {
+	return &ConfigInfoTool{model: model, tools: tools}
+}
+
+// SetConfig sets the config reported, e.g. after it is reloaded.
+func (t *ConfigInfoTool) SetConfig(cfg config.Config) {
+	t.mu.Lock()
+	t.cfg = cfg
+	t.mu.Unlock()
+}
+
+func (t *ConfigInfoTool) Name() string { return "config_info" }
+
+func (t *ConfigInfoTool) Description() string {
+	return "Show your own configuration (model, limits, provider host, enabled channels, MCP servers, tools). Read-only; secrets are never included."
+}
+
+func (t *ConfigInfoTool) Parameters() map[string]interface{} {
+	return map[string]interface{}{
+		"type":       "object",
+		"properties": map[string]interface{}{},
+	}
+}
+
+// configInfo is the report returned by config_info.
+type configInfo struct {
+	Model             string   `json:"model"`
+	MaxTokens         int      `json:"maxTokens,omitempty"`
+	Temperature       float64  `json:"temperature"`
+	MaxToolIterations int      `json:"maxToolIterations,omitempty"`
+	ProviderHost      string   `json:"providerHost,omitempty"`
+	Channels          []string `json:"channels"`
+	MCPServers        []string `json:"mcpServers,omitempty"`
+	Tools             []string `json:"tools"`
+}
+
+func (t *ConfigInfoTool) Execute(ctx context.Context, args map[string]interface{}) (string, error) {
+	t.mu.RLock()
+	cfg := t.cfg
+	t.mu.RUnlock()
+
+	d := cfg.Agents.Defaults
+	info := configInfo{
+		Model:             t.model(),
+		MaxTokens:         d.MaxTokens,
+		Temperature:       d.Temperature,
+		MaxToolIterations: d.MaxToolIterations,
+		Channels:          []string{},
+		Tools:             t.tools(),
+	}
+	// Only the host of the API base: a URL can carry credentials too.
+	p := cfg.Providers.OpenAI
+	if cfg.Providers.Ollama != nil {
+		p = cfg.Providers.Ollama
+	}
+	if p != nil && p.APIBase != "" {
+		if u, err := url.Parse(p.APIBase); err == nil {
+			info.ProviderHost = u.Host
+		}
+	}
+	ch := cfg.Channels
+	for _, c := range []struct {
+		name    string
+		enabled bool
+	}{
+		{"telegram", ch.Telegram.Enabled},
+		{"discord", ch.Discord.Enabled},
+		{"slack", ch.Slack.Enabled},
+		{"whatsapp", ch.WhatsApp.Enabled},
+		{"email", ch.Email.Enabled},
+	} {
+		if c.enabled {
+			info.Channels = append(info.Channels, c.name)
+		}
+	}
+	for name := range cfg.MCPServers {
+		info.MCPServers = append(info.MCPServers, name)
+	}
+	sort.Strings(info.MCPServers)
+	sort.Strings(info.Tools)
+
+	b, err := json.MarshalIndent(info, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	return string(b), nil
+}
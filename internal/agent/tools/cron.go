@@ -24,7 +24,7 @@ func NewCronTool(scheduler *cron.Scheduler) *CronTool {
 
 func (t *CronTool) Name() string { return "cron" }
 func (t *CronTool) Description() string {
-	return "Schedule one-time or recurring reminders/tasks. Actions: add (schedule), list (show pending), cancel (remove by name)."
+	return "Schedule one-time or recurring reminders/tasks, or a recurring digest that summarizes a web page, feed, or chat and posts it here. Actions: add (schedule), list (show pending), cancel (remove by name)."
 }
 
 func (t *CronTool) Parameters() map[string]interface{} {
@@ -40,9 +40,18 @@ func (t *CronTool) Parameters() map[string]interface{} {
 				"type":        "string",
 				"description": "A short name for the job (used to identify it for cancellation)",
 			},
+			"kind": map[string]interface{}{
+				"type":        "string",
+				"description": "reminder (default) delivers the message; digest fetches 'source', summarizes it following 'message', and posts the summary on every run",
+				"enum":        []string{"reminder", "digest"},
+			},
+			"source": map[string]interface{}{
+				"type":        "string",
+				"description": "For digests: an http(s) URL of a page or feed, or chat:<channel>:<chatID> to summarize a chat's recent history",
+			},
 			"message": map[string]interface{}{
 				"type":        "string",
-				"description": "The reminder message or task description to deliver when the job fires",
+				"description": "The reminder message or task description to deliver when the job fires (for digests: how to summarize, e.g. 'top 5 headlines')",
 			},
 			"delay": map[string]interface{}{
 				"type":        "string",
@@ -78,6 +87,14 @@ func (t *CronTool) Execute(ctx context.Context, args map[string]interface{}) (st
 		recurring, _ := args["recurring"].(bool)
 		intervalStr, _ := args["interval"].(string)
 
+		kind, _ := args["kind"].(string)
+		if kind == cron.KindDigest {
+			return t.addDigest(name, message, args)
+		}
+		if kind != "" && kind != "reminder" {
+			return "", fmt.Errorf("cron add: unknown kind %q (use reminder or digest)", kind)
+		}
+
 		if name == "" {
 			name = "reminder"
 		}
@@ -126,6 +143,10 @@ func (t *CronTool) Execute(ctx context.Context, args map[string]interface{}) (st
 		fmt.Fprintf(&sb, "%d pending job(s):\n", len(jobs))
 		for _, j := range jobs {
 			remaining := time.Until(j.FireAt).Round(time.Second)
+			if j.Kind == cron.KindDigest {
+				fmt.Fprintf(&sb, "- %s (%s): digest of %s — fires in %v\n", j.Name, j.ID, j.Source, remaining)
+				continue
+			}
 			fmt.Fprintf(&sb, "- %s (%s): %q — fires in %v\n", j.Name, j.ID, j.Message, remaining)
 		}
 		return sb.String(), nil
@@ -144,3 +165,36 @@ func (t *CronTool) Execute(ctx context.Context, args map[string]interface{}) (st
 		return "", fmt.Errorf("cron: unknown action %q (use add, list, or cancel)", action)
 	}
 }
+
+// addDigest schedules a recurring digest job.
+func (t *CronTool) addDigest(name, instructions string, args map[string]interface{}) (string, error) {
+	source, _ := args["source"].(string)
+	if source == "" {
+		return "", fmt.Errorf("cron add: 'source' is required for a digest")
+	}
+	if !strings.HasPrefix(source, "http://") && !strings.HasPrefix(source, "https://") && !strings.HasPrefix(source, "chat:") {
+		return "", fmt.Errorf("cron add: digest source must be an http(s) URL or chat:<channel>:<chatID>")
+	}
+	if name == "" {
+		name = "digest"
+	}
+	if instructions == "" {
+		instructions = "Summarize the most important points."
+	}
+	intervalStr, _ := args["interval"].(string)
+	if intervalStr == "" {
+		intervalStr, _ = args["delay"].(string)
+	}
+	if intervalStr == "" {
+		return "", fmt.Errorf("cron add: 'interval' is required for a digest (e.g. '24h')")
+	}
+	interval, err := time.ParseDuration(intervalStr)
+	if err != nil {
+		return "", fmt.Errorf("cron add: invalid interval %q: %v", intervalStr, err)
+	}
+	if interval < 2*time.Minute {
+		return "", fmt.Errorf("cron add: recurring interval must be at least 2m (got %v)", interval)
+	}
+	id := t.scheduler.AddDigest(name, source, instructions, interval, t.channel, t.chatID)
+	return fmt.Sprintf("Scheduled digest %q (id: %s) of %s. Will post every %v.", name, id, source, interval), nil
+}
@@ -0,0 +1,227 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"os/exec"
+	"path"
+	"strings"
+	"time"
+)
+
+// dockerLabel marks containers started by the tool; ps, logs and stop only
+// act on containers that carry it.
+const dockerLabel = "picobot.managed=true"
+
+// DockerPolicy configures the docker tool. It is disabled unless Enabled is
+// set, and even then only images matching AllowedImages (glob patterns such
+// as "python:*") can be run.
+type DockerPolicy struct {
+	Enabled       bool
+	AllowedImages []string
+	// AllowedFlags adds "docker run" flag names (e.g. "--network") to the
+	// default allowlist. Flags in deniedDockerFlags can never be allowed.
+	AllowedFlags []string
+	Timeout      time.Duration
+}
+
+// defaultDockerFlags are the run flags the model may pass, as --name=value.
+var defaultDockerFlags = []string{"--env", "--memory", "--cpus", "--name", "--workdir", "--user", "--entrypoint"}
+
+// deniedDockerFlags give a container access to the host and are always
+// rejected, whatever the configuration says.
+var deniedDockerFlags = map[string]bool{
+	"--privileged": true, "--volume": true, "-v": true, "--mount": true, "--volumes-from": true,
+	"--device": true, "--cap-add": true, "--security-opt": true, "--pid": true, "--ipc": true,
+	"--uts": true, "--userns": true, "--cgroupns": true, "--cgroup-parent": true, "--runtime": true,
+}
+
+// DockerTool runs and manages containers through the docker CLI, restricted
+// to allowlisted images and flags. Containers always run with all
+// capabilities dropped and no-new-privileges.
+type DockerTool struct {
+	policy  DockerPolicy
+	flags   map[string]bool
+	command func(ctx context.Context, args ...string) (string, error)
+}
+
+// NewDockerTool creates a docker tool governed by p.
+func NewDockerTool(p DockerPolicy) *DockerTool {
+	if p.Timeout <= 0 {
+		p.Timeout = 60 * time.Second
+	}
+	flags := make(map[string]bool)
+	for _, f := range append(append([]string(nil), defaultDockerFlags...), p.AllowedFlags...) {
+		if !deniedDockerFlags[f] {
+			flags[f] = true
+		}
+	}
+	return &DockerTool{policy: p, flags: flags, command: runDocker}
+}
+
+func runDocker(ctx context.Context, args ...string) (string, error) {
+	b, err := exec.CommandContext(ctx, "docker", args...).CombinedOutput()
+	out := strings.TrimRight(string(b), "\n")
+	if err != nil {
+		return out, fmt.Errorf("docker %s: %w", args[0], err)
+	}
+	return out, nil
+}
+
+func (t *DockerTool) Name() string { return "docker" }
+func (t *DockerTool) Description() string {
+	return "Run containers from allowlisted images and list, read logs of, or stop them (must be enabled in config)"
+}
+
+func (t *DockerTool) Parameters() map[string]interface{} {
+	return map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"action": map[string]interface{}{
+				"type":        "string",
+				"description": "run (start a container), ps (list containers started by this tool), logs, or stop",
+				"enum":        []string{"run", "ps", "logs", "stop"},
+			},
+			"image": map[string]interface{}{
+				"type":        "string",
+				"description": "Image to run, e.g. python:3.12-slim (must be allowlisted)",
+			},
+			"cmd": map[string]interface{}{
+				"type":        "array",
+				"description": "Command and arguments to run in the container",
+				"items":       map[string]interface{}{"type": "string"},
+			},
+			"flags": map[string]interface{}{
+				"type":        "array",
+				"description": "Extra docker run flags in --flag=value form, e.g. --env=KEY=value, --memory=256m",
+				"items":       map[string]interface{}{"type": "string"},
+			},
+			"detach": map[string]interface{}{
+				"type":        "boolean",
+				"description": "Run in the background and return the container ID instead of waiting for output",
+			},
+			"container": map[string]interface{}{
+				"type":        "string",
+				"description": "Container ID or name, for logs and stop",
+			},
+		},
+		"required": []string{"action"},
+	}
+}
+
+func (t *DockerTool) Execute(ctx context.Context, args map[string]interface{}) (string, error) {
+	if !t.policy.Enabled {
+		return "", fmt.Errorf("docker: the docker tool is not enabled; set agents.defaults.docker.enabled in the config")
+	}
+	ctx, cancel := context.WithTimeout(ctx, t.policy.Timeout)
+	defer cancel()
+
+	action, _ := args["action"].(string)
+	switch action {
+	case "run":
+		argv, err := t.runArgs(args)
+		if err != nil {
+			return "", err
+		}
+		return t.command(ctx, argv...)
+	case "ps":
+		return t.command(ctx, "ps", "--all", "--filter", "label="+dockerLabel,
+			"--format", "{{.ID}}\t{{.Names}}\t{{.Image}}\t{{.Status}}")
+	case "logs", "stop":
+		container, _ := args["container"].(string)
+		if container == "" || strings.HasPrefix(container, "-") {
+			return "", fmt.Errorf("docker %s: 'container' is required", action)
+		}
+		if err := t.checkManaged(ctx, container); err != nil {
+			return "", err
+		}
+		if action == "logs" {
+			return t.command(ctx, "logs", "--tail", "200", container)
+		}
+		return t.command(ctx, "stop", container)
+	default:
+		return "", fmt.Errorf("docker: unknown action %q (use run, ps, logs, or stop)", action)
+	}
+}
+
+// runArgs validates a run request and builds the docker CLI arguments.
+func (t *DockerTool) runArgs(args map[string]interface{}) ([]string, error) {
+	image, _ := args["image"].(string)
+	if image == "" || strings.HasPrefix(image, "-") {
+		return nil, fmt.Errorf("docker run: 'image' is required")
+	}
+	if !t.imageAllowed(image) {
+		return nil, fmt.Errorf("docker run: image %q is not in the allowed images list", image)
+	}
+	flags, err := stringList(args["flags"])
+	if err != nil {
+		return nil, fmt.Errorf("docker run: 'flags' %v", err)
+	}
+	cmd, err := stringList(args["cmd"])
+	if err != nil {
+		return nil, fmt.Errorf("docker run: 'cmd' %v", err)
+	}
+
+	argv := []string{"run", "--label", dockerLabel, "--cap-drop", "ALL", "--security-opt", "no-new-privileges"}
+	if detach, _ := args["detach"].(bool); detach {
+		argv = append(argv, "--detach")
+	} else {
+		argv = append(argv, "--rm")
+	}
+	for _, f := range flags {
+		name, value, _ := strings.Cut(f, "=")
+		if deniedDockerFlags[name] || (name == "--network" && (value == "host" || strings.HasPrefix(value, "container:"))) {
+			return nil, fmt.Errorf("docker run: flag %s is not allowed (it gives the container access to the host)", name)
+		}
+		if !strings.HasPrefix(name, "--") || !strings.Contains(f, "=") {
+			return nil, fmt.Errorf("docker run: flag %q must be in --flag=value form", f)
+		}
+		if !t.flags[name] {
+			return nil, fmt.Errorf("docker run: flag %s is not in the allowed flags list", name)
+		}
+		argv = append(argv, f)
+	}
+	return append(append(argv, image), cmd...), nil
+}
+
+func (t *DockerTool) imageAllowed(image string) bool {
+	for _, pattern := range t.policy.AllowedImages {
+		if ok, _ := path.Match(pattern, image); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// checkManaged refuses to act on containers the tool didn't start.
+func (t *DockerTool) checkManaged(ctx context.Context, container string) error {
+	key, value, _ := strings.Cut(dockerLabel, "=")
+	out, err := t.command(ctx, "inspect", "--format", `{{index .Config.Labels "`+key+`"}}`, container)
+	if err != nil {
+		return fmt.Errorf("docker: no such container %s", container)
+	}
+	if strings.TrimSpace(out) != value {
+		return fmt.Errorf("docker: container %s was not started by picobot", container)
+	}
+	return nil
+}
+
+// stringList converts a JSON array argument to strings; nil is an empty list.
+func stringList(v interface{}) ([]string, error) {
+	if v == nil {
+		return nil, nil
+	}
+	list, ok := v.([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("must be an array of strings")
+	}
+	out := make([]string, 0, len(list))
+	for _, x := range list {
+		s, ok := x.(string)
+		if !ok {
+			return nil, fmt.Errorf("must be an array of strings")
+		}
+		out = append(out, s)
+	}
+	return out, nil
+}
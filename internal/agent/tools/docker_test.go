@@ -0,0 +1,73 @@
+package tools
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestDockerToolDisabledByDefault(t *testing.T) {
+	tool := NewDockerTool(DockerPolicy{})
+	tool.command = func(ctx context.Context, args ...string) (string, error) {
+		t.Fatalf("docker should not be invoked, got %v", args)
+		return "", nil
+	}
+	_, err := tool.Execute(context.Background(), map[string]interface{}{"action": "ps"})
+	if err == nil || !strings.Contains(err.Error(), "not enabled") {
+		t.Fatalf("expected not-enabled error, got %v", err)
+	}
+}
+
+func TestDockerToolRunRestrictions(t *testing.T) {
+	var calls [][]string
+	tool := NewDockerTool(DockerPolicy{Enabled: true, AllowedImages: []string{"alpine:*"}})
+	tool.command = func(ctx context.Context, args ...string) (string, error) {
+		calls = append(calls, args)
+		return "ok", nil
+	}
+	run := func(flags ...interface{}) error {
+		_, err := tool.Execute(context.Background(), map[string]interface{}{
+			"action": "run", "image": "alpine:3.20", "cmd": []interface{}{"echo", "hi"}, "flags": flags,
+		})
+		return err
+	}
+
+	for _, flag := range []string{"-v=/:/host", "--volume=/:/host", "--privileged=true", "--network=host", "--cap-add=SYS_ADMIN"} {
+		if err := run(flag); err == nil || !strings.Contains(err.Error(), "not allowed") {
+			t.Errorf("%s: expected rejection, got %v", flag, err)
+		}
+	}
+	if err := run("--privileged"); err == nil {
+		t.Error("bare --privileged should be rejected")
+	}
+	if _, err := tool.Execute(context.Background(), map[string]interface{}{"action": "run", "image": "ubuntu:latest"}); err == nil {
+		t.Error("expected image outside the allowlist to be rejected")
+	}
+	if len(calls) != 0 {
+		t.Fatalf("docker was invoked for rejected requests: %v", calls)
+	}
+
+	if err := run("--memory=128m"); err != nil {
+		t.Fatalf("allowed run: %v", err)
+	}
+	got := strings.Join(calls[0], " ")
+	want := "run --label " + dockerLabel + " --cap-drop ALL --security-opt no-new-privileges --rm --memory=128m alpine:3.20 echo hi"
+	if got != want {
+		t.Fatalf("unexpected docker args:\n got %s\nwant %s", got, want)
+	}
+}
+
+func TestDockerToolOnlyTouchesManagedContainers(t *testing.T) {
+	tool := NewDockerTool(DockerPolicy{Enabled: true})
+	tool.command = func(ctx context.Context, args ...string) (string, error) {
+		if args[0] == "inspect" {
+			return "", nil // no picobot label
+		}
+		t.Fatalf("unexpected docker call %v", args)
+		return "", nil
+	}
+	_, err := tool.Execute(context.Background(), map[string]interface{}{"action": "stop", "container": "postgres"})
+	if err == nil || !strings.Contains(err.Error(), "not started by picobot") {
+		t.Fatalf("expected refusal, got %v", err)
+	}
+}
@@ -1,6 +1,7 @@
 package tools
 
 import (
+	"bytes"
 	"context"
 	"errors"
 	"fmt"
@@ -17,19 +18,41 @@ import (
 // - blacklist dangerous program names (rm, sudo, dd, mkfs, shutdown, reboot)
 // - arguments containing absolute paths, ~ or .. are rejected
 // - optional allowedDir enforces a working directory
+// - optional allowed programs restrict which programs may run at all; the
+//   blacklist still applies to them
 
 type ExecTool struct {
 	timeout    time.Duration
+	maxTimeout time.Duration // ceiling for the timeoutSeconds argument
 	allowedDir string
+	allowed    map[string]struct{}
 }
 
-func NewExecTool(timeoutSecs int) *ExecTool {
-	return &ExecTool{timeout: time.Duration(timeoutSecs) * time.Second}
+// maxExecTimeout is the longest a command may ask to run with timeoutSeconds,
+// unless the default timeout is already longer.
+const maxExecTimeout = 10 * time.Minute
+
+// NewExecTool creates an ExecTool. If allowed programs are given, only those
+// may run.
+func NewExecTool(timeoutSecs int, allowed ...string) *ExecTool {
+	return NewExecToolWithWorkspace(timeoutSecs, "", allowed...)
 }
 
 // NewExecToolWithWorkspace creates an ExecTool restricted to the provided workspace directory.
-func NewExecToolWithWorkspace(timeoutSecs int, allowedDir string) *ExecTool {
-	return &ExecTool{timeout: time.Duration(timeoutSecs) * time.Second, allowedDir: allowedDir}
+func NewExecToolWithWorkspace(timeoutSecs int, allowedDir string, allowed ...string) *ExecTool {
+	timeout := time.Duration(timeoutSecs) * time.Second
+	return &ExecTool{timeout: timeout, maxTimeout: max(timeout, maxExecTimeout), allowedDir: allowedDir, allowed: programSet(allowed)}
+}
+
+func programSet(progs []string) map[string]struct{} {
+	if len(progs) == 0 {
+		return nil
+	}
+	set := make(map[string]struct{}, len(progs))
+	for _, p := range progs {
+		set[p] = struct{}{}
+	}
+	return set
 }
 
 func (t *ExecTool) Name() string { return "exec" }
@@ -49,6 +72,18 @@ func (t *ExecTool) Parameters() map[string]interface{} {
 				},
 				"minItems": 1,
 			},
+			"timeoutSeconds": map[string]interface{}{
+				"type":        "integer",
+				"description": "Override the default timeout for this command, e.g. longer for a build or shorter to fail fast (max 600)",
+			},
+			"includeStderr": map[string]interface{}{
+				"type":        "boolean",
+				"description": "Also return stderr when the command succeeds (stderr is always included in the error when it fails)",
+			},
+			"stdin": map[string]interface{}{
+				"type":        "string",
+				"description": "Optional input written to the command's standard input, e.g. JSON for jq",
+			},
 		},
 		"required": []string{"cmd"},
 	}
@@ -109,6 +144,12 @@ func (t *ExecTool) Execute(ctx context.Context, args map[string]interface{}) (st
 	if isDangerousProg(prog) {
 		return "", fmt.Errorf("exec: program '%s' is disallowed", prog)
 	}
+	if t.allowed != nil {
+		// Match the name exactly, so "/tmp/x/jq" doesn't pass for "jq".
+		if _, ok := t.allowed[prog]; !ok {
+			return "", fmt.Errorf("exec: program '%s' is not in the allowed programs list", prog)
+		}
+	}
 	for _, a := range argv[1:] {
 		if hasUnsafeArg(a) {
 			return "", fmt.Errorf("exec: argument '%s' looks unsafe", a)
@@ -116,9 +157,16 @@ func (t *ExecTool) Execute(ctx context.Context, args map[string]interface{}) (st
 	}
 
 	cctx := ctx
-	if t.timeout > 0 {
+	timeout := t.timeout
+	if n, ok := numberArg(args, "timeoutSeconds"); ok && n > 0 {
+		timeout = time.Duration(n * float64(time.Second))
+		if t.maxTimeout > 0 {
+			timeout = min(timeout, t.maxTimeout)
+		}
+	}
+	if timeout > 0 {
 		var cancel context.CancelFunc
-		cctx, cancel = context.WithTimeout(ctx, t.timeout)
+		cctx, cancel = context.WithTimeout(ctx, timeout)
 		defer cancel()
 	}
 
@@ -126,12 +174,67 @@ func (t *ExecTool) Execute(ctx context.Context, args map[string]interface{}) (st
 	if t.allowedDir != "" {
 		cmd.Dir = t.allowedDir
 	}
-	b, err := cmd.CombinedOutput()
-	if err != nil {
-		return string(b), fmt.Errorf("exec error: %w", err)
+	// The reader hits EOF after the input, so stdin is closed and commands
+	// that read until EOF finish.
+	if stdin, ok := args["stdin"].(string); ok {
+		cmd.Stdin = strings.NewReader(stdin)
 	}
+	stdout := &cappedBuffer{limit: maxExecOutput}
+	stderr := &cappedBuffer{limit: maxExecOutput}
+	cmd.Stdout, cmd.Stderr = stdout, stderr
+	err := cmd.Run()
 	// Trim trailing newline for nicer test assertions
-	out := string(b)
-	out = strings.TrimRight(out, "\n")
+	out := strings.TrimRight(stdout.String(), "\n")
+	errOut := strings.TrimSpace(stderr.String())
+	if err != nil {
+		// The model only sees the error, so carry the end of stderr, where
+		// the diagnostic usually is.
+		if errOut != "" {
+			return out, fmt.Errorf("exec error: %w: %s", err, tail(errOut, maxExecErrorStderr))
+		}
+		return out, fmt.Errorf("exec error: %w", err)
+	}
+	if include, _ := args["includeStderr"].(bool); include && errOut != "" {
+		out += "\n[stderr]\n" + errOut
+	}
 	return out, nil
 }
+
+// Output limits: each stream keeps at most maxExecOutput bytes, and a
+// failing command's error carries at most maxExecErrorStderr of stderr.
+const (
+	maxExecOutput      = 256 << 10
+	maxExecErrorStderr = 2 << 10
+)
+
+// cappedBuffer keeps the first limit bytes written to it and discards the
+// rest, noting that it did.
+type cappedBuffer struct {
+	buf       bytes.Buffer
+	limit     int
+	truncated bool
+}
+
+func (b *cappedBuffer) Write(p []byte) (int, error) {
+	if room := b.limit - b.buf.Len(); room < len(p) {
+		b.buf.Write(p[:max(room, 0)])
+		b.truncated = true
+		return len(p), nil
+	}
+	return b.buf.Write(p)
+}
+
+func (b *cappedBuffer) String() string {
+	if b.truncated {
+		return b.buf.String() + fmt.Sprintf("\n[output truncated at %d bytes]", b.limit)
+	}
+	return b.buf.String()
+}
+
+// tail returns the last n bytes of s, marking the cut.
+func tail(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	return "..." + s[len(s)-n:]
+}
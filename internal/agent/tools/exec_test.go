@@ -4,7 +4,9 @@ import (
 	"context"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
+	"time"
 )
 
 func TestExecArrayEcho(t *testing.T) {
@@ -65,3 +67,82 @@ func TestExecTimeout(t *testing.T) {
 		t.Fatalf("expected timeout error")
 	}
 }
+
+func TestExecAllowedPrograms(t *testing.T) {
+	e := NewExecTool(2, "echo", "rm")
+	out, err := e.Execute(context.Background(), map[string]interface{}{"cmd": []interface{}{"echo", "allowed"}})
+	if err != nil || out != "allowed" {
+		t.Fatalf("allowed program should run, got %q (%v)", out, err)
+	}
+	if _, err := e.Execute(context.Background(), map[string]interface{}{"cmd": []interface{}{"ls"}}); err == nil {
+		t.Fatal("expected a program outside the allowlist to be rejected")
+	}
+	// The blacklist wins over the allowlist.
+	if _, err := e.Execute(context.Background(), map[string]interface{}{"cmd": []interface{}{"rm", "x"}}); err == nil {
+		t.Fatal("expected rm to stay disallowed")
+	}
+}
+
+func TestExecStdin(t *testing.T) {
+	e := NewExecTool(2)
+	out, err := e.Execute(context.Background(), map[string]interface{}{
+		"cmd":   []interface{}{"cat"},
+		"stdin": "piped\ninput\n",
+	})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if out != "piped\ninput" {
+		t.Fatalf("unexpected out: %q", out)
+	}
+}
+
+func TestExecSeparatesStderr(t *testing.T) {
+	d := t.TempDir()
+	e := NewExecToolWithWorkspace(2, d)
+
+	out, err := e.Execute(context.Background(), map[string]interface{}{"cmd": []interface{}{"ls", "missing-file"}})
+	if err == nil {
+		t.Fatal("expected ls of a missing file to fail")
+	}
+	if out != "" || !strings.Contains(err.Error(), "missing-file") {
+		t.Fatalf("expected stderr in the error and empty stdout, got out=%q err=%v", out, err)
+	}
+
+	args := map[string]interface{}{"cmd": []interface{}{"sh", "-c", "echo out; echo progress >&2"}}
+	out, err = e.Execute(context.Background(), args)
+	if err != nil || out != "out" {
+		t.Fatalf("stderr should be left out by default, got %q (%v)", out, err)
+	}
+	args["includeStderr"] = true
+	out, err = e.Execute(context.Background(), args)
+	if err != nil || out != "out\n[stderr]\nprogress" {
+		t.Fatalf("expected stderr to be included, got %q (%v)", out, err)
+	}
+}
+
+func TestCappedBuffer(t *testing.T) {
+	b := &cappedBuffer{limit: 4}
+	b.Write([]byte("ab"))
+	b.Write([]byte("cdef"))
+	if got := b.String(); got != "abcd\n[output truncated at 4 bytes]" {
+		t.Fatalf("unexpected capped output %q", got)
+	}
+}
+
+func TestExecTimeoutOverride(t *testing.T) {
+	e := NewExecTool(1)
+	sleep := func(timeout float64) error {
+		_, err := e.Execute(context.Background(), map[string]interface{}{"cmd": []interface{}{"sleep", "1.5"}, "timeoutSeconds": timeout})
+		return err
+	}
+	if err := sleep(3); err != nil {
+		t.Fatalf("a longer timeoutSeconds should let the command finish, got %v", err)
+	}
+
+	// Requests above the ceiling are clamped to it.
+	e.maxTimeout = time.Second
+	if err := sleep(60); err == nil {
+		t.Fatal("expected timeoutSeconds to be clamped to the ceiling")
+	}
+}
@@ -1,13 +1,34 @@
 package tools
 
 import (
+	"bufio"
 	"context"
+	"errors"
 	"fmt"
+	"io"
+	"io/fs"
 	"os"
+	"path"
 	"path/filepath"
+	"strings"
+	"time"
 )
 
-// FilesystemTool provides read/write/list operations within the filesystem.
+// Limits for the read action: reads return at most maxReadBytes unless the
+// caller asks for more, and never more than maxReadBytesCap.
+const (
+	maxReadBytes    = 256 << 10
+	maxReadBytesCap = 4 << 20
+)
+
+// Limits for the search action, so a broad pattern on a big tree can't run
+// away.
+const (
+	maxSearchResults = 200
+	maxSearchTime    = 5 * time.Second
+)
+
+// FilesystemTool provides read/write/append/edit/list/search/delete/mkdir operations within the filesystem.
 // All operations are sandboxed to the workspace directory using os.Root (Go 1.24+),
 // which provides kernel-enforced path containment via openat() syscalls.
 // This prevents symlink escapes, TOCTOU races, and path traversal attacks.
@@ -34,8 +55,10 @@ func (t *FilesystemTool) Close() error {
 	return t.root.Close()
 }
 
-func (t *FilesystemTool) Name() string        { return "filesystem" }
-func (t *FilesystemTool) Description() string { return "Read, write, and list files in the workspace" }
+func (t *FilesystemTool) Name() string { return "filesystem" }
+func (t *FilesystemTool) Description() string {
+	return "Read, write, append to, edit, list, search for, and delete files, and create directories, in the workspace"
+}
 
 func (t *FilesystemTool) Parameters() map[string]interface{} {
 	return map[string]interface{}{
@@ -43,8 +66,8 @@ func (t *FilesystemTool) Parameters() map[string]interface{} {
 		"properties": map[string]interface{}{
 			"action": map[string]interface{}{
 				"type":        "string",
-				"description": "The filesystem operation to perform",
-				"enum":        []string{"read", "write", "list"},
+				"description": "The filesystem operation to perform. edit replaces 'old' with 'new' in a file. search finds files under path matching pattern. delete removes a file or an empty directory (not recursive); mkdir creates a directory and any missing parents.",
+				"enum":        []string{"read", "write", "append", "edit", "list", "search", "delete", "mkdir"},
 			},
 			"path": map[string]interface{}{
 				"type":        "string",
@@ -52,7 +75,35 @@ func (t *FilesystemTool) Parameters() map[string]interface{} {
 			},
 			"content": map[string]interface{}{
 				"type":        "string",
-				"description": "Content to write (required when action is 'write')",
+				"description": "Content to write (required when action is 'write' or 'append')",
+			},
+			"startLine": map[string]interface{}{
+				"type":        "integer",
+				"description": "First line to read, 1-based (read)",
+			},
+			"endLine": map[string]interface{}{
+				"type":        "integer",
+				"description": "Last line to read, inclusive (read). Defaults to the end of the file.",
+			},
+			"maxBytes": map[string]interface{}{
+				"type":        "integer",
+				"description": "Maximum bytes to return (read, default 262144). Longer output is truncated with a notice.",
+			},
+			"old": map[string]interface{}{
+				"type":        "string",
+				"description": "Exact text to replace (edit). Must appear exactly once unless 'all' is set.",
+			},
+			"new": map[string]interface{}{
+				"type":        "string",
+				"description": "Replacement text (edit)",
+			},
+			"all": map[string]interface{}{
+				"type":        "boolean",
+				"description": "Replace every occurrence of 'old' instead of requiring a single one (edit)",
+			},
+			"pattern": map[string]interface{}{
+				"type":        "string",
+				"description": "Glob for search, e.g. '*.md'. Matched against file names, or against the path relative to 'path' if it contains a '/'.",
 			},
 		},
 		"required": []string{"action", "path"},
@@ -84,12 +135,8 @@ func (t *FilesystemTool) Execute(ctx context.Context, args map[string]interface{
 
 	switch action {
 	case "read":
-		b, err := t.root.ReadFile(pathStr)
-		if err != nil {
-			return "", err
-		}
-		return string(b), nil
-	case "write":
+		return t.read(pathStr, args)
+	case "write", "append":
 		contentRaw := args["content"]
 		content := ""
 		switch v := contentRaw.(type) {
@@ -105,10 +152,34 @@ func (t *FilesystemTool) Execute(ctx context.Context, args map[string]interface{
 				return "", err
 			}
 		}
+		if action == "append" {
+			// O_APPEND makes each write land at the end, so concurrent appends
+			// don't overwrite each other the way read-modify-write would.
+			f, err := t.root.OpenFile(pathStr, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+			if err != nil {
+				return "", err
+			}
+			if _, err := f.WriteString(content); err != nil {
+				_ = f.Close()
+				return "", err
+			}
+			if err := f.Close(); err != nil {
+				return "", err
+			}
+			return "appended", nil
+		}
 		if err := t.root.WriteFile(pathStr, []byte(content), 0o644); err != nil {
 			return "", err
 		}
 		return "written", nil
+	case "edit":
+		oldStr, _ := args["old"].(string)
+		newStr, ok := args["new"].(string)
+		if oldStr == "" || !ok {
+			return "", fmt.Errorf("filesystem: 'old' and 'new' are required for edit")
+		}
+		all, _ := args["all"].(bool)
+		return t.edit(pathStr, oldStr, newStr, all)
 	case "list":
 		f, err := t.root.Open(pathStr)
 		if err != nil {
@@ -128,7 +199,203 @@ func (t *FilesystemTool) Execute(ctx context.Context, args map[string]interface{
 			out += name + "\n"
 		}
 		return out, nil
+	case "search":
+		pattern, _ := args["pattern"].(string)
+		if pattern == "" {
+			return "", fmt.Errorf("filesystem: 'pattern' is required for search")
+		}
+		if _, err := path.Match(pattern, ""); err != nil {
+			return "", fmt.Errorf("filesystem: invalid pattern %q", pattern)
+		}
+		return t.search(ctx, filepath.ToSlash(filepath.Clean(pathStr)), pattern)
+	case "delete":
+		// Remove, not RemoveAll: a non-empty directory must be emptied first,
+		// so one call can't wipe out a whole tree by mistake.
+		if filepath.Clean(pathStr) == "." {
+			return "", fmt.Errorf("filesystem: refusing to delete the workspace root")
+		}
+		if err := t.root.Remove(pathStr); err != nil {
+			if errors.Is(err, fs.ErrNotExist) {
+				return "", fmt.Errorf("filesystem: %s does not exist", pathStr)
+			}
+			return "", err
+		}
+		return "deleted", nil
+	case "mkdir":
+		if err := t.root.MkdirAll(pathStr, 0o755); err != nil {
+			return "", err
+		}
+		return "created", nil
 	default:
 		return "", fmt.Errorf("filesystem: unknown action %s", action)
 	}
 }
+
+// read returns the file at p, or only the lines startLine..endLine of it,
+// capped at maxBytes.
+func (t *FilesystemTool) read(p string, args map[string]interface{}) (string, error) {
+	limit := maxReadBytes
+	if n, ok := numberArg(args, "maxBytes"); ok {
+		if n < 1 {
+			return "", fmt.Errorf("filesystem: 'maxBytes' must be at least 1")
+		}
+		limit = int(min(n, maxReadBytesCap))
+	}
+	start, hasStart := numberArg(args, "startLine")
+	end, hasEnd := numberArg(args, "endLine")
+
+	f, err := t.root.Open(p)
+	if err != nil {
+		return "", err
+	}
+	defer func() { _ = f.Close() }()
+
+	if !hasStart && !hasEnd {
+		b, err := io.ReadAll(io.LimitReader(f, int64(limit)+1))
+		if err != nil {
+			return "", err
+		}
+		return truncateRead(string(b), limit), nil
+	}
+
+	first, last := 1, 0 // last 0 means the end of the file
+	if hasStart {
+		first = int(start)
+	}
+	if hasEnd {
+		last = int(end)
+	}
+	if first < 1 {
+		return "", fmt.Errorf("filesystem: 'startLine' must be at least 1")
+	}
+	if hasEnd && last < first {
+		return "", fmt.Errorf("filesystem: 'endLine' (%d) is before 'startLine' (%d)", last, first)
+	}
+
+	var out strings.Builder
+	r := bufio.NewReader(f)
+	n := 0
+	for last == 0 || n < last {
+		line, err := r.ReadString('\n')
+		if line != "" {
+			n++
+			if n >= first {
+				out.WriteString(line)
+			}
+		}
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return "", err
+		}
+		if out.Len() > limit {
+			break
+		}
+	}
+	if n < first {
+		return "", fmt.Errorf("filesystem: 'startLine' %d is past the end of %s (%d lines)", first, p, n)
+	}
+	header := fmt.Sprintf("// lines %d-%d of %s\n", first, n, p)
+	return header + truncateRead(out.String(), limit), nil
+}
+
+// truncateRead cuts s to limit bytes and says so.
+func truncateRead(s string, limit int) string {
+	if len(s) <= limit {
+		return s
+	}
+	return s[:limit] + fmt.Sprintf("\n[truncated at %d bytes; use startLine/endLine or maxBytes to read the rest]", limit)
+}
+
+// edit replaces oldStr with newStr in the file at p. Unless all is set, oldStr
+// must occur exactly once, so the model can't change the wrong spot by
+// accident. The file keeps its permissions.
+func (t *FilesystemTool) edit(p, oldStr, newStr string, all bool) (string, error) {
+	info, err := t.root.Stat(p)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return "", fmt.Errorf("filesystem: %s does not exist", p)
+		}
+		return "", err
+	}
+	b, err := t.root.ReadFile(p)
+	if err != nil {
+		return "", err
+	}
+	content := string(b)
+	n := strings.Count(content, oldStr)
+	switch {
+	case n == 0:
+		return "", fmt.Errorf("filesystem: 'old' text not found in %s", p)
+	case n > 1 && !all:
+		return "", fmt.Errorf("filesystem: 'old' text appears %d times in %s; include more context to make it unique, or set all", n, p)
+	}
+	if all {
+		content = strings.ReplaceAll(content, oldStr, newStr)
+	} else {
+		content = strings.Replace(content, oldStr, newStr, 1)
+	}
+	if err := t.root.WriteFile(p, []byte(content), info.Mode().Perm()); err != nil {
+		return "", err
+	}
+	if err := t.root.Chmod(p, info.Mode().Perm()); err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("replaced %d occurrence(s)", n), nil
+}
+
+// search walks dir inside the root and returns paths matching pattern,
+// newline-separated, stopping at maxSearchResults or maxSearchTime.
+func (t *FilesystemTool) search(ctx context.Context, dir, pattern string) (string, error) {
+	ctx, cancel := context.WithTimeout(ctx, maxSearchTime)
+	defer cancel()
+	var matches []string
+	stopped := ""
+	errStop := errors.New("stop")
+	err := fs.WalkDir(t.root.FS(), dir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if p == dir {
+				return err
+			}
+			return nil // skip unreadable entries
+		}
+		if ctx.Err() != nil {
+			stopped = "search stopped after " + maxSearchTime.String()
+			return errStop
+		}
+		if p == dir {
+			return nil
+		}
+		name := d.Name()
+		if strings.Contains(pattern, "/") {
+			name = strings.TrimPrefix(p, dir+"/")
+		}
+		if ok, _ := path.Match(pattern, name); !ok {
+			return nil
+		}
+		if d.IsDir() {
+			p += "/"
+		}
+		matches = append(matches, p)
+		if len(matches) == maxSearchResults {
+			stopped = fmt.Sprintf("showing the first %d matches", maxSearchResults)
+			return errStop
+		}
+		return nil
+	})
+	if err != nil && err != errStop {
+		return "", err
+	}
+	out := strings.Join(matches, "\n")
+	if len(matches) > 0 {
+		out += "\n"
+	}
+	if stopped != "" {
+		out += "(" + stopped + ")\n"
+	}
+	if out == "" {
+		return "no matches", nil
+	}
+	return out, nil
+}
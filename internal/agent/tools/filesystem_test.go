@@ -0,0 +1,255 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestFilesystemAppendAccumulates(t *testing.T) {
+	dir := t.TempDir()
+	fs, err := NewFilesystemTool(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer fs.Close()
+
+	for _, line := range []string{"one\n", "two\n", "three\n"} {
+		res, err := fs.Execute(context.Background(), map[string]interface{}{"action": "append", "path": "logs/run.log", "content": line})
+		if err != nil || res != "appended" {
+			t.Fatalf("append %q: %q (%v)", line, res, err)
+		}
+	}
+	b, err := os.ReadFile(filepath.Join(dir, "logs", "run.log"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(b) != "one\ntwo\nthree\n" {
+		t.Fatalf("unexpected file content %q", b)
+	}
+
+	// Appends stay inside the sandbox.
+	if _, err := fs.Execute(context.Background(), map[string]interface{}{"action": "append", "path": "../escape.log", "content": "x"}); err == nil {
+		t.Fatal("expected append outside the workspace to fail")
+	}
+}
+
+func TestFilesystemDeleteAndMkdir(t *testing.T) {
+	dir := t.TempDir()
+	fs, err := NewFilesystemTool(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer fs.Close()
+	run := func(action, path string) (string, error) {
+		return fs.Execute(context.Background(), map[string]interface{}{"action": action, "path": path})
+	}
+
+	if res, err := run("mkdir", "a/b/c"); err != nil || res != "created" {
+		t.Fatalf("mkdir: %q (%v)", res, err)
+	}
+	if fi, err := os.Stat(filepath.Join(dir, "a", "b", "c")); err != nil || !fi.IsDir() {
+		t.Fatalf("expected directory a/b/c: %v", err)
+	}
+	if _, err := run("mkdir", "a/b/c"); err != nil {
+		t.Fatalf("mkdir of an existing directory should succeed: %v", err)
+	}
+
+	if err := os.WriteFile(filepath.Join(dir, "a", "b", "c", "f.txt"), []byte("x"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := run("delete", "a/b/c"); err == nil {
+		t.Fatal("expected deleting a non-empty directory to fail")
+	}
+	if res, err := run("delete", "a/b/c/f.txt"); err != nil || res != "deleted" {
+		t.Fatalf("delete file: %q (%v)", res, err)
+	}
+	if _, err := run("delete", "a/b/c"); err != nil {
+		t.Fatalf("delete empty directory: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "a", "b", "c")); !os.IsNotExist(err) {
+		t.Fatalf("expected a/b/c removed, stat err %v", err)
+	}
+
+	if _, err := run("delete", "missing.txt"); err == nil || err.Error() != "filesystem: missing.txt does not exist" {
+		t.Fatalf("expected a clear error for a missing path, got %v", err)
+	}
+
+	// Nothing outside the root can be touched.
+	outside := filepath.Join(filepath.Dir(dir), filepath.Base(dir)+"-outside.txt")
+	if err := os.WriteFile(outside, []byte("keep"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(outside)
+	if _, err := run("delete", "../"+filepath.Base(outside)); err == nil {
+		t.Fatal("expected delete outside the workspace to fail")
+	}
+	if _, err := os.Stat(outside); err != nil {
+		t.Fatalf("file outside the workspace was removed: %v", err)
+	}
+	if _, err := run("mkdir", "../escape"); err == nil {
+		t.Fatal("expected mkdir outside the workspace to fail")
+	}
+	if _, err := run("delete", "."); err == nil {
+		t.Fatal("expected deleting the workspace root to fail")
+	}
+}
+
+func TestFilesystemSearch(t *testing.T) {
+	dir := t.TempDir()
+	for _, p := range []string{"notes/a.md", "notes/deep/b.md", "notes/c.txt", "top.md"} {
+		full := filepath.Join(dir, filepath.FromSlash(p))
+		if err := os.MkdirAll(filepath.Dir(full), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(full, []byte("x"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	fs, err := NewFilesystemTool(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer fs.Close()
+	search := func(path, pattern string) (string, error) {
+		return fs.Execute(context.Background(), map[string]interface{}{"action": "search", "path": path, "pattern": pattern})
+	}
+
+	if got, err := search("", "*.md"); err != nil || got != "notes/a.md\nnotes/deep/b.md\ntop.md\n" {
+		t.Fatalf("search *.md: %q (%v)", got, err)
+	}
+	if got, err := search("notes", "deep/*.md"); err != nil || got != "notes/deep/b.md\n" {
+		t.Fatalf("search with subdirectory: %q (%v)", got, err)
+	}
+	if got, err := search("", "*.go"); err != nil || got != "no matches" {
+		t.Fatalf("expected no matches, got %q (%v)", got, err)
+	}
+	if _, err := search("..", "*"); err == nil {
+		t.Fatal("expected search outside the workspace to fail")
+	}
+
+	for i := 0; i < maxSearchResults+5; i++ {
+		if err := os.WriteFile(filepath.Join(dir, fmt.Sprintf("f%03d.log", i)), nil, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	got, err := search("", "*.log")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := strings.Count(got, ".log\n"); n != maxSearchResults || !strings.Contains(got, "(showing the first 200 matches)") {
+		t.Fatalf("expected results capped at %d, got %d:\n%s", maxSearchResults, n, got[len(got)-60:])
+	}
+}
+
+func TestFilesystemEdit(t *testing.T) {
+	dir := t.TempDir()
+	fs, err := NewFilesystemTool(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer fs.Close()
+	file := filepath.Join(dir, "run.sh")
+	if err := os.WriteFile(file, []byte("echo a\necho b\necho a\n"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	edit := func(old, new string, all bool) (string, error) {
+		return fs.Execute(context.Background(), map[string]interface{}{"action": "edit", "path": "run.sh", "old": old, "new": new, "all": all})
+	}
+
+	if _, err := edit("echo a", "echo c", false); err == nil || !strings.Contains(err.Error(), "appears 2 times") {
+		t.Fatalf("expected ambiguous edit to fail, got %v", err)
+	}
+	if _, err := edit("echo z", "echo c", false); err == nil || !strings.Contains(err.Error(), "not found") {
+		t.Fatalf("expected missing text to fail, got %v", err)
+	}
+	if res, err := edit("echo b", "echo B", false); err != nil || res != "replaced 1 occurrence(s)" {
+		t.Fatalf("single edit: %q (%v)", res, err)
+	}
+	if _, err := edit("echo a", "echo A", true); err != nil {
+		t.Fatal(err)
+	}
+	b, _ := os.ReadFile(file)
+	if string(b) != "echo A\necho B\necho A\n" {
+		t.Fatalf("unexpected content %q", b)
+	}
+	info, err := os.Stat(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode().Perm() != 0o755 {
+		t.Fatalf("mode = %v, want 0755 preserved", info.Mode().Perm())
+	}
+}
+
+func TestFilesystemReadRanges(t *testing.T) {
+	dir := t.TempDir()
+	fs, err := NewFilesystemTool(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer fs.Close()
+	var lines []string
+	for i := 1; i <= 50; i++ {
+		lines = append(lines, fmt.Sprintf("line %d", i))
+	}
+	if err := os.WriteFile(filepath.Join(dir, "file.go"), []byte(strings.Join(lines, "\n")+"\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	read := func(args map[string]interface{}) (string, error) {
+		args["action"] = "read"
+		args["path"] = "file.go"
+		return fs.Execute(context.Background(), args)
+	}
+
+	got, err := read(map[string]interface{}{"startLine": float64(10), "endLine": float64(12)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != "// lines 10-12 of file.go\nline 10\nline 11\nline 12\n" {
+		t.Fatalf("unexpected range read %q", got)
+	}
+
+	// An end past EOF is clamped to the last line.
+	got, err = read(map[string]interface{}{"startLine": float64(49), "endLine": float64(100)})
+	if err != nil || got != "// lines 49-50 of file.go\nline 49\nline 50\n" {
+		t.Fatalf("clamped read: %q (%v)", got, err)
+	}
+
+	for _, args := range []map[string]interface{}{
+		{"startLine": float64(51)},
+		{"startLine": float64(0)},
+		{"startLine": float64(20), "endLine": float64(10)},
+	} {
+		if _, err := read(args); err == nil {
+			t.Errorf("expected %v to be rejected", args)
+		}
+	}
+
+	got, err = read(map[string]interface{}{"maxBytes": float64(14)})
+	if err != nil || !strings.HasPrefix(got, "line 1\nline 2\n\n[truncated at 14 bytes") {
+		t.Fatalf("byte-limited read: %q (%v)", got, err)
+	}
+}
+
+func TestFilesystemReadDefaultCap(t *testing.T) {
+	dir := t.TempDir()
+	fs, err := NewFilesystemTool(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer fs.Close()
+	if err := os.WriteFile(filepath.Join(dir, "big.txt"), []byte(strings.Repeat("x", maxReadBytes+100)), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	got, err := fs.Execute(context.Background(), map[string]interface{}{"action": "read", "path": "big.txt"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(got, strings.Repeat("x", maxReadBytes)+"\n[truncated at 262144 bytes") {
+		t.Fatalf("expected output capped at %d bytes, got %d bytes", maxReadBytes, len(got))
+	}
+}
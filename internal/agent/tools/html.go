@@ -0,0 +1,217 @@
+package tools
+
+import (
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// htmlToText renders an HTML document as readable plain text, or as
+// Markdown when markdown is set. Scripts, styles and other non-content
+// elements are dropped, whitespace is collapsed, and link text is kept
+// (Markdown output also keeps the link target).
+func htmlToText(r io.Reader, markdown bool) (string, error) {
+	doc, err := html.Parse(r)
+	if err != nil {
+		return "", err
+	}
+	c := &htmlConverter{markdown: markdown}
+	c.walk(doc)
+	return c.result(), nil
+}
+
+type htmlConverter struct {
+	markdown bool
+	sb       strings.Builder
+	pre      int // depth inside <pre>
+	list     []listState
+}
+
+type listState struct {
+	ordered bool
+	n       int
+}
+
+var (
+	spaceRE      = regexp.MustCompile(`[ \t\r\n\f]+`)
+	blankLinesRE = regexp.MustCompile(`\n{3,}`)
+)
+
+// skipped holds elements whose content is never shown to a reader.
+var skipped = map[atom.Atom]bool{
+	atom.Script: true, atom.Style: true, atom.Noscript: true, atom.Template: true,
+	atom.Head: true, atom.Svg: true, atom.Iframe: true, atom.Object: true,
+}
+
+// blocks holds elements rendered on their own lines.
+var blocks = map[atom.Atom]bool{
+	atom.P: true, atom.Div: true, atom.Section: true, atom.Article: true, atom.Main: true,
+	atom.Header: true, atom.Footer: true, atom.Nav: true, atom.Aside: true,
+	atom.H1: true, atom.H2: true, atom.H3: true, atom.H4: true, atom.H5: true, atom.H6: true,
+	atom.Ul: true, atom.Ol: true, atom.Li: true, atom.Table: true, atom.Tr: true,
+	atom.Blockquote: true, atom.Pre: true, atom.Hr: true, atom.Form: true,
+	atom.Dl: true, atom.Dt: true, atom.Dd: true, atom.Figure: true, atom.Figcaption: true,
+}
+
+func (c *htmlConverter) write(s string) { c.sb.WriteString(s) }
+
+func (c *htmlConverter) newline() {
+	if c.sb.Len() > 0 && !strings.HasSuffix(c.sb.String(), "\n") {
+		c.write("\n")
+	}
+}
+
+func (c *htmlConverter) walk(n *html.Node) {
+	switch n.Type {
+	case html.TextNode:
+		if c.pre > 0 {
+			c.write(n.Data)
+		} else {
+			c.write(spaceRE.ReplaceAllString(n.Data, " "))
+		}
+		return
+	case html.ElementNode:
+		if skipped[n.DataAtom] {
+			return
+		}
+	case html.CommentNode, html.DoctypeNode:
+		return
+	}
+
+	if n.Type != html.ElementNode {
+		c.children(n)
+		return
+	}
+
+	a := n.DataAtom
+	if blocks[a] {
+		c.newline()
+		if a != atom.Li && a != atom.Tr && a != atom.Dd && a != atom.Dt {
+			c.write("\n")
+		}
+	}
+	switch a {
+	case atom.Br:
+		c.write("\n")
+	case atom.Hr:
+		if c.markdown {
+			c.write("---\n")
+		}
+	case atom.H1, atom.H2, atom.H3, atom.H4, atom.H5, atom.H6:
+		if c.markdown {
+			c.write(strings.Repeat("#", int(n.Data[1]-'0')) + " ")
+		}
+		c.children(n)
+	case atom.Ul, atom.Ol:
+		c.list = append(c.list, listState{ordered: a == atom.Ol})
+		c.children(n)
+		c.list = c.list[:len(c.list)-1]
+	case atom.Li:
+		indent := ""
+		if len(c.list) > 1 {
+			indent = strings.Repeat("  ", len(c.list)-1)
+		}
+		marker := "- "
+		if len(c.list) > 0 && c.list[len(c.list)-1].ordered {
+			c.list[len(c.list)-1].n++
+			marker = strconv.Itoa(c.list[len(c.list)-1].n) + ". "
+		}
+		c.write(indent + marker)
+		c.children(n)
+	case atom.Pre:
+		c.pre++
+		if c.markdown {
+			c.write("```\n")
+		}
+		c.children(n)
+		if c.markdown {
+			c.newline()
+			c.write("```")
+		}
+		c.pre--
+	case atom.Code:
+		if c.markdown && c.pre == 0 {
+			c.write("`")
+			c.children(n)
+			c.write("`")
+		} else {
+			c.children(n)
+		}
+	case atom.Strong, atom.B:
+		c.wrap(n, "**")
+	case atom.Em, atom.I:
+		c.wrap(n, "_")
+	case atom.A:
+		href := attr(n, "href")
+		if !c.markdown || href == "" || strings.HasPrefix(href, "#") || strings.HasPrefix(href, "javascript:") {
+			c.children(n)
+			break
+		}
+		c.write("[")
+		c.children(n)
+		c.write("](" + href + ")")
+	case atom.Img:
+		if alt := attr(n, "alt"); alt != "" {
+			c.write(alt)
+		}
+	case atom.Td, atom.Th:
+		c.children(n)
+		c.write(" | ")
+	case atom.Blockquote:
+		if c.markdown {
+			c.write("> ")
+		}
+		c.children(n)
+	default:
+		c.children(n)
+	}
+	if blocks[a] {
+		c.newline()
+	}
+}
+
+func (c *htmlConverter) children(n *html.Node) {
+	for ch := n.FirstChild; ch != nil; ch = ch.NextSibling {
+		c.walk(ch)
+	}
+}
+
+func (c *htmlConverter) wrap(n *html.Node, mark string) {
+	if c.markdown {
+		c.write(mark)
+	}
+	c.children(n)
+	if c.markdown {
+		c.write(mark)
+	}
+}
+
+// result trims each line and squeezes runs of blank lines.
+func (c *htmlConverter) result() string {
+	lines := strings.Split(c.sb.String(), "\n")
+	inPre := false
+	for i, l := range lines {
+		if strings.HasPrefix(strings.TrimSpace(l), "```") {
+			inPre = !inPre
+		}
+		if !inPre {
+			lines[i] = strings.TrimRight(strings.TrimLeft(l, " "), " ")
+		}
+	}
+	out := strings.Join(lines, "\n")
+	out = strings.ReplaceAll(out, " | \n", "\n")
+	return strings.TrimSpace(blankLinesRE.ReplaceAllString(out, "\n\n"))
+}
+
+func attr(n *html.Node, key string) string {
+	for _, a := range n.Attr {
+		if a.Key == key {
+			return a.Val
+		}
+	}
+	return ""
+}
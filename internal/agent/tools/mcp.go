@@ -3,6 +3,7 @@ package tools
 import (
 	"context"
 	"fmt"
+	"strings"
 
 	"github.com/local/picobot/internal/mcp"
 )
@@ -35,6 +36,87 @@ func (t *MCPTool) Parameters() map[string]interface{} {
 	return t.tool.InputSchema
 }
 
+// Execute calls the tool and returns its text. Image and audio items keep
+// their data: they are handed to the turn's MediaCollector (if any) as data
+// URIs and appear in the text as placeholders.
 func (t *MCPTool) Execute(ctx context.Context, args map[string]interface{}) (string, error) {
-	return t.client.CallTool(ctx, t.tool.Name, args)
+	items, err := t.client.CallToolContent(ctx, t.tool.Name, args)
+	if err != nil {
+		return "", err
+	}
+	if mc := mediaCollectorFrom(ctx); mc != nil {
+		for _, item := range items {
+			if item.Data != "" && (item.Type == "image" || item.Type == "audio") {
+				mc.Add("data:" + item.MimeType + ";base64," + item.Data)
+			}
+		}
+	}
+	return mcp.ContentText(items), nil
+}
+
+// ReadResourceTool lists and reads resources published by MCP servers.
+type ReadResourceTool struct {
+	mgr *mcp.Manager
+}
+
+// NewReadResourceTool creates a tool backed by the given MCP manager.
+func NewReadResourceTool(mgr *mcp.Manager) *ReadResourceTool {
+	return &ReadResourceTool{mgr: mgr}
+}
+
+func (t *ReadResourceTool) Name() string { return "read_resource" }
+
+func (t *ReadResourceTool) Description() string {
+	return "Read a resource (file, document, record) published by a connected MCP server. Call without a uri to list available resources."
+}
+
+func (t *ReadResourceTool) Parameters() map[string]interface{} {
+	return map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"uri": map[string]interface{}{
+				"type":        "string",
+				"description": "Namespaced resource ID as returned by the listing ({server}_{uri}). Omit to list resources.",
+			},
+		},
+	}
+}
+
+func (t *ReadResourceTool) Execute(ctx context.Context, args map[string]interface{}) (string, error) {
+	id, _ := args["uri"].(string)
+	if id == "" {
+		resources := t.mgr.GetAllResources(ctx)
+		if len(resources) == 0 {
+			return "No MCP resources available.", nil
+		}
+		var sb strings.Builder
+		for _, r := range resources {
+			fmt.Fprintf(&sb, "- %s", r.ID)
+			if r.Name != "" {
+				fmt.Fprintf(&sb, " (%s)", r.Name)
+			}
+			if r.Description != "" {
+				fmt.Fprintf(&sb, ": %s", r.Description)
+			}
+			if r.MimeType != "" {
+				fmt.Fprintf(&sb, " [%s]", r.MimeType)
+			}
+			sb.WriteString("\n")
+		}
+		return sb.String(), nil
+	}
+
+	contents, err := t.mgr.ReadResource(ctx, id)
+	if err != nil {
+		return "", fmt.Errorf("read_resource: %w", err)
+	}
+	var parts []string
+	for _, c := range contents {
+		if c.Blob != "" {
+			parts = append(parts, fmt.Sprintf("[binary resource %s, %s, %d bytes base64]", c.URI, c.MimeType, len(c.Blob)))
+			continue
+		}
+		parts = append(parts, c.Text)
+	}
+	return strings.Join(parts, "\n"), nil
 }
@@ -49,6 +49,13 @@ func newTestMCPServer(t *testing.T) (*httptest.Server, *mcp.Client) {
 			}
 			_ = json.Unmarshal(req.Params, &params)
 			text := strings.ToUpper(params.Arguments["text"].(string))
+			if text == "PIC" {
+				_ = json.NewEncoder(w).Encode(rpcResp{
+					JSONRPC: "2.0", ID: req.ID,
+					Result: json.RawMessage(`{"content":[{"type":"text","text":"chart:"},{"type":"image","data":"iVBORw0K","mimeType":"image/png"}]}`),
+				})
+				return
+			}
 			_ = json.NewEncoder(w).Encode(rpcResp{
 				JSONRPC: "2.0", ID: req.ID,
 				Result: json.RawMessage(`{"content":[{"type":"text","text":"` + text + `"}]}`),
@@ -134,3 +141,30 @@ func TestMCPToolRegistration(t *testing.T) {
 		t.Fatal("mcp_testsvr_upper not found in definitions")
 	}
 }
+
+func TestMCPToolExecuteCollectsImages(t *testing.T) {
+	srv, client := newTestMCPServer(t)
+	defer srv.Close()
+	defer func() { _ = client.Close() }()
+
+	mcpTool := NewMCPTool(client, "testsvr", client.Tools()[0])
+	media := &MediaCollector{}
+	ctx := WithMediaCollector(context.Background(), media)
+
+	result, err := mcpTool.Execute(ctx, map[string]interface{}{"text": "pic"})
+	if err != nil {
+		t.Fatalf("Execute: %v", err)
+	}
+	if result != "chart:\n[image: image/png]" {
+		t.Fatalf("unexpected text result %q", result)
+	}
+	items := media.Items()
+	if len(items) != 1 || items[0] != "data:image/png;base64,iVBORw0K" {
+		t.Fatalf("expected one data URI, got %v", items)
+	}
+
+	// Without a collector the image is still summarised, not an error.
+	if _, err := mcpTool.Execute(context.Background(), map[string]interface{}{"text": "pic"}); err != nil {
+		t.Fatalf("Execute without collector: %v", err)
+	}
+}
@@ -0,0 +1,41 @@
+package tools
+
+import (
+	"context"
+	"sync"
+)
+
+// MediaCollector gathers media produced by tools during a single turn, as
+// data URIs ("data:image/png;base64,..."), so the agent loop can attach them
+// to the outbound reply.
+type MediaCollector struct {
+	mu    sync.Mutex
+	items []string
+}
+
+// Add records one media item.
+func (c *MediaCollector) Add(uri string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.items = append(c.items, uri)
+}
+
+// Items returns the collected media in the order they were added.
+func (c *MediaCollector) Items() []string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]string(nil), c.items...)
+}
+
+type mediaCollectorKey struct{}
+
+// WithMediaCollector returns a context that carries c to tool executions.
+func WithMediaCollector(ctx context.Context, c *MediaCollector) context.Context {
+	return context.WithValue(ctx, mediaCollectorKey{}, c)
+}
+
+// mediaCollectorFrom returns the collector carried by ctx, or nil.
+func mediaCollectorFrom(ctx context.Context) *MediaCollector {
+	c, _ := ctx.Value(mediaCollectorKey{}).(*MediaCollector)
+	return c
+}
@@ -77,6 +77,10 @@ func NewReadMemoryTool(mem *memory.MemoryStore) *ReadMemoryTool {
 	return &ReadMemoryTool{mem: mem}
 }
 
+// SetStore switches the memory store the tool reads from, e.g. to a
+// per-conversation scope.
+func (t *ReadMemoryTool) SetStore(mem *memory.MemoryStore) { t.mem = mem }
+
 func (t *ReadMemoryTool) Name() string        { return "read_memory" }
 func (t *ReadMemoryTool) Description() string { return "Read the contents of a memory file" }
 func (t *ReadMemoryTool) Parameters() map[string]interface{} {
@@ -111,6 +115,61 @@ func (t *ReadMemoryTool) Execute(ctx context.Context, args map[string]interface{
 	return content, nil
 }
 
+// ─── search_memory ────
+
+// SearchMemoryTool searches the daily notes for a word or phrase.
+type SearchMemoryTool struct {
+	mem *memory.MemoryStore
+}
+
+func NewSearchMemoryTool(mem *memory.MemoryStore) *SearchMemoryTool {
+	return &SearchMemoryTool{mem: mem}
+}
+
+func (t *SearchMemoryTool) Name() string { return "search_memory" }
+func (t *SearchMemoryTool) Description() string {
+	return "Search daily memory notes for lines containing all the given words, e.g. to recall what was discussed on an earlier day"
+}
+func (t *SearchMemoryTool) Parameters() map[string]interface{} {
+	return map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"query": map[string]interface{}{
+				"type":        "string",
+				"description": "Words to look for (case-insensitive; every word must appear in the line)",
+			},
+			"days": map[string]interface{}{
+				"type":        "integer",
+				"description": "How many days back to search, including today (default 30, max 365)",
+			},
+		},
+		"required": []string{"query"},
+	}
+}
+
+func (t *SearchMemoryTool) Execute(ctx context.Context, args map[string]interface{}) (string, error) {
+	query, _ := args["query"].(string)
+	if strings.TrimSpace(query) == "" {
+		return "", fmt.Errorf("search_memory: 'query' argument required")
+	}
+	days := 30
+	if n, ok := numberArg(args, "days"); ok && n > 0 {
+		days = int(n)
+	}
+	matches, err := t.mem.SearchNotes(query, days)
+	if err != nil {
+		return "", fmt.Errorf("search_memory: %w", err)
+	}
+	if len(matches) == 0 {
+		return fmt.Sprintf("No daily notes in the last %d days mention %q.", days, query), nil
+	}
+	var sb strings.Builder
+	for _, m := range matches {
+		fmt.Fprintf(&sb, "%s: %s\n", m.Date, m.Excerpt)
+	}
+	return strings.TrimRight(sb.String(), "\n"), nil
+}
+
 // ─── edit_memory ────
 
 // EditMemoryTool finds and replaces text within a memory file.
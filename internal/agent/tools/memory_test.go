@@ -6,6 +6,7 @@ import (
 	"path/filepath"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/local/picobot/internal/agent/memory"
 )
@@ -118,6 +119,44 @@ func TestReadMemoryTool_InvalidTarget(t *testing.T) {
 	}
 }
 
+func TestReadMemoryTool_ReadsWhatWriteMemoryWrote(t *testing.T) {
+	mem := memory.NewMemoryStoreWithWorkspace(t.TempDir(), 10)
+	write, read := NewWriteMemoryTool(mem), NewReadMemoryTool(mem)
+	ctx := context.Background()
+
+	for _, args := range []map[string]interface{}{
+		{"target": "long", "content": "user prefers metric units", "append": false},
+		{"target": "long", "content": "user lives in Oslo"},
+		{"target": "today", "content": "booked the dentist"},
+	} {
+		if _, err := write.Execute(ctx, args); err != nil {
+			t.Fatalf("write_memory %v: %v", args, err)
+		}
+	}
+	long, err := read.Execute(ctx, map[string]interface{}{"target": "long"})
+	if err != nil || long != "user prefers metric units\nuser lives in Oslo" {
+		t.Fatalf("read long = %q, %v", long, err)
+	}
+	today, err := read.Execute(ctx, map[string]interface{}{"target": "today"})
+	if err != nil || !strings.Contains(today, "booked the dentist") {
+		t.Fatalf("read today = %q, %v", today, err)
+	}
+
+	// After switching stores, both tools use the new one.
+	other := memory.NewMemoryStoreWithWorkspace(t.TempDir(), 10)
+	write.SetStore(other)
+	read.SetStore(other)
+	if _, err := write.Execute(ctx, map[string]interface{}{"target": "long", "content": "scoped fact", "append": false}); err != nil {
+		t.Fatal(err)
+	}
+	if out, _ := read.Execute(ctx, map[string]interface{}{"target": "long"}); out != "scoped fact" {
+		t.Fatalf("read after SetStore = %q, want scoped fact", out)
+	}
+	if prev, _ := mem.ReadLongTerm(); strings.Contains(prev, "scoped fact") {
+		t.Fatal("write after SetStore leaked into the original store")
+	}
+}
+
 // ─── edit_memory ────
 
 func TestEditMemoryTool_Replace(t *testing.T) {
@@ -241,3 +280,29 @@ func TestDeleteMemoryTool_NotFound(t *testing.T) {
 		t.Fatal("expected error for nonexistent file")
 	}
 }
+
+// ─── search_memory ────
+
+func TestSearchMemoryTool(t *testing.T) {
+	mem := memory.NewMemoryStoreWithWorkspace(t.TempDir(), 10)
+	if err := mem.AppendToday("Discussed the quarterly budget with Alex"); err != nil {
+		t.Fatal(err)
+	}
+	tool := NewSearchMemoryTool(mem)
+
+	out, err := tool.Execute(context.Background(), map[string]interface{}{"query": "budget alex", "days": float64(7)})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	today := time.Now().UTC().Format("2006-01-02")
+	if !strings.HasPrefix(out, today+": ") || !strings.Contains(out, "quarterly budget") {
+		t.Fatalf("unexpected result: %q", out)
+	}
+	out, err = tool.Execute(context.Background(), map[string]interface{}{"query": "holiday"})
+	if err != nil || !strings.HasPrefix(out, "No daily notes") {
+		t.Fatalf("expected no matches, got %q (%v)", out, err)
+	}
+	if _, err := tool.Execute(context.Background(), map[string]interface{}{}); err == nil {
+		t.Fatal("expected an error without a query")
+	}
+}
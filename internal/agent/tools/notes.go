@@ -0,0 +1,285 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log"
+	"math"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+
+	"github.com/local/picobot/internal/providers"
+)
+
+// EmbedFunc computes one embedding vector per text (see providers.Embedder).
+type EmbedFunc func(ctx context.Context, texts []string) ([][]float64, error)
+
+// note is one entry in a conversation's note index.
+type note struct {
+	ID        int       `json:"id"`
+	Text      string    `json:"text"`
+	Created   time.Time `json:"created"`
+	Embedding []float64 `json:"embedding,omitempty"`
+}
+
+// NotesTool stores short notes per conversation under notes/ in the
+// workspace and finds them again by meaning. Notes are embedded with embed
+// when it is available; otherwise, or when embedding fails, search falls
+// back to keyword overlap.
+type NotesTool struct {
+	root    *os.Root
+	embed   EmbedFunc
+	mu      sync.Mutex
+	channel string
+	chatID  string
+}
+
+// NewNotesTool creates a notes tool writing into root. embed may be nil.
+func NewNotesTool(root *os.Root, embed EmbedFunc) *NotesTool {
+	return &NotesTool{root: root, embed: embed}
+}
+
+func (t *NotesTool) Name() string { return "notes" }
+
+func (t *NotesTool) Description() string {
+	return "Save notes for this conversation and search them by meaning, e.g. to recall facts the user mentioned earlier"
+}
+
+func (t *NotesTool) Parameters() map[string]interface{} {
+	return map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"action": map[string]interface{}{
+				"type":        "string",
+				"description": "add, search, list, or delete",
+				"enum":        []string{"add", "search", "list", "delete"},
+			},
+			"text": map[string]interface{}{
+				"type":        "string",
+				"description": "Note text (add)",
+			},
+			"query": map[string]interface{}{
+				"type":        "string",
+				"description": "What to look for (search)",
+			},
+			"limit": map[string]interface{}{
+				"type":        "integer",
+				"description": "Maximum results (search, default 5)",
+			},
+			"id": map[string]interface{}{
+				"type":        "integer",
+				"description": "Note ID (delete)",
+			},
+		},
+		"required": []string{"action"},
+	}
+}
+
+// SetContext sets the conversation whose notes are used.
+func (t *NotesTool) SetContext(channel, chatID string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.channel = channel
+	t.chatID = chatID
+}
+
+func (t *NotesTool) Execute(ctx context.Context, args map[string]interface{}) (string, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	path := "notes/" + strings.NewReplacer(":", "-", "/", "-").Replace(t.channel+"-"+t.chatID) + ".json"
+	notes, err := t.load(path)
+	if err != nil {
+		return "", fmt.Errorf("notes: %w", err)
+	}
+
+	action, _ := args["action"].(string)
+	switch action {
+	case "add":
+		text, _ := args["text"].(string)
+		text = strings.TrimSpace(text)
+		if text == "" {
+			return "", fmt.Errorf("notes: 'text' is required for add")
+		}
+		n := note{ID: 1, Text: text, Created: time.Now()}
+		if len(notes) > 0 {
+			n.ID = notes[len(notes)-1].ID + 1
+		}
+		n.Embedding = t.embedOne(ctx, text)
+		if err := t.save(path, append(notes, n)); err != nil {
+			return "", fmt.Errorf("notes: %w", err)
+		}
+		return fmt.Sprintf("Saved note %d.", n.ID), nil
+	case "search":
+		query, _ := args["query"].(string)
+		if strings.TrimSpace(query) == "" {
+			return "", fmt.Errorf("notes: 'query' is required for search")
+		}
+		limit := 5
+		if n, ok := numberArg(args, "limit"); ok && n > 0 {
+			limit = int(n)
+		}
+		found := rankNotes(notes, query, t.embedOne(ctx, query), limit)
+		if len(found) == 0 {
+			return "No matching notes.", nil
+		}
+		return formatNotes(found), nil
+	case "list":
+		if len(notes) == 0 {
+			return "No notes yet.", nil
+		}
+		return formatNotes(notes), nil
+	case "delete":
+		id, ok := numberArg(args, "id")
+		if !ok {
+			return "", fmt.Errorf("notes: 'id' is required for delete")
+		}
+		for i, n := range notes {
+			if n.ID == int(id) {
+				if err := t.save(path, append(notes[:i], notes[i+1:]...)); err != nil {
+					return "", fmt.Errorf("notes: %w", err)
+				}
+				return fmt.Sprintf("Deleted note %d.", n.ID), nil
+			}
+		}
+		return "", fmt.Errorf("notes: no note with id %d", int(id))
+	default:
+		return "", fmt.Errorf("notes: unknown action %q (use add, search, list, or delete)", action)
+	}
+}
+
+// embedOne returns text's embedding, or nil if embeddings are unavailable.
+func (t *NotesTool) embedOne(ctx context.Context, text string) []float64 {
+	if t.embed == nil {
+		return nil
+	}
+	vecs, err := t.embed(ctx, []string{text})
+	if err != nil || len(vecs) != 1 {
+		if !errors.Is(err, providers.ErrNoEmbeddings) {
+			log.Printf("notes: embedding failed, using keyword search: %v", err)
+		}
+		return nil
+	}
+	return vecs[0]
+}
+
+func (t *NotesTool) load(path string) ([]note, error) {
+	b, err := t.root.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var notes []note
+	if err := json.Unmarshal(b, &notes); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return notes, nil
+}
+
+func (t *NotesTool) save(path string, notes []note) error {
+	if err := t.root.MkdirAll("notes", 0o755); err != nil {
+		return err
+	}
+	b, err := json.MarshalIndent(notes, "", "  ")
+	if err != nil {
+		return err
+	}
+	return t.root.WriteFile(path, b, 0o644)
+}
+
+// rankNotes scores notes against the query and returns the best matches.
+// Notes with an embedding comparable to the query's are scored by cosine
+// similarity, the rest by keyword overlap.
+func rankNotes(notes []note, query string, queryVec []float64, limit int) []note {
+	type scored struct {
+		note  note
+		score float64
+	}
+	terms := keywords(query)
+	var hits []scored
+	for _, n := range notes {
+		var score float64
+		if len(queryVec) > 0 && len(n.Embedding) == len(queryVec) {
+			score = cosine(queryVec, n.Embedding)
+		} else {
+			score = keywordScore(terms, keywords(n.Text))
+		}
+		if score > 0 {
+			hits = append(hits, scored{n, score})
+		}
+	}
+	sort.SliceStable(hits, func(i, j int) bool { return hits[i].score > hits[j].score })
+	out := make([]note, 0, min(limit, len(hits)))
+	for _, h := range hits[:min(limit, len(hits))] {
+		out = append(out, h.note)
+	}
+	return out
+}
+
+// stopwords are too common to say anything about a note's topic.
+var stopwords = map[string]bool{
+	"the": true, "and": true, "for": true, "are": true, "was": true, "what": true, "whats": true,
+	"with": true, "that": true, "this": true, "from": true, "have": true, "has": true, "you": true,
+	"your": true, "about": true, "did": true, "does": true, "when": true, "where": true, "who": true,
+}
+
+// keywords lowercases text and returns its significant words.
+func keywords(text string) []string {
+	words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	out := words[:0]
+	for _, w := range words {
+		if len(w) >= 3 && !stopwords[w] {
+			out = append(out, w)
+		}
+	}
+	return out
+}
+
+// keywordScore is the fraction of query terms found in the note. Words
+// sharing a stem of four or more letters ("meeting", "meetings") match.
+func keywordScore(query, note []string) float64 {
+	if len(query) == 0 {
+		return 0
+	}
+	matched := 0
+	for _, q := range query {
+		for _, w := range note {
+			if q == w || (len(q) >= 4 && len(w) >= 4 && (strings.HasPrefix(q, w) || strings.HasPrefix(w, q))) {
+				matched++
+				break
+			}
+		}
+	}
+	return float64(matched) / float64(len(query))
+}
+
+func cosine(a, b []float64) float64 {
+	var dot, na, nb float64
+	for i := range a {
+		dot += a[i] * b[i]
+		na += a[i] * a[i]
+		nb += b[i] * b[i]
+	}
+	if na == 0 || nb == 0 {
+		return 0
+	}
+	return dot / (math.Sqrt(na) * math.Sqrt(nb))
+}
+
+func formatNotes(notes []note) string {
+	var sb strings.Builder
+	for _, n := range notes {
+		fmt.Fprintf(&sb, "[%d] %s (%s)\n", n.ID, n.Text, n.Created.Format("2006-01-02"))
+	}
+	return sb.String()
+}
@@ -0,0 +1,75 @@
+package tools
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestNotesKeywordSearch(t *testing.T) {
+	notes := NewNotesTool(openTestRoot(t), nil)
+	notes.SetContext("telegram", "42")
+	ctx := context.Background()
+	for _, text := range []string{
+		"The wifi password at the cabin is sunflower42",
+		"Dentist appointment moved to Tuesday",
+		"Prefers meetings after 10am",
+	} {
+		if _, err := notes.Execute(ctx, map[string]interface{}{"action": "add", "text": text}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	got, err := notes.Execute(ctx, map[string]interface{}{"action": "search", "query": "what's the cabin wifi?"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(got, "[1] The wifi password at the cabin is sunflower42") || strings.Contains(got, "Dentist") {
+		t.Fatalf("unexpected search result %q", got)
+	}
+	if got, _ := notes.Execute(ctx, map[string]interface{}{"action": "search", "query": "when is my next meeting"}); !strings.Contains(got, "Prefers meetings") {
+		t.Fatalf("expected a stem match, got %q", got)
+	}
+
+	// Notes are scoped to the conversation.
+	notes.SetContext("telegram", "99")
+	if got, _ := notes.Execute(ctx, map[string]interface{}{"action": "search", "query": "cabin wifi"}); got != "No matching notes." {
+		t.Fatalf("another conversation should not see these notes, got %q", got)
+	}
+}
+
+func TestNotesEmbeddingSearch(t *testing.T) {
+	// A toy embedding: [mentions animals, mentions money].
+	embed := func(ctx context.Context, texts []string) ([][]float64, error) {
+		var out [][]float64
+		for _, s := range texts {
+			s = strings.ToLower(s)
+			v := []float64{0.1, 0.1}
+			for _, w := range []string{"dog", "cat", "pet", "vet"} {
+				if strings.Contains(s, w) {
+					v[0]++
+				}
+			}
+			for _, w := range []string{"rent", "bank", "salary", "budget"} {
+				if strings.Contains(s, w) {
+					v[1]++
+				}
+			}
+			out = append(out, v)
+		}
+		return out, nil
+	}
+	notes := NewNotesTool(openTestRoot(t), embed)
+	notes.SetContext("cli", "direct")
+	ctx := context.Background()
+	notes.Execute(ctx, map[string]interface{}{"action": "add", "text": "Rent is due on the 1st, paid from the joint bank account"})
+	notes.Execute(ctx, map[string]interface{}{"action": "add", "text": "Max the dog sees the vet every March"})
+
+	got, err := notes.Execute(ctx, map[string]interface{}{"action": "search", "query": "pet health", "limit": float64(1)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(got, "Max the dog") || strings.Contains(got, "Rent") {
+		t.Fatalf("expected the semantically closest note, got %q", got)
+	}
+}
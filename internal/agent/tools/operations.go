@@ -0,0 +1,136 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Operation is a running turn or tool call that can be cancelled.
+type Operation struct {
+	ID          string
+	Kind        string // "turn" or "tool"
+	Description string
+	Started     time.Time
+	cancel      context.CancelFunc
+}
+
+// Operations tracks running operations so they can be listed and cancelled.
+type Operations struct {
+	mu     sync.Mutex
+	nextID int
+	ops    map[string]*Operation
+}
+
+// NewOperations creates an empty tracker.
+func NewOperations() *Operations {
+	return &Operations{ops: make(map[string]*Operation)}
+}
+
+// Start registers an operation and returns a context that is cancelled when
+// the operation is cancelled, along with a func that must be called when the
+// operation finishes.
+func (o *Operations) Start(ctx context.Context, kind, description string) (context.Context, string, func()) {
+	ctx, cancel := context.WithCancel(ctx)
+	o.mu.Lock()
+	o.nextID++
+	id := kind + "-" + strconv.Itoa(o.nextID)
+	o.ops[id] = &Operation{ID: id, Kind: kind, Description: description, Started: time.Now(), cancel: cancel}
+	o.mu.Unlock()
+	return ctx, id, func() {
+		o.mu.Lock()
+		delete(o.ops, id)
+		o.mu.Unlock()
+		cancel()
+	}
+}
+
+// List returns the running operations, oldest first.
+func (o *Operations) List() []Operation {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	out := make([]Operation, 0, len(o.ops))
+	for _, op := range o.ops {
+		out = append(out, *op)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Started.Before(out[j].Started) })
+	return out
+}
+
+// Cancel cancels the operation with the given ID. It reports false if no
+// such operation is running.
+func (o *Operations) Cancel(id string) bool {
+	o.mu.Lock()
+	op, ok := o.ops[id]
+	o.mu.Unlock()
+	if ok {
+		op.cancel()
+	}
+	return ok
+}
+
+// OperationsTool lets the agent list running turns and tool calls and cancel
+// one by ID.
+type OperationsTool struct {
+	ops *Operations
+}
+
+// NewOperationsTool creates a tool backed by ops.
+func NewOperationsTool(ops *Operations) *OperationsTool {
+	return &OperationsTool{ops: ops}
+}
+
+func (t *OperationsTool) Name() string { return "operations" }
+
+func (t *OperationsTool) Description() string {
+	return "List running turns and tool calls with their elapsed time, or cancel one by ID"
+}
+
+func (t *OperationsTool) Parameters() map[string]interface{} {
+	return map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"action": map[string]interface{}{
+				"type":        "string",
+				"description": "What to do",
+				"enum":        []string{"list", "cancel"},
+			},
+			"id": map[string]interface{}{
+				"type":        "string",
+				"description": "Operation ID to cancel (from list)",
+			},
+		},
+		"required": []string{"action"},
+	}
+}
+
+func (t *OperationsTool) Execute(ctx context.Context, args map[string]interface{}) (string, error) {
+	action, _ := args["action"].(string)
+	switch action {
+	case "list":
+		ops := t.ops.List()
+		if len(ops) == 0 {
+			return "No operations running.", nil
+		}
+		var sb strings.Builder
+		for _, op := range ops {
+			fmt.Fprintf(&sb, "%s  %s  %s\n", op.ID, time.Since(op.Started).Round(time.Second), op.Description)
+		}
+		return sb.String(), nil
+	case "cancel":
+		id, _ := args["id"].(string)
+		if id == "" {
+			return "", fmt.Errorf("operations: 'id' is required for cancel")
+		}
+		if !t.ops.Cancel(id) {
+			return "", fmt.Errorf("operations: no running operation %q", id)
+		}
+		return fmt.Sprintf("Cancelled %s.", id), nil
+	default:
+		return "", fmt.Errorf("operations: unknown action %q (use list or cancel)", action)
+	}
+}
@@ -0,0 +1,53 @@
+package tools
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestOperationsListAndCancel(t *testing.T) {
+	ops := NewOperations()
+	tool := NewOperationsTool(ops)
+
+	ctx, id, done := ops.Start(context.Background(), "tool", "exec sleep 600")
+	finished := make(chan error, 1)
+	go func() {
+		defer done()
+		<-ctx.Done() // the "long" operation
+		finished <- ctx.Err()
+	}()
+
+	out, err := tool.Execute(context.Background(), map[string]interface{}{"action": "list"})
+	if err != nil {
+		t.Fatalf("list: %v", err)
+	}
+	if !strings.Contains(out, id) || !strings.Contains(out, "exec sleep 600") {
+		t.Fatalf("list output missing operation: %q", out)
+	}
+
+	if _, err := tool.Execute(context.Background(), map[string]interface{}{"action": "cancel", "id": id}); err != nil {
+		t.Fatalf("cancel: %v", err)
+	}
+	select {
+	case err := <-finished:
+		if err != context.Canceled {
+			t.Fatalf("expected context.Canceled, got %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("operation was not cancelled")
+	}
+
+	// Once finished the operation disappears from the list.
+	deadline := time.Now().Add(time.Second)
+	for len(ops.List()) != 0 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if n := len(ops.List()); n != 0 {
+		t.Fatalf("expected no operations, got %d", n)
+	}
+	if _, err := tool.Execute(context.Background(), map[string]interface{}{"action": "cancel", "id": id}); err == nil {
+		t.Fatal("expected error cancelling a finished operation")
+	}
+}
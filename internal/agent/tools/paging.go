@@ -0,0 +1,156 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode/utf8"
+)
+
+const (
+	// DefaultPageSize is the largest tool result, in bytes, passed to the
+	// model in one piece.
+	DefaultPageSize = 16 * 1024
+	// defaultMaxPagedResults bounds how many large results are kept for
+	// paging; the oldest is evicted first.
+	defaultMaxPagedResults = 20
+)
+
+// PageStore holds large tool results split into pages, so the model can read
+// them piece by piece with get_page instead of receiving everything at once.
+type PageStore struct {
+	pageSize   int
+	maxResults int
+
+	mu      sync.Mutex
+	nextID  int
+	order   []string // result IDs, oldest first
+	results map[string][]string
+}
+
+// NewPageStore creates a store that splits results into pages of at most
+// pageSize bytes.
+func NewPageStore(pageSize int) *PageStore {
+	if pageSize <= 0 {
+		pageSize = DefaultPageSize
+	}
+	return &PageStore{pageSize: pageSize, maxResults: defaultMaxPagedResults, results: make(map[string][]string)}
+}
+
+// Paginate returns s unchanged if it fits in one page. Otherwise it stores
+// the pages and returns the first one with a note telling the model how to
+// fetch the rest.
+func (s *PageStore) Paginate(text string) string {
+	if len(text) <= s.pageSize {
+		return text
+	}
+	pages := splitPages(text, s.pageSize)
+
+	s.mu.Lock()
+	s.nextID++
+	id := "out-" + strconv.Itoa(s.nextID)
+	s.results[id] = pages
+	s.order = append(s.order, id)
+	if len(s.order) > s.maxResults {
+		delete(s.results, s.order[0])
+		s.order = s.order[1:]
+	}
+	s.mu.Unlock()
+
+	return pages[0] + pageFooter(id, 1, len(pages))
+}
+
+// Page returns page n (1-based) of a stored result and the total page count.
+func (s *PageStore) Page(id string, n int) (string, int, error) {
+	s.mu.Lock()
+	pages, ok := s.results[id]
+	s.mu.Unlock()
+	if !ok {
+		return "", 0, fmt.Errorf("no stored output %q (it may have expired)", id)
+	}
+	if n < 1 || n > len(pages) {
+		return "", len(pages), fmt.Errorf("page %d out of range (1-%d)", n, len(pages))
+	}
+	return pages[n-1], len(pages), nil
+}
+
+func pageFooter(id string, n, total int) string {
+	if n == total {
+		return fmt.Sprintf("\n[page %d of %d of output %s]", n, total, id)
+	}
+	return fmt.Sprintf("\n[page %d of %d of output %s; call get_page with id %q and page %d for more]", n, total, id, id, n+1)
+}
+
+// splitPages cuts text into chunks of at most size bytes, preferring to break
+// after a newline and never splitting a UTF-8 sequence.
+func splitPages(text string, size int) []string {
+	var pages []string
+	for len(text) > size {
+		cut := size
+		for cut > 0 && !utf8.RuneStart(text[cut]) {
+			cut--
+		}
+		if nl := strings.LastIndexByte(text[:cut], '\n'); nl >= size/2 {
+			cut = nl + 1
+		}
+		pages = append(pages, text[:cut])
+		text = text[cut:]
+	}
+	return append(pages, text)
+}
+
+// GetPageTool returns further pages of a large tool result.
+type GetPageTool struct {
+	store *PageStore
+}
+
+// NewGetPageTool creates a get_page tool reading from store.
+func NewGetPageTool(store *PageStore) *GetPageTool {
+	return &GetPageTool{store: store}
+}
+
+func (t *GetPageTool) Name() string { return "get_page" }
+
+func (t *GetPageTool) Description() string {
+	return "Fetch another page of a large tool output that was split into pages"
+}
+
+func (t *GetPageTool) Parameters() map[string]interface{} {
+	return map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"id": map[string]interface{}{
+				"type":        "string",
+				"description": "Output ID from the page note, e.g. out-3",
+			},
+			"page": map[string]interface{}{
+				"type":        "integer",
+				"description": "Page number, starting at 1",
+			},
+		},
+		"required": []string{"id", "page"},
+	}
+}
+
+func (t *GetPageTool) Execute(ctx context.Context, args map[string]interface{}) (string, error) {
+	id, _ := args["id"].(string)
+	if id == "" {
+		return "", fmt.Errorf("get_page: 'id' is required")
+	}
+	var n int
+	switch v := args["page"].(type) {
+	case float64:
+		n = int(v)
+	case int:
+		n = v
+	default:
+		return "", fmt.Errorf("get_page: 'page' must be a number")
+	}
+	page, total, err := t.store.Page(id, n)
+	if err != nil {
+		return "", fmt.Errorf("get_page: %w", err)
+	}
+	return page + pageFooter(id, n, total), nil
+}
@@ -0,0 +1,51 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func TestPagingLargeOutput(t *testing.T) {
+	var sb strings.Builder
+	for i := 1; i <= 500; i++ {
+		fmt.Fprintf(&sb, "line %03d: some command output\n", i)
+	}
+	output := sb.String()
+
+	store := NewPageStore(1024)
+	first := store.Paginate(output)
+	m := regexp.MustCompile(`\[page 1 of (\d+) of output (out-\d+);`).FindStringSubmatch(first)
+	if m == nil {
+		t.Fatalf("first page has no paging note: %q", first[len(first)-120:])
+	}
+	var total int
+	fmt.Sscan(m[1], &total)
+	id := m[2]
+
+	tool := NewGetPageTool(store)
+	got := strings.SplitN(first, "\n[page", 2)[0]
+	for n := 2; n <= total; n++ {
+		page, err := tool.Execute(context.Background(), map[string]interface{}{"id": id, "page": float64(n)})
+		if err != nil {
+			t.Fatalf("page %d: %v", n, err)
+		}
+		body, note, _ := strings.Cut(page, "\n[page ")
+		if !strings.HasPrefix(note, fmt.Sprintf("%d of %d", n, total)) {
+			t.Fatalf("page %d has wrong note %q", n, note)
+		}
+		got += body
+	}
+	if got != output {
+		t.Fatal("pages do not reassemble into the original output")
+	}
+
+	if _, err := tool.Execute(context.Background(), map[string]interface{}{"id": id, "page": float64(total + 1)}); err == nil {
+		t.Fatal("expected error for page past the end")
+	}
+	if small := store.Paginate("short"); small != "short" {
+		t.Fatalf("small output should pass through, got %q", small)
+	}
+}
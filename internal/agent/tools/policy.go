@@ -0,0 +1,100 @@
+package tools
+
+import (
+	"context"
+	"path"
+	"strings"
+)
+
+// Policy restricts which tools may be used, e.g. for a channel. Entries are
+// glob patterns over tool names ("exec", "mcp_*"); an entry of the form
+// "tool:action" (e.g. "filesystem:write") matches only calls with that
+// "action" argument. With a non-empty Allow list only matching calls are
+// permitted; Deny then removes any that remain.
+type Policy struct {
+	Allow []string
+	Deny  []string
+	// Message, if set, is the reply the user gets when the model tries a
+	// call the policy refuses, e.g. "I can't run commands in this channel."
+	// The turn then ends. Empty leaves it to the model to explain.
+	Message string
+}
+
+// policyMatch reports whether entry matches a call of tool name with action.
+// An empty action (as when listing definitions) matches only entries without
+// an action part, unless anyAction is set.
+func policyMatch(entry, name, action string, anyAction bool) bool {
+	toolPat, actionPat, hasAction := strings.Cut(entry, ":")
+	if ok, _ := path.Match(toolPat, name); !ok {
+		return false
+	}
+	if !hasAction || anyAction {
+		return true
+	}
+	ok, _ := path.Match(actionPat, action)
+	return ok
+}
+
+// Permits reports whether the policy allows calling tool name with the given
+// "action" argument (empty for tools without one).
+func (p *Policy) Permits(name, action string) bool {
+	if p == nil {
+		return true
+	}
+	if len(p.Allow) > 0 {
+		allowed := false
+		for _, e := range p.Allow {
+			if policyMatch(e, name, action, false) {
+				allowed = true
+				break
+			}
+		}
+		if !allowed {
+			return false
+		}
+	}
+	for _, e := range p.Deny {
+		if policyMatch(e, name, action, false) {
+			return false
+		}
+	}
+	return true
+}
+
+// visible reports whether tool name should be offered to the model at all:
+// hidden only if no call to it could be permitted.
+func (p *Policy) visible(name string) bool {
+	if p == nil {
+		return true
+	}
+	if len(p.Allow) > 0 {
+		allowed := false
+		for _, e := range p.Allow {
+			if policyMatch(e, name, "", true) {
+				allowed = true
+				break
+			}
+		}
+		if !allowed {
+			return false
+		}
+	}
+	for _, e := range p.Deny {
+		if !strings.Contains(e, ":") && policyMatch(e, name, "", false) {
+			return false
+		}
+	}
+	return true
+}
+
+type policyKey struct{}
+
+// WithPolicy returns a context whose tool executions are checked against p.
+func WithPolicy(ctx context.Context, p *Policy) context.Context {
+	return context.WithValue(ctx, policyKey{}, p)
+}
+
+func policyFrom(ctx context.Context) *Policy {
+	p, _ := ctx.Value(policyKey{}).(*Policy)
+	return p
+}
@@ -0,0 +1,116 @@
+package tools
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"rsc.io/qr"
+)
+
+// maxQRContent bounds the text encoded in one QR code. The format tops out
+// near 3 KB, and codes that dense are hard to scan from a phone screen.
+const maxQRContent = 1024
+
+// QRCodeTool encodes text (a link, Wi-Fi details, ...) as a QR code PNG in
+// the workspace. The image is also attached to the reply for channels that
+// can send attachments.
+type QRCodeTool struct {
+	root *os.Root
+}
+
+// NewQRCodeTool creates a qrcode tool writing into root.
+func NewQRCodeTool(root *os.Root) *QRCodeTool {
+	return &QRCodeTool{root: root}
+}
+
+func (t *QRCodeTool) Name() string { return "qrcode" }
+
+func (t *QRCodeTool) Description() string {
+	return "Encode text such as a link as a QR code PNG in the workspace and attach it to the reply"
+}
+
+func (t *QRCodeTool) Parameters() map[string]interface{} {
+	return map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"content": map[string]interface{}{
+				"type":        "string",
+				"description": fmt.Sprintf("Text to encode, at most %d bytes", maxQRContent),
+			},
+			"path": map[string]interface{}{
+				"type":        "string",
+				"description": "Output .png file in the workspace (default: qrcodes/qr-<time>.png)",
+			},
+			"level": map[string]interface{}{
+				"type":        "string",
+				"description": "Error correction: L, M (default), Q or H. Higher survives more damage but makes a denser code.",
+				"enum":        []string{"L", "M", "Q", "H"},
+			},
+			"scale": map[string]interface{}{
+				"type":        "integer",
+				"description": "Pixels per QR module, 1-32 (default 8)",
+			},
+		},
+		"required": []string{"content"},
+	}
+}
+
+var qrLevels = map[string]qr.Level{"L": qr.L, "M": qr.M, "Q": qr.Q, "H": qr.H}
+
+func (t *QRCodeTool) Execute(ctx context.Context, args map[string]interface{}) (string, error) {
+	content, _ := args["content"].(string)
+	if content == "" {
+		return "", fmt.Errorf("qrcode: 'content' is required")
+	}
+	if len(content) > maxQRContent {
+		return "", fmt.Errorf("qrcode: content is %d bytes, over the %d byte limit", len(content), maxQRContent)
+	}
+	levelName, _ := args["level"].(string)
+	if levelName == "" {
+		levelName = "M"
+	}
+	level, ok := qrLevels[strings.ToUpper(levelName)]
+	if !ok {
+		return "", fmt.Errorf("qrcode: unknown level %q (use L, M, Q or H)", levelName)
+	}
+	scale := 8
+	if n, ok := numberArg(args, "scale"); ok {
+		if n < 1 || n > 32 {
+			return "", fmt.Errorf("qrcode: 'scale' must be between 1 and 32")
+		}
+		scale = int(n)
+	}
+	name, _ := args["path"].(string)
+	if name == "" {
+		name = "qrcodes/qr-" + time.Now().Format("20060102-150405") + ".png"
+	}
+	name = filepath.Clean(name)
+	if !strings.EqualFold(filepath.Ext(name), ".png") {
+		return "", fmt.Errorf("qrcode: 'path' must end in .png")
+	}
+
+	code, err := qr.Encode(content, level)
+	if err != nil {
+		return "", fmt.Errorf("qrcode: %w", err)
+	}
+	code.Scale = scale
+	png := code.PNG()
+	if dir := filepath.Dir(name); dir != "." {
+		if err := t.root.MkdirAll(dir, 0o755); err != nil {
+			return "", fmt.Errorf("qrcode: %w", err)
+		}
+	}
+	if err := t.root.WriteFile(name, png, 0o644); err != nil {
+		return "", fmt.Errorf("qrcode: %w", err)
+	}
+	if media := mediaCollectorFrom(ctx); media != nil {
+		media.Add("data:image/png;base64," + base64.StdEncoding.EncodeToString(png))
+	}
+	px := (code.Size + 8) * scale
+	return fmt.Sprintf("Wrote a %dx%d px QR code to %s.", px, px, name), nil
+}
@@ -0,0 +1,56 @@
+package tools
+
+import (
+	"bytes"
+	"context"
+	"image/color"
+	"image/png"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestQRCodeWritesPNG(t *testing.T) {
+	d := t.TempDir()
+	root, err := os.OpenRoot(d)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer root.Close()
+	tool := NewQRCodeTool(root)
+	media := &MediaCollector{}
+	ctx := WithMediaCollector(context.Background(), media)
+
+	res, err := tool.Execute(ctx, map[string]interface{}{"content": "https://example.com/picobot", "path": "codes/link.png", "scale": 4})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(res, "codes/link.png") {
+		t.Fatalf("result should name the file, got %q", res)
+	}
+	data, err := os.ReadFile(filepath.Join(d, "codes", "link.png"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	img, err := png.Decode(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("not a PNG: %v", err)
+	}
+	// A QR code is 21+4n modules wide, plus a 4-module quiet zone on each side.
+	b := img.Bounds()
+	if modules := b.Dx()/4 - 8; b.Dx()%4 != 0 || b.Dy() != b.Dx() || modules < 21 || (modules-21)%4 != 0 {
+		t.Fatalf("image is %v, not a square QR code at scale 4", b)
+	}
+	// The top-left finder pattern starts just inside the quiet zone.
+	if g := color.GrayModel.Convert(img.At(4*4, 4*4)).(color.Gray); g.Y != 0 {
+		t.Fatalf("expected a black finder module, got %v", g)
+	}
+	if items := media.Items(); len(items) != 1 || !strings.HasPrefix(items[0], "data:image/png;base64,") {
+		t.Fatalf("the PNG should be attached, got %d items", len(items))
+	}
+
+	if _, err := tool.Execute(ctx, map[string]interface{}{"content": strings.Repeat("x", maxQRContent+1)}); err == nil {
+		t.Fatal("content over the limit should be rejected")
+	}
+}
@@ -4,13 +4,24 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"log"
+	"sort"
+	"strings"
 	"sync"
 	"time"
 
 	"github.com/local/picobot/internal/providers"
 )
 
+// ErrUnknownTool is returned (wrapped) by Registry.Execute when the model
+// calls a tool that isn't registered.
+var ErrUnknownTool = errors.New("unknown tool")
+
+// ErrNotPermitted is returned (wrapped) by Registry.Execute when the Policy
+// attached to the context refuses a call.
+var ErrNotPermitted = errors.New("not permitted in this channel")
+
 // Tool is the interface for tools callable by the agent.
 type Tool interface {
 	Name() string
@@ -39,6 +50,13 @@ func (r *Registry) Register(t Tool) {
 	r.tools[t.Name()] = t
 }
 
+// Unregister removes a tool from the registry, if present.
+func (r *Registry) Unregister(name string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.tools, name)
+}
+
 // Get returns a tool by name (or nil if not found).
 func (r *Registry) Get(name string) Tool {
 	r.mu.RLock()
@@ -46,12 +64,39 @@ func (r *Registry) Get(name string) Tool {
 	return r.tools[name]
 }
 
+// ContextTool is implemented by tools that need to know which conversation
+// the current turn belongs to, e.g. to reply or schedule into it.
+type ContextTool interface {
+	SetContext(channel, chatID string)
+}
+
+// SetContext passes the current turn's channel and chat to every registered
+// ContextTool.
+func (r *Registry) SetContext(channel, chatID string) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	for _, t := range r.tools {
+		if ct, ok := t.(ContextTool); ok {
+			ct.SetContext(channel, chatID)
+		}
+	}
+}
+
 // Definitions returns the list of tool definitions to expose to the model.
 func (r *Registry) Definitions() []providers.ToolDefinition {
+	return r.DefinitionsFor(nil)
+}
+
+// DefinitionsFor returns the tool definitions permitted by p (all tools if p
+// is nil).
+func (r *Registry) DefinitionsFor(p *Policy) []providers.ToolDefinition {
 	r.mu.RLock()
 	defer r.mu.RUnlock()
 	defs := make([]providers.ToolDefinition, 0, len(r.tools))
 	for _, t := range r.tools {
+		if !p.visible(t.Name()) {
+			continue
+		}
 		defs = append(defs, providers.ToolDefinition{
 			Name:        t.Name(),
 			Description: t.Description(),
@@ -61,7 +106,9 @@ func (r *Registry) Definitions() []providers.ToolDefinition {
 	return defs
 }
 
-// Execute executes a registered tool by name with args and returns result or error.
+// Execute executes a registered tool by name with args and returns result or
+// error. Calls not permitted by the Policy attached to ctx (see WithPolicy)
+// are refused.
 func (r *Registry) Execute(ctx context.Context, name string, args map[string]interface{}) (string, error) {
 	if name == "" {
 		return "", errors.New("tool name is required")
@@ -70,7 +117,20 @@ func (r *Registry) Execute(ctx context.Context, name string, args map[string]int
 	t, ok := r.tools[name]
 	r.mu.RUnlock()
 	if !ok {
-		return "", errors.New("tool not found")
+		// Name the tools that do exist so the model can correct itself.
+		var names []string
+		for _, d := range r.DefinitionsFor(policyFrom(ctx)) {
+			names = append(names, d.Name)
+		}
+		sort.Strings(names)
+		return "", fmt.Errorf("%w %q; available tools are: %s", ErrUnknownTool, name, strings.Join(names, ", "))
+	}
+	action, _ := args["action"].(string)
+	if !policyFrom(ctx).Permits(name, action) {
+		if action != "" {
+			return "", fmt.Errorf("%s %s is %w", name, action, ErrNotPermitted)
+		}
+		return "", fmt.Errorf("%s is %w", name, ErrNotPermitted)
 	}
 
 	// Log tool execution start
@@ -32,3 +32,51 @@ func TestMessageToolPublishesOutbound(t *testing.T) {
 		t.Fatalf("no outbound message published")
 	}
 }
+
+func TestRegistrySetContextReachesContextTools(t *testing.T) {
+	b := chat.NewHub(10)
+	r := NewRegistry()
+	r.Register(NewMessageTool(b))
+	r.SetContext("telegram", "42")
+
+	if _, err := r.Execute(context.Background(), "message", map[string]interface{}{"content": "hi"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	select {
+	case out := <-b.Out:
+		if out.Channel != "telegram" || out.ChatID != "42" {
+			t.Fatalf("message sent to %s:%s, want telegram:42", out.Channel, out.ChatID)
+		}
+	default:
+		t.Fatalf("no outbound message published")
+	}
+}
+
+func TestPolicyPermits(t *testing.T) {
+	p := &Policy{Deny: []string{"exec", "filesystem:write"}}
+	tests := []struct {
+		name, action string
+		want         bool
+	}{
+		{"exec", "", false},
+		{"filesystem", "write", false},
+		{"filesystem", "read", true},
+		{"web", "", true},
+	}
+	for _, tt := range tests {
+		if got := p.Permits(tt.name, tt.action); got != tt.want {
+			t.Errorf("Permits(%q, %q) = %v, want %v", tt.name, tt.action, got, tt.want)
+		}
+	}
+
+	allow := &Policy{Allow: []string{"web*", "filesystem:read"}}
+	if !allow.Permits("web_search", "") || !allow.Permits("filesystem", "read") {
+		t.Error("allowlisted calls should be permitted")
+	}
+	if allow.Permits("exec", "") || allow.Permits("filesystem", "write") {
+		t.Error("calls outside the allowlist should be refused")
+	}
+	if !allow.visible("filesystem") || allow.visible("exec") {
+		t.Error("filesystem should stay visible and exec be hidden")
+	}
+}
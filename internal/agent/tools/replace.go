@@ -0,0 +1,184 @@
+package tools
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"regexp"
+	"strings"
+	"time"
+)
+
+const (
+	// replaceTimeout bounds a whole replace run. Go's RE2 engine matches in
+	// linear time, so there is no catastrophic backtracking, but a broad glob
+	// over a large workspace can still take a long time.
+	replaceTimeout = 10 * time.Second
+	// replaceMaxFileSize skips files too large to be sensible edit targets.
+	replaceMaxFileSize = 1 << 20
+	// replaceMaxReportLines caps the dry-run report.
+	replaceMaxReportLines = 100
+)
+
+// ReplaceTool applies a regex substitution across workspace files matching a
+// glob, sed-style. All file access goes through os.Root.
+type ReplaceTool struct {
+	root    *os.Root
+	timeout time.Duration
+}
+
+// NewReplaceTool creates a replace tool rooted at the workspace.
+func NewReplaceTool(root *os.Root) *ReplaceTool {
+	return &ReplaceTool{root: root, timeout: replaceTimeout}
+}
+
+func (t *ReplaceTool) Name() string { return "replace" }
+
+func (t *ReplaceTool) Description() string {
+	return "Regex find-and-replace across workspace files matching a glob. Use dry_run to preview affected lines before applying."
+}
+
+func (t *ReplaceTool) Parameters() map[string]interface{} {
+	return map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"pattern": map[string]interface{}{
+				"type":        "string",
+				"description": "Regular expression (Go RE2 syntax), matched line by line",
+			},
+			"replacement": map[string]interface{}{
+				"type":        "string",
+				"description": "Replacement text; $1, ${name} expand capture groups",
+			},
+			"glob": map[string]interface{}{
+				"type":        "string",
+				"description": "Files to edit, relative to workspace. A pattern without '/' (e.g. '*.md') matches file names in any directory; otherwise it matches the full path (e.g. 'skills/*/SKILL.md').",
+			},
+			"dry_run": map[string]interface{}{
+				"type":        "boolean",
+				"description": "If true, report the lines that would change without writing anything",
+			},
+		},
+		"required": []string{"pattern", "replacement", "glob"},
+	}
+}
+
+// replaceChange records one changed line for reporting.
+type replaceChange struct {
+	file     string
+	line     int
+	old, new string
+}
+
+func (t *ReplaceTool) Execute(ctx context.Context, args map[string]interface{}) (string, error) {
+	pattern, _ := args["pattern"].(string)
+	if pattern == "" {
+		return "", fmt.Errorf("replace: 'pattern' is required")
+	}
+	replacement, ok := args["replacement"].(string)
+	if !ok {
+		return "", fmt.Errorf("replace: 'replacement' must be a string")
+	}
+	glob, _ := args["glob"].(string)
+	if glob == "" {
+		return "", fmt.Errorf("replace: 'glob' is required")
+	}
+	if _, err := path.Match(glob, ""); err != nil {
+		return "", fmt.Errorf("replace: invalid glob: %w", err)
+	}
+	dryRun, _ := args["dry_run"].(bool)
+
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return "", fmt.Errorf("replace: invalid pattern: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, t.timeout)
+	defer cancel()
+
+	var changes []replaceChange
+	files := 0
+	err = fs.WalkDir(t.root.FS(), ".", func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if d.IsDir() || !globMatch(glob, p) {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil || info.Size() > replaceMaxFileSize {
+			return nil
+		}
+		data, err := t.root.ReadFile(p)
+		if err != nil {
+			return err
+		}
+		lines := strings.SplitAfter(string(data), "\n")
+		changed := false
+		for i, line := range lines {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			body := strings.TrimSuffix(line, "\n")
+			if !re.MatchString(body) {
+				continue
+			}
+			repl := re.ReplaceAllString(body, replacement)
+			if repl == body {
+				continue
+			}
+			changes = append(changes, replaceChange{file: p, line: i + 1, old: body, new: repl})
+			lines[i] = repl + line[len(body):]
+			changed = true
+		}
+		if !changed {
+			return nil
+		}
+		files++
+		if dryRun {
+			return nil
+		}
+		return t.root.WriteFile(p, []byte(strings.Join(lines, "")), info.Mode().Perm())
+	})
+	if errors.Is(err, context.DeadlineExceeded) {
+		return "", fmt.Errorf("replace: timed out after %v (%d files changed before stopping)", t.timeout, files)
+	}
+	if err != nil {
+		return "", fmt.Errorf("replace: %w", err)
+	}
+
+	if len(changes) == 0 {
+		return "No matches.", nil
+	}
+	var sb strings.Builder
+	if dryRun {
+		fmt.Fprintf(&sb, "Dry run: %d lines in %d files would change\n", len(changes), files)
+	} else {
+		fmt.Fprintf(&sb, "Replaced %d lines in %d files\n", len(changes), files)
+	}
+	for i, c := range changes {
+		if i == replaceMaxReportLines {
+			fmt.Fprintf(&sb, "... and %d more\n", len(changes)-i)
+			break
+		}
+		fmt.Fprintf(&sb, "%s:%d\n  - %s\n  + %s\n", c.file, c.line, c.old, c.new)
+	}
+	return sb.String(), nil
+}
+
+// globMatch matches slash-separated path p against glob. Globs without a '/'
+// match the base name, so "*.md" finds markdown files at any depth.
+func globMatch(glob, p string) bool {
+	if !strings.Contains(glob, "/") {
+		ok, _ := path.Match(glob, path.Base(p))
+		return ok
+	}
+	ok, _ := path.Match(glob, p)
+	return ok
+}
@@ -0,0 +1,118 @@
+package tools
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func newReplaceWorkspace(t *testing.T) (string, *os.Root) {
+	t.Helper()
+	d := t.TempDir()
+	files := map[string]string{
+		"a.md":              "hello world\nbye world\n",
+		"notes/b.md":        "world peace\nnothing here\n",
+		"notes/c.txt":       "world of text\n",
+		"skills/x/SKILL.md": "# world skill\n",
+	}
+	for name, content := range files {
+		p := filepath.Join(d, name)
+		if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(p, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	root, err := os.OpenRoot(d)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = root.Close() })
+	return d, root
+}
+
+func TestReplaceDryRun(t *testing.T) {
+	d, root := newReplaceWorkspace(t)
+	tool := NewReplaceTool(root)
+
+	out, err := tool.Execute(context.Background(), map[string]interface{}{
+		"pattern": `(\w+) world`, "replacement": "${1} planet", "glob": "*.md", "dry_run": true,
+	})
+	if err != nil {
+		t.Fatalf("Execute: %v", err)
+	}
+	if !strings.Contains(out, "Dry run: 2 lines in 1 files") {
+		t.Fatalf("unexpected summary: %q", out)
+	}
+	if !strings.Contains(out, "a.md:1\n  - hello world\n  + hello planet") || !strings.Contains(out, "a.md:2") {
+		t.Fatalf("report missing changed lines: %q", out)
+	}
+
+	b, _ := os.ReadFile(filepath.Join(d, "a.md"))
+	if string(b) != "hello world\nbye world\n" {
+		t.Fatalf("dry run must not modify files, got %q", b)
+	}
+}
+
+func TestReplaceApply(t *testing.T) {
+	d, root := newReplaceWorkspace(t)
+	tool := NewReplaceTool(root)
+
+	out, err := tool.Execute(context.Background(), map[string]interface{}{
+		"pattern": "world", "replacement": "planet", "glob": "*.md",
+	})
+	if err != nil {
+		t.Fatalf("Execute: %v", err)
+	}
+	if !strings.HasPrefix(out, "Replaced 4 lines in 3 files") {
+		t.Fatalf("unexpected summary: %q", out)
+	}
+
+	want := map[string]string{
+		"a.md":              "hello planet\nbye planet\n",
+		"notes/b.md":        "planet peace\nnothing here\n",
+		"notes/c.txt":       "world of text\n",
+		"skills/x/SKILL.md": "# planet skill\n",
+	}
+	for name, content := range want {
+		b, _ := os.ReadFile(filepath.Join(d, name))
+		if string(b) != content {
+			t.Errorf("%s: got %q, want %q", name, b, content)
+		}
+	}
+
+	// A glob with '/' matches the full relative path only.
+	out, err = tool.Execute(context.Background(), map[string]interface{}{
+		"pattern": "text", "replacement": "prose", "glob": "notes/*.txt",
+	})
+	if err != nil || !strings.HasPrefix(out, "Replaced 1 lines in 1 files") {
+		t.Fatalf("path glob: %q %v", out, err)
+	}
+}
+
+func TestReplaceTimeout(t *testing.T) {
+	_, root := newReplaceWorkspace(t)
+	tool := NewReplaceTool(root)
+	tool.timeout = time.Nanosecond
+
+	_, err := tool.Execute(context.Background(), map[string]interface{}{
+		"pattern": "world", "replacement": "planet", "glob": "*",
+	})
+	if err == nil || !strings.Contains(err.Error(), "timed out") {
+		t.Fatalf("expected timeout error, got %v", err)
+	}
+}
+
+func TestReplaceInvalidPattern(t *testing.T) {
+	_, root := newReplaceWorkspace(t)
+	tool := NewReplaceTool(root)
+	if _, err := tool.Execute(context.Background(), map[string]interface{}{
+		"pattern": "(", "replacement": "", "glob": "*",
+	}); err == nil {
+		t.Fatal("expected error for invalid pattern")
+	}
+}
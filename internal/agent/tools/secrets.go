@@ -0,0 +1,127 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// secretRef matches a {{secret:NAME}} placeholder in a tool argument.
+var secretRef = regexp.MustCompile(`\{\{secret:([A-Za-z0-9_.-]+)\}\}`)
+
+// SecretStore holds named secrets the model can use without seeing them. The
+// model writes {{secret:NAME}} where a secret is needed, tools that support
+// it (currently the web tool's headers) substitute the value, and any value
+// that shows up in a result is masked before the model sees it.
+// A nil store has no secrets.
+type SecretStore struct {
+	values map[string]string
+}
+
+// LoadSecrets builds a store from the listed environment variables (each
+// exposed under its own name) and an optional JSON file of name/value pairs.
+// The file path may start with ~/.
+func LoadSecrets(envNames []string, file string) (*SecretStore, error) {
+	s := &SecretStore{values: make(map[string]string)}
+	if strings.HasPrefix(file, "~/") {
+		home, _ := os.UserHomeDir()
+		file = filepath.Join(home, file[2:])
+	}
+	if file != "" {
+		b, err := os.ReadFile(file)
+		if err != nil {
+			return nil, fmt.Errorf("secrets: %w", err)
+		}
+		if err := json.Unmarshal(b, &s.values); err != nil {
+			return nil, fmt.Errorf("secrets: %s: %w", file, err)
+		}
+	}
+	for _, name := range envNames {
+		if v, ok := os.LookupEnv(name); ok {
+			s.values[name] = v
+		}
+	}
+	for name, v := range s.values {
+		if v == "" {
+			delete(s.values, name)
+		}
+	}
+	return s, nil
+}
+
+// Names returns the available secret names, sorted.
+func (s *SecretStore) Names() []string {
+	if s == nil {
+		return nil
+	}
+	names := make([]string, 0, len(s.values))
+	for name := range s.values {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// expand replaces {{secret:NAME}} placeholders in v with their values.
+func (s *SecretStore) expand(v string) (string, error) {
+	var missing string
+	out := secretRef.ReplaceAllStringFunc(v, func(ref string) string {
+		name := secretRef.FindStringSubmatch(ref)[1]
+		if s != nil {
+			if val, ok := s.values[name]; ok {
+				return val
+			}
+		}
+		if missing == "" {
+			missing = name
+		}
+		return ref
+	})
+	if missing != "" {
+		return "", fmt.Errorf("unknown secret %q", missing)
+	}
+	return out, nil
+}
+
+// redact masks any secret value found in text.
+func (s *SecretStore) redact(text string) string {
+	if s == nil {
+		return text
+	}
+	for name, val := range s.values {
+		text = strings.ReplaceAll(text, val, "[secret:"+name+"]")
+	}
+	return text
+}
+
+// SecretsTool lists the names of the configured secrets, never their values.
+type SecretsTool struct {
+	store *SecretStore
+}
+
+func NewSecretsTool(store *SecretStore) *SecretsTool { return &SecretsTool{store: store} }
+
+func (t *SecretsTool) Name() string { return "secrets" }
+func (t *SecretsTool) Description() string {
+	return "List the names of configured secrets. Use one by writing {{secret:NAME}} in a web request header; the value is filled in for you and never shown."
+}
+
+func (t *SecretsTool) Parameters() map[string]interface{} {
+	return map[string]interface{}{
+		"type":       "object",
+		"properties": map[string]interface{}{},
+	}
+}
+
+func (t *SecretsTool) Execute(ctx context.Context, args map[string]interface{}) (string, error) {
+	names := t.store.Names()
+	if len(names) == 0 {
+		return "no secrets are configured", nil
+	}
+	return "Available secrets (use as {{secret:NAME}} in web headers):\n" + strings.Join(names, "\n"), nil
+}
@@ -0,0 +1,78 @@
+package tools
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestWebToolInjectsSecretWithoutRevealingIt(t *testing.T) {
+	const token = "s3cr3t-token-value"
+	var gotAuth string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotAuth = r.Header.Get("Authorization")
+		// Echo the header back, as some debugging endpoints do.
+		w.Write([]byte("you sent: " + gotAuth))
+	}))
+	defer srv.Close()
+
+	file := filepath.Join(t.TempDir(), "secrets.json")
+	if err := os.WriteFile(file, []byte(`{"API_TOKEN": "`+token+`"}`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	store, err := LoadSecrets(nil, file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	web := NewWebTool()
+	web.SetAllowPrivateNetworks(true)
+	web.SetSecrets(store)
+
+	for _, full := range []bool{false, true} {
+		out, err := web.Execute(context.Background(), map[string]interface{}{
+			"url":     srv.URL,
+			"headers": map[string]interface{}{"Authorization": "Bearer {{secret:API_TOKEN}}"},
+			"full":    full,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if gotAuth != "Bearer "+token {
+			t.Fatalf("server got Authorization %q, want the secret value", gotAuth)
+		}
+		if strings.Contains(out, token) || !strings.Contains(out, "Bearer [secret:API_TOKEN]") {
+			t.Fatalf("secret must be masked in the result, got %q", out)
+		}
+	}
+
+	list, _ := NewSecretsTool(store).Execute(context.Background(), nil)
+	if !strings.Contains(list, "API_TOKEN") || strings.Contains(list, token) {
+		t.Fatalf("secrets tool should list names only, got %q", list)
+	}
+
+	_, err = web.Execute(context.Background(), map[string]interface{}{
+		"url":     srv.URL,
+		"headers": map[string]interface{}{"Authorization": "{{secret:MISSING}}"},
+	})
+	if err == nil || !strings.Contains(err.Error(), `unknown secret "MISSING"`) {
+		t.Fatalf("expected unknown secret error, got %v", err)
+	}
+}
+
+func TestLoadSecretsFromEnv(t *testing.T) {
+	t.Setenv("PICOBOT_TEST_SECRET", "from-env")
+	store, err := LoadSecrets([]string{"PICOBOT_TEST_SECRET", "PICOBOT_TEST_UNSET"}, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if names := store.Names(); len(names) != 1 || names[0] != "PICOBOT_TEST_SECRET" {
+		t.Fatalf("names = %v", names)
+	}
+	if got, _ := store.expand("x {{secret:PICOBOT_TEST_SECRET}}"); got != "x from-env" {
+		t.Fatalf("expand = %q", got)
+	}
+}
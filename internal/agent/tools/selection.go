@@ -0,0 +1,59 @@
+package tools
+
+import (
+	"path"
+	"sort"
+
+	"github.com/local/picobot/internal/providers"
+)
+
+// ToolSelection caps how many tools are offered to the model in one turn,
+// which keeps requests small when many MCP servers are connected.
+type ToolSelection struct {
+	// Max is the most tools offered per turn; 0 means no cap.
+	Max int
+	// Priority lists glob patterns over tool names ("filesystem", "mcp_github_*").
+	// Tools matching an earlier pattern are kept first.
+	Priority []string
+}
+
+// Select returns at most s.Max of defs. Tools matching a Priority pattern
+// come first, in pattern order; the remaining slots go to the tools whose
+// name and description best match the user's message, then by name.
+func (s ToolSelection) Select(defs []providers.ToolDefinition, message string) []providers.ToolDefinition {
+	if s.Max <= 0 || len(defs) <= s.Max {
+		return defs
+	}
+	type ranked struct {
+		def      providers.ToolDefinition
+		priority int // index of the first matching pattern, len(Priority) if none
+		score    float64
+	}
+	terms := keywords(message)
+	all := make([]ranked, 0, len(defs))
+	for _, d := range defs {
+		r := ranked{def: d, priority: len(s.Priority)}
+		for i, pattern := range s.Priority {
+			if ok, _ := path.Match(pattern, d.Name); ok {
+				r.priority = i
+				break
+			}
+		}
+		r.score = keywordScore(terms, keywords(d.Name+" "+d.Description))
+		all = append(all, r)
+	}
+	sort.Slice(all, func(i, j int) bool {
+		if all[i].priority != all[j].priority {
+			return all[i].priority < all[j].priority
+		}
+		if all[i].score != all[j].score {
+			return all[i].score > all[j].score
+		}
+		return all[i].def.Name < all[j].def.Name
+	})
+	out := make([]providers.ToolDefinition, 0, s.Max)
+	for _, r := range all[:s.Max] {
+		out = append(out, r.def)
+	}
+	return out
+}
@@ -0,0 +1,47 @@
+package tools
+
+import (
+	"testing"
+
+	"github.com/local/picobot/internal/providers"
+)
+
+func TestToolSelectionCapsAndPrioritizes(t *testing.T) {
+	defs := []providers.ToolDefinition{
+		{Name: "mcp_github_create_issue", Description: "Create a GitHub issue"},
+		{Name: "mcp_weather_forecast", Description: "Get the weather forecast for a city"},
+		{Name: "exec", Description: "Run a shell command"},
+		{Name: "filesystem", Description: "Read and write files"},
+		{Name: "mcp_github_list_prs", Description: "List pull requests"},
+	}
+	s := ToolSelection{Max: 3, Priority: []string{"filesystem", "mcp_github_*"}}
+
+	got := s.Select(defs, "what's the weather in Paris?")
+	var names []string
+	for _, d := range got {
+		names = append(names, d.Name)
+	}
+	want := []string{"filesystem", "mcp_github_create_issue", "mcp_github_list_prs"}
+	if len(names) != len(want) {
+		t.Fatalf("expected %d tools, got %v", len(want), names)
+	}
+	for i := range want {
+		if names[i] != want[i] {
+			t.Fatalf("expected %v, got %v", want, names)
+		}
+	}
+
+	// With room left after the priority tools, relevance decides.
+	s.Max = 4
+	got = s.Select(defs, "what's the weather in Paris?")
+	if len(got) != 4 || got[3].Name != "mcp_weather_forecast" {
+		t.Fatalf("expected the weather tool to fill the last slot, got %v", got)
+	}
+}
+
+func TestToolSelectionWithoutCapKeepsAll(t *testing.T) {
+	defs := []providers.ToolDefinition{{Name: "a"}, {Name: "b"}}
+	if got := (ToolSelection{}).Select(defs, "hi"); len(got) != 2 {
+		t.Fatalf("expected all tools without a cap, got %d", len(got))
+	}
+}
@@ -6,12 +6,18 @@ import (
 	"fmt"
 	"os"
 	"strings"
+	"unicode/utf8"
+
+	"github.com/local/picobot/internal/agent/skills"
 )
 
-// SkillMetadata holds metadata parsed from SKILL.md frontmatter.
+// SkillMetadata holds metadata parsed from SKILL.md frontmatter. Tags and
+// Triggers are optional; see skills.Skill.
 type SkillMetadata struct {
-	Name        string `json:"name"`
-	Description string `json:"description"`
+	Name        string   `json:"name"`
+	Description string   `json:"description"`
+	Tags        []string `json:"tags,omitempty"`
+	Triggers    []string `json:"trigger,omitempty"`
 }
 
 // SkillManager provides tools for managing skills in the workspace.
@@ -71,10 +77,16 @@ func (sm *SkillManager) GetSkill(name string) (string, error) {
 // CreateSkill creates a new skill with the given name and content.
 // Path traversal is prevented by os.Root at the kernel level.
 func (sm *SkillManager) CreateSkill(name, description, content string) error {
-	if name == "" {
+	return sm.SaveSkill(SkillMetadata{Name: name, Description: description}, content)
+}
+
+// SaveSkill writes a skill's SKILL.md, creating or replacing it. The tags
+// and trigger frontmatter lines are only written when set.
+func (sm *SkillManager) SaveSkill(meta SkillMetadata, content string) error {
+	if meta.Name == "" {
 		return fmt.Errorf("skill name is required")
 	}
-	name = strings.TrimSpace(name)
+	name := strings.TrimSpace(meta.Name)
 
 	skillDir := "skills/" + name
 	if err := sm.root.MkdirAll(skillDir, 0o755); err != nil {
@@ -82,12 +94,130 @@ func (sm *SkillManager) CreateSkill(name, description, content string) error {
 	}
 
 	// Create SKILL.md with frontmatter
-	frontmatter := fmt.Sprintf("---\nname: %s\ndescription: %s\n---\n\n", name, description)
-	fullContent := frontmatter + content
+	frontmatter := fmt.Sprintf("---\nname: %s\ndescription: %s\n", name, meta.Description)
+	if len(meta.Tags) > 0 {
+		frontmatter += "tags: " + skills.FormatList(meta.Tags) + "\n"
+	}
+	if len(meta.Triggers) > 0 {
+		frontmatter += "trigger: " + skills.FormatList(meta.Triggers) + "\n"
+	}
+	fullContent := frontmatter + "---\n\n" + content
 
 	return sm.root.WriteFile(skillDir+"/SKILL.md", []byte(fullContent), 0o644)
 }
 
+// UpdateSkill rewrites an existing skill in place. An empty description or
+// content keeps the current one. Updating a skill that doesn't exist is an
+// error, so a misspelled name can't silently create a new skill.
+func (sm *SkillManager) UpdateSkill(name, description, content string) error {
+	return sm.UpdateSkillMeta(SkillMetadata{Name: name, Description: description}, content)
+}
+
+// UpdateSkillMeta is UpdateSkill that can also change tags and triggers. Nil
+// Tags or Triggers keep the current ones; an empty non-nil list clears them.
+func (sm *SkillManager) UpdateSkillMeta(update SkillMetadata, content string) error {
+	name := strings.TrimSpace(update.Name)
+	if name == "" {
+		return fmt.Errorf("skill name is required")
+	}
+	path := "skills/" + name + "/SKILL.md"
+	raw, err := sm.root.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return fmt.Errorf("skill '%s' does not exist", name)
+		}
+		return err
+	}
+	meta, err := sm.parseSkillMetadata(path)
+	if err != nil {
+		return err
+	}
+	meta.Name = name
+	if update.Description != "" {
+		meta.Description = update.Description
+	}
+	if update.Tags != nil {
+		meta.Tags = update.Tags
+	}
+	if update.Triggers != nil {
+		meta.Triggers = update.Triggers
+	}
+	if content == "" {
+		content = skillBody(string(raw))
+	}
+	return sm.SaveSkill(meta, content)
+}
+
+// skillBody returns the content of a SKILL.md after its frontmatter.
+func skillBody(raw string) string {
+	rest, ok := strings.CutPrefix(raw, "---\n")
+	if !ok {
+		return raw
+	}
+	if _, body, found := strings.Cut(rest, "\n---\n"); found {
+		return strings.TrimPrefix(body, "\n")
+	}
+	return raw
+}
+
+// SkillMatch is a skill found by SearchSkills, with a snippet around the hit.
+type SkillMatch struct {
+	Name    string `json:"name"`
+	Field   string `json:"field"` // where the query matched: name, description, or content
+	Snippet string `json:"snippet"`
+}
+
+// SearchSkills finds skills whose name, description, or content contains
+// query (case-insensitive). Matches in the name rank first, then the
+// description, then the content; ties keep the listing order.
+func (sm *SkillManager) SearchSkills(query string) ([]SkillMatch, error) {
+	q := strings.ToLower(strings.TrimSpace(query))
+	if q == "" {
+		return nil, fmt.Errorf("query is required")
+	}
+	skills, err := sm.ListSkills()
+	if err != nil {
+		return nil, err
+	}
+	var byField [3][]SkillMatch
+	for _, meta := range skills {
+		raw, err := sm.GetSkill(meta.Name)
+		if err != nil {
+			continue
+		}
+		for i, f := range []struct{ name, text string }{
+			{"name", meta.Name}, {"description", meta.Description}, {"content", skillBody(raw)},
+		} {
+			if at := strings.Index(strings.ToLower(f.text), q); at >= 0 {
+				byField[i] = append(byField[i], SkillMatch{Name: meta.Name, Field: f.name, Snippet: snippet(f.text, at, len(q))})
+				break
+			}
+		}
+	}
+	return append(append(byField[0], byField[1]...), byField[2]...), nil
+}
+
+// snippet returns up to 40 bytes either side of text[at:at+n] on one line.
+func snippet(text string, at, n int) string {
+	const radius = 40
+	start, end := max(at-radius, 0), min(at+n+radius, len(text))
+	// Don't cut a UTF-8 sequence in half.
+	for start > 0 && !utf8.RuneStart(text[start]) {
+		start--
+	}
+	for end < len(text) && !utf8.RuneStart(text[end]) {
+		end++
+	}
+	s := strings.Join(strings.Fields(text[start:end]), " ")
+	if start > 0 {
+		s = "..." + s
+	}
+	if end < len(text) {
+		s += "..."
+	}
+	return s
+}
+
 // DeleteSkill removes a skill directory.
 func (sm *SkillManager) DeleteSkill(name string) error {
 	return sm.root.RemoveAll("skills/" + name)
@@ -100,7 +230,7 @@ func (sm *SkillManager) parseSkillMetadata(skillPath string) (SkillMetadata, err
 		return SkillMetadata{}, err
 	}
 
-	// parse YAML frontmatter (simple parser for name and description)
+	// parse YAML frontmatter (simple parser for name, description, tags and trigger)
 	lines := strings.Split(string(content), "\n")
 	if len(lines) < 3 || lines[0] != "---" {
 		return SkillMetadata{}, fmt.Errorf("invalid frontmatter")
@@ -123,6 +253,10 @@ func (sm *SkillManager) parseSkillMetadata(skillPath string) (SkillMetadata, err
 			meta.Name = value
 		case "description":
 			meta.Description = value
+		case "tags":
+			meta.Tags = skills.ParseList(value)
+		case "trigger", "triggers":
+			meta.Triggers = skills.ParseList(value)
 		}
 	}
 
@@ -167,6 +301,16 @@ func (t *CreateSkillTool) Parameters() map[string]interface{} {
 				"type":        "string",
 				"description": "The markdown content for the skill (instructions, examples, etc.)",
 			},
+			"tags": map[string]interface{}{
+				"type":        "array",
+				"description": "Optional topic keywords, e.g. git, release",
+				"items":       map[string]interface{}{"type": "string"},
+			},
+			"trigger": map[string]interface{}{
+				"type":        "array",
+				"description": "Optional keywords or regular expressions; a user message matching one suggests this skill",
+				"items":       map[string]interface{}{"type": "string"},
+			},
 		},
 		"required": []string{"name", "description", "content"},
 	}
@@ -186,12 +330,90 @@ func (t *CreateSkillTool) Execute(ctx context.Context, args map[string]interface
 		return "", fmt.Errorf("content (string) is required")
 	}
 
-	if err := t.manager.CreateSkill(name, description, content); err != nil {
+	tags, err := stringList(args["tags"])
+	if err != nil {
+		return "", fmt.Errorf("tags %v", err)
+	}
+	triggers, err := stringList(args["trigger"])
+	if err != nil {
+		return "", fmt.Errorf("trigger %v", err)
+	}
+	if err := t.manager.SaveSkill(SkillMetadata{Name: name, Description: description, Tags: tags, Triggers: triggers}, content); err != nil {
 		return "", err
 	}
 	return fmt.Sprintf("Skill '%s' created successfully", name), nil
 }
 
+// UpdateSkillTool allows the agent to refine an existing skill.
+type UpdateSkillTool struct {
+	manager *SkillManager
+}
+
+func NewUpdateSkillTool(manager *SkillManager) *UpdateSkillTool {
+	return &UpdateSkillTool{manager: manager}
+}
+
+func (t *UpdateSkillTool) Name() string { return "update_skill" }
+
+func (t *UpdateSkillTool) Description() string {
+	return "Update an existing skill's description and/or content in place"
+}
+
+func (t *UpdateSkillTool) Parameters() map[string]interface{} {
+	return map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"name": map[string]interface{}{
+				"type":        "string",
+				"description": "The name of the skill to update",
+			},
+			"description": map[string]interface{}{
+				"type":        "string",
+				"description": "New description (omit to keep the current one)",
+			},
+			"content": map[string]interface{}{
+				"type":        "string",
+				"description": "New markdown content, replacing the old (omit to keep the current content)",
+			},
+			"tags": map[string]interface{}{
+				"type":        "array",
+				"description": "New topic keywords (omit to keep the current ones, [] to clear)",
+				"items":       map[string]interface{}{"type": "string"},
+			},
+			"trigger": map[string]interface{}{
+				"type":        "array",
+				"description": "New trigger keywords or regular expressions (omit to keep the current ones, [] to clear)",
+				"items":       map[string]interface{}{"type": "string"},
+			},
+		},
+		"required": []string{"name"},
+	}
+}
+
+func (t *UpdateSkillTool) Execute(ctx context.Context, args map[string]interface{}) (string, error) {
+	name, ok := args["name"].(string)
+	if !ok {
+		return "", fmt.Errorf("name (string) is required")
+	}
+	description, _ := args["description"].(string)
+	content, _ := args["content"].(string)
+	tags, err := stringList(args["tags"])
+	if err != nil {
+		return "", fmt.Errorf("tags %v", err)
+	}
+	triggers, err := stringList(args["trigger"])
+	if err != nil {
+		return "", fmt.Errorf("trigger %v", err)
+	}
+	if description == "" && content == "" && tags == nil && triggers == nil {
+		return "", fmt.Errorf("description, content, tags or trigger is required")
+	}
+	if err := t.manager.UpdateSkillMeta(SkillMetadata{Name: name, Description: description, Tags: tags, Triggers: triggers}, content); err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("Skill '%s' updated successfully", name), nil
+}
+
 // ListSkillsTool lists all available skills.
 type ListSkillsTool struct {
 	manager *SkillManager
@@ -204,7 +426,7 @@ func NewListSkillsTool(manager *SkillManager) *ListSkillsTool {
 func (t *ListSkillsTool) Name() string { return "list_skills" }
 
 func (t *ListSkillsTool) Description() string {
-	return "List all available skills with their names and descriptions"
+	return "List all available skills with their names, descriptions, tags and triggers"
 }
 
 func (t *ListSkillsTool) Parameters() map[string]interface{} {
@@ -229,6 +451,53 @@ func (t *ListSkillsTool) Execute(ctx context.Context, args map[string]interface{
 	return string(result), nil
 }
 
+// SearchSkillsTool finds skills by keyword.
+type SearchSkillsTool struct {
+	manager *SkillManager
+}
+
+func NewSearchSkillsTool(manager *SkillManager) *SearchSkillsTool {
+	return &SearchSkillsTool{manager: manager}
+}
+
+func (t *SearchSkillsTool) Name() string { return "search_skills" }
+
+func (t *SearchSkillsTool) Description() string {
+	return "Search skills by keyword in their name, description and content; returns matching skill names with a snippet"
+}
+
+func (t *SearchSkillsTool) Parameters() map[string]interface{} {
+	return map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"query": map[string]interface{}{
+				"type":        "string",
+				"description": "Word or phrase to look for (case-insensitive)",
+			},
+		},
+		"required": []string{"query"},
+	}
+}
+
+func (t *SearchSkillsTool) Execute(ctx context.Context, args map[string]interface{}) (string, error) {
+	query, ok := args["query"].(string)
+	if !ok {
+		return "", fmt.Errorf("query (string) is required")
+	}
+	matches, err := t.manager.SearchSkills(query)
+	if err != nil {
+		return "", err
+	}
+	if len(matches) == 0 {
+		return "No skills found", nil
+	}
+	result, err := json.MarshalIndent(matches, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	return string(result), nil
+}
+
 // ReadSkillTool reads a skill's content.
 type ReadSkillTool struct {
 	manager *SkillManager
@@ -3,6 +3,8 @@ package tools
 import (
 	"context"
 	"os"
+	"reflect"
+	"strings"
 	"testing"
 )
 
@@ -197,6 +199,141 @@ func TestDeleteSkillTool_Execute(t *testing.T) {
 	}
 }
 
+func TestSkillManager_UpdateSkill(t *testing.T) {
+	root := openTestRoot(t)
+	mgr := NewSkillManager(root)
+	if err := mgr.CreateSkill("test-skill", "Old description", "# Old\n\nOld content"); err != nil {
+		t.Fatalf("CreateSkill failed: %v", err)
+	}
+
+	// Description only: content is kept.
+	if err := mgr.UpdateSkill("test-skill", "New description", ""); err != nil {
+		t.Fatalf("UpdateSkill failed: %v", err)
+	}
+	got, _ := mgr.GetSkill("test-skill")
+	want := "---\nname: test-skill\ndescription: New description\n---\n\n# Old\n\nOld content"
+	if got != want {
+		t.Fatalf("after description update got %q, want %q", got, want)
+	}
+
+	// Content only: description is kept.
+	if err := mgr.UpdateSkill("test-skill", "", "# New\n\nNew content"); err != nil {
+		t.Fatalf("UpdateSkill failed: %v", err)
+	}
+	got, _ = mgr.GetSkill("test-skill")
+	want = "---\nname: test-skill\ndescription: New description\n---\n\n# New\n\nNew content"
+	if got != want {
+		t.Fatalf("after content update got %q, want %q", got, want)
+	}
+
+	if err := mgr.UpdateSkill("missing", "x", ""); err == nil || !containsString(err.Error(), "does not exist") {
+		t.Errorf("expected updating a missing skill to fail, got %v", err)
+	}
+}
+
+func TestUpdateSkillTool_Execute(t *testing.T) {
+	root := openTestRoot(t)
+	mgr := NewSkillManager(root)
+	if err := mgr.CreateSkill("test-skill", "Test description", "Content"); err != nil {
+		t.Fatalf("CreateSkill failed: %v", err)
+	}
+
+	tool := NewUpdateSkillTool(mgr)
+	if _, err := tool.Execute(context.Background(), map[string]interface{}{"name": "test-skill"}); err == nil {
+		t.Error("expected an error when neither description nor content is given")
+	}
+	result, err := tool.Execute(context.Background(), map[string]interface{}{"name": "test-skill", "content": "Better content"})
+	if err != nil {
+		t.Fatalf("Execute failed: %v", err)
+	}
+	if !containsString(result, "updated successfully") {
+		t.Errorf("Unexpected result: %s", result)
+	}
+	skills, _ := mgr.ListSkills()
+	if len(skills) != 1 || skills[0].Description != "Test description" {
+		t.Errorf("metadata should be preserved, got %+v", skills)
+	}
+}
+
+func TestSkillManager_SearchSkills(t *testing.T) {
+	root := openTestRoot(t)
+	mgr := NewSkillManager(root)
+	for _, s := range []struct{ name, desc, content string }{
+		{"weather", "Check the forecast", "Use the web tool to fetch a forecast for the user's city."},
+		{"travel", "Plan trips and check the weather at the destination", "Book flights. Pack for rain."},
+		{"packing", "Make packing lists", "Look at the Weather skill first, then list clothes."},
+		{"cooking", "Recipes", "Preheat the oven."},
+	} {
+		if err := mgr.CreateSkill(s.name, s.desc, s.content); err != nil {
+			t.Fatalf("CreateSkill %s failed: %v", s.name, err)
+		}
+	}
+
+	matches, err := mgr.SearchSkills("WEATHER")
+	if err != nil {
+		t.Fatalf("SearchSkills failed: %v", err)
+	}
+	var got []string
+	for _, m := range matches {
+		got = append(got, m.Name+":"+m.Field)
+	}
+	want := "weather:name travel:description packing:content"
+	if strings.Join(got, " ") != want {
+		t.Fatalf("matches = %v, want %s", got, want)
+	}
+	if !containsString(matches[2].Snippet, "Look at the Weather skill") {
+		t.Errorf("snippet should surround the match, got %q", matches[2].Snippet)
+	}
+
+	result, err := NewSearchSkillsTool(mgr).Execute(context.Background(), map[string]interface{}{"query": "sushi"})
+	if err != nil || result != "No skills found" {
+		t.Errorf("expected no results, got %q (%v)", result, err)
+	}
+}
+
+func TestSkillManager_TagsAndTriggers(t *testing.T) {
+	root := openTestRoot(t)
+	mgr := NewSkillManager(root)
+	meta := SkillMetadata{Name: "release", Description: "Cut a release", Tags: []string{"git", "versioning"}, Triggers: []string{"ship it", "v\\d+, tag"}}
+	if err := mgr.SaveSkill(meta, "Steps"); err != nil {
+		t.Fatalf("SaveSkill failed: %v", err)
+	}
+	list, err := mgr.ListSkills()
+	if err != nil || len(list) != 1 {
+		t.Fatalf("ListSkills = %+v, %v", list, err)
+	}
+	if !reflect.DeepEqual(list[0], meta) {
+		t.Fatalf("round trip got %+v, want %+v", list[0], meta)
+	}
+
+	// Updating the description keeps tags and triggers; an empty list clears.
+	if err := mgr.UpdateSkill("release", "Publish a release", ""); err != nil {
+		t.Fatalf("UpdateSkill failed: %v", err)
+	}
+	list, _ = mgr.ListSkills()
+	if len(list[0].Tags) != 2 || len(list[0].Triggers) != 2 {
+		t.Fatalf("update dropped metadata: %+v", list[0])
+	}
+	tool := NewUpdateSkillTool(mgr)
+	if _, err := tool.Execute(context.Background(), map[string]interface{}{"name": "release", "trigger": []interface{}{}}); err != nil {
+		t.Fatalf("Execute failed: %v", err)
+	}
+	got, _ := mgr.GetSkill("release")
+	want := "---\nname: release\ndescription: Publish a release\ntags: [git, versioning]\n---\n\nSteps"
+	if got != want {
+		t.Fatalf("got %q, want %q", got, want)
+	}
+
+	// Skills without the new fields still list as before.
+	if err := mgr.CreateSkill("plain", "No extras", "Body"); err != nil {
+		t.Fatalf("CreateSkill failed: %v", err)
+	}
+	raw, _ := mgr.GetSkill("plain")
+	if containsString(raw, "tags:") || containsString(raw, "trigger:") {
+		t.Errorf("plain skill should not get empty tags or trigger lines: %q", raw)
+	}
+}
+
 func containsString(s, substr string) bool {
 	return len(s) >= len(substr) && (s == substr ||
 		(len(s) > len(substr) && (s[:len(substr)] == substr ||
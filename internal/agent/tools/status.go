@@ -0,0 +1,97 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/local/picobot/internal/chat"
+)
+
+// statusSeverities are the accepted severities, mildest first, with the
+// marker shown in the posted update.
+var statusSeverities = []struct{ name, marker string }{
+	{"info", "🔵"},
+	{"minor", "🟡"},
+	{"major", "🟠"},
+	{"critical", "🔴"},
+}
+
+// StatusUpdateTool posts structured status updates (severity, component,
+// message) to one configured channel and chat, such as an incident channel.
+// Without a destination it is registered but refuses to post.
+type StatusUpdateTool struct {
+	hub     *chat.Hub
+	channel string
+	chatID  string
+}
+
+// NewStatusUpdateTool creates a status_update tool posting to channel/chatID.
+func NewStatusUpdateTool(hub *chat.Hub, channel, chatID string) *StatusUpdateTool {
+	return &StatusUpdateTool{hub: hub, channel: channel, chatID: chatID}
+}
+
+func (t *StatusUpdateTool) Name() string { return "status_update" }
+func (t *StatusUpdateTool) Description() string {
+	return "Post a structured status update (severity, component, message) to the configured status/incident channel"
+}
+
+func (t *StatusUpdateTool) Parameters() map[string]interface{} {
+	severities := make([]string, len(statusSeverities))
+	for i, s := range statusSeverities {
+		severities[i] = s.name
+	}
+	return map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"severity": map[string]interface{}{
+				"type":        "string",
+				"description": "How serious the status is",
+				"enum":        severities,
+			},
+			"component": map[string]interface{}{
+				"type":        "string",
+				"description": "Affected component or service, e.g. api or database",
+			},
+			"message": map[string]interface{}{
+				"type":        "string",
+				"description": "What is happening",
+			},
+		},
+		"required": []string{"severity", "component", "message"},
+	}
+}
+
+func (t *StatusUpdateTool) Execute(ctx context.Context, args map[string]interface{}) (string, error) {
+	if t.channel == "" || t.chatID == "" {
+		return "", fmt.Errorf("status_update: no destination is configured; set agents.defaults.statusUpdates in the config")
+	}
+	severity, _ := args["severity"].(string)
+	component, _ := args["component"].(string)
+	message, _ := args["message"].(string)
+	component, message = strings.TrimSpace(component), strings.TrimSpace(message)
+	marker := ""
+	var names []string
+	for _, s := range statusSeverities {
+		names = append(names, s.name)
+		if strings.EqualFold(severity, s.name) {
+			severity, marker = s.name, s.marker
+		}
+	}
+	if marker == "" {
+		return "", fmt.Errorf("status_update: invalid severity %q (use %s)", severity, strings.Join(names, ", "))
+	}
+	if component == "" || message == "" {
+		return "", fmt.Errorf("status_update: 'component' and 'message' are required")
+	}
+
+	content := fmt.Sprintf("%s [%s] %s: %s\n%s", marker, strings.ToUpper(severity), component, message,
+		time.Now().UTC().Format("2006-01-02 15:04 UTC"))
+	select {
+	case t.hub.Out <- chat.Outbound{Channel: t.channel, ChatID: t.chatID, Content: content}:
+		return fmt.Sprintf("posted %s status for %s to %s:%s", severity, component, t.channel, t.chatID), nil
+	default:
+		return "", fmt.Errorf("status_update: outbound channel full")
+	}
+}
@@ -0,0 +1,53 @@
+package tools
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/local/picobot/internal/chat"
+)
+
+func TestStatusUpdateRoutesToTarget(t *testing.T) {
+	hub := chat.NewHub(10)
+	tool := NewStatusUpdateTool(hub, "slack", "C-incidents")
+
+	res, err := tool.Execute(context.Background(), map[string]interface{}{
+		"severity": "Major", "component": "database", "message": "Replica lag above 5 minutes",
+	})
+	if err != nil {
+		t.Fatalf("Execute: %v", err)
+	}
+	if !strings.Contains(res, "slack:C-incidents") {
+		t.Errorf("unexpected result %q", res)
+	}
+	out := <-hub.Out
+	if out.Channel != "slack" || out.ChatID != "C-incidents" {
+		t.Fatalf("routed to %s:%s, want slack:C-incidents", out.Channel, out.ChatID)
+	}
+	if !strings.HasPrefix(out.Content, "🟠 [MAJOR] database: Replica lag above 5 minutes\n") {
+		t.Fatalf("unexpected status format %q", out.Content)
+	}
+}
+
+func TestStatusUpdateRejectsInvalidInput(t *testing.T) {
+	hub := chat.NewHub(10)
+	tool := NewStatusUpdateTool(hub, "slack", "C-incidents")
+	ctx := context.Background()
+
+	_, err := tool.Execute(ctx, map[string]interface{}{"severity": "apocalyptic", "component": "api", "message": "down"})
+	if err == nil || !strings.Contains(err.Error(), "invalid severity") {
+		t.Fatalf("expected invalid severity error, got %v", err)
+	}
+	if _, err := tool.Execute(ctx, map[string]interface{}{"severity": "info", "message": "ok"}); err == nil {
+		t.Fatal("expected an error without a component")
+	}
+	if _, err := NewStatusUpdateTool(hub, "", "").Execute(ctx, map[string]interface{}{"severity": "info", "component": "api", "message": "ok"}); err == nil {
+		t.Fatal("expected an error without a configured destination")
+	}
+	select {
+	case out := <-hub.Out:
+		t.Fatalf("nothing should be posted, got %+v", out)
+	default:
+	}
+}
@@ -0,0 +1,240 @@
+package tools
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Limits for the tail tool, so one call can't flood the context or hold a
+// turn open for long.
+const (
+	defaultTailLines = 20
+	maxTailLines     = 500
+	maxTailBytes     = 64 << 10 // of output per call
+	maxTailFollow    = 60 * time.Second
+	maxLogListing    = 100
+	tailPollInterval = 200 * time.Millisecond
+)
+
+// TailTool lists log files in the workspace and returns the last lines of
+// one, optionally waiting a bounded time for more to be written, via os.Root.
+type TailTool struct {
+	root *os.Root
+}
+
+// NewTailTool creates a tail tool reading from root.
+func NewTailTool(root *os.Root) *TailTool {
+	return &TailTool{root: root}
+}
+
+func (t *TailTool) Name() string { return "tail" }
+
+func (t *TailTool) Description() string {
+	return "List .log files in the workspace, or show the last lines of a workspace file and optionally follow it for a few seconds to see new output"
+}
+
+func (t *TailTool) Parameters() map[string]interface{} {
+	return map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"action": map[string]interface{}{
+				"type":        "string",
+				"description": "tail (default) or list",
+				"enum":        []string{"tail", "list"},
+			},
+			"path": map[string]interface{}{
+				"type":        "string",
+				"description": "File to tail, or directory to list (default: the workspace)",
+			},
+			"lines": map[string]interface{}{
+				"type":        "integer",
+				"description": fmt.Sprintf("Number of lines from the end, 1-%d (default %d)", maxTailLines, defaultTailLines),
+			},
+			"follow_seconds": map[string]interface{}{
+				"type":        "integer",
+				"description": fmt.Sprintf("Keep reading lines appended to the file for this many seconds, at most %d (default 0)", int(maxTailFollow.Seconds())),
+			},
+		},
+	}
+}
+
+func (t *TailTool) Execute(ctx context.Context, args map[string]interface{}) (string, error) {
+	action, _ := args["action"].(string)
+	name, _ := args["path"].(string)
+	switch action {
+	case "", "tail":
+		return t.tail(ctx, name, args)
+	case "list":
+		return t.list(name)
+	default:
+		return "", fmt.Errorf("tail: unknown action %q (use tail or list)", action)
+	}
+}
+
+func (t *TailTool) list(dir string) (string, error) {
+	if dir == "" {
+		dir = "."
+	}
+	dir = path.Clean(filepath.ToSlash(dir))
+	var b strings.Builder
+	n := 0
+	err := fs.WalkDir(t.root.FS(), dir, func(name string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.Type().IsRegular() || !strings.HasSuffix(name, ".log") {
+			return nil
+		}
+		if n++; n > maxLogListing {
+			return fs.SkipAll
+		}
+		if info, err := d.Info(); err == nil {
+			fmt.Fprintf(&b, "%s (%d bytes, modified %s)\n", name, info.Size(), info.ModTime().Format(time.RFC3339))
+		}
+		return nil
+	})
+	if err != nil {
+		return "", fmt.Errorf("tail: %w", err)
+	}
+	if n == 0 {
+		return "No .log files in " + dir, nil
+	}
+	if n > maxLogListing {
+		fmt.Fprintf(&b, "(stopped after %d files)\n", maxLogListing)
+	}
+	return b.String(), nil
+}
+
+func (t *TailTool) tail(ctx context.Context, name string, args map[string]interface{}) (string, error) {
+	if name == "" {
+		return "", fmt.Errorf("tail: 'path' is required")
+	}
+	lines := defaultTailLines
+	if n, ok := numberArg(args, "lines"); ok {
+		if n < 1 || n > maxTailLines {
+			return "", fmt.Errorf("tail: 'lines' must be between 1 and %d", maxTailLines)
+		}
+		lines = int(n)
+	}
+	var follow time.Duration
+	if n, ok := numberArg(args, "follow_seconds"); ok {
+		if n < 0 || time.Duration(n)*time.Second > maxTailFollow {
+			return "", fmt.Errorf("tail: 'follow_seconds' must be between 0 and %d", int(maxTailFollow.Seconds()))
+		}
+		follow = time.Duration(n * float64(time.Second))
+	}
+
+	f, err := t.root.Open(filepath.Clean(name))
+	if err != nil {
+		return "", fmt.Errorf("tail: %w", err)
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return "", fmt.Errorf("tail: %w", err)
+	}
+	if info.IsDir() {
+		return "", fmt.Errorf("tail: %s is a directory (use action list)", name)
+	}
+	last, offset, err := lastLines(f, info.Size(), lines)
+	if err != nil {
+		return "", fmt.Errorf("tail: %w", err)
+	}
+	out := last
+	if follow > 0 {
+		more, err := followFile(ctx, f, offset, follow)
+		if err != nil {
+			return "", fmt.Errorf("tail: %w", err)
+		}
+		if more != "" {
+			out += fmt.Sprintf("--- appended during %s ---\n%s", follow, more)
+		} else {
+			out += fmt.Sprintf("--- nothing appended during %s ---\n", follow)
+		}
+	}
+	if len(out) > maxTailBytes {
+		out = "(output truncated to the last " + fmt.Sprint(maxTailBytes>>10) + " KB)\n" + out[len(out)-maxTailBytes:]
+	}
+	if out == "" {
+		return name + " is empty", nil
+	}
+	return out, nil
+}
+
+// lastLines returns the last n lines of a file of the given size, reading
+// backwards in blocks, and the offset it read up to.
+func lastLines(f io.ReaderAt, size int64, n int) (string, int64, error) {
+	const block = 8 << 10
+	var buf []byte
+	pos := size
+	for pos > 0 && bytes.Count(bytes.TrimSuffix(buf, []byte("\n")), []byte("\n")) < n && len(buf) < maxTailBytes {
+		read := int64(block)
+		if pos < read {
+			read = pos
+		}
+		pos -= read
+		chunk := make([]byte, read)
+		if _, err := f.ReadAt(chunk, pos); err != nil && err != io.EOF {
+			return "", 0, err
+		}
+		buf = append(chunk, buf...)
+	}
+	text := strings.TrimSuffix(string(buf), "\n")
+	if text == "" {
+		return "", size, nil
+	}
+	all := strings.Split(text, "\n")
+	if len(all) > n {
+		all = all[len(all)-n:]
+	}
+	return strings.Join(all, "\n") + "\n", size, nil
+}
+
+// followFile collects data appended to f after offset until d has passed or
+// ctx is done. If the file is truncated it is read again from the start.
+func followFile(ctx context.Context, f *os.File, offset int64, d time.Duration) (string, error) {
+	var out bytes.Buffer
+	deadline := time.NewTimer(d)
+	defer deadline.Stop()
+	ticker := time.NewTicker(tailPollInterval)
+	defer ticker.Stop()
+	read := func() error {
+		info, err := f.Stat()
+		if err != nil {
+			return err
+		}
+		if info.Size() < offset {
+			offset = 0
+			out.WriteString("(file truncated)\n")
+		}
+		if info.Size() == offset {
+			return nil
+		}
+		n, err := io.Copy(&out, io.NewSectionReader(f, offset, info.Size()-offset))
+		offset += n
+		return err
+	}
+	for {
+		select {
+		case <-ctx.Done():
+			return out.String(), nil
+		case <-deadline.C:
+			return out.String(), read()
+		case <-ticker.C:
+			if err := read(); err != nil {
+				return out.String(), err
+			}
+			if out.Len() > maxTailBytes {
+				return out.String(), nil
+			}
+		}
+	}
+}
@@ -0,0 +1,92 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func newTailTool(t *testing.T) (*TailTool, string) {
+	t.Helper()
+	d := t.TempDir()
+	root, err := os.OpenRoot(d)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { root.Close() })
+	return NewTailTool(root), d
+}
+
+func TestTailReturnsLastLines(t *testing.T) {
+	tool, d := newTailTool(t)
+	var b strings.Builder
+	for i := 1; i <= 5000; i++ {
+		fmt.Fprintf(&b, "line %d\n", i)
+	}
+	os.MkdirAll(filepath.Join(d, "logs"), 0o755)
+	os.WriteFile(filepath.Join(d, "logs", "job.log"), []byte(b.String()), 0o644)
+
+	res, err := tool.Execute(context.Background(), map[string]interface{}{"path": "logs/job.log", "lines": 3})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res != "line 4998\nline 4999\nline 5000\n" {
+		t.Fatalf("unexpected tail %q", res)
+	}
+
+	res, err = tool.Execute(context.Background(), map[string]interface{}{"path": "logs/job.log"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.Count(res, "\n"); got != defaultTailLines || !strings.HasPrefix(res, "line 4981\n") {
+		t.Fatalf("default should be %d lines, got %d starting %q", defaultTailLines, got, res[:10])
+	}
+
+	res, err = tool.Execute(context.Background(), map[string]interface{}{"action": "list"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(res, "logs/job.log") {
+		t.Fatalf("list should include the log, got %q", res)
+	}
+
+	if _, err := tool.Execute(context.Background(), map[string]interface{}{"path": "../outside.log"}); err == nil {
+		t.Fatal("expected paths outside the workspace to be refused")
+	}
+}
+
+func TestTailFollowStopsAtTimeBound(t *testing.T) {
+	tool, d := newTailTool(t)
+	logPath := filepath.Join(d, "run.log")
+	os.WriteFile(logPath, []byte("started\n"), 0o644)
+
+	go func() {
+		time.Sleep(300 * time.Millisecond)
+		f, err := os.OpenFile(logPath, os.O_APPEND|os.O_WRONLY, 0o644)
+		if err != nil {
+			return
+		}
+		f.WriteString("step 1 done\n")
+		f.Close()
+	}()
+
+	start := time.Now()
+	res, err := tool.Execute(context.Background(), map[string]interface{}{"path": "run.log", "follow_seconds": 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if elapsed := time.Since(start); elapsed < time.Second || elapsed > 3*time.Second {
+		t.Fatalf("follow should end after about 1s, took %v", elapsed)
+	}
+	if !strings.HasPrefix(res, "started\n") || !strings.Contains(res, "step 1 done\n") {
+		t.Fatalf("unexpected output %q", res)
+	}
+
+	if _, err := tool.Execute(context.Background(), map[string]interface{}{"path": "run.log", "follow_seconds": 600}); err == nil {
+		t.Fatal("expected follow beyond the limit to be refused")
+	}
+}
@@ -0,0 +1,91 @@
+package tools
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/local/picobot/internal/session"
+)
+
+// ExportTranscriptTool exports the current conversation's saved history as a
+// Markdown or JSON file under exports/ in the workspace. The file is also
+// attached to the reply for channels that can send attachments.
+type ExportTranscriptTool struct {
+	sessions *session.SessionManager
+	root     *os.Root
+	channel  string
+	chatID   string
+}
+
+// NewExportTranscriptTool creates an export_transcript tool reading from
+// sessions and writing into root.
+func NewExportTranscriptTool(sessions *session.SessionManager, root *os.Root) *ExportTranscriptTool {
+	return &ExportTranscriptTool{sessions: sessions, root: root}
+}
+
+func (t *ExportTranscriptTool) Name() string { return "export_transcript" }
+
+func (t *ExportTranscriptTool) Description() string {
+	return "Export this conversation's saved history as a Markdown or JSON file, optionally with emails, numbers and secrets redacted"
+}
+
+func (t *ExportTranscriptTool) Parameters() map[string]interface{} {
+	return map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"format": map[string]interface{}{
+				"type":        "string",
+				"description": "markdown (default) or json",
+				"enum":        []string{"markdown", "json"},
+			},
+			"redact": map[string]interface{}{
+				"type":        "boolean",
+				"description": "Mask email addresses, phone/account numbers and API keys",
+			},
+		},
+	}
+}
+
+// SetContext sets the conversation to export.
+func (t *ExportTranscriptTool) SetContext(channel, chatID string) {
+	t.channel = channel
+	t.chatID = chatID
+}
+
+func (t *ExportTranscriptTool) Execute(ctx context.Context, args map[string]interface{}) (string, error) {
+	format, _ := args["format"].(string)
+	if format == "" {
+		format = "markdown"
+	}
+	redact, _ := args["redact"].(bool)
+
+	key := t.channel + ":" + t.chatID
+	sess := t.sessions.GetOrCreate(key)
+	if len(sess.GetHistory()) == 0 {
+		return "", fmt.Errorf("export_transcript: no saved history for this conversation")
+	}
+	text, err := session.Export(sess, format, redact)
+	if err != nil {
+		return "", fmt.Errorf("export_transcript: %w", err)
+	}
+
+	ext, mimeType := ".md", "text/markdown"
+	if format == "json" {
+		ext, mimeType = ".json", "application/json"
+	}
+	name := "exports/" + strings.NewReplacer(":", "-", "/", "-").Replace(key) + "-" + time.Now().Format("20060102-150405") + ext
+	if err := t.root.MkdirAll("exports", 0o755); err != nil {
+		return "", fmt.Errorf("export_transcript: %w", err)
+	}
+	if err := t.root.WriteFile(name, []byte(text), 0o644); err != nil {
+		return "", fmt.Errorf("export_transcript: %w", err)
+	}
+	if media := mediaCollectorFrom(ctx); media != nil {
+		media.Add("data:" + mimeType + ";base64," + base64.StdEncoding.EncodeToString([]byte(text)))
+	}
+	return fmt.Sprintf("Exported %d messages to %s.", len(sess.GetHistory()), name), nil
+}
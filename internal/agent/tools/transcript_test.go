@@ -0,0 +1,68 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/local/picobot/internal/session"
+)
+
+func TestExportTranscript(t *testing.T) {
+	dir := t.TempDir()
+	root, err := os.OpenRoot(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer root.Close()
+	sm := session.NewSessionManager(dir)
+	sess := sm.GetOrCreate("telegram:42")
+	sess.AddMessage("user", "hi, mail me at ann@example.com")
+	sess.AddMessage("assistant", "Sure, noted.")
+	sess.AddMessage("user", "call +1 (555) 123-4567 tomorrow")
+
+	tool := NewExportTranscriptTool(sm, root)
+	tool.SetContext("telegram", "42")
+	media := &MediaCollector{}
+	res, err := tool.Execute(WithMediaCollector(context.Background(), media), map[string]interface{}{"redact": true})
+	if err != nil {
+		t.Fatalf("Execute: %v", err)
+	}
+	files, _ := filepath.Glob(filepath.Join(dir, "exports", "telegram-42-*.md"))
+	if len(files) != 1 || !strings.Contains(res, "exports/") {
+		t.Fatalf("expected one markdown export, got %v (%s)", files, res)
+	}
+	b, _ := os.ReadFile(files[0])
+	md := string(b)
+	first := strings.Index(md, "**user:** hi, mail me at [email]")
+	second := strings.Index(md, "**assistant:** Sure, noted.")
+	third := strings.Index(md, "**user:** call [number] tomorrow")
+	if first < 0 || second < first || third < second {
+		t.Fatalf("turns missing or out of order:\n%s", md)
+	}
+	if items := media.Items(); len(items) != 1 || !strings.HasPrefix(items[0], "data:text/markdown;base64,") {
+		t.Fatalf("expected the export attached, got %v", media.Items())
+	}
+
+	// JSON keeps the turns in order, unredacted unless asked.
+	if _, err := tool.Execute(context.Background(), map[string]interface{}{"format": "json"}); err != nil {
+		t.Fatalf("Execute json: %v", err)
+	}
+	files, _ = filepath.Glob(filepath.Join(dir, "exports", "telegram-42-*.json"))
+	if len(files) != 1 {
+		t.Fatalf("expected one json export, got %v", files)
+	}
+	b, _ = os.ReadFile(files[0])
+	var out struct {
+		Turns []session.Turn `json:"turns"`
+	}
+	if err := json.Unmarshal(b, &out); err != nil {
+		t.Fatal(err)
+	}
+	if len(out.Turns) != 3 || out.Turns[1].Role != "assistant" || out.Turns[0].Content != "hi, mail me at ann@example.com" {
+		t.Fatalf("unexpected turns %+v", out.Turns)
+	}
+}
@@ -0,0 +1,87 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/local/picobot/internal/providers"
+)
+
+// UsageStatsTool reports how many tokens conversations have used since the
+// agent started, as counted from the provider's replies.
+type UsageStatsTool struct {
+	totals  func() map[string]providers.Usage // keyed by "channel:chatID"
+	channel string
+	chatID  string
+}
+
+// NewUsageStatsTool creates a usage_stats tool reading the per-conversation
+// totals from totals.
+func NewUsageStatsTool(totals func() map[string]providers.Usage) *UsageStatsTool {
+	return &UsageStatsTool{totals: totals}
+}
+
+func (t *UsageStatsTool) Name() string { return "usage_stats" }
+
+func (t *UsageStatsTool) Description() string {
+	return "Show how many LLM tokens (prompt, completion, total) this conversation, or every conversation, has used since picobot started"
+}
+
+func (t *UsageStatsTool) Parameters() map[string]interface{} {
+	return map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"scope": map[string]interface{}{
+				"type":        "string",
+				"description": "chat (default) for this conversation, or all for every conversation",
+				"enum":        []string{"chat", "all"},
+			},
+		},
+	}
+}
+
+// SetContext sets the conversation reported by the chat scope.
+func (t *UsageStatsTool) SetContext(channel, chatID string) {
+	t.channel = channel
+	t.chatID = chatID
+}
+
+func (t *UsageStatsTool) Execute(ctx context.Context, args map[string]interface{}) (string, error) {
+	scope, _ := args["scope"].(string)
+	totals := t.totals()
+	switch scope {
+	case "", "chat":
+		key := t.channel + ":" + t.chatID
+		return fmt.Sprintf("%s: %s", key, formatUsage(totals[key])), nil
+	case "all":
+		if len(totals) == 0 {
+			return "No tokens used yet.", nil
+		}
+		keys := make([]string, 0, len(totals))
+		var sum providers.Usage
+		for k, u := range totals {
+			keys = append(keys, k)
+			sum = sum.Add(u)
+		}
+		sort.Slice(keys, func(i, j int) bool {
+			if a, b := totals[keys[i]].TotalTokens, totals[keys[j]].TotalTokens; a != b {
+				return a > b
+			}
+			return keys[i] < keys[j]
+		})
+		var sb strings.Builder
+		for _, k := range keys {
+			fmt.Fprintf(&sb, "%s: %s\n", k, formatUsage(totals[k]))
+		}
+		fmt.Fprintf(&sb, "all conversations: %s", formatUsage(sum))
+		return sb.String(), nil
+	default:
+		return "", fmt.Errorf("usage_stats: unknown scope %q (use chat or all)", scope)
+	}
+}
+
+func formatUsage(u providers.Usage) string {
+	return fmt.Sprintf("%d tokens (%d prompt, %d completion)", u.TotalTokens, u.PromptTokens, u.CompletionTokens)
+}
@@ -0,0 +1,36 @@
+package tools
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/local/picobot/internal/providers"
+)
+
+func TestUsageStatsTool(t *testing.T) {
+	totals := map[string]providers.Usage{
+		"telegram:1": {PromptTokens: 90, CompletionTokens: 10, TotalTokens: 100},
+		"discord:2":  {PromptTokens: 400, CompletionTokens: 100, TotalTokens: 500},
+	}
+	tool := NewUsageStatsTool(func() map[string]providers.Usage { return totals })
+	tool.SetContext("telegram", "1")
+
+	out, err := tool.Execute(context.Background(), map[string]interface{}{})
+	if err != nil || out != "telegram:1: 100 tokens (90 prompt, 10 completion)" {
+		t.Fatalf("chat scope: %q, %v", out, err)
+	}
+
+	out, err = tool.Execute(context.Background(), map[string]interface{}{"scope": "all"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(out, "\n")
+	if len(lines) != 3 || !strings.HasPrefix(lines[0], "discord:2: 500") || lines[2] != "all conversations: 600 tokens (490 prompt, 110 completion)" {
+		t.Fatalf("all scope:\n%s", out)
+	}
+
+	if _, err := tool.Execute(context.Background(), map[string]interface{}{"scope": "week"}); err == nil {
+		t.Fatal("want an error for an unknown scope")
+	}
+}
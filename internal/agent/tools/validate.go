@@ -0,0 +1,197 @@
+package tools
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ValidateTool checks whether JSON, YAML, or XML is well-formed and reports
+// the position of the first parse error. Files are read through os.Root so
+// paths cannot escape the workspace.
+type ValidateTool struct {
+	root *os.Root
+}
+
+// NewValidateTool creates a validator. root may be nil, in which case only
+// inline content can be validated.
+func NewValidateTool(root *os.Root) *ValidateTool {
+	return &ValidateTool{root: root}
+}
+
+func (t *ValidateTool) Name() string { return "validate" }
+
+func (t *ValidateTool) Description() string {
+	return "Check whether JSON, YAML, or XML (inline content or a workspace file) is well-formed; reports the line and column of the first error"
+}
+
+func (t *ValidateTool) Parameters() map[string]interface{} {
+	return map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"format": map[string]interface{}{
+				"type":        "string",
+				"description": "Format to validate. Optional when path has a .json, .yaml, .yml, or .xml extension.",
+				"enum":        []string{"json", "yaml", "xml"},
+			},
+			"content": map[string]interface{}{
+				"type":        "string",
+				"description": "Content to validate",
+			},
+			"path": map[string]interface{}{
+				"type":        "string",
+				"description": "Workspace file to validate (relative to workspace), used instead of content",
+			},
+		},
+	}
+}
+
+func (t *ValidateTool) Execute(ctx context.Context, args map[string]interface{}) (string, error) {
+	format, _ := args["format"].(string)
+	content, hasContent := args["content"].(string)
+	path, _ := args["path"].(string)
+
+	var data []byte
+	switch {
+	case path != "":
+		if t.root == nil {
+			return "", fmt.Errorf("validate: file access is not available")
+		}
+		b, err := t.root.ReadFile(filepath.Clean(path))
+		if err != nil {
+			return "", fmt.Errorf("validate: %w", err)
+		}
+		data = b
+		if format == "" {
+			format = formatFromExt(path)
+		}
+	case hasContent:
+		data = []byte(content)
+	default:
+		return "", fmt.Errorf("validate: 'content' or 'path' is required")
+	}
+
+	var err error
+	switch strings.ToLower(format) {
+	case "json":
+		err = validateJSON(data)
+	case "yaml", "yml":
+		err = validateYAML(data)
+	case "xml":
+		err = validateXML(data)
+	case "":
+		return "", fmt.Errorf("validate: 'format' is required (json, yaml, or xml)")
+	default:
+		return "", fmt.Errorf("validate: unsupported format %q", format)
+	}
+	name := strings.ToUpper(format)
+	if err != nil {
+		return fmt.Sprintf("invalid %s: %v", name, err), nil
+	}
+	return fmt.Sprintf("valid %s", name), nil
+}
+
+func formatFromExt(path string) string {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json":
+		return "json"
+	case ".yaml", ".yml":
+		return "yaml"
+	case ".xml":
+		return "xml"
+	}
+	return ""
+}
+
+// lineCol converts a 0-based byte offset into 1-based line and column numbers.
+func lineCol(data []byte, offset int64) (int, int) {
+	if offset > int64(len(data)) {
+		offset = int64(len(data))
+	}
+	before := data[:offset]
+	line := bytes.Count(before, []byte("\n")) + 1
+	col := int(offset) - bytes.LastIndexByte(before, '\n')
+	return line, col
+}
+
+func validateJSON(data []byte) error {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	var v interface{}
+	if err := dec.Decode(&v); err != nil {
+		var syn *json.SyntaxError
+		if errors.As(err, &syn) {
+			// Offset is just past the offending byte.
+			line, col := lineCol(data, max(syn.Offset-1, 0))
+			return fmt.Errorf("line %d, column %d: %s", line, col, syn.Error())
+		}
+		if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+			line, col := lineCol(data, int64(len(data)))
+			return fmt.Errorf("line %d, column %d: unexpected end of input", line, col)
+		}
+		return err
+	}
+	end := dec.InputOffset()
+	if _, err := dec.Token(); err != io.EOF {
+		rest := data[end:]
+		end += int64(len(rest) - len(bytes.TrimLeft(rest, " \t\r\n")))
+		line, col := lineCol(data, end)
+		return fmt.Errorf("line %d, column %d: unexpected data after top-level value", line, col)
+	}
+	return nil
+}
+
+var yamlLineRE = regexp.MustCompile(`line (\d+)`)
+
+func validateYAML(data []byte) error {
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	for {
+		var n yaml.Node
+		err := dec.Decode(&n)
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			msg := strings.TrimPrefix(err.Error(), "yaml: ")
+			if !yamlLineRE.MatchString(msg) {
+				// The parser omits the position for some errors (e.g. EOF).
+				msg = fmt.Sprintf("line %d: %s", bytes.Count(data, []byte("\n"))+1, msg)
+			}
+			return errors.New(msg)
+		}
+	}
+}
+
+func validateXML(data []byte) error {
+	dec := xml.NewDecoder(bytes.NewReader(data))
+	sawRoot := false
+	for {
+		tok, err := dec.Token()
+		if err == io.EOF {
+			if !sawRoot {
+				return errors.New("no root element")
+			}
+			return nil
+		}
+		if err != nil {
+			line, col := dec.InputPos()
+			var syn *xml.SyntaxError
+			if errors.As(err, &syn) {
+				return fmt.Errorf("line %d, column %d: %s", line, col, syn.Msg)
+			}
+			return fmt.Errorf("line %d, column %d: %v", line, col, err)
+		}
+		if _, ok := tok.(xml.StartElement); ok {
+			sawRoot = true
+		}
+	}
+}
@@ -0,0 +1,74 @@
+package tools
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestValidateValidInput(t *testing.T) {
+	v := NewValidateTool(nil)
+	cases := map[string]string{
+		"json": `{"name": "picobot", "tags": [1, 2]}`,
+		"yaml": "name: picobot\ntags:\n  - a\n  - b\n",
+		"xml":  `<config><name>picobot</name></config>`,
+	}
+	for format, content := range cases {
+		out, err := v.Execute(context.Background(), map[string]interface{}{"format": format, "content": content})
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", format, err)
+		}
+		if !strings.HasPrefix(out, "valid ") {
+			t.Fatalf("%s: expected valid, got %q", format, out)
+		}
+	}
+}
+
+func TestValidateReportsPosition(t *testing.T) {
+	v := NewValidateTool(nil)
+	cases := []struct {
+		format, content, want string
+	}{
+		{"json", "{\n  \"a\": 1,\n  \"b\": }\n", "line 3, column 8"},
+		{"json", `{"a": 1} {"b": 2}`, "line 1, column 10"},
+		{"yaml", "name: picobot\ntags: [a, b\nother: x\n", "line "},
+		{"yaml", "a: 1\n b: 2\n", "line 2"},
+		{"xml", "<config>\n  <name>picobot</nam>\n</config>", "line 2"},
+	}
+	for _, tc := range cases {
+		out, err := v.Execute(context.Background(), map[string]interface{}{"format": tc.format, "content": tc.content})
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", tc.format, err)
+		}
+		if !strings.HasPrefix(out, "invalid "+strings.ToUpper(tc.format)) || !strings.Contains(out, tc.want) {
+			t.Fatalf("%s %q: expected error containing %q, got %q", tc.format, tc.content, tc.want, out)
+		}
+	}
+}
+
+func TestValidateWorkspaceFile(t *testing.T) {
+	d := t.TempDir()
+	if err := os.WriteFile(filepath.Join(d, "cfg.json"), []byte("{\"a\": [1, 2,]}"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	root, err := os.OpenRoot(d)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer root.Close()
+
+	v := NewValidateTool(root)
+	out, err := v.Execute(context.Background(), map[string]interface{}{"path": "cfg.json"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.Contains(out, "invalid JSON: line 1, column 13") {
+		t.Fatalf("unexpected result: %q", out)
+	}
+
+	if _, err := v.Execute(context.Background(), map[string]interface{}{"path": "../outside.json"}); err == nil {
+		t.Fatal("expected error for path outside workspace")
+	}
+}
@@ -1,21 +1,78 @@
 package tools
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
 	"fmt"
 	"io"
+	"mime"
+	"net"
 	"net/http"
+	"strings"
+	"time"
 )
 
+const (
+	// maxWebRequestBody caps the request body the model may send.
+	maxWebRequestBody = 1 << 20
+	// maxWebRedirects is the default redirect limit; maxWebRedirectsCap is
+	// the highest limit a request may ask for.
+	maxWebRedirects    = 10
+	maxWebRedirectsCap = 30
+	// defaultWebTimeout and maxWebResponse are the defaults for the
+	// timeoutSeconds and maxBytes arguments, which are clamped to the caps.
+	defaultWebTimeout = 15 * time.Second
+	maxWebTimeoutCap  = 120 * time.Second
+	maxWebResponse    = 1 << 20
+	maxWebResponseCap = 10 << 20
+)
+
+// webMethods are the HTTP methods the web tool may use.
+var webMethods = map[string]bool{
+	"GET": true, "HEAD": true, "POST": true, "PUT": true, "PATCH": true, "DELETE": true,
+}
+
 // WebTool supports fetch operations.
-// Args: {"url": "https://..."}
+// Args: {"url": "https://...", "method": "POST", "headers": {...}, "body": "...", "format": "text", "full": true, "maxRedirects": 0, "timeoutSeconds": 30, "maxBytes": 4194304}
+//
+// By default it refuses to reach loopback, private and link-local addresses,
+// checking the first URL, every redirect, and the address actually dialed.
+//
+// Header values may reference secrets as {{secret:NAME}}; see SecretStore.
+type WebTool struct {
+	allowPrivate bool
+	secrets      *SecretStore
+	transport    *http.Transport
+	lookup       func(ctx context.Context, host string) ([]net.IP, error)
+	dial         func(ctx context.Context, network, addr string) (net.Conn, error)
+}
 
-type WebTool struct{}
+func NewWebTool() *WebTool {
+	t := &WebTool{lookup: defaultLookup, dial: defaultDialer.DialContext}
+	// No proxy: the address checks must apply to the destination itself.
+	t.transport = &http.Transport{
+		DialContext:         t.dialContext,
+		ForceAttemptHTTP2:   true,
+		MaxIdleConns:        100,
+		IdleConnTimeout:     90 * time.Second,
+		TLSHandshakeTimeout: 10 * time.Second,
+	}
+	return t
+}
 
-func NewWebTool() *WebTool { return &WebTool{} }
+// SetAllowPrivateNetworks lets the tool fetch loopback and private-network
+// addresses, e.g. services on the local LAN.
+func (t *WebTool) SetAllowPrivateNetworks(allow bool) { t.allowPrivate = allow }
 
-func (t *WebTool) Name() string        { return "web" }
-func (t *WebTool) Description() string { return "Fetch web content from a URL" }
+// SetSecrets lets header values use {{secret:NAME}} placeholders. Secret
+// values are masked in everything the tool returns.
+func (t *WebTool) SetSecrets(s *SecretStore) { t.secrets = s }
+
+func (t *WebTool) Name() string { return "web" }
+func (t *WebTool) Description() string {
+	return "Fetch web content from a URL, or call an HTTP API with another method and a request body"
+}
 
 func (t *WebTool) Parameters() map[string]interface{} {
 	return map[string]interface{}{
@@ -25,6 +82,45 @@ func (t *WebTool) Parameters() map[string]interface{} {
 				"type":        "string",
 				"description": "The URL to fetch (must be http or https)",
 			},
+			"method": map[string]interface{}{
+				"type":        "string",
+				"description": "HTTP method (default GET)",
+				"enum":        []string{"GET", "HEAD", "POST", "PUT", "PATCH", "DELETE"},
+			},
+			"headers": map[string]interface{}{
+				"type":                 "object",
+				"description":          "Extra request headers, e.g. {\"Accept\": \"application/json\"}. Values may use {{secret:NAME}} for a configured secret.",
+				"additionalProperties": map[string]interface{}{"type": "string"},
+			},
+			"body": map[string]interface{}{
+				"type":        "string",
+				"description": "Request body (max 1 MB), not allowed with GET or HEAD. Sent as application/json if it is valid JSON and no Content-Type header is given.",
+			},
+			"format": map[string]interface{}{
+				"type":        "string",
+				"description": "How to return HTML responses: text (readable text, the default for HTML), markdown (text with headings, lists and links), or raw (the page source, the default for other content types)",
+				"enum":        []string{"raw", "text", "markdown"},
+			},
+			"followRedirects": map[string]interface{}{
+				"type":        "boolean",
+				"description": "Follow redirects (default true). If false, a 3xx response is returned as is; use full to see its Location.",
+			},
+			"maxRedirects": map[string]interface{}{
+				"type":        "integer",
+				"description": "Maximum redirects to follow (default 10, max 30); 0 means don't follow",
+			},
+			"timeoutSeconds": map[string]interface{}{
+				"type":        "integer",
+				"description": "Request timeout in seconds (default 15, max 120)",
+			},
+			"maxBytes": map[string]interface{}{
+				"type":        "integer",
+				"description": "Maximum response body to read, in bytes (default 1 MiB, max 10 MiB); longer bodies are truncated",
+			},
+			"full": map[string]interface{}{
+				"type":        "boolean",
+				"description": "Return a JSON object with status, final URL after redirects, content type, and body instead of the body alone. Useful for inspecting API errors.",
+			},
 		},
 		"required": []string{"url"},
 	}
@@ -35,18 +131,152 @@ func (t *WebTool) Execute(ctx context.Context, args map[string]interface{}) (str
 	if !ok || u == "" {
 		return "", fmt.Errorf("web: 'url' argument required")
 	}
-	req, err := http.NewRequestWithContext(ctx, "GET", u, nil)
+	method := "GET"
+	if m, _ := args["method"].(string); m != "" {
+		method = strings.ToUpper(m)
+	}
+	if !webMethods[method] {
+		return "", fmt.Errorf("web: unsupported method %q", method)
+	}
+	format, _ := args["format"].(string)
+	switch format {
+	case "", "raw", "text", "markdown":
+	default:
+		return "", fmt.Errorf("web: unsupported format %q (use raw, text, or markdown)", format)
+	}
+	redirects := maxWebRedirects
+	if follow, ok := args["followRedirects"].(bool); ok && !follow {
+		redirects = 0
+	} else if n, ok := numberArg(args, "maxRedirects"); ok {
+		if n < 0 || n > maxWebRedirectsCap {
+			return "", fmt.Errorf("web: maxRedirects must be between 0 and %d", maxWebRedirectsCap)
+		}
+		redirects = int(n)
+	}
+	timeout := defaultWebTimeout
+	if n, ok := numberArg(args, "timeoutSeconds"); ok && n > 0 {
+		timeout = min(time.Duration(n*float64(time.Second)), maxWebTimeoutCap)
+	}
+	limit := maxWebResponse
+	if n, ok := numberArg(args, "maxBytes"); ok && n > 0 {
+		limit = min(int(n), maxWebResponseCap)
+	}
+	body, _ := args["body"].(string)
+	if body != "" && (method == "GET" || method == "HEAD") {
+		return "", fmt.Errorf("web: 'body' is not allowed with %s", method)
+	}
+	if len(body) > maxWebRequestBody {
+		return "", fmt.Errorf("web: request body exceeds %d bytes", maxWebRequestBody)
+	}
+
+	var bodyReader io.Reader
+	if body != "" {
+		bodyReader = strings.NewReader(body)
+	}
+	// Derived from the caller's context, so an earlier caller deadline still wins.
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, method, u, bodyReader)
 	if err != nil {
 		return "", err
 	}
-	resp, err := http.DefaultClient.Do(req)
+	if headers, ok := args["headers"].(map[string]interface{}); ok {
+		for k, v := range headers {
+			s, ok := v.(string)
+			if !ok {
+				return "", fmt.Errorf("web: header %q must be a string", k)
+			}
+			s, err := t.secrets.expand(s)
+			if err != nil {
+				return "", fmt.Errorf("web: header %q: %w", k, err)
+			}
+			req.Header.Set(k, s)
+		}
+	}
+	if body != "" && req.Header.Get("Content-Type") == "" && json.Valid([]byte(body)) {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if err := t.validateTarget(ctx, req.URL); err != nil {
+		return "", fmt.Errorf("web: %w", err)
+	}
+	resp, err := t.client(redirects).Do(req)
 	if err != nil {
-		return "", err
+		return "", fmt.Errorf("%s", t.secrets.redact(err.Error()))
 	}
 	defer resp.Body.Close()
-	b, err := io.ReadAll(resp.Body)
+	b, err := io.ReadAll(io.LimitReader(resp.Body, int64(limit)+1))
+	if err != nil {
+		return "", err
+	}
+	truncated := len(b) > limit
+	if truncated {
+		b = b[:limit]
+	}
+	if format == "" {
+		format = "raw"
+		if isHTML(resp.Header.Get("Content-Type")) {
+			format = "text"
+		}
+	}
+	text := string(b)
+	if format != "raw" {
+		if text, err = htmlToText(bytes.NewReader(b), format == "markdown"); err != nil {
+			return "", fmt.Errorf("web: converting HTML: %w", err)
+		}
+	}
+	if truncated {
+		text += fmt.Sprintf("\n[response truncated at %d bytes]", limit)
+	}
+	if full, _ := args["full"].(bool); full {
+		out, err := webResult(resp, text)
+		return t.secrets.redact(out), err
+	}
+	// A server may echo request headers back; don't let a secret leak that way.
+	return t.secrets.redact(text), nil
+}
+
+// numberArg reads a numeric argument, which arrives as float64 from JSON.
+func numberArg(args map[string]interface{}, key string) (float64, bool) {
+	switch v := args[key].(type) {
+	case float64:
+		return v, true
+	case int:
+		return float64(v), true
+	}
+	return 0, false
+}
+
+// client returns a client that follows at most maxRedirects redirects,
+// validating each target. With 0 the first response is returned even if it is
+// a redirect; past a non-zero limit the request fails.
+func (t *WebTool) client(maxRedirects int) *http.Client {
+	return &http.Client{Transport: t.transport, CheckRedirect: func(req *http.Request, via []*http.Request) error {
+		if maxRedirects == 0 {
+			return http.ErrUseLastResponse
+		}
+		if len(via) > maxRedirects {
+			return fmt.Errorf("stopped after %d redirects", maxRedirects)
+		}
+		return t.validateTarget(req.Context(), req.URL)
+	}}
+}
+
+// webResult renders a response as the JSON object returned for "full": true.
+func webResult(resp *http.Response, body string) (string, error) {
+	out, err := json.MarshalIndent(struct {
+		Status      int    `json:"status"`
+		URL         string `json:"url"`
+		ContentType string `json:"contentType,omitempty"`
+		Location    string `json:"location,omitempty"`
+		Body        string `json:"body"`
+	}{resp.StatusCode, resp.Request.URL.String(), resp.Header.Get("Content-Type"), resp.Header.Get("Location"), body}, "", "  ")
 	if err != nil {
 		return "", err
 	}
-	return string(b), nil
+	return string(out), nil
+}
+
+func isHTML(contentType string) bool {
+	mt, _, _ := mime.ParseMediaType(contentType)
+	return mt == "text/html" || mt == "application/xhtml+xml"
 }
@@ -0,0 +1,101 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// cgnat is the shared address space (RFC 6598), often used for internal
+// services but not covered by net.IP.IsPrivate.
+var cgnat = &net.IPNet{IP: net.IPv4(100, 64, 0, 0), Mask: net.CIDRMask(10, 32)}
+
+// blockedIP reports whether ip is loopback, private, link-local or otherwise
+// not a public unicast address.
+func blockedIP(ip net.IP) bool {
+	return ip.IsLoopback() || ip.IsPrivate() || ip.IsUnspecified() ||
+		ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() || ip.IsInterfaceLocalMulticast() ||
+		ip.IsMulticast() || cgnat.Contains(ip) || (ip.To4() != nil && ip.To4()[0] == 0)
+}
+
+// validateTarget rejects URLs the web tool must not fetch: non-HTTP schemes
+// and, unless private networks are allowed, hosts that are or resolve to a
+// non-public address. It runs for the first request and for every redirect.
+func (t *WebTool) validateTarget(ctx context.Context, u *url.URL) error {
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return fmt.Errorf("only http and https URLs are allowed")
+	}
+	host := u.Hostname()
+	if host == "" {
+		return fmt.Errorf("URL has no host")
+	}
+	if t.allowPrivate {
+		return nil
+	}
+	if strings.EqualFold(host, "localhost") || strings.HasSuffix(strings.ToLower(host), ".localhost") {
+		return fmt.Errorf("%s is a local address", host)
+	}
+	_, err := t.publicIPs(ctx, host)
+	return err
+}
+
+// publicIPs resolves host and fails if any of its addresses is blocked, so a
+// name with one public and one private record cannot be used to reach the
+// private one.
+func (t *WebTool) publicIPs(ctx context.Context, host string) ([]net.IP, error) {
+	if ip := net.ParseIP(host); ip != nil {
+		if blockedIP(ip) {
+			return nil, fmt.Errorf("%s is a private or local address", host)
+		}
+		return []net.IP{ip}, nil
+	}
+	ips, err := t.lookup(ctx, host)
+	if err != nil {
+		return nil, err
+	}
+	if len(ips) == 0 {
+		return nil, fmt.Errorf("no addresses found for %s", host)
+	}
+	for _, ip := range ips {
+		if blockedIP(ip) {
+			return nil, fmt.Errorf("%s resolves to a private or local address (%s)", host, ip)
+		}
+	}
+	return ips, nil
+}
+
+// dialContext resolves the host once, checks the addresses, and dials a
+// checked address directly. Because the connection never re-resolves the
+// name, a DNS answer that changes between validateTarget and the dial
+// (DNS rebinding) cannot redirect it to a private address.
+func (t *WebTool) dialContext(ctx context.Context, network, addr string) (net.Conn, error) {
+	if t.allowPrivate {
+		return t.dial(ctx, network, addr)
+	}
+	host, port, err := net.SplitHostPort(addr)
+	if err != nil {
+		return nil, err
+	}
+	ips, err := t.publicIPs(ctx, host)
+	if err != nil {
+		return nil, err
+	}
+	var lastErr error
+	for _, ip := range ips {
+		conn, err := t.dial(ctx, network, net.JoinHostPort(ip.String(), port))
+		if err == nil {
+			return conn, nil
+		}
+		lastErr = err
+	}
+	return nil, lastErr
+}
+
+func defaultLookup(ctx context.Context, host string) ([]net.IP, error) {
+	return net.DefaultResolver.LookupIP(ctx, "ip", host)
+}
+
+var defaultDialer = &net.Dialer{Timeout: 30 * time.Second, KeepAlive: 30 * time.Second}
@@ -0,0 +1,117 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+// newGuardedWebTool returns a web tool whose DNS is faked: public.test
+// resolves to a documentation (public) address that is dialed through to srv,
+// evil.test resolves to a private address, and rebind.test resolves public
+// once and private afterwards.
+func newGuardedWebTool(t *testing.T, srv *httptest.Server) *WebTool {
+	t.Helper()
+	public := net.ParseIP("203.0.113.10")
+	var rebinds atomic.Int32
+	tool := NewWebTool()
+	tool.lookup = func(ctx context.Context, host string) ([]net.IP, error) {
+		switch host {
+		case "public.test":
+			return []net.IP{public}, nil
+		case "evil.test":
+			return []net.IP{net.ParseIP("192.168.1.20")}, nil
+		case "localhost":
+			return []net.IP{net.ParseIP("127.0.0.1")}, nil
+		case "rebind.test":
+			if rebinds.Add(1) == 1 {
+				return []net.IP{public}, nil
+			}
+			return []net.IP{net.ParseIP("10.0.0.5")}, nil
+		}
+		return nil, fmt.Errorf("no such host %s", host)
+	}
+	tool.dial = func(ctx context.Context, network, addr string) (net.Conn, error) {
+		host, _, _ := net.SplitHostPort(addr)
+		if host != public.String() {
+			t.Errorf("dialed unexpected address %s", addr)
+			return nil, fmt.Errorf("unexpected dial")
+		}
+		return (&net.Dialer{}).DialContext(ctx, network, srv.Listener.Addr().String())
+	}
+	return tool
+}
+
+func TestWebToolBlocksPrivateRedirects(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if to := r.URL.Query().Get("to"); to != "" {
+			http.Redirect(w, r, to, http.StatusFound)
+			return
+		}
+		w.Write([]byte("public page"))
+	}))
+	defer srv.Close()
+	tool := newGuardedWebTool(t, srv)
+	port := srv.Listener.Addr().(*net.TCPAddr).Port
+
+	got, err := tool.Execute(context.Background(), map[string]interface{}{"url": "http://public.test/"})
+	if err != nil || got != "public page" {
+		t.Fatalf("public fetch: %q (%v)", got, err)
+	}
+
+	targets := map[string]string{
+		"localhost":     fmt.Sprintf("http://localhost:%d/", port),
+		"192.168 IP":    "http://192.168.0.1/admin",
+		"private name":  "http://evil.test/",
+		"loopback IPv6": "http://[::1]/",
+	}
+	for name, target := range targets {
+		// Direct, one hop, and a 302 chain through another public hop.
+		hop := "http://public.test/?to=" + url.QueryEscape(target)
+		chain := "http://public.test/?to=" + url.QueryEscape(hop)
+		for _, u := range []string{target, hop, chain} {
+			_, err := tool.Execute(context.Background(), map[string]interface{}{"url": u})
+			if err == nil || !strings.Contains(err.Error(), "local address") {
+				t.Errorf("%s via %s: expected block, got %v", name, u, err)
+			}
+		}
+	}
+}
+
+func TestWebToolBlocksDNSRebinding(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("should not be reached"))
+	}))
+	defer srv.Close()
+	tool := newGuardedWebTool(t, srv)
+
+	// The name passes validation, then resolves privately when dialed.
+	_, err := tool.Execute(context.Background(), map[string]interface{}{"url": "http://rebind.test/"})
+	if err == nil || !strings.Contains(err.Error(), "10.0.0.5") {
+		t.Fatalf("expected the dial-time check to block the rebound address, got %v", err)
+	}
+}
+
+func TestWebToolAllowPrivateNetworks(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("lan"))
+	}))
+	defer srv.Close()
+	tool := NewWebTool()
+	if _, err := tool.Execute(context.Background(), map[string]interface{}{"url": srv.URL}); err == nil {
+		t.Fatal("expected loopback to be blocked by default")
+	}
+	tool.SetAllowPrivateNetworks(true)
+	if got, err := tool.Execute(context.Background(), map[string]interface{}{"url": srv.URL}); err != nil || got != "lan" {
+		t.Fatalf("expected loopback allowed, got %q (%v)", got, err)
+	}
+	if _, err := tool.Execute(context.Background(), map[string]interface{}{"url": "file:///etc/passwd"}); err == nil {
+		t.Fatal("expected non-http scheme to be rejected")
+	}
+}
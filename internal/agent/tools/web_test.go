@@ -0,0 +1,220 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestWebToolPostJSON(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := io.ReadAll(r.Body)
+		w.Write([]byte(r.Method + " " + r.Header.Get("Content-Type") + " " + r.Header.Get("X-Api-Key") + " " + string(b)))
+	}))
+	defer srv.Close()
+
+	tool := NewWebTool()
+	tool.SetAllowPrivateNetworks(true) // httptest listens on loopback
+	got, err := tool.Execute(context.Background(), map[string]interface{}{
+		"url":     srv.URL,
+		"method":  "post",
+		"headers": map[string]interface{}{"X-Api-Key": "k1"},
+		"body":    `{"q":"go"}`,
+	})
+	if err != nil {
+		t.Fatalf("Execute: %v", err)
+	}
+	if got != `POST application/json k1 {"q":"go"}` {
+		t.Fatalf("unexpected echo %q", got)
+	}
+
+	// Without a method the tool still does a plain GET.
+	got, err = tool.Execute(context.Background(), map[string]interface{}{"url": srv.URL})
+	if err != nil || !strings.HasPrefix(got, "GET ") {
+		t.Fatalf("expected GET, got %q (%v)", got, err)
+	}
+}
+
+func TestWebToolRejectsBadRequests(t *testing.T) {
+	tool := NewWebTool()
+	tool.SetAllowPrivateNetworks(true) // httptest listens on loopback
+	cases := []map[string]interface{}{
+		{"url": "http://example.com", "method": "TRACE"},
+		{"url": "http://example.com", "body": "x"},
+		{"url": "http://example.com", "method": "POST", "body": strings.Repeat("x", maxWebRequestBody+1)},
+	}
+	for _, args := range cases {
+		if _, err := tool.Execute(context.Background(), args); err == nil {
+			t.Errorf("expected error for %v", args["method"])
+		}
+	}
+}
+
+func TestWebToolConvertsHTML(t *testing.T) {
+	const page = `<!doctype html><html><head><title>T</title><style>p{color:red}</style></head>
+<body><script>alert(1)</script><h1>Hello</h1>
+<p>Some   <b>bold</b>
+ text and a <a href="https://example.com/x">link</a>.</p>
+<ul><li>one</li><li>two</li></ul></body></html>`
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.Write([]byte(page))
+	}))
+	defer srv.Close()
+
+	tool := NewWebTool()
+	tool.SetAllowPrivateNetworks(true) // httptest listens on loopback
+	cases := []struct{ format, want string }{
+		{"", "Hello\n\nSome bold text and a link.\n\n- one\n- two"},
+		{"markdown", "# Hello\n\nSome **bold** text and a [link](https://example.com/x).\n\n- one\n- two"},
+		{"raw", page},
+	}
+	for _, c := range cases {
+		got, err := tool.Execute(context.Background(), map[string]interface{}{"url": srv.URL, "format": c.format})
+		if err != nil {
+			t.Fatalf("format %q: %v", c.format, err)
+		}
+		if got != c.want {
+			t.Errorf("format %q:\n got %q\nwant %q", c.format, got, c.want)
+		}
+	}
+
+	if _, err := tool.Execute(context.Background(), map[string]interface{}{"url": srv.URL, "format": "pdf"}); err == nil {
+		t.Error("expected error for unknown format")
+	}
+}
+
+func TestWebToolFullResult(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/old", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/api", http.StatusFound)
+	})
+	mux.HandleFunc("/api", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusUnprocessableEntity)
+		w.Write([]byte(`{"error":"bad field"}`))
+	})
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	tool := NewWebTool()
+	tool.SetAllowPrivateNetworks(true) // httptest listens on loopback
+	got, err := tool.Execute(context.Background(), map[string]interface{}{"url": srv.URL + "/old", "full": true})
+	if err != nil {
+		t.Fatalf("Execute: %v", err)
+	}
+	var res struct {
+		Status      int    `json:"status"`
+		URL         string `json:"url"`
+		ContentType string `json:"contentType"`
+		Body        string `json:"body"`
+	}
+	if err := json.Unmarshal([]byte(got), &res); err != nil {
+		t.Fatalf("result is not JSON: %v\n%s", err, got)
+	}
+	if res.Status != 422 || res.URL != srv.URL+"/api" || res.ContentType != "application/json" || res.Body != `{"error":"bad field"}` {
+		t.Fatalf("unexpected result %+v", res)
+	}
+
+	// Without full, only the body comes back.
+	got, err = tool.Execute(context.Background(), map[string]interface{}{"url": srv.URL + "/api"})
+	if err != nil || got != `{"error":"bad field"}` {
+		t.Fatalf("expected plain body, got %q (%v)", got, err)
+	}
+}
+
+func TestWebToolRedirectPolicy(t *testing.T) {
+	// /hop/N redirects to /hop/N-1; /hop/0 answers.
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n, _ := strconv.Atoi(strings.TrimPrefix(r.URL.Path, "/hop/"))
+		if n == 0 {
+			w.Write([]byte("arrived"))
+			return
+		}
+		http.Redirect(w, r, "/hop/"+strconv.Itoa(n-1), http.StatusMovedPermanently)
+	}))
+	defer srv.Close()
+	tool := NewWebTool()
+	tool.SetAllowPrivateNetworks(true) // httptest listens on loopback
+
+	// No-follow returns the 3xx itself.
+	got, err := tool.Execute(context.Background(), map[string]interface{}{
+		"url": srv.URL + "/hop/1", "followRedirects": false, "full": true,
+	})
+	if err != nil {
+		t.Fatalf("no-follow: %v", err)
+	}
+	if !strings.Contains(got, `"status": 301`) || !strings.Contains(got, `"location": "/hop/0"`) {
+		t.Fatalf("expected the 301 with its Location, got %s", got)
+	}
+
+	// A custom cap is enforced...
+	if _, err := tool.Execute(context.Background(), map[string]interface{}{
+		"url": srv.URL + "/hop/3", "maxRedirects": 2.0,
+	}); err == nil || !strings.Contains(err.Error(), "stopped after 2 redirects") {
+		t.Fatalf("expected redirect cap error, got %v", err)
+	}
+	// ...and a chain within it is followed.
+	got, err = tool.Execute(context.Background(), map[string]interface{}{
+		"url": srv.URL + "/hop/2", "maxRedirects": 2.0,
+	})
+	if err != nil || got != "arrived" {
+		t.Fatalf("expected to follow 2 redirects, got %q (%v)", got, err)
+	}
+
+	if _, err := tool.Execute(context.Background(), map[string]interface{}{
+		"url": srv.URL, "maxRedirects": 100.0,
+	}); err == nil {
+		t.Fatal("expected error for maxRedirects above the cap")
+	}
+}
+
+func TestWebToolTimeoutAndMaxBytes(t *testing.T) {
+	release := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/slow" {
+			select {
+			case <-release:
+			case <-r.Context().Done():
+			}
+			return
+		}
+		w.Write([]byte(strings.Repeat("a", 100)))
+	}))
+	defer srv.Close()
+	defer close(release)
+	tool := NewWebTool()
+	tool.SetAllowPrivateNetworks(true) // httptest listens on loopback
+
+	start := time.Now()
+	if _, err := tool.Execute(context.Background(), map[string]interface{}{"url": srv.URL + "/slow", "timeoutSeconds": 0.1}); err == nil {
+		t.Fatal("expected timeout error")
+	}
+	// A shorter caller deadline still applies when timeoutSeconds is longer.
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	if _, err := tool.Execute(ctx, map[string]interface{}{"url": srv.URL + "/slow", "timeoutSeconds": 60.0}); err == nil {
+		t.Fatal("expected caller deadline error")
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Fatalf("timeouts took %v", elapsed)
+	}
+
+	got, err := tool.Execute(context.Background(), map[string]interface{}{"url": srv.URL, "maxBytes": 10.0})
+	if err != nil {
+		t.Fatalf("Execute: %v", err)
+	}
+	if got != strings.Repeat("a", 10)+"\n[response truncated at 10 bytes]" {
+		t.Fatalf("unexpected truncated body %q", got)
+	}
+	got, err = tool.Execute(context.Background(), map[string]interface{}{"url": srv.URL})
+	if err != nil || got != strings.Repeat("a", 100) {
+		t.Fatalf("expected full body by default, got %q (%v)", got, err)
+	}
+}
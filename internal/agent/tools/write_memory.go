@@ -41,6 +41,10 @@ func NewWriteMemoryTool(mem *memory.MemoryStore) *WriteMemoryTool {
 	return &WriteMemoryTool{mem: mem}
 }
 
+// SetStore switches the memory store the tool writes to; see
+// ReadMemoryTool.SetStore.
+func (w *WriteMemoryTool) SetStore(mem *memory.MemoryStore) { w.mem = mem }
+
 func (w *WriteMemoryTool) Name() string { return "write_memory" }
 func (w *WriteMemoryTool) Description() string {
 	return "Write or append to memory (today's note or long-term MEMORY.md). NEVER store heartbeat status, health checks, or 'no pending tasks' results."
@@ -0,0 +1,25 @@
+package agent
+
+import "github.com/local/picobot/internal/providers"
+
+// addUsage adds the tokens of one provider reply to conversation key's total.
+func (a *AgentLoop) addUsage(key string, u providers.Usage) {
+	if u == (providers.Usage{}) {
+		return
+	}
+	a.usageMu.Lock()
+	a.usage[key] = a.usage[key].Add(u)
+	a.usageMu.Unlock()
+}
+
+// usageTotals returns a copy of the token totals per conversation
+// ("channel:chatID") since the agent started.
+func (a *AgentLoop) usageTotals() map[string]providers.Usage {
+	a.usageMu.Lock()
+	defer a.usageMu.Unlock()
+	out := make(map[string]providers.Usage, len(a.usage))
+	for k, u := range a.usage {
+		out[k] = u
+	}
+	return out
+}
@@ -0,0 +1,71 @@
+package channels
+
+import (
+	"sync"
+)
+
+// allowList is the set of IDs permitted to talk to a channel. It can be
+// replaced while the channel runs, e.g. when the config file is reloaded.
+// An empty list permits everyone.
+type allowList struct {
+	mu        sync.RWMutex
+	ids       map[string]struct{}
+	normalize func(string) string
+}
+
+func newAllowList(ids []string, normalize func(string) string) *allowList {
+	l := &allowList{normalize: normalize}
+	l.set(ids)
+	return l
+}
+
+func (l *allowList) set(ids []string) {
+	m := make(map[string]struct{}, len(ids))
+	for _, id := range ids {
+		if l.normalize != nil {
+			id = l.normalize(id)
+		}
+		m[id] = struct{}{}
+	}
+	l.mu.Lock()
+	l.ids = m
+	l.mu.Unlock()
+}
+
+// permits reports whether id may send messages.
+func (l *allowList) permits(id string) bool {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	if len(l.ids) == 0 {
+		return true
+	}
+	_, ok := l.ids[id]
+	return ok
+}
+
+var (
+	allowListsMu sync.Mutex
+	allowLists   = map[string]*allowList{}
+)
+
+// registerAllowList makes a running channel's list updatable through
+// UpdateAllowList under name.
+func registerAllowList(name string, l *allowList) {
+	allowListsMu.Lock()
+	allowLists[name] = l
+	allowListsMu.Unlock()
+}
+
+// UpdateAllowList replaces the allow list of a running channel. name is the
+// channel ("telegram", "discord", "slack", "whatsapp", "email"), or
+// "slack:channels" for Slack's channel list. It reports false if that
+// channel isn't running.
+func UpdateAllowList(name string, ids []string) bool {
+	allowListsMu.Lock()
+	l, ok := allowLists[name]
+	allowListsMu.Unlock()
+	if ok {
+		l.set(ids)
+	}
+	return ok
+}
@@ -0,0 +1,31 @@
+package channels
+
+import "testing"
+
+func TestUpdateAllowListReplacesRunningList(t *testing.T) {
+	l := newAllowList([]string{"Alice@Example.com "}, normalizeAddress)
+	if !l.permits("alice@example.com") || l.permits("bob@example.com") {
+		t.Fatal("initial list not applied")
+	}
+
+	if UpdateAllowList("test-allowlist", nil) {
+		t.Fatal("update of an unregistered list should report false")
+	}
+	registerAllowList("test-allowlist", l)
+	t.Cleanup(func() {
+		allowListsMu.Lock()
+		delete(allowLists, "test-allowlist")
+		allowListsMu.Unlock()
+	})
+
+	if !UpdateAllowList("test-allowlist", []string{"BOB@example.com"}) {
+		t.Fatal("update of a registered list should report true")
+	}
+	if l.permits("alice@example.com") || !l.permits("bob@example.com") {
+		t.Fatal("updated list not applied")
+	}
+	UpdateAllowList("test-allowlist", nil)
+	if !l.permits("anyone") {
+		t.Fatal("an empty list should permit everyone")
+	}
+}
@@ -16,12 +16,15 @@ import (
 // It exists to enable testing without a live Discord WebSocket connection.
 type discordSender interface {
 	ChannelMessageSend(channelID, content string, options ...discordgo.RequestOption) (*discordgo.Message, error)
+	ChannelMessageSendReply(channelID, content string, reference *discordgo.MessageReference, options ...discordgo.RequestOption) (*discordgo.Message, error)
 	ChannelTyping(channelID string, options ...discordgo.RequestOption) error
 }
 
 // StartDiscord starts a Discord bot using the discordgo library.
 // allowFrom restricts which Discord user IDs may send messages; empty means allow all.
-func StartDiscord(ctx context.Context, hub *chat.Hub, token string, allowFrom []string) error {
+// media screens inbound attachments, and trigger decides which messages
+// address the bot.
+func StartDiscord(ctx context.Context, hub *chat.Hub, token string, allowFrom []string, media MediaGuard, trigger Trigger) error {
 	if token == "" {
 		return fmt.Errorf("discord token not provided")
 	}
@@ -50,6 +53,9 @@ func StartDiscord(ctx context.Context, hub *chat.Hub, token string, allowFrom []
 	log.Printf("discord: connected as %s (%s)", botUser.Username, botUser.ID)
 
 	client := newDiscordClient(ctx, session, hub, botUser.ID, allowFrom)
+	client.media = media
+	client.trigger = trigger
+	registerAllowList("discord", client.allowed)
 	session.AddHandler(client.handleMessage)
 	go client.runOutbound()
 	go func() {
@@ -70,7 +76,9 @@ type discordClient struct {
 	hub        *chat.Hub
 	outCh      <-chan chat.Outbound
 	botID      string
-	allowed    map[string]struct{}
+	allowed    *allowList
+	media      MediaGuard
+	trigger    Trigger
 	ctx        context.Context
 	typingMu   sync.Mutex
 	typingStop map[string]chan struct{}
@@ -79,16 +87,13 @@ type discordClient struct {
 // newDiscordClient constructs a discordClient and registers it as the hub's
 // "discord" outbound subscriber. Inject a mock discordSender for tests.
 func newDiscordClient(ctx context.Context, sender discordSender, hub *chat.Hub, botID string, allowFrom []string) *discordClient {
-	allowed := make(map[string]struct{}, len(allowFrom))
-	for _, id := range allowFrom {
-		allowed[id] = struct{}{}
-	}
 	return &discordClient{
 		sender:     sender,
 		hub:        hub,
 		outCh:      hub.Subscribe("discord"),
 		botID:      botID,
-		allowed:    allowed,
+		allowed:    newAllowList(allowFrom, nil),
+		media:      MediaGuard{MaxBytes: DefaultInboundMediaMaxBytes},
 		ctx:        ctx,
 		typingStop: make(map[string]chan struct{}),
 	}
@@ -104,45 +109,54 @@ func (c *discordClient) handleMessage(_ *discordgo.Session, m *discordgo.Message
 	}
 
 	// Enforce allowlist when one is configured.
-	if len(c.allowed) > 0 {
-		if _, ok := c.allowed[m.Author.ID]; !ok {
-			log.Printf("discord: dropped message from unauthorised user %s (%s)", m.Author.Username, m.Author.ID)
-			return
-		}
+	if !c.allowed.permits(m.Author.ID) {
+		log.Printf("discord: dropped message from unauthorised user %s (%s)", m.Author.Username, m.Author.ID)
+		return
 	}
 
 	isDM := m.GuildID == ""
 
-	// In guild channels only respond when the bot is @-mentioned.
-	if !isDM {
-		mentioned := false
-		for _, u := range m.Mentions {
-			if u.ID == c.botID {
-				mentioned = true
-				break
-			}
-		}
-		if !mentioned {
-			return
-		}
-	}
-
 	// Strip bot @-mentions from the message text.
+	mentioned := false
 	content := m.Content
 	for _, u := range m.Mentions {
 		if u.ID == c.botID {
+			mentioned = true
 			content = strings.ReplaceAll(content, "<@"+u.ID+">", "")
 			content = strings.ReplaceAll(content, "<@!"+u.ID+">", "")
 		}
 	}
+	content, triggered := c.trigger.strip(content)
 	content = strings.TrimSpace(content)
 
-	// Append file attachment URLs as inline references.
+	// In guild channels only respond when the bot is addressed, and in DMs
+	// too if so configured.
+	if (!isDM || c.trigger.RequireInDMs) && !mentioned && !triggered {
+		return
+	}
+
+	// Append file attachment URLs as inline references, unless too large or
+	// of a type that isn't accepted. Small text files are inlined instead
+	// when so configured.
 	for _, att := range m.Attachments {
+		if marker, ok := c.media.admit(c.ctx, c.hub, "discord", m.ChannelID, att.Filename, att.URL, int64(att.Size), att.ContentType); !ok {
+			content += "\n" + marker
+			continue
+		}
+		if text, ok := c.media.inlineText(c.ctx, att.URL, att.Filename, int64(att.Size), att.ContentType); ok {
+			content += "\n" + text
+			continue
+		}
 		content += fmt.Sprintf("\n[attachment: %s]", att.URL)
 	}
 
 	if content == "" {
+		if (mentioned || triggered) && len(m.Attachments) == 0 && c.trigger.BareReply != "" {
+			out := chat.Outbound{Channel: "discord", ChatID: m.ChannelID, Content: c.trigger.BareReply}
+			if err := c.hub.Send(c.ctx, out); err != nil {
+				log.Printf("discord: dropping reply to bare mention from %s: %v", m.Author.ID, err)
+			}
+		}
 		return
 	}
 
@@ -151,7 +165,7 @@ func (c *discordClient) handleMessage(_ *discordgo.Session, m *discordgo.Message
 
 	c.startTyping(m.ChannelID)
 
-	c.hub.In <- chat.Inbound{
+	if err := c.hub.Receive(c.ctx, chat.Inbound{
 		Channel:   "discord",
 		SenderID:  m.Author.ID,
 		ChatID:    m.ChannelID,
@@ -161,8 +175,11 @@ func (c *discordClient) handleMessage(_ *discordgo.Session, m *discordgo.Message
 			"username":   senderName,
 			"guild_id":   m.GuildID,
 			"channel_id": m.ChannelID,
+			"message_id": m.ID,
 			"is_dm":      isDM,
 		},
+	}); err != nil {
+		log.Printf("discord: dropping message from %s: %v", m.Author.ID, err)
 	}
 }
 
@@ -174,8 +191,16 @@ func (c *discordClient) runOutbound() {
 			return
 		case out := <-c.outCh:
 			c.stopTyping(out.ChatID)
-			for _, chunk := range splitMessage(out.Content, 2000) {
-				if _, err := c.sender.ChannelMessageSend(out.ChatID, chunk); err != nil {
+			for i, chunk := range splitMessage(out.Content, 2000) {
+				var err error
+				if i == 0 && out.ReplyTo != "" {
+					// Only the first part is a reply; the rest follow it.
+					ref := &discordgo.MessageReference{MessageID: out.ReplyTo, ChannelID: out.ChatID}
+					_, err = c.sender.ChannelMessageSendReply(out.ChatID, chunk, ref)
+				} else {
+					_, err = c.sender.ChannelMessageSend(out.ChatID, chunk)
+				}
+				if err != nil {
 					log.Printf("discord: send error: %v", err)
 				}
 			}
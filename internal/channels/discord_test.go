@@ -6,6 +6,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/bwmarrin/discordgo"
 	"github.com/local/picobot/internal/chat"
 )
 
@@ -88,7 +89,7 @@ func TestTruncate(t *testing.T) {
 // TestStartDiscord_EmptyToken tests that StartDiscord returns an error with empty token.
 func TestStartDiscord_EmptyToken(t *testing.T) {
 	hub := chat.NewHub(100)
-	err := StartDiscord(context.Background(), hub, "", nil)
+	err := StartDiscord(context.Background(), hub, "", nil, NewMediaGuard(nil), Trigger{})
 	if err == nil {
 		t.Error("StartDiscord with empty token should return error")
 	}
@@ -312,3 +313,85 @@ func TestDiscordClient_NewlineSplit(t *testing.T) {
 		t.Error("second chunk should start with 'b'")
 	}
 }
+
+// replySender records which messages were sent as replies.
+type replySender struct {
+	nopDiscordSender
+	sent chan string // "reply:<id>:<content>" or "send:<content>"
+}
+
+func (s replySender) ChannelMessageSend(_ string, content string, _ ...discordgo.RequestOption) (*discordgo.Message, error) {
+	s.sent <- "send:" + content
+	return &discordgo.Message{}, nil
+}
+
+func (s replySender) ChannelMessageSendReply(_ string, content string, ref *discordgo.MessageReference, _ ...discordgo.RequestOption) (*discordgo.Message, error) {
+	s.sent <- "reply:" + ref.MessageID + ":" + content
+	return &discordgo.Message{}, nil
+}
+
+// TestDiscordClient_ReplyTo tests that an outbound with ReplyTo is sent as a
+// Discord reply, and one without as a plain message.
+func TestDiscordClient_ReplyTo(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	hub := chat.NewHub(10)
+	sender := replySender{sent: make(chan string, 10)}
+	c := newDiscordClient(ctx, sender, hub, "bot", nil)
+	hub.StartRouter(ctx)
+	go c.runOutbound()
+
+	hub.Out <- chat.Outbound{Channel: "discord", ChatID: "c1", Content: "threaded", ReplyTo: "m42"}
+	hub.Out <- chat.Outbound{Channel: "discord", ChatID: "c1", Content: "standalone"}
+	for _, want := range []string{"reply:m42:threaded", "send:standalone"} {
+		select {
+		case got := <-sender.sent:
+			if got != want {
+				t.Fatalf("sent %q, want %q", got, want)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("timeout waiting for %q", want)
+		}
+	}
+}
+
+// TestDiscordClient_BareMentionReply tests that a message that is only a
+// mention gets the configured reply when enabled, and is ignored otherwise.
+func TestDiscordClient_BareMentionReply(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	hub := chat.NewHub(10)
+	sender := replySender{sent: make(chan string, 10)}
+	c := newDiscordClient(ctx, sender, hub, "bot", nil)
+	hub.StartRouter(ctx)
+	go c.runOutbound()
+
+	bare := &discordgo.MessageCreate{Message: &discordgo.Message{
+		ID: "m1", ChannelID: "c1", GuildID: "g1", Content: "<@bot>",
+		Author:   &discordgo.User{ID: "u1", Username: "alice"},
+		Mentions: []*discordgo.User{{ID: "bot"}},
+	}}
+
+	c.handleMessage(nil, bare)
+	select {
+	case got := <-sender.sent:
+		t.Fatalf("bare mention should be ignored by default, sent %q", got)
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	c.trigger.BareReply = DefaultBareReply
+	c.handleMessage(nil, bare)
+	select {
+	case got := <-sender.sent:
+		if got != "send:"+DefaultBareReply {
+			t.Fatalf("sent %q", got)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("timeout waiting for the bare mention reply")
+	}
+	select {
+	case in := <-hub.In:
+		t.Fatalf("bare mention should not reach the agent, got %+v", in)
+	default:
+	}
+}
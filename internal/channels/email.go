@@ -0,0 +1,521 @@
+package channels
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"log"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/emersion/go-imap"
+	imapclient "github.com/emersion/go-imap/client"
+
+	"github.com/local/picobot/internal/chat"
+	"github.com/local/picobot/internal/config"
+)
+
+// maxEmailBodyBytes caps how much of a single message body is read into memory.
+const maxEmailBodyBytes = 1 << 20 // 1 MiB
+
+// maxEmailThreads caps how many threads are remembered for replies; the
+// least recently used thread is forgotten first.
+const maxEmailThreads = 1000
+
+// emailMailbox is the subset of IMAP operations used for inbound polling.
+// It exists to enable testing without a live IMAP server.
+type emailMailbox interface {
+	// FetchUnseen returns the raw RFC 5322 bytes of every unread message and
+	// marks them as read.
+	FetchUnseen() ([][]byte, error)
+}
+
+// emailSender delivers a raw RFC 5322 message. It exists to enable testing
+// without a live SMTP server.
+type emailSender interface {
+	SendMail(from string, to []string, msg []byte) error
+}
+
+// StartEmail starts the email channel: it polls the IMAP inbox for unread
+// messages and sends replies over SMTP.
+// cfg.AllowFrom restricts which sender addresses may interact; empty means allow all.
+func StartEmail(ctx context.Context, hub *chat.Hub, cfg config.EmailConfig) error {
+	if cfg.IMAPServer == "" || cfg.SMTPServer == "" {
+		return fmt.Errorf("email imapServer and smtpServer must be set")
+	}
+	if cfg.Username == "" || cfg.Password == "" {
+		return fmt.Errorf("email username and password must be set")
+	}
+	address := cfg.Address
+	if address == "" {
+		address = cfg.Username
+	}
+	interval := time.Duration(cfg.PollIntervalS) * time.Second
+	if interval <= 0 {
+		interval = 60 * time.Second
+	}
+
+	mailbox := &imapMailbox{addr: cfg.IMAPServer, username: cfg.Username, password: cfg.Password}
+	sender := &smtpSender{addr: cfg.SMTPServer, username: cfg.Username, password: cfg.Password}
+	client := newEmailClient(ctx, mailbox, sender, hub, address, cfg.AllowFrom)
+	registerAllowList("email", client.allowed)
+
+	go client.runInbound(interval)
+	go client.runOutbound()
+	log.Printf("email: polling %s as %s every %v", cfg.IMAPServer, address, interval)
+	return nil
+}
+
+// emailThread remembers what is needed to reply into an existing conversation.
+type emailThread struct {
+	to            string
+	subject       string
+	lastMessageID string
+	references    []string
+	used          time.Time
+}
+
+// emailClient handles email messaging using an emailMailbox and emailSender.
+type emailClient struct {
+	mailbox emailMailbox
+	sender  emailSender
+	hub     *chat.Hub
+	outCh   <-chan chat.Outbound
+	address string
+	allowed *allowList
+	ctx     context.Context
+
+	mu      sync.Mutex
+	threads map[string]*emailThread
+}
+
+// normalizeAddress makes email addresses in allowFrom comparable with the
+// lowercased sender.
+func normalizeAddress(a string) string {
+	return strings.ToLower(strings.TrimSpace(a))
+}
+
+// newEmailClient constructs an emailClient and registers it as the hub's
+// "email" outbound subscriber. Inject mocks for tests.
+func newEmailClient(ctx context.Context, mailbox emailMailbox, sender emailSender, hub *chat.Hub, address string, allowFrom []string) *emailClient {
+	return &emailClient{
+		mailbox: mailbox,
+		sender:  sender,
+		hub:     hub,
+		outCh:   hub.Subscribe("email"),
+		address: address,
+		allowed: newAllowList(allowFrom, normalizeAddress),
+		ctx:     ctx,
+		threads: make(map[string]*emailThread),
+	}
+}
+
+// runInbound polls the mailbox immediately and then every interval until ctx is done.
+func (c *emailClient) runInbound(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		c.poll()
+		select {
+		case <-c.ctx.Done():
+			log.Println("email: stopping inbound polling")
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// poll fetches unread messages and forwards each to the hub.
+func (c *emailClient) poll() {
+	raws, err := c.mailbox.FetchUnseen()
+	if err != nil {
+		log.Printf("email: fetch error: %v", err)
+	}
+	for _, raw := range raws {
+		c.handleRaw(raw)
+	}
+}
+
+// handleRaw parses a single RFC 5322 message and publishes it as an Inbound.
+func (c *emailClient) handleRaw(raw []byte) {
+	msg, err := mail.ReadMessage(bytes.NewReader(raw))
+	if err != nil {
+		log.Printf("email: invalid message: %v", err)
+		return
+	}
+	from, err := mail.ParseAddress(msg.Header.Get("From"))
+	if err != nil {
+		log.Printf("email: invalid From header: %v", err)
+		return
+	}
+	sender := strings.ToLower(from.Address)
+	if strings.EqualFold(sender, c.address) {
+		return
+	}
+	if !c.allowed.permits(sender) {
+		log.Printf("email: dropped message from unauthorized sender %s", sender)
+		return
+	}
+
+	messageID := strings.TrimSpace(msg.Header.Get("Message-ID"))
+	inReplyTo := strings.TrimSpace(msg.Header.Get("In-Reply-To"))
+	refs := parseMessageIDs(msg.Header.Get("References"))
+
+	// The thread is identified by its root message: the first References
+	// entry, else In-Reply-To, else this message itself.
+	threadID := messageID
+	if len(refs) > 0 {
+		threadID = refs[0]
+	} else if inReplyTo != "" {
+		threadID = inReplyTo
+	}
+	if threadID == "" {
+		threadID = sender
+	}
+	// Message-IDs may contain '/' and other characters that are unsafe in
+	// the file names sessions are stored under, so the chat is keyed by a
+	// hash of the thread root instead.
+	threadID = emailThreadID(threadID)
+
+	subject, err := new(mime.WordDecoder).DecodeHeader(msg.Header.Get("Subject"))
+	if err != nil {
+		subject = msg.Header.Get("Subject")
+	}
+
+	body, err := extractEmailText(msg.Header.Get("Content-Type"), msg.Header.Get("Content-Transfer-Encoding"), msg.Body)
+	if err != nil {
+		log.Printf("email: failed to read body from %s: %v", sender, err)
+		return
+	}
+	content := stripQuotedReply(body)
+	if content == "" {
+		content = strings.TrimSpace(subject)
+	}
+	if content == "" {
+		return
+	}
+
+	if inReplyTo != "" && !containsString(refs, inReplyTo) {
+		refs = append(refs, inReplyTo)
+	}
+	if messageID != "" {
+		refs = append(refs, messageID)
+	}
+	c.mu.Lock()
+	c.threads[threadID] = &emailThread{
+		to:            from.Address,
+		subject:       subject,
+		lastMessageID: messageID,
+		references:    refs,
+		used:          time.Now(),
+	}
+	c.evictThreads()
+	c.mu.Unlock()
+
+	log.Printf("email: message from %s in thread %s: %s", sender, threadID, truncate(content, 50))
+
+	if err := c.hub.Receive(c.ctx, chat.Inbound{
+		Channel:   "email",
+		SenderID:  sender,
+		ChatID:    threadID,
+		Content:   content,
+		Timestamp: time.Now(),
+		Metadata: map[string]interface{}{
+			"subject":    subject,
+			"message_id": messageID,
+			"from_name":  from.Name,
+		},
+	}); err != nil {
+		log.Printf("email: dropping message from %s: %v", sender, err)
+	}
+}
+
+// runOutbound reads replies from the hub's email subscription and sends them.
+func (c *emailClient) runOutbound() {
+	for {
+		select {
+		case <-c.ctx.Done():
+			log.Println("email: stopping outbound sender")
+			return
+		case out := <-c.outCh:
+			if err := c.send(out); err != nil {
+				log.Printf("email: send error: %v", err)
+			}
+		}
+	}
+}
+
+// send replies into the thread identified by out.ChatID, preserving
+// In-Reply-To/References so mail clients keep the conversation threaded.
+// Progress messages are skipped so each turn sends a single email.
+func (c *emailClient) send(out chat.Outbound) error {
+	if out.Progress {
+		return nil
+	}
+	c.mu.Lock()
+	th, ok := c.threads[out.ChatID]
+	if !ok {
+		c.mu.Unlock()
+		return fmt.Errorf("no known thread for chat %q", out.ChatID)
+	}
+	msgID := newMessageID(c.address)
+	raw := buildEmailReply(c.address, th, msgID, out.Content)
+	// Subsequent replies chain off the message we are about to send.
+	th.references = append(th.references, msgID)
+	th.lastMessageID = msgID
+	th.used = time.Now()
+	to := th.to
+	c.mu.Unlock()
+
+	return c.sender.SendMail(c.address, []string{to}, raw)
+}
+
+// evictThreads forgets the least recently used threads beyond
+// maxEmailThreads. c.mu must be held.
+func (c *emailClient) evictThreads() {
+	for len(c.threads) > maxEmailThreads {
+		oldest := ""
+		for id, th := range c.threads {
+			if oldest == "" || th.used.Before(c.threads[oldest].used) {
+				oldest = id
+			}
+		}
+		delete(c.threads, oldest)
+	}
+}
+
+// emailThreadID derives a file-name-safe chat ID from a thread's root
+// Message-ID (or sender address).
+func emailThreadID(root string) string {
+	sum := sha256.Sum256([]byte(root))
+	return hex.EncodeToString(sum[:8])
+}
+
+// buildEmailReply renders a plain-text RFC 5322 reply for the given thread.
+func buildEmailReply(from string, th *emailThread, msgID, body string) []byte {
+	subject := th.subject
+	if !strings.HasPrefix(strings.ToLower(subject), "re:") {
+		subject = "Re: " + subject
+	}
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "From: %s\r\n", from)
+	fmt.Fprintf(&buf, "To: %s\r\n", th.to)
+	fmt.Fprintf(&buf, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", subject))
+	fmt.Fprintf(&buf, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	fmt.Fprintf(&buf, "Message-ID: %s\r\n", msgID)
+	if th.lastMessageID != "" {
+		fmt.Fprintf(&buf, "In-Reply-To: %s\r\n", th.lastMessageID)
+	}
+	if len(th.references) > 0 {
+		fmt.Fprintf(&buf, "References: %s\r\n", strings.Join(th.references, " "))
+	}
+	buf.WriteString("MIME-Version: 1.0\r\n")
+	buf.WriteString("Content-Type: text/plain; charset=utf-8\r\n")
+	buf.WriteString("Content-Transfer-Encoding: quoted-printable\r\n")
+	buf.WriteString("\r\n")
+
+	qp := quotedprintable.NewWriter(&buf)
+	_, _ = qp.Write([]byte(strings.ReplaceAll(body, "\n", "\r\n")))
+	_ = qp.Close()
+	return buf.Bytes()
+}
+
+// newMessageID returns a unique RFC 5322 Message-ID using the domain of addr.
+func newMessageID(addr string) string {
+	domain := "picobot.local"
+	if i := strings.LastIndex(addr, "@"); i >= 0 && i < len(addr)-1 {
+		domain = addr[i+1:]
+	}
+	b := make([]byte, 12)
+	_, _ = rand.Read(b)
+	return fmt.Sprintf("<%d.%s@%s>", time.Now().UnixNano(), hex.EncodeToString(b), domain)
+}
+
+// parseMessageIDs splits a References/In-Reply-To header into its <id> tokens.
+func parseMessageIDs(header string) []string {
+	var ids []string
+	for _, f := range strings.Fields(header) {
+		if strings.HasPrefix(f, "<") && strings.HasSuffix(f, ">") {
+			ids = append(ids, f)
+		}
+	}
+	return ids
+}
+
+func containsString(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+// extractEmailText returns the text/plain content of a message body,
+// descending into multipart containers and undoing transfer encodings.
+func extractEmailText(contentType, transferEncoding string, body io.Reader) (string, error) {
+	mediaType, params, err := mime.ParseMediaType(contentType)
+	if err != nil || contentType == "" {
+		mediaType = "text/plain"
+	}
+
+	switch strings.ToLower(strings.TrimSpace(transferEncoding)) {
+	case "base64":
+		body = base64.NewDecoder(base64.StdEncoding, body)
+	case "quoted-printable":
+		body = quotedprintable.NewReader(body)
+	}
+
+	if strings.HasPrefix(mediaType, "multipart/") {
+		mr := multipart.NewReader(body, params["boundary"])
+		for {
+			part, err := mr.NextPart()
+			if err == io.EOF {
+				return "", nil
+			}
+			if err != nil {
+				return "", err
+			}
+			text, err := extractEmailText(part.Header.Get("Content-Type"), part.Header.Get("Content-Transfer-Encoding"), part)
+			if err != nil {
+				return "", err
+			}
+			if text != "" {
+				return text, nil
+			}
+		}
+	}
+
+	if mediaType != "text/plain" {
+		return "", nil
+	}
+	b, err := io.ReadAll(io.LimitReader(body, maxEmailBodyBytes))
+	if err != nil {
+		return "", err
+	}
+	return string(b), nil
+}
+
+// replyHeaderRE matches the attribution line most clients put above quoted
+// history, e.g. "On Mon, 2 Mar 2026 at 10:00, Alice <a@example.com> wrote:".
+var replyHeaderRE = regexp.MustCompile(`(?i)^on\b.+\bwrote:$`)
+
+// stripQuotedReply removes quoted reply history and signatures so the agent
+// only sees what the sender newly wrote.
+func stripQuotedReply(body string) string {
+	lines := strings.Split(strings.ReplaceAll(body, "\r\n", "\n"), "\n")
+	var out []string
+	for i, line := range lines {
+		trimmed := strings.TrimSpace(line)
+		if strings.HasPrefix(trimmed, ">") {
+			continue
+		}
+		if replyHeaderRE.MatchString(trimmed) {
+			break
+		}
+		// Attribution lines are often wrapped across two lines.
+		if i+1 < len(lines) && strings.HasPrefix(strings.ToLower(trimmed), "on ") &&
+			replyHeaderRE.MatchString(trimmed+" "+strings.TrimSpace(lines[i+1])) {
+			break
+		}
+		if trimmed == "-----Original Message-----" || strings.HasPrefix(trimmed, "________________________________") {
+			break
+		}
+		if line == "-- " {
+			break
+		}
+		out = append(out, line)
+	}
+	return strings.TrimSpace(strings.Join(out, "\n"))
+}
+
+// imapMailbox implements emailMailbox against a real IMAP server over TLS.
+type imapMailbox struct {
+	addr     string
+	username string
+	password string
+}
+
+func (m *imapMailbox) FetchUnseen() ([][]byte, error) {
+	c, err := imapclient.DialWithDialerTLS(&net.Dialer{Timeout: 30 * time.Second}, m.addr, nil)
+	if err != nil {
+		return nil, fmt.Errorf("imap dial: %w", err)
+	}
+	defer func() { _ = c.Logout() }()
+	c.Timeout = 60 * time.Second
+
+	if err := c.Login(m.username, m.password); err != nil {
+		return nil, fmt.Errorf("imap login: %w", err)
+	}
+	if _, err := c.Select("INBOX", false); err != nil {
+		return nil, fmt.Errorf("imap select: %w", err)
+	}
+
+	criteria := imap.NewSearchCriteria()
+	criteria.WithoutFlags = []string{imap.SeenFlag}
+	uids, err := c.UidSearch(criteria)
+	if err != nil {
+		return nil, fmt.Errorf("imap search: %w", err)
+	}
+	if len(uids) == 0 {
+		return nil, nil
+	}
+
+	seqset := new(imap.SeqSet)
+	seqset.AddNum(uids...)
+	// A non-peek BODY[] fetch sets \Seen, so each message is delivered once.
+	section := &imap.BodySectionName{}
+	msgs := make(chan *imap.Message, len(uids))
+	done := make(chan error, 1)
+	go func() {
+		done <- c.UidFetch(seqset, []imap.FetchItem{section.FetchItem()}, msgs)
+	}()
+
+	var raws [][]byte
+	for msg := range msgs {
+		r := msg.GetBody(section)
+		if r == nil {
+			continue
+		}
+		b, err := io.ReadAll(r)
+		if err != nil {
+			continue
+		}
+		raws = append(raws, b)
+	}
+	if err := <-done; err != nil {
+		return raws, fmt.Errorf("imap fetch: %w", err)
+	}
+	return raws, nil
+}
+
+// smtpSender implements emailSender using net/smtp with PLAIN auth.
+// smtp.SendMail upgrades to TLS via STARTTLS when the server offers it.
+type smtpSender struct {
+	addr     string
+	username string
+	password string
+}
+
+func (s *smtpSender) SendMail(from string, to []string, msg []byte) error {
+	host, _, err := net.SplitHostPort(s.addr)
+	if err != nil {
+		return fmt.Errorf("smtp address %q: %w", s.addr, err)
+	}
+	auth := smtp.PlainAuth("", s.username, s.password, host)
+	return smtp.SendMail(s.addr, auth, from, to, msg)
+}
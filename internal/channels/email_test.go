@@ -0,0 +1,220 @@
+package channels
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/local/picobot/internal/chat"
+)
+
+type mockMailbox struct {
+	raws [][]byte
+}
+
+func (m *mockMailbox) FetchUnseen() ([][]byte, error) {
+	raws := m.raws
+	m.raws = nil
+	return raws, nil
+}
+
+type sentMail struct {
+	from string
+	to   []string
+	msg  string
+}
+
+type mockEmailSender struct {
+	mu   sync.Mutex
+	sent []sentMail
+}
+
+func (m *mockEmailSender) SendMail(from string, to []string, msg []byte) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.sent = append(m.sent, sentMail{from: from, to: to, msg: string(msg)})
+	return nil
+}
+
+func rawEmail(from, msgID, inReplyTo, refs, body string) []byte {
+	var sb strings.Builder
+	sb.WriteString("From: " + from + "\r\n")
+	sb.WriteString("To: bot@example.com\r\n")
+	sb.WriteString("Subject: Weekly plan\r\n")
+	sb.WriteString("Message-ID: " + msgID + "\r\n")
+	if inReplyTo != "" {
+		sb.WriteString("In-Reply-To: " + inReplyTo + "\r\n")
+	}
+	if refs != "" {
+		sb.WriteString("References: " + refs + "\r\n")
+	}
+	sb.WriteString("Content-Type: text/plain; charset=utf-8\r\n\r\n")
+	sb.WriteString(body)
+	return []byte(sb.String())
+}
+
+func TestEmailInboundThreadingAndReply(t *testing.T) {
+	hub := chat.NewHub(10)
+	mb := &mockMailbox{raws: [][]byte{
+		rawEmail("Alice <alice@example.com>", "<m2@example.com>", "<m1@example.com>", "<m1@example.com>",
+			"Sounds good, add a review on Friday.\r\n\r\nOn Mon, 2 Mar 2026 at 10:00, Bot <bot@example.com> wrote:\r\n> Here is the plan\r\n"),
+	}}
+	sender := &mockEmailSender{}
+	c := newEmailClient(context.Background(), mb, sender, hub, "bot@example.com", nil)
+
+	c.poll()
+
+	var in chat.Inbound
+	select {
+	case in = <-hub.In:
+	case <-time.After(time.Second):
+		t.Fatal("expected inbound message")
+	}
+	if in.Channel != "email" || in.SenderID != "alice@example.com" {
+		t.Fatalf("unexpected inbound: %+v", in)
+	}
+	if in.ChatID != emailThreadID("<m1@example.com>") {
+		t.Fatalf("expected thread root as ChatID, got %q", in.ChatID)
+	}
+	if in.Content != "Sounds good, add a review on Friday." {
+		t.Fatalf("quoted history not stripped: %q", in.Content)
+	}
+
+	if err := c.send(chat.Outbound{Channel: "email", ChatID: in.ChatID, Content: "Added."}); err != nil {
+		t.Fatalf("send: %v", err)
+	}
+	if len(sender.sent) != 1 {
+		t.Fatalf("expected 1 sent mail, got %d", len(sender.sent))
+	}
+	got := sender.sent[0]
+	if len(got.to) != 1 || got.to[0] != "alice@example.com" {
+		t.Fatalf("unexpected recipients: %v", got.to)
+	}
+	if !strings.Contains(got.msg, "In-Reply-To: <m2@example.com>\r\n") {
+		t.Fatalf("missing In-Reply-To header:\n%s", got.msg)
+	}
+	if !strings.Contains(got.msg, "References: <m1@example.com> <m2@example.com>\r\n") {
+		t.Fatalf("missing References header:\n%s", got.msg)
+	}
+	if !strings.Contains(got.msg, "Subject: Re: Weekly plan\r\n") {
+		t.Fatalf("missing reply subject:\n%s", got.msg)
+	}
+}
+
+func TestEmailAllowFrom(t *testing.T) {
+	hub := chat.NewHub(10)
+	mb := &mockMailbox{raws: [][]byte{
+		rawEmail("mallory@example.com", "<x@example.com>", "", "", "hi"),
+		rawEmail("Alice@Example.com", "<y@example.com>", "", "", "hello"),
+	}}
+	c := newEmailClient(context.Background(), mb, &mockEmailSender{}, hub, "bot@example.com", []string{"alice@example.com"})
+
+	c.poll()
+
+	select {
+	case in := <-hub.In:
+		if in.SenderID != "alice@example.com" || in.ChatID != emailThreadID("<y@example.com>") {
+			t.Fatalf("unexpected inbound: %+v", in)
+		}
+	default:
+		t.Fatal("expected inbound from allowed sender")
+	}
+	select {
+	case in := <-hub.In:
+		t.Fatalf("unexpected extra inbound: %+v", in)
+	default:
+	}
+}
+
+func TestEmailThreadIDIsPathSafe(t *testing.T) {
+	hub := chat.NewHub(10)
+	mb := &mockMailbox{raws: [][]byte{rawEmail("alice@example.com", "<a/b@host>", "", "", "hi")}}
+	sender := &mockEmailSender{}
+	c := newEmailClient(context.Background(), mb, sender, hub, "bot@example.com", nil)
+
+	c.poll()
+
+	in := <-hub.In
+	if strings.ContainsAny(in.ChatID, `/\<>:`) {
+		t.Fatalf("ChatID %q is not safe to use in a file name", in.ChatID)
+	}
+	if err := c.send(chat.Outbound{Channel: "email", ChatID: in.ChatID, Content: "hello"}); err != nil {
+		t.Fatalf("send: %v", err)
+	}
+	if !strings.Contains(sender.sent[0].msg, "In-Reply-To: <a/b@host>\r\n") {
+		t.Fatalf("missing In-Reply-To header:\n%s", sender.sent[0].msg)
+	}
+}
+
+func TestEmailSkipsProgressMessages(t *testing.T) {
+	hub := chat.NewHub(10)
+	mb := &mockMailbox{raws: [][]byte{rawEmail("alice@example.com", "<p@example.com>", "", "", "hi")}}
+	sender := &mockEmailSender{}
+	c := newEmailClient(context.Background(), mb, sender, hub, "bot@example.com", nil)
+
+	c.poll()
+	in := <-hub.In
+
+	if err := c.send(chat.Outbound{Channel: "email", ChatID: in.ChatID, Content: "🤖 Running: web {}", Progress: true}); err != nil {
+		t.Fatalf("send: %v", err)
+	}
+	if len(sender.sent) != 0 {
+		t.Fatalf("a progress message should not be emailed, sent %d", len(sender.sent))
+	}
+	if err := c.send(chat.Outbound{Channel: "email", ChatID: in.ChatID, Content: "Here you go."}); err != nil {
+		t.Fatalf("send: %v", err)
+	}
+	if len(sender.sent) != 1 {
+		t.Fatalf("expected the reply to be emailed, sent %d", len(sender.sent))
+	}
+}
+
+func TestEmailThreadsAreBounded(t *testing.T) {
+	hub := chat.NewHub(maxEmailThreads + 10)
+	var raws [][]byte
+	for i := 0; i < maxEmailThreads+5; i++ {
+		raws = append(raws, rawEmail("alice@example.com", fmt.Sprintf("<m%d@example.com>", i), "", "", "hi"))
+	}
+	c := newEmailClient(context.Background(), &mockMailbox{raws: raws}, &mockEmailSender{}, hub, "bot@example.com", nil)
+
+	c.poll()
+
+	if n := len(c.threads); n != maxEmailThreads {
+		t.Fatalf("remembered %d threads, want %d", n, maxEmailThreads)
+	}
+}
+
+func TestStripQuotedReply(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		want string
+	}{
+		{"plain", "just text", "just text"},
+		{"quoted lines", "new\n> old\n> older", "new"},
+		{"wrapped attribution", "new\nOn Tue, 3 Mar 2026, Alice\n<alice@example.com> wrote:\nold", "new"},
+		{"outlook", "new\n-----Original Message-----\nFrom: x", "new"},
+		{"signature", "new\n-- \nAlice", "new"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := stripQuotedReply(tt.in); got != tt.want {
+				t.Fatalf("stripQuotedReply() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestExtractEmailTextMultipart(t *testing.T) {
+	body := "--b\r\nContent-Type: text/html\r\n\r\n<p>hi</p>\r\n--b\r\nContent-Type: text/plain\r\nContent-Transfer-Encoding: quoted-printable\r\n\r\nhello=20world\r\n--b--\r\n"
+	text, err := extractEmailText(`multipart/alternative; boundary="b"`, "", strings.NewReader(body))
+	if err != nil {
+		t.Fatalf("extractEmailText: %v", err)
+	}
+	if strings.TrimSpace(text) != "hello world" {
+		t.Fatalf("unexpected text: %q", text)
+	}
+}
@@ -0,0 +1,167 @@
+package channels
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"mime"
+	"net/http"
+	"path"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/local/picobot/internal/chat"
+	"github.com/local/picobot/internal/config"
+)
+
+// DefaultInboundMediaMaxBytes is the largest inbound attachment accepted
+// when no limit is configured.
+const DefaultInboundMediaMaxBytes = 20 << 20
+
+// MediaGuard screens inbound attachments by size and MIME type before they
+// are handed to the agent (and so before anything downloads them).
+type MediaGuard struct {
+	MaxBytes int64
+	// AllowedTypes are MIME types or "type/*" patterns; empty allows all.
+	AllowedTypes []string
+	// InlineTextMaxBytes, if set, inlines the content of text attachments
+	// (.txt, .md, .json, text/*) up to this size into the message, so the
+	// agent can read them without a tool call.
+	InlineTextMaxBytes int64
+	// Client is used to HEAD attachments whose size and type the platform
+	// didn't report, and to download inlined text. Defaults to a client with
+	// a short timeout.
+	Client *http.Client
+}
+
+// NewMediaGuard builds a guard from config; nil uses the defaults.
+func NewMediaGuard(cfg *config.InboundMediaConfig) MediaGuard {
+	g := MediaGuard{MaxBytes: DefaultInboundMediaMaxBytes}
+	if cfg != nil {
+		if cfg.MaxBytes > 0 {
+			g.MaxBytes = cfg.MaxBytes
+		}
+		g.AllowedTypes = cfg.AllowedTypes
+		g.InlineTextMaxBytes = cfg.InlineTextMaxBytes
+	}
+	return g
+}
+
+// Check returns a user-facing reason if an attachment of the given size (-1
+// if unknown) and MIME type must be rejected.
+func (g MediaGuard) Check(size int64, mimeType string) error {
+	if g.MaxBytes > 0 && size > g.MaxBytes {
+		return fmt.Errorf("it is %s, over the %s limit", formatBytes(size), formatBytes(g.MaxBytes))
+	}
+	if len(g.AllowedTypes) == 0 {
+		return nil
+	}
+	mt, _, _ := mime.ParseMediaType(mimeType)
+	for _, a := range g.AllowedTypes {
+		if a == mt || (strings.HasSuffix(a, "/*") && mt != "" && strings.HasPrefix(mt, strings.TrimSuffix(a, "*"))) {
+			return nil
+		}
+	}
+	if mt == "" {
+		mt = "an unknown type"
+	}
+	return fmt.Errorf("%s files are not accepted", mt)
+}
+
+// inlineTextExts are the file extensions treated as text even when the
+// platform reports no or a generic MIME type.
+var inlineTextExts = map[string]bool{".txt": true, ".md": true, ".json": true}
+
+// inlineText downloads a small text attachment and returns it formatted for
+// the message. ok is false if inlining is off, the attachment isn't text or
+// is too large, or it can't be fetched; the caller then just links it.
+func (g MediaGuard) inlineText(ctx context.Context, url, name string, size int64, mimeType string) (text string, ok bool) {
+	if g.InlineTextMaxBytes <= 0 || size > g.InlineTextMaxBytes {
+		return "", false
+	}
+	mt, _, _ := mime.ParseMediaType(mimeType)
+	if !strings.HasPrefix(mt, "text/") && mt != "application/json" && !inlineTextExts[strings.ToLower(path.Ext(name))] {
+		return "", false
+	}
+	client := g.Client
+	if client == nil {
+		client = &http.Client{Timeout: 10 * time.Second}
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return "", false
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		log.Printf("could not download attachment %s: %v", name, err)
+		return "", false
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 400 {
+		log.Printf("could not download attachment %s: %s", name, resp.Status)
+		return "", false
+	}
+	b, err := io.ReadAll(io.LimitReader(resp.Body, g.InlineTextMaxBytes+1))
+	if err != nil || int64(len(b)) > g.InlineTextMaxBytes || !utf8.Valid(b) {
+		return "", false
+	}
+	return fmt.Sprintf("[attachment %s]\n```\n%s\n```", name, strings.TrimRight(string(b), "\n")), true
+}
+
+// Probe asks the server for an attachment's size and type with a HEAD
+// request. Size is -1 if the server doesn't say.
+func (g MediaGuard) Probe(ctx context.Context, url string) (int64, string, error) {
+	client := g.Client
+	if client == nil {
+		client = &http.Client{Timeout: 10 * time.Second}
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodHead, url, nil)
+	if err != nil {
+		return -1, "", err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return -1, "", err
+	}
+	resp.Body.Close()
+	if resp.StatusCode >= 400 {
+		return -1, "", fmt.Errorf("HEAD %s: %s", url, resp.Status)
+	}
+	return resp.ContentLength, resp.Header.Get("Content-Type"), nil
+}
+
+// admit checks one attachment. If it is rejected, the user is sent a note and
+// the returned marker should replace the attachment in the message, so the
+// agent knows something was dropped.
+func (g MediaGuard) admit(ctx context.Context, hub *chat.Hub, channel, chatID, name, url string, size int64, mimeType string) (marker string, ok bool) {
+	if size <= 0 && mimeType == "" {
+		var err error
+		if size, mimeType, err = g.Probe(ctx, url); err != nil {
+			log.Printf("%s: could not check attachment %s: %v", channel, name, err)
+		}
+	}
+	err := g.Check(size, mimeType)
+	if err == nil {
+		return "", true
+	}
+	log.Printf("%s: rejected attachment %s in %s: %v", channel, name, chatID, err)
+	note := fmt.Sprintf("I couldn't accept the attachment %s: %v.", name, err)
+	select {
+	case hub.Out <- chat.Outbound{Channel: channel, ChatID: chatID, Content: note}:
+	default:
+		log.Printf("%s: outbound channel full, dropping attachment note", channel)
+	}
+	return fmt.Sprintf("[attachment rejected: %s (%v)]", name, err), false
+}
+
+func formatBytes(n int64) string {
+	switch {
+	case n >= 1<<20:
+		return fmt.Sprintf("%.1f MB", float64(n)/(1<<20))
+	case n >= 1<<10:
+		return fmt.Sprintf("%.1f KB", float64(n)/(1<<10))
+	}
+	return fmt.Sprintf("%d bytes", n)
+}
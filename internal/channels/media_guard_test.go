@@ -0,0 +1,125 @@
+package channels
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/local/picobot/internal/chat"
+	"github.com/local/picobot/internal/config"
+)
+
+type nopDiscordSender struct{}
+
+func (nopDiscordSender) ChannelMessageSend(string, string, ...discordgo.RequestOption) (*discordgo.Message, error) {
+	return &discordgo.Message{}, nil
+}
+func (nopDiscordSender) ChannelMessageSendReply(string, string, *discordgo.MessageReference, ...discordgo.RequestOption) (*discordgo.Message, error) {
+	return &discordgo.Message{}, nil
+}
+func (nopDiscordSender) ChannelTyping(string, ...discordgo.RequestOption) error { return nil }
+
+func TestDiscordRejectsOversizedAttachment(t *testing.T) {
+	hub := chat.NewHub(10)
+	c := newDiscordClient(context.Background(), nopDiscordSender{}, hub, "bot", nil)
+	c.media = NewMediaGuard(&config.InboundMediaConfig{MaxBytes: 1 << 20})
+	defer c.stopAllTyping()
+
+	c.handleMessage(nil, &discordgo.MessageCreate{Message: &discordgo.Message{
+		ChannelID: "c1",
+		Author:    &discordgo.User{ID: "u1"},
+		Content:   "look at this",
+		Attachments: []*discordgo.MessageAttachment{
+			{Filename: "big.zip", URL: "https://cdn.example/big.zip", Size: 5 << 20, ContentType: "application/zip"},
+			{Filename: "ok.png", URL: "https://cdn.example/ok.png", Size: 1000, ContentType: "image/png"},
+		},
+	}})
+
+	select {
+	case out := <-hub.Out:
+		if out.Channel != "discord" || out.ChatID != "c1" || !strings.Contains(out.Content, "big.zip") || !strings.Contains(out.Content, "5.0 MB") {
+			t.Fatalf("unexpected rejection note: %+v", out)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("expected a note telling the user the attachment was rejected")
+	}
+	in := <-hub.In
+	if strings.Contains(in.Content, "cdn.example/big.zip") || !strings.Contains(in.Content, "[attachment rejected: big.zip") {
+		t.Fatalf("oversized attachment should be replaced by a rejection marker, got %q", in.Content)
+	}
+	if !strings.Contains(in.Content, "[attachment: https://cdn.example/ok.png]") {
+		t.Fatalf("small attachment should be passed through, got %q", in.Content)
+	}
+}
+
+func TestMediaGuardCheckTypes(t *testing.T) {
+	g := NewMediaGuard(&config.InboundMediaConfig{AllowedTypes: []string{"image/*", "application/pdf"}})
+	for mt, ok := range map[string]bool{
+		"image/png": true, "application/pdf": true, "text/plain; charset=utf-8": false, "": false,
+	} {
+		if err := g.Check(10, mt); (err == nil) != ok {
+			t.Errorf("Check(%q) = %v, want allowed=%v", mt, err, ok)
+		}
+	}
+}
+
+func TestMediaGuardProbesUnknownAttachments(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodHead {
+			t.Errorf("method = %s, want HEAD", r.Method)
+		}
+		w.Header().Set("Content-Type", "video/mp4")
+		w.Header().Set("Content-Length", "50000000")
+	}))
+	defer srv.Close()
+
+	hub := chat.NewHub(10)
+	g := MediaGuard{MaxBytes: DefaultInboundMediaMaxBytes, Client: srv.Client()}
+	marker, ok := g.admit(context.Background(), hub, "slack", "D1", "clip.mp4", srv.URL, 0, "")
+	if ok || !strings.Contains(marker, "over the 20.0 MB limit") {
+		t.Fatalf("admit = %q, %v; want a size rejection", marker, ok)
+	}
+	if out := <-hub.Out; out.ChatID != "D1" {
+		t.Fatalf("note sent to %q, want D1", out.ChatID)
+	}
+}
+
+func TestDiscordInlinesSmallTextAttachment(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/notes.md":
+			w.Write([]byte("# Shopping\n- milk\n"))
+		default:
+			w.Write([]byte("binary-ish"))
+		}
+	}))
+	defer srv.Close()
+
+	hub := chat.NewHub(10)
+	c := newDiscordClient(context.Background(), nopDiscordSender{}, hub, "bot", nil)
+	c.media = MediaGuard{MaxBytes: DefaultInboundMediaMaxBytes, InlineTextMaxBytes: 1024, Client: srv.Client()}
+	defer c.stopAllTyping()
+
+	c.handleMessage(nil, &discordgo.MessageCreate{Message: &discordgo.Message{
+		ChannelID: "c1",
+		Author:    &discordgo.User{ID: "u1"},
+		Content:   "what's on my list?",
+		Attachments: []*discordgo.MessageAttachment{
+			{Filename: "notes.md", URL: srv.URL + "/notes.md", Size: 18, ContentType: "text/markdown"},
+			{Filename: "photo.png", URL: srv.URL + "/photo.png", Size: 10, ContentType: "image/png"},
+			{Filename: "big.txt", URL: srv.URL + "/big.txt", Size: 4096, ContentType: "text/plain"},
+		},
+	}})
+
+	in := <-hub.In
+	if !strings.Contains(in.Content, "[attachment notes.md]\n```\n# Shopping\n- milk\n```") {
+		t.Fatalf("text attachment should be inlined, got %q", in.Content)
+	}
+	if !strings.Contains(in.Content, "[attachment: "+srv.URL+"/photo.png]") || !strings.Contains(in.Content, "[attachment: "+srv.URL+"/big.txt]") {
+		t.Fatalf("non-text and oversized attachments should stay links, got %q", in.Content)
+	}
+}
@@ -23,7 +23,9 @@ type slackPoster interface {
 // StartSlack starts a Slack bot using Socket Mode.
 // allowUsers restricts which Slack user IDs may send messages; empty means allow all.
 // allowChannels restricts which Slack channel IDs may send messages; empty means allow all.
-func StartSlack(ctx context.Context, hub *chat.Hub, appToken, botToken string, allowUsers, allowChannels []string) error {
+// trigger can make direct messages require a mention or trigger word; in
+// channels the bot only sees messages that mention it.
+func StartSlack(ctx context.Context, hub *chat.Hub, appToken, botToken string, allowUsers, allowChannels []string, media MediaGuard, trigger Trigger) error {
 	if appToken == "" {
 		return fmt.Errorf("slack app token not provided")
 	}
@@ -52,6 +54,10 @@ func StartSlack(ctx context.Context, hub *chat.Hub, appToken, botToken string, a
 
 	socketClient := socketmode.New(api)
 	client := newSlackClient(ctx, socketClient, api, hub, auth.UserID, allowUsers, allowChannels)
+	client.media = media
+	client.trigger = trigger
+	registerAllowList("slack", client.allowedUsers)
+	registerAllowList("slack:channels", client.allowedChans)
 
 	go client.runOutbound()
 	go client.runEvents()
@@ -76,29 +82,23 @@ type slackClient struct {
 	hub          *chat.Hub
 	outCh        <-chan chat.Outbound
 	botID        string
-	allowedUsers map[string]struct{}
-	allowedChans map[string]struct{}
+	allowedUsers *allowList
+	allowedChans *allowList
+	media        MediaGuard
+	trigger      Trigger
 	ctx          context.Context
 }
 
 func newSlackClient(ctx context.Context, socket *socketmode.Client, poster slackPoster, hub *chat.Hub, botID string, allowUsers, allowChannels []string) *slackClient {
-	allowedUsers := make(map[string]struct{}, len(allowUsers))
-	for _, id := range allowUsers {
-		allowedUsers[id] = struct{}{}
-	}
-	allowedChans := make(map[string]struct{}, len(allowChannels))
-	for _, id := range allowChannels {
-		allowedChans[id] = struct{}{}
-	}
-
 	return &slackClient{
 		socket:       socket,
 		poster:       poster,
 		hub:          hub,
 		outCh:        hub.Subscribe("slack"),
 		botID:        botID,
-		allowedUsers: allowedUsers,
-		allowedChans: allowedChans,
+		allowedUsers: newAllowList(allowUsers, nil),
+		allowedChans: newAllowList(allowChannels, nil),
+		media:        MediaGuard{MaxBytes: DefaultInboundMediaMaxBytes},
 		ctx:          ctx,
 	}
 }
@@ -163,7 +163,7 @@ func (c *slackClient) handleMention(ev *slackevents.AppMentionEvent) {
 
 	log.Printf("slack: mention from %s in %s: %s", ev.User, ev.Channel, truncate(content, 50))
 
-	c.hub.In <- chat.Inbound{
+	if err := c.hub.Receive(c.ctx, chat.Inbound{
 		Channel:   "slack",
 		SenderID:  ev.User,
 		ChatID:    chatID,
@@ -173,8 +173,11 @@ func (c *slackClient) handleMention(ev *slackevents.AppMentionEvent) {
 			"channel_id": ev.Channel,
 			"team_id":    teamID,
 			"thread_ts":  threadTS,
+			"message_id": ev.TimeStamp,
 			"is_dm":      false,
 		},
+	}); err != nil {
+		log.Printf("slack: dropping message from %s: %v", ev.User, err)
 	}
 }
 
@@ -197,19 +200,37 @@ func (c *slackClient) handleMessage(ev *slackevents.MessageEvent) {
 	}
 
 	content := strings.TrimSpace(ev.Text)
-	content = appendSlackAttachments(content, ev.Files)
+	if c.trigger.RequireInDMs {
+		mentioned := c.botID != "" && strings.Contains(content, "<@"+c.botID+">")
+		stripped, triggered := c.trigger.strip(stripSlackMention(content, c.botID))
+		if !mentioned && !triggered {
+			return
+		}
+		content = strings.TrimSpace(stripped)
+	}
+	threadTS := ev.ThreadTimeStamp
+	chatID := formatSlackChatID(ev.Channel, threadTS)
+
+	var files []slackevents.File
+	for _, f := range ev.Files {
+		url := firstNonEmpty(f.URLPrivate, f.URLPrivateDownload, f.Permalink)
+		if marker, ok := c.media.admit(c.ctx, c.hub, "slack", chatID, f.Name, url, int64(f.Size), f.Mimetype); !ok {
+			content += "\n" + marker
+			continue
+		}
+		files = append(files, f)
+	}
+	content = appendSlackAttachments(content, files)
 	content = strings.TrimSpace(content)
 	if content == "" {
 		return
 	}
 
-	threadTS := ev.ThreadTimeStamp
-	chatID := formatSlackChatID(ev.Channel, threadTS)
 	teamID := firstNonEmpty(ev.SourceTeam, ev.UserTeam)
 
 	log.Printf("slack: message from %s in %s: %s", ev.User, ev.Channel, truncate(content, 50))
 
-	c.hub.In <- chat.Inbound{
+	if err := c.hub.Receive(c.ctx, chat.Inbound{
 		Channel:   "slack",
 		SenderID:  ev.User,
 		ChatID:    chatID,
@@ -219,8 +240,11 @@ func (c *slackClient) handleMessage(ev *slackevents.MessageEvent) {
 			"channel_id": ev.Channel,
 			"team_id":    teamID,
 			"thread_ts":  threadTS,
+			"message_id": ev.TimeStamp,
 			"is_dm":      isDM,
 		},
+	}); err != nil {
+		log.Printf("slack: dropping message from %s: %v", ev.User, err)
 	}
 }
 
@@ -236,6 +260,10 @@ func (c *slackClient) runOutbound() {
 				log.Printf("slack: invalid chat ID %q", out.ChatID)
 				continue
 			}
+			if threadTS == "" {
+				// Replying to a message outside a thread starts one under it.
+				threadTS = out.ReplyTo
+			}
 			for _, chunk := range splitMessage(out.Content, 4000) {
 				opts := []slack.MsgOption{slack.MsgOptionText(chunk, false)}
 				if threadTS != "" {
@@ -250,33 +278,15 @@ func (c *slackClient) runOutbound() {
 }
 
 func (c *slackClient) isAllowed(userID, channelID string, isDM bool) bool {
-	if len(c.allowedUsers) > 0 {
-		if _, ok := c.allowedUsers[userID]; !ok {
-			return false
-		}
+	if !c.allowedUsers.permits(userID) {
+		return false
 	}
-	if isDM {
-		return true
-	}
-	if len(c.allowedChans) > 0 {
-		if _, ok := c.allowedChans[channelID]; !ok {
-			return false
-		}
-	}
-	return true
+	return isDM || c.allowedChans.permits(channelID)
 }
 
 func (c *slackClient) logUnauthorized(userID, channelID string, isDM bool) {
-	userAllowed := true
-	channelAllowed := true
-	if len(c.allowedUsers) > 0 {
-		_, userAllowed = c.allowedUsers[userID]
-	}
-	if isDM {
-		channelAllowed = true
-	} else if len(c.allowedChans) > 0 {
-		_, channelAllowed = c.allowedChans[channelID]
-	}
+	userAllowed := c.allowedUsers.permits(userID)
+	channelAllowed := isDM || c.allowedChans.permits(channelID)
 	log.Printf("slack: dropped message: user allowed=%t channel allowed=%t user=%s channel=%s", userAllowed, channelAllowed, userID, channelID)
 }
 
@@ -33,11 +33,8 @@ func StartTelegramWithBase(ctx context.Context, hub *chat.Hub, token, base strin
 		return fmt.Errorf("base URL is required")
 	}
 
-	// Build a fast lookup set for allowed user IDs.
-	allowed := make(map[string]struct{}, len(allowFrom))
-	for _, id := range allowFrom {
-		allowed[id] = struct{}{}
-	}
+	allowed := newAllowList(allowFrom, nil)
+	registerAllowList("telegram", allowed)
 
 	client := &http.Client{Timeout: 45 * time.Second}
 
@@ -97,19 +94,22 @@ func StartTelegramWithBase(ctx context.Context, hub *chat.Hub, token, base strin
 					fromID = strconv.FormatInt(m.From.ID, 10)
 				}
 				// Enforce allowFrom: if the list is non-empty, reject unknown senders.
-				if len(allowed) > 0 {
-					if _, ok := allowed[fromID]; !ok {
-						log.Printf("telegram: dropping message from unauthorized user %s", fromID)
-						continue
-					}
+				if !allowed.permits(fromID) {
+					log.Printf("telegram: dropping message from unauthorized user %s", fromID)
+					continue
 				}
 				chatID := strconv.FormatInt(m.Chat.ID, 10)
-				hub.In <- chat.Inbound{
+				if err := hub.Receive(ctx, chat.Inbound{
 					Channel:   "telegram",
 					SenderID:  fromID,
 					ChatID:    chatID,
 					Content:   m.Text,
 					Timestamp: time.Now(),
+					Metadata: map[string]interface{}{
+						"message_id": strconv.FormatInt(m.MessageID, 10),
+					},
+				}); err != nil {
+					log.Printf("telegram: dropping message from %s: %v", fromID, err)
 				}
 			}
 		}
@@ -132,6 +132,10 @@ func StartTelegramWithBase(ctx context.Context, hub *chat.Hub, token, base strin
 				v := url.Values{}
 				v.Set("chat_id", out.ChatID)
 				v.Set("text", out.Content)
+				if id, err := strconv.ParseInt(out.ReplyTo, 10, 64); err == nil {
+					// Still send the reply if the original was deleted.
+					v.Set("reply_parameters", fmt.Sprintf(`{"message_id":%d,"allow_sending_without_reply":true}`, id))
+				}
 				resp, err := client.PostForm(u, v)
 				if err != nil {
 					log.Printf("telegram sendMessage error: %v", err)
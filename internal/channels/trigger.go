@@ -0,0 +1,39 @@
+package channels
+
+import "strings"
+
+// Trigger decides when a message addresses the bot. By default direct
+// messages always do, and group messages only when the bot is mentioned.
+type Trigger struct {
+	// RequireInDMs makes direct messages need a mention or trigger word too,
+	// for DMs shared with scripts or other users.
+	RequireInDMs bool
+	// Words are prefixes (such as "!bot" or the bot's name) that address the
+	// bot like a mention does. Matching ignores case.
+	Words []string
+	// BareReply, if set, answers a message that addresses the bot but says
+	// nothing else (e.g. just "@picobot"), instead of ignoring it. The reply
+	// is sent directly, without a turn of the agent.
+	BareReply string
+}
+
+// DefaultBareReply is the usual BareReply.
+const DefaultBareReply = "Hi! How can I help?"
+
+// strip removes a leading trigger word (and any following punctuation such
+// as "picobot, ...") from content, reporting whether one was found.
+func (t Trigger) strip(content string) (string, bool) {
+	trimmed := strings.TrimSpace(content)
+	for _, w := range t.Words {
+		if w == "" || len(trimmed) < len(w) || !strings.EqualFold(trimmed[:len(w)], w) {
+			continue
+		}
+		rest := trimmed[len(w):]
+		// "picobot" must not match "picobots are ...".
+		if rest != "" && !strings.ContainsRune(" \t\n,:;!?.", rune(rest[0])) {
+			continue
+		}
+		return strings.TrimLeft(rest, " \t\n,:;"), true
+	}
+	return content, false
+}
@@ -0,0 +1,73 @@
+package channels
+
+import (
+	"context"
+	"testing"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/local/picobot/internal/chat"
+	"github.com/slack-go/slack/slackevents"
+)
+
+func TestTriggerStrip(t *testing.T) {
+	tr := Trigger{Words: []string{"!bot", "Picobot"}}
+	for in, want := range map[string]string{
+		"!bot status":          "status",
+		"picobot, what's up?":  "what's up?",
+		"PICOBOT: hi":          "hi",
+		"picobots are neat":    "",
+		"hello picobot":        "",
+		"!botany is a science": "",
+	} {
+		got, ok := tr.strip(in)
+		if ok != (want != "") || (ok && got != want) {
+			t.Errorf("strip(%q) = %q, %v; want %q", in, got, ok, want)
+		}
+	}
+}
+
+func TestDiscordRequireMentionInDMs(t *testing.T) {
+	hub := chat.NewHub(10)
+	c := newDiscordClient(context.Background(), nopDiscordSender{}, hub, "bot", nil)
+	c.trigger = Trigger{RequireInDMs: true, Words: []string{"!bot"}}
+	defer c.stopAllTyping()
+	dm := func(content string, mentions ...*discordgo.User) {
+		c.handleMessage(nil, &discordgo.MessageCreate{Message: &discordgo.Message{
+			ChannelID: "dm1", Author: &discordgo.User{ID: "u1"}, Content: content, Mentions: mentions,
+		}})
+	}
+
+	dm("deploy finished")
+	select {
+	case in := <-hub.In:
+		t.Fatalf("un-triggered DM should be ignored, got %q", in.Content)
+	default:
+	}
+
+	dm("!bot status please")
+	if in := <-hub.In; in.Content != "status please" {
+		t.Fatalf("trigger word should be stripped, got %q", in.Content)
+	}
+	dm("<@bot> hello", &discordgo.User{ID: "bot"})
+	if in := <-hub.In; in.Content != "hello" {
+		t.Fatalf("mention should address the bot, got %q", in.Content)
+	}
+}
+
+func TestSlackRequireMentionInDMs(t *testing.T) {
+	hub := chat.NewHub(10)
+	c := newSlackClient(context.Background(), nil, &mockSlackPoster{}, hub, "UBOT", nil, nil)
+	c.trigger = Trigger{RequireInDMs: true}
+
+	c.handleMessage(&slackevents.MessageEvent{User: "U1", Channel: "D1", ChannelType: "im", Text: "cron output"})
+	select {
+	case in := <-hub.In:
+		t.Fatalf("un-triggered DM should be ignored, got %q", in.Content)
+	default:
+	}
+
+	c.handleMessage(&slackevents.MessageEvent{User: "U1", Channel: "D1", ChannelType: "im", Text: "<@UBOT> summarize"})
+	if in := <-hub.In; in.Content != "summarize" {
+		t.Fatalf("mentioned DM should be delivered without the mention, got %q", in.Content)
+	}
+}
@@ -113,6 +113,7 @@ func StartWhatsApp(ctx context.Context, hub *chat.Hub, dbPath string, allowFrom
 	own := *rawClient.Store.ID
 	ownLID := rawClient.Store.GetLID()
 	waClient := newWhatsAppClient(ctx, sender, hub, allowFrom, own, ownLID)
+	registerAllowList("whatsapp", waClient.allowed)
 	rawClient.AddEventHandler(waClient.handleEvent)
 
 	if err := rawClient.Connect(); err != nil {
@@ -223,7 +224,7 @@ type whatsappClient struct {
 	sender     whatsappSender
 	hub        *chat.Hub
 	outCh      <-chan chat.Outbound
-	allowed    map[string]struct{}
+	allowed    *allowList
 	own        types.JID // phone JID  (e.g. 85298765432@s.whatsapp.net)
 	ownLID     types.JID // LID JID    (e.g. 169032883908635@lid) — may be empty
 	ctx        context.Context
@@ -236,15 +237,11 @@ type whatsappClient struct {
 // ownJID  = rawClient.Store.ID   (phone JID)  — pass types.JID{} in tests.
 // ownLID  = rawClient.Store.GetLID() (LID JID) — pass types.JID{} in tests.
 func newWhatsAppClient(ctx context.Context, sender whatsappSender, hub *chat.Hub, allowFrom []string, ownJID, ownLID types.JID) *whatsappClient {
-	allowed := make(map[string]struct{}, len(allowFrom))
-	for _, num := range allowFrom {
-		allowed[num] = struct{}{}
-	}
 	return &whatsappClient{
 		sender:     sender,
 		hub:        hub,
 		outCh:      hub.Subscribe("whatsapp"),
-		allowed:    allowed,
+		allowed:    newAllowList(allowFrom, nil),
 		own:        ownJID,
 		ownLID:     ownLID,
 		ctx:        ctx,
@@ -296,12 +293,10 @@ func (c *whatsappClient) handleMessage(msg *events.Message) {
 			return
 		}
 		senderID := msg.Info.Sender.User
-		if len(c.allowed) > 0 {
-			if _, ok := c.allowed[senderID]; !ok {
-				log.Printf("whatsapp: dropped message from unauthorized sender %s (add '%s' to allowFrom to permit)",
-					msg.Info.Sender.String(), senderID)
-				return
-			}
+		if !c.allowed.permits(senderID) {
+			log.Printf("whatsapp: dropped message from unauthorized sender %s (add '%s' to allowFrom to permit)",
+				msg.Info.Sender.String(), senderID)
+			return
 		}
 	}
 
@@ -323,7 +318,7 @@ func (c *whatsappClient) handleMessage(msg *events.Message) {
 
 	c.startTyping(msg.Info.Chat)
 
-	c.hub.In <- chat.Inbound{
+	if err := c.hub.Receive(c.ctx, chat.Inbound{
 		Channel:   "whatsapp",
 		SenderID:  senderID,
 		ChatID:    chatID,
@@ -333,6 +328,8 @@ func (c *whatsappClient) handleMessage(msg *events.Message) {
 			"message_id": msg.Info.ID,
 			"is_group":   msg.Info.IsGroup,
 		},
+	}); err != nil {
+		log.Printf("whatsapp: dropping message from %s: %v", senderID, err)
 	}
 }
 
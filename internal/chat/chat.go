@@ -2,7 +2,11 @@ package chat
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"log"
+	"maps"
+	"strings"
 	"sync"
 	"time"
 )
@@ -20,14 +24,42 @@ type Inbound struct {
 
 // Outbound represents a message produced by the agent.
 type Outbound struct {
-	Channel  string
-	ChatID   string
-	Content  string
-	ReplyTo  string
-	Media    []string
+	Channel string
+	ChatID  string
+	Content string
+	// ReplyTo, if set, is the platform ID of the message this one answers;
+	// channels that support it send the reply threaded to that message.
+	ReplyTo string
+	Media   []string
+	// Kind is empty for messages meant for the user. Other kinds (see
+	// KindThinking) are side-channel events that only reach subscribers
+	// which asked for them.
+	Kind     string
 	Metadata map[string]interface{}
+	// NoFooter leaves the channel's footer (see Hub.SetFooter) off this
+	// message.
+	NoFooter bool
+	// Progress marks a transient status line sent while a turn runs, such
+	// as tool activity. Channels where every message is costly to the
+	// user (e.g. email) skip it and send only the reply.
+	Progress bool
 }
 
+// KindThinking marks an Outbound carrying the model's reasoning, stripped
+// from the answer, for UIs that can show it collapsed.
+const KindThinking = "thinking"
+
+// KindTool marks an Outbound reporting tool activity during a turn, so UIs
+// can show progress. Metadata holds "tool" (the tool name) and "status"
+// ("started", "finished" or "failed"); Content is a short description.
+const KindTool = "tool"
+
+// KindDelta marks an Outbound carrying a reply while it is being generated,
+// for channels and UIs that show it growing (e.g. by editing a message).
+// Content is the whole text so far, not just the new part; the finished
+// reply follows as an ordinary message.
+const KindDelta = "delta"
+
 // Hub provides simple buffered channels for inbound/outbound messages.
 //
 // When only one channel (e.g. Telegram) is active, goroutines may read from
@@ -38,33 +70,213 @@ type Hub struct {
 	In  chan Inbound
 	Out chan Outbound
 
-	subMu sync.RWMutex
-	subs  map[string]chan Outbound
+	subMu       sync.RWMutex
+	subs        map[string]*subscriber
+	observers   []chan Outbound
+	mediaLimits map[string]MediaLimits
+	footers     map[string]string
+	policy      QueuePolicy
+	stats       hubStats
+}
+
+// ErrQueueFull is returned by Send and Receive when a message could not be
+// queued under the hub's QueuePolicy.
+var ErrQueueFull = errors.New("message queue full")
+
+// QueuePolicy decides what happens when a queue (In, Out or a subscriber's)
+// is full. By default a sender waits until there is room or its context is
+// done.
+type QueuePolicy struct {
+	// Timeout, if set, bounds the wait; the message is then dropped.
+	Timeout time.Duration
+	// Drop discards the message at once instead of waiting.
+	Drop bool
+}
+
+type subscriber struct {
+	ch    chan Outbound
+	kinds map[string]bool // event kinds delivered in addition to plain messages
 }
 
 // NewHub constructs a new Hub with the given buffer size.
 func NewHub(buffer int) *Hub {
 	return &Hub{
-		In:   make(chan Inbound, buffer),
-		Out:  make(chan Outbound, buffer),
-		subs: make(map[string]chan Outbound),
+		In:          make(chan Inbound, buffer),
+		Out:         make(chan Outbound, buffer),
+		subs:        make(map[string]*subscriber),
+		mediaLimits: maps.Clone(DefaultMediaLimits),
+		footers:     make(map[string]string),
+	}
+}
+
+// SetMediaLimits sets the attachment limits checked for a channel's outbound
+// messages, replacing the default from DefaultMediaLimits.
+func (h *Hub) SetMediaLimits(channel string, limits MediaLimits) {
+	h.subMu.Lock()
+	h.mediaLimits[channel] = limits
+	h.subMu.Unlock()
+}
+
+// SetFooter sets text appended to every message for the user on channel,
+// such as an attribution or a disclaimer. Channel "" sets the default for
+// channels without a footer of their own; setting "" for a channel turns the
+// default off there. The footer follows a blank line, so channels that split
+// long messages at line breaks keep it whole in the last part.
+func (h *Hub) SetFooter(channel, footer string) {
+	h.subMu.Lock()
+	h.footers[channel] = footer
+	h.subMu.Unlock()
+}
+
+// addFooter appends out's channel footer unless out is an event, is empty
+// or asked for none.
+func (h *Hub) addFooter(out Outbound) Outbound {
+	if out.Kind != "" || out.NoFooter || strings.TrimSpace(out.Content) == "" {
+		return out
+	}
+	h.subMu.RLock()
+	footer, ok := h.footers[out.Channel]
+	if !ok {
+		footer = h.footers[""]
+	}
+	h.subMu.RUnlock()
+	if footer != "" {
+		out.Content += "\n\n" + footer
+	}
+	return out
+}
+
+// checkMedia validates out's attachments against its channel's limits. If
+// they don't fit, the attachments are dropped and the reason is appended to
+// the text, so the user sees why instead of the channel's API rejecting the
+// whole message.
+func (h *Hub) checkMedia(out Outbound) Outbound {
+	if len(out.Media) == 0 {
+		return out
+	}
+	h.subMu.RLock()
+	limits, ok := h.mediaLimits[out.Channel]
+	h.subMu.RUnlock()
+	if !ok {
+		return out
+	}
+	if err := limits.Validate(out.Media); err != nil {
+		log.Printf("hub: not sending attachments to %s:%s: %v", out.Channel, out.ChatID, err)
+		out.Media = nil
+		out.Content = strings.TrimSpace(out.Content + "\n\n(Attachments not sent: " + err.Error() + ")")
+	}
+	return out
+}
+
+// SetQueuePolicy sets how Send, Receive and the router behave when a queue
+// is full.
+func (h *Hub) SetQueuePolicy(p QueuePolicy) {
+	h.subMu.Lock()
+	h.policy = p
+	h.subMu.Unlock()
+}
+
+// Send queues an outbound message, subject to the QueuePolicy. It returns
+// ErrQueueFull (counted in Stats) if the message had to be dropped, or
+// ctx's error if ctx was done first.
+func (h *Hub) Send(ctx context.Context, out Outbound) error {
+	err := enqueue(ctx, h, h.Out, out)
+	if errors.Is(err, ErrQueueFull) {
+		h.stats.channel(out.Channel).outboundDropped.Add(1)
+	}
+	return err
+}
+
+// Receive hands an inbound message to the agent, subject to the
+// QueuePolicy. Channels call it instead of writing to In so a stalled
+// agent turns into an error rather than a goroutine blocked forever.
+func (h *Hub) Receive(ctx context.Context, in Inbound) error {
+	err := enqueue(ctx, h, h.In, in)
+	c := h.stats.channel(in.Channel)
+	switch {
+	case err == nil:
+		c.inbound.Add(1)
+	case errors.Is(err, ErrQueueFull):
+		c.inboundDropped.Add(1)
+	}
+	return err
+}
+
+// enqueue sends v on ch according to h's QueuePolicy.
+func enqueue[T any](ctx context.Context, h *Hub, ch chan<- T, v T) error {
+	select {
+	case ch <- v:
+		return nil
+	default:
+	}
+	h.subMu.RLock()
+	p := h.policy
+	h.subMu.RUnlock()
+	if p.Drop {
+		return ErrQueueFull
+	}
+	var timeout <-chan time.Time
+	if p.Timeout > 0 {
+		t := time.NewTimer(p.Timeout)
+		defer t.Stop()
+		timeout = t.C
+	}
+	select {
+	case ch <- v:
+		return nil
+	case <-timeout:
+		return fmt.Errorf("%w after waiting %s", ErrQueueFull, p.Timeout)
+	case <-ctx.Done():
+		return ctx.Err()
 	}
 }
 
 // Subscribe registers a named outbound queue and returns a receive-only channel
 // that will receive every Outbound message whose Channel field matches name.
+// Events with a non-empty Kind are delivered only if listed in kinds.
 // Register all subscribers before calling StartRouter.
-func (h *Hub) Subscribe(name string) <-chan Outbound {
+func (h *Hub) Subscribe(name string, kinds ...string) <-chan Outbound {
+	sub := &subscriber{ch: make(chan Outbound, cap(h.Out)), kinds: make(map[string]bool)}
+	for _, k := range kinds {
+		sub.kinds[k] = true
+	}
+	h.subMu.Lock()
+	h.subs[name] = sub
+	h.subMu.Unlock()
+	return sub.ch
+}
+
+// SubscribeAll returns a channel that receives a copy of every Outbound the
+// router handles, whatever its channel or kind, e.g. for transcript logging.
+// Observers never hold up delivery: if one falls behind, copies it has no
+// room for are dropped. Register observers before calling StartRouter.
+func (h *Hub) SubscribeAll() <-chan Outbound {
 	ch := make(chan Outbound, cap(h.Out))
 	h.subMu.Lock()
-	h.subs[name] = ch
+	h.observers = append(h.observers, ch)
 	h.subMu.Unlock()
 	return ch
 }
 
+// Wants reports whether any subscriber asked for events of kind, or any
+// observer is registered, so producers can skip building events nobody reads.
+func (h *Hub) Wants(kind string) bool {
+	h.subMu.RLock()
+	defer h.subMu.RUnlock()
+	if len(h.observers) > 0 {
+		return true
+	}
+	for _, sub := range h.subs {
+		if sub.kinds[kind] {
+			return true
+		}
+	}
+	return false
+}
+
 // StartRouter reads from Out and dispatches each message to the registered
-// subscriber for its channel. Messages for unregistered channels are dropped
-// with a warning. This must be called after all subscribers are registered.
+// subscriber for its channel, waiting for room as the QueuePolicy allows.
+// Messages for unregistered channels are dropped with a warning. This must be called after all subscribers are registered.
 func (h *Hub) StartRouter(ctx context.Context) {
 	go func() {
 		for {
@@ -76,17 +288,32 @@ func (h *Hub) StartRouter(ctx context.Context) {
 					return
 				}
 				h.subMu.RLock()
-				ch, exists := h.subs[out.Channel]
-				h.subMu.RUnlock()
-				if exists {
+				sub, exists := h.subs[out.Channel]
+				for _, o := range h.observers {
 					select {
-					case ch <- out:
-					case <-ctx.Done():
-						return
+					case o <- out:
+					default:
 					}
-				} else {
+				}
+				h.subMu.RUnlock()
+				if exists && out.Kind != "" && !sub.kinds[out.Kind] {
+					continue
+				}
+				c := h.stats.channel(out.Channel)
+				if !exists {
+					c.outboundDropped.Add(1)
 					log.Printf("hub: no subscriber for channel %q, dropping outbound message", out.Channel)
+					continue
+				}
+				if err := enqueue(ctx, h, sub.ch, h.addFooter(h.checkMedia(out))); err != nil {
+					if ctx.Err() != nil {
+						return
+					}
+					c.outboundDropped.Add(1)
+					log.Printf("hub: dropping outbound message for %s:%s: %v", out.Channel, out.ChatID, err)
+					continue
 				}
+				c.outbound.Add(1)
 			}
 		}
 	}()
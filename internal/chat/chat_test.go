@@ -0,0 +1,255 @@
+package chat
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestRouterDeliversEventKindsOnlyOnRequest(t *testing.T) {
+	h := NewHub(10)
+	plain := h.Subscribe("discord")
+	ui := h.Subscribe("web", KindThinking)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	h.StartRouter(ctx)
+
+	for _, ch := range []string{"discord", "web"} {
+		h.Out <- Outbound{Channel: ch, Content: "reasoning", Kind: KindThinking}
+		h.Out <- Outbound{Channel: ch, Content: "answer"}
+	}
+
+	recv := func(c <-chan Outbound) Outbound {
+		select {
+		case out := <-c:
+			return out
+		case <-time.After(time.Second):
+			t.Fatal("timeout waiting for outbound")
+			return Outbound{}
+		}
+	}
+	if out := recv(plain); out.Kind != "" || out.Content != "answer" {
+		t.Fatalf("discord should only get the answer, got %+v", out)
+	}
+	if out := recv(ui); out.Kind != KindThinking {
+		t.Fatalf("web should get the thinking event first, got %+v", out)
+	}
+	if out := recv(ui); out.Content != "answer" {
+		t.Fatalf("web should then get the answer, got %+v", out)
+	}
+}
+
+func TestMediaLimitsValidate(t *testing.T) {
+	tooMany := make([]string, 11)
+	for i := range tooMany {
+		tooMany[i] = "data:image/png;base64,AAAA"
+	}
+	err := DefaultMediaLimits["discord"].Validate(tooMany)
+	if err == nil || err.Error() != "11 attachments exceed the limit of 10 per message" {
+		t.Fatalf("expected over-limit error, got %v", err)
+	}
+	if err := DefaultMediaLimits["discord"].Validate(tooMany[:10]); err != nil {
+		t.Fatalf("10 attachments should be allowed: %v", err)
+	}
+
+	images := MediaLimits{AllowedTypes: []string{"image/*"}}
+	if err := images.Validate([]string{"https://example.com/a.jpg", "data:image/png;base64,AAAA"}); err != nil {
+		t.Fatalf("images should be allowed: %v", err)
+	}
+	if err := images.Validate([]string{"data:application/pdf;base64,AAAA"}); err == nil {
+		t.Fatal("expected pdf to be rejected")
+	}
+}
+
+func TestRouterDropsOverLimitAttachments(t *testing.T) {
+	h := NewHub(10)
+	ch := h.Subscribe("discord")
+	h.SetMediaLimits("discord", MediaLimits{MaxCount: 1})
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	h.StartRouter(ctx)
+
+	h.Out <- Outbound{Channel: "discord", Content: "charts", Media: []string{"data:image/png;base64,A", "data:image/png;base64,B"}}
+	select {
+	case out := <-ch:
+		if len(out.Media) != 0 {
+			t.Fatalf("expected attachments dropped, got %d", len(out.Media))
+		}
+		if out.Content != "charts\n\n(Attachments not sent: 2 attachments exceed the limit of 1 per message)" {
+			t.Fatalf("unexpected content %q", out.Content)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("timeout waiting for outbound")
+	}
+}
+
+func TestRouterAppendsFooterUnlessSuppressed(t *testing.T) {
+	h := NewHub(10)
+	discord := h.Subscribe("discord")
+	slack := h.Subscribe("slack")
+	email := h.Subscribe("email")
+	h.SetFooter("", "— powered by picobot")
+	h.SetFooter("slack", "_Replies may be inaccurate._")
+	h.SetFooter("email", "")
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	h.StartRouter(ctx)
+
+	recv := func(ch <-chan Outbound) Outbound {
+		t.Helper()
+		select {
+		case out := <-ch:
+			return out
+		case <-time.After(time.Second):
+			t.Fatal("timeout waiting for outbound")
+			return Outbound{}
+		}
+	}
+
+	h.Out <- Outbound{Channel: "discord", Content: "hello"}
+	if got := recv(discord).Content; got != "hello\n\n— powered by picobot" {
+		t.Fatalf("default footer: got %q", got)
+	}
+	h.Out <- Outbound{Channel: "slack", Content: "hello"}
+	if got := recv(slack).Content; got != "hello\n\n_Replies may be inaccurate._" {
+		t.Fatalf("channel footer: got %q", got)
+	}
+	h.Out <- Outbound{Channel: "email", Content: "hello"}
+	if got := recv(email).Content; got != "hello" {
+		t.Fatalf("footer turned off for channel: got %q", got)
+	}
+	h.Out <- Outbound{Channel: "discord", Content: "hello", NoFooter: true}
+	if got := recv(discord).Content; got != "hello" {
+		t.Fatalf("suppressed footer: got %q", got)
+	}
+}
+
+func TestSendTimesOutWhenQueueIsFull(t *testing.T) {
+	h := NewHub(1)
+	h.SetQueuePolicy(QueuePolicy{Timeout: 20 * time.Millisecond})
+	ctx := context.Background()
+
+	if err := h.Send(ctx, Outbound{Channel: "discord", Content: "one"}); err != nil {
+		t.Fatalf("first send should fit: %v", err)
+	}
+	if err := h.Send(ctx, Outbound{Channel: "discord", Content: "two"}); !errors.Is(err, ErrQueueFull) {
+		t.Fatalf("expected ErrQueueFull, got %v", err)
+	}
+	if err := h.Receive(ctx, Inbound{Content: "in"}); err != nil {
+		t.Fatalf("inbound queue has room: %v", err)
+	}
+	if err := h.Receive(ctx, Inbound{Content: "in"}); !errors.Is(err, ErrQueueFull) {
+		t.Fatalf("expected ErrQueueFull for inbound, got %v", err)
+	}
+	if got := h.Dropped(); got != 2 {
+		t.Fatalf("expected 2 dropped messages, got %d", got)
+	}
+}
+
+func TestSendWaitsForContextByDefault(t *testing.T) {
+	h := NewHub(1)
+	h.Out <- Outbound{Content: "filler"}
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if err := h.Send(ctx, Outbound{Content: "blocked"}); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected the context deadline, got %v", err)
+	}
+	if got := h.Dropped(); got != 0 {
+		t.Fatalf("a cancelled send is not a drop, got %d", got)
+	}
+}
+
+func TestRouterCountsDroppedMessages(t *testing.T) {
+	h := NewHub(1)
+	h.SetQueuePolicy(QueuePolicy{Drop: true})
+	ch := h.Subscribe("discord")
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	h.StartRouter(ctx)
+
+	// Nobody reads ch, so once it holds one message the rest are dropped.
+	h.Out <- Outbound{Channel: "discord", Content: "kept"}
+	h.Out <- Outbound{Channel: "discord", Content: "dropped"}
+	h.Out <- Outbound{Channel: "unknown", Content: "no subscriber"}
+	deadline := time.Now().Add(time.Second)
+	for h.Dropped() < 2 && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	if got := h.Dropped(); got != 2 {
+		t.Fatalf("expected 2 dropped messages, got %d", got)
+	}
+	if out := <-ch; out.Content != "kept" {
+		t.Fatalf("unexpected delivered message %q", out.Content)
+	}
+}
+
+func TestStatsCountPerChannel(t *testing.T) {
+	h := NewHub(1)
+	h.SetQueuePolicy(QueuePolicy{Timeout: 50 * time.Millisecond})
+	ch := h.Subscribe("slack")
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	h.StartRouter(ctx)
+
+	if err := h.Receive(ctx, Inbound{Channel: "slack", Content: "hi"}); err != nil {
+		t.Fatal(err)
+	}
+	// The subscriber is never read, so it overflows after the first message.
+	for _, c := range []string{"one", "two", "three"} {
+		if err := h.Send(ctx, Outbound{Channel: "slack", Content: c}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	deadline := time.Now().Add(time.Second)
+	for h.Stats().OutboundDropped < 1 && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	st := h.Stats()
+	slack := st.Channels["slack"]
+	if slack.Inbound != 1 || st.Inbound != 1 {
+		t.Fatalf("expected 1 inbound message, got %+v", st)
+	}
+	if slack.Outbound != 1 {
+		t.Fatalf("expected 1 delivered message, got %+v", slack)
+	}
+	if slack.OutboundDropped < 1 || st.OutboundDropped != slack.OutboundDropped {
+		t.Fatalf("expected the overflow to be counted as dropped, got %+v", st)
+	}
+	if out := <-ch; out.Content != "one" {
+		t.Fatalf("unexpected delivered message %q", out.Content)
+	}
+}
+
+func TestSubscribeAllSeesEveryMessageWithoutBlocking(t *testing.T) {
+	h := NewHub(2)
+	discord := h.Subscribe("discord")
+	all := h.SubscribeAll()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	h.StartRouter(ctx)
+
+	h.Out <- Outbound{Channel: "discord", Content: "one"}
+	h.Out <- Outbound{Channel: "slack", Content: "no subscriber"}
+	for i := 0; i < 5; i++ {
+		// The observer is never read while these go out, so it overflows,
+		// but delivery to discord must go on.
+		h.Out <- Outbound{Channel: "discord", Content: "more"}
+		select {
+		case <-discord:
+		case <-time.After(time.Second):
+			t.Fatal("delivery blocked by a slow observer")
+		}
+	}
+	if out := <-discord; out.Content != "more" {
+		t.Fatalf("expected every message delivered, last got %q", out.Content)
+	}
+
+	if out := <-all; out.Content != "one" {
+		t.Fatalf("observer should see the first message, got %q", out.Content)
+	}
+	if out := <-all; out.Channel != "slack" {
+		t.Fatalf("observer should see messages without a subscriber, got %+v", out)
+	}
+}
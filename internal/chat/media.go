@@ -0,0 +1,69 @@
+package chat
+
+import (
+	"fmt"
+	"mime"
+	"net/url"
+	"path"
+	"strings"
+)
+
+// MediaLimits describes which attachments a channel can deliver with one
+// message. Zero values mean no limit.
+type MediaLimits struct {
+	MaxCount int
+	// AllowedTypes are MIME types or "type/*" patterns, e.g. "image/*".
+	AllowedTypes []string
+}
+
+// DefaultMediaLimits holds the attachment limits of the built-in channels.
+var DefaultMediaLimits = map[string]MediaLimits{
+	"discord":  {MaxCount: 10},
+	"telegram": {MaxCount: 10},
+}
+
+// Validate checks media (data URIs or URLs) against the limits and returns
+// an error describing the first problem found.
+func (l MediaLimits) Validate(media []string) error {
+	if l.MaxCount > 0 && len(media) > l.MaxCount {
+		return fmt.Errorf("%d attachments exceed the limit of %d per message", len(media), l.MaxCount)
+	}
+	if len(l.AllowedTypes) == 0 {
+		return nil
+	}
+	for i, m := range media {
+		mt := mediaType(m)
+		if !typeAllowed(mt, l.AllowedTypes) {
+			if mt == "" {
+				mt = "unknown type"
+			}
+			return fmt.Errorf("attachment %d (%s) is not an allowed type (%s)", i+1, mt, strings.Join(l.AllowedTypes, ", "))
+		}
+	}
+	return nil
+}
+
+// mediaType returns the MIME type of a data URI, or guesses it from a URL's
+// file extension. It returns "" if unknown.
+func mediaType(m string) string {
+	if rest, ok := strings.CutPrefix(m, "data:"); ok {
+		mt, _, _ := strings.Cut(rest, ",")
+		mt, _, _ = strings.Cut(mt, ";")
+		return mt
+	}
+	p := m
+	if u, err := url.Parse(m); err == nil {
+		p = u.Path
+	}
+	mt, _, _ := mime.ParseMediaType(mime.TypeByExtension(path.Ext(p)))
+	return mt
+}
+
+func typeAllowed(mt string, allowed []string) bool {
+	for _, a := range allowed {
+		if a == mt || (strings.HasSuffix(a, "/*") && mt != "" && strings.HasPrefix(mt, strings.TrimSuffix(a, "*"))) {
+			return true
+		}
+	}
+	return false
+}
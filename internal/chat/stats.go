@@ -0,0 +1,71 @@
+package chat
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+// Stats is a snapshot of the hub's message counters.
+type Stats struct {
+	Inbound         uint64 // messages queued for the agent
+	InboundDropped  uint64 // inbound messages dropped because In was full
+	Outbound        uint64 // messages delivered to a channel's subscriber
+	OutboundDropped uint64 // outbound messages dropped: queue full or no subscriber
+	// Channels breaks the counters down by channel name.
+	Channels map[string]ChannelStats
+}
+
+// ChannelStats holds the counters for one channel.
+type ChannelStats struct {
+	Inbound         uint64
+	InboundDropped  uint64
+	Outbound        uint64
+	OutboundDropped uint64
+}
+
+// counters are one channel's live counters.
+type counters struct {
+	inbound, inboundDropped, outbound, outboundDropped atomic.Uint64
+}
+
+// hubStats holds the per-channel counters. Incrementing is lock-free once a
+// channel has been seen.
+type hubStats struct {
+	channels sync.Map // channel name -> *counters
+}
+
+func (s *hubStats) channel(name string) *counters {
+	if c, ok := s.channels.Load(name); ok {
+		return c.(*counters)
+	}
+	c, _ := s.channels.LoadOrStore(name, &counters{})
+	return c.(*counters)
+}
+
+// Stats returns the message counters since the hub was created.
+func (h *Hub) Stats() Stats {
+	st := Stats{Channels: make(map[string]ChannelStats)}
+	h.stats.channels.Range(func(k, v any) bool {
+		c := v.(*counters)
+		cs := ChannelStats{
+			Inbound:         c.inbound.Load(),
+			InboundDropped:  c.inboundDropped.Load(),
+			Outbound:        c.outbound.Load(),
+			OutboundDropped: c.outboundDropped.Load(),
+		}
+		st.Channels[k.(string)] = cs
+		st.Inbound += cs.Inbound
+		st.InboundDropped += cs.InboundDropped
+		st.Outbound += cs.Outbound
+		st.OutboundDropped += cs.OutboundDropped
+		return true
+	})
+	return st
+}
+
+// Dropped returns how many messages were dropped because a queue was full
+// or, for outbound messages, no subscriber was registered for the channel.
+func (h *Hub) Dropped() uint64 {
+	st := h.Stats()
+	return st.InboundDropped + st.OutboundDropped
+}
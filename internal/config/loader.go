@@ -1,32 +1,185 @@
 package config
 
 import (
+	"bytes"
 	"encoding/json"
+	"errors"
 	"os"
 	"path/filepath"
+	"reflect"
+	"regexp"
 	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
 )
 
-// LoadConfig loads config from ~/.picobot/config.json if present, then applies any environment variable overrides on top.
+// configFileNames are the config files looked for in ~/.picobot, in order of
+// preference. config.json stays the default.
+var configFileNames = []string{"config.json", "config.yaml", "config.yml"}
+
+// findConfigFile returns the first config file that exists in dir, or
+// config.json if none does.
+func findConfigFile(dir string) string {
+	for _, name := range configFileNames {
+		path := filepath.Join(dir, name)
+		if _, err := os.Stat(path); err == nil {
+			return path
+		}
+	}
+	return filepath.Join(dir, configFileNames[0])
+}
+
+// isYAML reports whether path names a YAML config file.
+func isYAML(path string) bool {
+	ext := strings.ToLower(filepath.Ext(path))
+	return ext == ".yaml" || ext == ".yml"
+}
+
+// LoadConfig loads config from ~/.picobot/config.json (or config.yaml /
+// config.yml) if present, expands ${VAR} references in its strings, then
+// applies any environment variable overrides on top. If the result fails
+// Validate, the config is returned along with the *ValidationError.
 func LoadConfig() (Config, error) {
 	home, err := os.UserHomeDir()
 	if err != nil {
 		home = "."
 	}
-	path := filepath.Join(home, ".picobot", "config.json")
+	return loadFile(findConfigFile(filepath.Join(home, ".picobot")))
+}
+
+// loadFile reads the config file at path the way LoadConfig does.
+func loadFile(path string) (Config, error) {
+	cfg, err := ReadConfigFile(path)
+	if err != nil {
+		return Config{}, err
+	}
+	expandEnv(reflect.ValueOf(&cfg))
+	// env vars always take precedence over the config file, enabling runtime overrides without editing config.json.
+	applyEnvOverrides(&cfg)
+	if _, err := os.Stat(path); err != nil {
+		return cfg, nil // nothing written yet, nothing to validate
+	}
+	return cfg, cfg.Validate()
+}
+
+// envRefRE matches ${NAME} and ${NAME:-default}.
+var envRefRE = regexp.MustCompile(`\$\{([A-Za-z_][A-Za-z0-9_]*)(?::-([^}]*))?\}`)
+
+// expandEnvString replaces ${NAME} with the value of environment variable
+// NAME (empty if unset) and ${NAME:-default} with its value, or default if
+// it is unset or empty. Any other "$" is left alone.
+func expandEnvString(s string) string {
+	if !strings.Contains(s, "${") {
+		return s
+	}
+	return envRefRE.ReplaceAllStringFunc(s, func(ref string) string {
+		m := envRefRE.FindStringSubmatch(ref)
+		if v := os.Getenv(m[1]); v != "" || !strings.Contains(ref, ":-") {
+			return v
+		}
+		return m[2]
+	})
+}
+
+// expandEnv applies expandEnvString to every string reachable from v:
+// struct fields, pointers, slices and map values.
+func expandEnv(v reflect.Value) {
+	switch v.Kind() {
+	case reflect.Pointer:
+		if !v.IsNil() {
+			expandEnv(v.Elem())
+		}
+	case reflect.Struct:
+		for i := 0; i < v.NumField(); i++ {
+			if v.Field(i).CanSet() {
+				expandEnv(v.Field(i))
+			}
+		}
+	case reflect.Slice:
+		for i := 0; i < v.Len(); i++ {
+			expandEnv(v.Index(i))
+		}
+	case reflect.Map:
+		// Map values aren't addressable, so expand a copy and store it back.
+		iter := v.MapRange()
+		for iter.Next() {
+			val := reflect.New(v.Type().Elem()).Elem()
+			val.Set(iter.Value())
+			expandEnv(val)
+			v.SetMapIndex(iter.Key(), val)
+		}
+	case reflect.String:
+		v.SetString(expandEnvString(v.String()))
+	}
+}
+
+// ReadConfigFile decodes the config file at path as JSON, or as YAML for a
+// .yaml or .yml path. A missing file gives the zero Config. YAML uses the
+// same keys as JSON. Unlike LoadConfig, it neither expands ${VAR} references
+// nor applies environment overrides, so the result can be saved back without
+// writing secrets from the environment into the file.
+func ReadConfigFile(path string) (Config, error) {
 	var cfg Config
-	f, err := os.Open(path)
-	if err == nil {
-		defer func() { _ = f.Close() }()
-		if err := json.NewDecoder(f).Decode(&cfg); err != nil {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return cfg, nil
+	}
+	if err != nil {
+		return Config{}, err
+	}
+	if isYAML(path) {
+		// Go through JSON so the struct's json tags name the YAML keys.
+		var v interface{}
+		if err := yaml.Unmarshal(data, &v); err != nil {
+			return Config{}, err
+		}
+		if v == nil {
+			return cfg, nil
+		}
+		if data, err = json.Marshal(v); err != nil {
 			return Config{}, err
 		}
 	}
-	// env vars always take precedence over the config file, enabling runtime overrides without editing config.json.
-	applyEnvOverrides(&cfg)
+	if err := json.NewDecoder(bytes.NewReader(data)).Decode(&cfg); err != nil {
+		return Config{}, err
+	}
 	return cfg, nil
 }
 
+// marshalYAML encodes cfg as block-style YAML with the same keys, in the
+// same order, as its JSON form.
+func marshalYAML(cfg Config) ([]byte, error) {
+	b, err := json.Marshal(cfg)
+	if err != nil {
+		return nil, err
+	}
+	// JSON is valid YAML, so parsing it keeps the key order; clearing the
+	// styles turns the flow mappings and quoted strings into plain YAML.
+	var doc yaml.Node
+	if err := yaml.Unmarshal(b, &doc); err != nil {
+		return nil, err
+	}
+	var clear func(n *yaml.Node)
+	clear = func(n *yaml.Node) {
+		n.Style = 0
+		for _, c := range n.Content {
+			clear(c)
+		}
+	}
+	clear(&doc)
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(&doc); err != nil {
+		return nil, err
+	}
+	if err := enc.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
 // applyEnvOverrides updates config fields from all environment variables
 func applyEnvOverrides(cfg *Config) {
 	if v := os.Getenv("PICOBOT_MODEL"); v != "" {
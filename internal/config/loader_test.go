@@ -0,0 +1,137 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestReadConfigFileJSONAndYAMLAgree(t *testing.T) {
+	d := t.TempDir()
+	jsonPath := filepath.Join(d, "config.json")
+	yamlPath := filepath.Join(d, "config.yaml")
+	if err := os.WriteFile(jsonPath, []byte(`{
+  "agents": {"defaults": {"model": "gpt-4o-mini", "maxToolIterations": 20, "enableToolActivityIndicator": false}},
+  "channels": {"telegram": {"enabled": true, "token": "123456:ABC", "allowFrom": ["8881234567"]}},
+  "mcpServers": {"github": {"url": "https://api.example/mcp", "headers": {"X-Team": "007"}}}
+}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(yamlPath, []byte(`# hand-edited
+agents:
+  defaults:
+    model: gpt-4o-mini
+    maxToolIterations: 20
+    enableToolActivityIndicator: false
+channels:
+  telegram:
+    enabled: true
+    token: "123456:ABC"
+    allowFrom: ["8881234567"]
+mcpServers:
+  github:
+    url: https://api.example/mcp
+    headers:
+      X-Team: "007"
+`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	fromJSON, err := ReadConfigFile(jsonPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fromYAML, err := ReadConfigFile(yamlPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(fromJSON, fromYAML) {
+		t.Fatalf("configs differ:\njson: %+v\nyaml: %+v", fromJSON, fromYAML)
+	}
+	if fromYAML.Channels.Telegram.AllowFrom[0] != "8881234567" || fromYAML.MCPServers["github"].Headers["X-Team"] != "007" {
+		t.Fatalf("unexpected values: %+v", fromYAML)
+	}
+}
+
+func TestSaveConfigKeepsYAMLFormat(t *testing.T) {
+	d := t.TempDir()
+	cfg := DefaultConfig()
+	cfg.Channels.Telegram.Token = "123456:ABC"
+	cfg.Channels.Telegram.AllowFrom = []string{"8881234567"}
+	path := filepath.Join(d, "config.yml")
+	if err := SaveConfig(cfg, path); err != nil {
+		t.Fatal(err)
+	}
+	b, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.HasPrefix(strings.TrimSpace(string(b)), "{") || !strings.Contains(string(b), "agents:\n") {
+		t.Fatalf("expected block YAML, got:\n%s", b)
+	}
+	got, err := ReadConfigFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, cfg) {
+		t.Fatalf("round trip changed the config:\nsaved: %+v\nread:  %+v", cfg, got)
+	}
+}
+
+func TestFindConfigFilePrefersJSON(t *testing.T) {
+	d := t.TempDir()
+	if got := findConfigFile(d); got != filepath.Join(d, "config.json") {
+		t.Fatalf("with no file, got %s", got)
+	}
+	os.WriteFile(filepath.Join(d, "config.yaml"), []byte("{}"), 0o644)
+	if got := findConfigFile(d); got != filepath.Join(d, "config.yaml") {
+		t.Fatalf("with only config.yaml, got %s", got)
+	}
+	os.WriteFile(filepath.Join(d, "config.json"), []byte("{}"), 0o644)
+	if got := findConfigFile(d); got != filepath.Join(d, "config.json") {
+		t.Fatalf("config.json should win, got %s", got)
+	}
+}
+
+func TestLoadConfigExpandsEnvReferences(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("PICOBOT_TEST_KEY", "sk-from-env")
+	t.Setenv("PICOBOT_TEST_UNSET", "")
+	os.MkdirAll(filepath.Join(home, ".picobot"), 0o755)
+	if err := os.WriteFile(filepath.Join(home, ".picobot", "config.json"), []byte(`{
+  "agents": {"defaults": {"model": "gpt-4o-mini"}},
+  "providers": {"openai": {"apiKey": "${PICOBOT_TEST_KEY}", "apiBase": "${PICOBOT_TEST_UNSET:-https://api.openai.com/v1}"}},
+  "channels": {"telegram": {"token": "pa$$word $HOME ${not a ref}"}},
+  "mcpServers": {"gh": {"url": "https://api.example/mcp", "headers": {"Authorization": "Bearer ${PICOBOT_TEST_KEY}"}}}
+}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg, err := LoadConfig()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := cfg.Providers.OpenAI.APIKey; got != "sk-from-env" {
+		t.Errorf("set variable: got %q", got)
+	}
+	if got := cfg.Providers.OpenAI.APIBase; got != "https://api.openai.com/v1" {
+		t.Errorf("unset variable with default: got %q", got)
+	}
+	if got := cfg.Channels.Telegram.Token; got != "pa$$word $HOME ${not a ref}" {
+		t.Errorf("literal $ should be left alone, got %q", got)
+	}
+	if got := cfg.MCPServers["gh"].Headers["Authorization"]; got != "Bearer sk-from-env" {
+		t.Errorf("map values should be expanded, got %q", got)
+	}
+
+	raw, err := ReadConfigFile(filepath.Join(home, ".picobot", "config.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if raw.Providers.OpenAI.APIKey != "${PICOBOT_TEST_KEY}" {
+		t.Errorf("ReadConfigFile should keep references as written, got %q", raw.Providers.OpenAI.APIKey)
+	}
+}
@@ -28,6 +28,7 @@ func DefaultConfig() Config {
 			Discord:  DiscordConfig{Enabled: false, Token: "", AllowFrom: []string{}},
 			Slack:    SlackConfig{Enabled: false, AppToken: "", BotToken: "", AllowUsers: []string{}, AllowChannels: []string{}},
 			WhatsApp: WhatsAppConfig{Enabled: false, DBPath: "", AllowFrom: []string{}},
+			Email:    EmailConfig{Enabled: false, PollIntervalS: 60, AllowFrom: []string{}},
 		},
 		MCPServers: map[string]MCPServerConfig{},
 		Providers: ProvidersConfig{
@@ -39,12 +40,19 @@ func DefaultConfig() Config {
 // boolPtr returns a pointer to the given bool value.
 func boolPtr(b bool) *bool { return &b }
 
-// SaveConfig writes the config to the given path (creating parent dirs).
+// SaveConfig writes the config to the given path (creating parent dirs), as
+// YAML if the path ends in .yaml or .yml and as JSON otherwise.
 func SaveConfig(cfg Config, path string) error {
 	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
 		return err
 	}
-	b, err := json.MarshalIndent(cfg, "", "  ")
+	var b []byte
+	var err error
+	if isYAML(path) {
+		b, err = marshalYAML(cfg)
+	} else {
+		b, err = json.MarshalIndent(cfg, "", "  ")
+	}
 	if err != nil {
 		return err
 	}
@@ -120,6 +128,7 @@ Never create files directly in the workspace root. Always use a project folder.
 - Use the write_memory tool with target "today" for daily notes
 - Use the write_memory tool with target "long" for long-term information
 - Use read_memory to check what is already stored before writing new entries
+- Use search_memory to find what was noted on earlier days instead of reading every daily note
 - Use edit_memory to update or correct individual facts without rewriting the whole file
 - Use list_memory to see all available memory files
 - Use delete_memory to clean up outdated daily notes
@@ -225,6 +234,17 @@ Search the web using DuckDuckGo (no API key required).
 Send a message to the current channel/chat.
 - content: the message text
 
+### status_update
+Post a status update to the configured status channel (agents.defaults.statusUpdates), wherever the conversation is.
+- severity: info, minor, major, or critical
+- component: the affected system, e.g. "database"
+- message: what happened
+
+### ask_user
+Ask the user a clarifying question and wait for their reply, which is returned as the result.
+- question: the question, ideally with the options you are choosing between
+- Only use it when guessing would likely be wrong; otherwise make a reasonable assumption and say so
+
 ## Memory
 
 ### write_memory
@@ -241,6 +261,11 @@ List all memory files (daily notes and long-term MEMORY.md).
 Read the contents of a specific memory file.
 - target: "today", "long", or a date "YYYY-MM-DD"
 
+### search_memory
+Search daily notes for lines containing all the given words.
+- query: words to look for
+- days: how many days back to search (default 30)
+
 ### edit_memory
 Find and replace text within a memory file.
 - target: "today", "long", or "YYYY-MM-DD"
@@ -363,12 +388,14 @@ func extractEmbeddedSkills(targetDir string) error {
 }
 
 // ResolveDefaultPaths returns absolute paths for the config and workspace based on home directory.
+// The config path is that of an existing config.yaml or config.yml when there
+// is no config.json, so the config is saved back in the format it was written in.
 func ResolveDefaultPaths() (cfgPath string, workspacePath string, err error) {
 	home, err := os.UserHomeDir()
 	if err != nil {
 		return "", "", err
 	}
-	cfgPath = filepath.Join(home, ".picobot", "config.json")
+	cfgPath = findConfigFile(filepath.Join(home, ".picobot"))
 	workspacePath = filepath.Join(home, ".picobot", "workspace")
 	return cfgPath, workspacePath, nil
 }
@@ -9,12 +9,28 @@ type Config struct {
 }
 
 // MCPServerConfig describes a single MCP server connection.
-// Use Command+Args for stdio transport, or URL+Headers for HTTP transport.
+// Use Command+Args for stdio transport, or URL+Headers for HTTP transport; a
+// ws:// or wss:// URL selects the WebSocket transport. BearerToken, if set, is
+// sent as "Authorization: Bearer <token>" on every HTTP request (or on the
+// WebSocket handshake).
 type MCPServerConfig struct {
-	Command string            `json:"command,omitempty"`
-	Args    []string          `json:"args,omitempty"`
-	URL     string            `json:"url,omitempty"`
-	Headers map[string]string `json:"headers,omitempty"`
+	Command     string            `json:"command,omitempty"`
+	Args        []string          `json:"args,omitempty"`
+	URL         string            `json:"url,omitempty"`
+	Headers     map[string]string `json:"headers,omitempty"`
+	BearerToken string            `json:"bearerToken,omitempty"`
+	// InitTimeoutS bounds the initialize handshake and tool discovery
+	// (default 30). RequestTimeoutS bounds each later request (default 60).
+	InitTimeoutS    int `json:"initTimeoutS,omitempty"`
+	RequestTimeoutS int `json:"requestTimeoutS,omitempty"`
+	// AllowTools and DenyTools are glob patterns (e.g. "read_*") matched
+	// against the server's tool names. With an allowlist only matching tools
+	// are registered; the denylist then removes any that remain.
+	AllowTools []string `json:"allowTools,omitempty"`
+	DenyTools  []string `json:"denyTools,omitempty"`
+	// RefreshIntervalS, if set, re-lists the server's tools this often so
+	// tools added or removed after connecting are picked up.
+	RefreshIntervalS int `json:"refreshIntervalS,omitempty"`
 }
 
 type AgentsConfig struct {
@@ -22,14 +38,121 @@ type AgentsConfig struct {
 }
 
 type AgentDefaults struct {
-	Workspace                   string  `json:"workspace"`
-	Model                       string  `json:"model"`
-	MaxTokens                   int     `json:"maxTokens"`
-	Temperature                 float64 `json:"temperature"`
-	MaxToolIterations           int     `json:"maxToolIterations"`
-	HeartbeatIntervalS          int     `json:"heartbeatIntervalS"`
-	RequestTimeoutS             int     `json:"requestTimeoutS"`
-	EnableToolActivityIndicator *bool   `json:"enableToolActivityIndicator,omitempty"`
+	Workspace                   string            `json:"workspace"`
+	Model                       string            `json:"model"`
+	MaxTokens                   int               `json:"maxTokens"`
+	Temperature                 float64           `json:"temperature"`
+	MaxToolIterations           int               `json:"maxToolIterations"`
+	HeartbeatIntervalS          int               `json:"heartbeatIntervalS"`
+	RequestTimeoutS             int               `json:"requestTimeoutS"`
+	TurnTimeoutS                int               `json:"turnTimeoutS,omitempty"`
+	AskUserTimeoutS             int               `json:"askUserTimeoutS,omitempty"`
+	EnableToolActivityIndicator *bool             `json:"enableToolActivityIndicator,omitempty"`
+	QuietHours                  *QuietHoursConfig `json:"quietHours,omitempty"`
+	// ChannelTools restricts the tools available to messages from a channel,
+	// keyed by channel name (e.g. "discord").
+	ChannelTools map[string]ToolPolicyConfig `json:"channelTools,omitempty"`
+	// Stop sequences sent to the provider with every request.
+	Stop []string `json:"stop,omitempty"`
+	// Greeting, if set, is sent once to each new conversation.
+	Greeting string `json:"greeting,omitempty"`
+	// MaxUnknownToolCalls stops a turn after this many calls to tools that
+	// don't exist (default 3).
+	MaxUnknownToolCalls int `json:"maxUnknownToolCalls,omitempty"`
+	// ChannelMaxToolIterations overrides MaxToolIterations per channel.
+	ChannelMaxToolIterations map[string]int `json:"channelMaxToolIterations,omitempty"`
+	// WebAllowPrivateNetworks lets the web tool fetch loopback, private and
+	// link-local addresses (e.g. services on the LAN). Off by default.
+	WebAllowPrivateNetworks bool `json:"webAllowPrivateNetworks,omitempty"`
+	// ExecAllowedPrograms, if set, are the only programs the exec tool may run.
+	ExecAllowedPrograms []string `json:"execAllowedPrograms,omitempty"`
+	// Docker enables and restricts the docker tool. Disabled by default.
+	Docker *DockerToolConfig `json:"docker,omitempty"`
+	// Secrets the agent may use in tool calls without seeing them.
+	Secrets *SecretsConfig `json:"secrets,omitempty"`
+	// StatusUpdates is where the status_update tool posts.
+	StatusUpdates *StatusUpdatesConfig `json:"statusUpdates,omitempty"`
+	// MaxTools caps how many tools are offered to the model per turn; 0 means
+	// no cap. Tools matching ToolPriority patterns are kept first, the rest
+	// by relevance to the user's message.
+	MaxTools     int      `json:"maxTools,omitempty"`
+	ToolPriority []string `json:"toolPriority,omitempty"`
+	// Summarize enables automatic summaries of long conversations into memory.
+	Summarize *SummarizeConfig `json:"summarize,omitempty"`
+	// FallbackModels are tried in order when the model is overloaded or
+	// unavailable (429 or 5xx). LongContextModel is tried when a request is
+	// too long for the model's context window.
+	FallbackModels   []string `json:"fallbackModels,omitempty"`
+	LongContextModel string   `json:"longContextModel,omitempty"`
+	// SystemPrompt replaces the default opening of the system prompt
+	// ("You are Picobot, a helpful assistant."). It is a Go template that can
+	// use {{.Date}}, {{.Time}}, {{.Weekday}}, {{.Channel}} and {{.ChatID}}.
+	// SystemPromptFile reads it from a file instead. Empty keeps the default.
+	SystemPrompt     string `json:"systemPrompt,omitempty"`
+	SystemPromptFile string `json:"systemPromptFile,omitempty"`
+	// Events publishes turn lifecycle events to a webhook.
+	Events *EventsConfig `json:"events,omitempty"`
+}
+
+// SummarizeConfig sets when a conversation is summarized: every EveryTurns
+// turns and/or once its history exceeds about MaxHistoryTokens tokens. Model
+// optionally names a cheaper model to write the summaries.
+type SummarizeConfig struct {
+	EveryTurns       int    `json:"everyTurns,omitempty"`
+	MaxHistoryTokens int    `json:"maxHistoryTokens,omitempty"`
+	Model            string `json:"model,omitempty"`
+}
+
+// StatusUpdatesConfig names the channel (e.g. "slack") and chat ID that
+// status updates are posted to.
+type StatusUpdatesConfig struct {
+	Channel string `json:"channel"`
+	ChatID  string `json:"chatId"`
+}
+
+// EventsConfig is where turn events (turn_started, tool_called,
+// turn_completed, turn_error) are POSTed as JSON. Emails, keys, numbers and
+// secret-looking tool arguments are redacted unless Redact is false.
+type EventsConfig struct {
+	Webhook string            `json:"webhook"`
+	Headers map[string]string `json:"headers,omitempty"`
+	Redact  *bool             `json:"redact,omitempty"`
+}
+
+// SecretsConfig names where secrets come from: environment variables exposed
+// under their own names, and/or a JSON file of name/value pairs.
+type SecretsConfig struct {
+	Env  []string `json:"env,omitempty"`
+	File string   `json:"file,omitempty"`
+}
+
+// DockerToolConfig lists the images (glob patterns such as "python:*") the
+// docker tool may run and any run flags allowed beyond the defaults.
+type DockerToolConfig struct {
+	Enabled       bool     `json:"enabled"`
+	AllowedImages []string `json:"allowedImages,omitempty"`
+	AllowedFlags  []string `json:"allowedFlags,omitempty"`
+	TimeoutS      int      `json:"timeoutS,omitempty"`
+}
+
+// ToolPolicyConfig lists tool name patterns a channel may (Allow) or may not
+// (Deny) use. "tool:action" entries such as "filesystem:write" target a
+// single action of a tool. Message, if set, is the reply sent when a call
+// is refused.
+type ToolPolicyConfig struct {
+	Allow   []string `json:"allow,omitempty"`
+	Deny    []string `json:"deny,omitempty"`
+	Message string   `json:"message,omitempty"`
+}
+
+// QuietHoursConfig holds back scheduled (cron) messages during a daily window.
+// Start and End are "HH:MM" in Timezone (IANA name, default local time).
+// Action is "defer" (deliver when the window ends, default) or "drop".
+type QuietHoursConfig struct {
+	Start    string `json:"start"`
+	End      string `json:"end"`
+	Timezone string `json:"timezone,omitempty"`
+	Action   string `json:"action,omitempty"`
 }
 
 type ChannelsConfig struct {
@@ -37,18 +160,62 @@ type ChannelsConfig struct {
 	Discord  DiscordConfig  `json:"discord"`
 	Slack    SlackConfig    `json:"slack"`
 	WhatsApp WhatsAppConfig `json:"whatsapp"`
+	Email    EmailConfig    `json:"email"`
+	// InboundMedia limits the attachments users can send on any channel.
+	InboundMedia *InboundMediaConfig `json:"inboundMedia,omitempty"`
+	// Queue sets what happens when a message queue between the channels and
+	// the agent is full.
+	Queue *QueueConfig `json:"queue,omitempty"`
+	// Footer is appended to every reply, e.g. an attribution or disclaimer.
+	// Footers overrides it per channel name; "" turns it off for a channel.
+	Footer  string            `json:"footer,omitempty"`
+	Footers map[string]string `json:"footers,omitempty"`
+}
+
+// QueueConfig bounds how long a full queue is waited on (TimeoutS) or drops
+// messages at once (Drop). By default senders wait indefinitely.
+type QueueConfig struct {
+	TimeoutS int  `json:"timeoutS,omitempty"`
+	Drop     bool `json:"drop,omitempty"`
+}
+
+// InboundMediaConfig limits inbound attachments. Oversized or disallowed
+// attachments are rejected with a note to the user.
+type InboundMediaConfig struct {
+	MaxBytes int64 `json:"maxBytes,omitempty"`
+	// AllowedTypes are MIME types or "type/*" patterns; empty allows all.
+	AllowedTypes []string `json:"allowedTypes,omitempty"`
+	// InlineTextMaxBytes, if set, puts the content of text attachments up to
+	// this size (.txt, .md, .json) directly into the message (Discord).
+	InlineTextMaxBytes int64 `json:"inlineTextMaxBytes,omitempty"`
 }
 
 type DiscordConfig struct {
 	Enabled   bool     `json:"enabled"`
 	Token     string   `json:"token"`
 	AllowFrom []string `json:"allowFrom"`
+	// RequireMentionInDMs ignores direct messages that don't mention the bot
+	// or start with one of TriggerWords.
+	RequireMentionInDMs bool `json:"requireMentionInDMs,omitempty"`
+	// TriggerWords are prefixes (e.g. "!bot") that address the bot like a
+	// mention, in DMs and guild channels.
+	TriggerWords []string `json:"triggerWords,omitempty"`
+	// ReplyToMessage sends replies as Discord replies to the user's message
+	// instead of standalone messages.
+	ReplyToMessage bool `json:"replyToMessage,omitempty"`
+	// RespondToBareMention answers a message that is only a mention (or
+	// trigger word) with BareMentionReply, "Hi! How can I help?" by default,
+	// instead of ignoring it.
+	RespondToBareMention bool   `json:"respondToBareMention,omitempty"`
+	BareMentionReply     string `json:"bareMentionReply,omitempty"`
 }
 
 type TelegramConfig struct {
 	Enabled   bool     `json:"enabled"`
 	Token     string   `json:"token"`
 	AllowFrom []string `json:"allowFrom"`
+	// ReplyToMessage sends replies quoting the user's message.
+	ReplyToMessage bool `json:"replyToMessage,omitempty"`
 }
 
 type SlackConfig struct {
@@ -57,6 +224,13 @@ type SlackConfig struct {
 	BotToken      string   `json:"botToken"`
 	AllowUsers    []string `json:"allowUsers"`
 	AllowChannels []string `json:"allowChannels"`
+	// RequireMentionInDMs ignores direct messages that don't mention the bot
+	// or start with one of TriggerWords.
+	RequireMentionInDMs bool     `json:"requireMentionInDMs,omitempty"`
+	TriggerWords        []string `json:"triggerWords,omitempty"`
+	// ReplyToMessage answers a message outside a thread in a new thread under
+	// it, instead of in the channel.
+	ReplyToMessage bool `json:"replyToMessage,omitempty"`
 }
 
 type WhatsAppConfig struct {
@@ -65,11 +239,59 @@ type WhatsAppConfig struct {
 	AllowFrom []string `json:"allowFrom"`
 }
 
+// EmailConfig configures the IMAP/SMTP email channel.
+// IMAPServer and SMTPServer are "host:port" addresses; IMAP uses implicit TLS
+// (usually port 993) and SMTP upgrades via STARTTLS (usually port 587).
+type EmailConfig struct {
+	Enabled       bool     `json:"enabled"`
+	IMAPServer    string   `json:"imapServer"`
+	SMTPServer    string   `json:"smtpServer"`
+	Username      string   `json:"username"`
+	Password      string   `json:"password"`
+	Address       string   `json:"address"`
+	PollIntervalS int      `json:"pollIntervalS"`
+	AllowFrom     []string `json:"allowFrom"`
+}
+
 type ProvidersConfig struct {
 	OpenAI *ProviderConfig `json:"openai,omitempty"`
+	// Ollama uses Ollama's native API (apiBase e.g. http://localhost:11434)
+	// and is preferred over OpenAI when set. Models without tool support
+	// keep working, without tools.
+	Ollama *ProviderConfig `json:"ollama,omitempty"`
 }
 
 type ProviderConfig struct {
-	APIKey  string `json:"apiKey"`
-	APIBase string `json:"apiBase"`
+	APIKey    string               `json:"apiKey"`
+	APIBase   string               `json:"apiBase"`
+	Transport *HTTPTransportConfig `json:"transport,omitempty"`
+	// TimeoutS bounds each request to this provider, overriding
+	// agents.defaults.requestTimeoutS. ConnectTimeoutS bounds establishing the
+	// connection (TCP and TLS) alone, so an unreachable host fails fast even
+	// when slow generations are allowed.
+	TimeoutS        int `json:"timeoutS,omitempty"`
+	ConnectTimeoutS int `json:"connectTimeoutS,omitempty"`
+	// MaxAttempts is how many times a chat request is tried in all while the
+	// API answers 429 (rate limited) or 5xx, with exponential backoff
+	// between tries (default 3; 1 disables retries). OpenAI only.
+	MaxAttempts int `json:"maxAttempts,omitempty"`
+	// Required makes the gateway refuse to start if the provider fails its
+	// startup connectivity check. Otherwise the failure is only logged.
+	Required bool `json:"required,omitempty"`
+	// Reasoning controls reasoning returned in a separate response field:
+	// "separate" (default) treats it like <think> tags, "strip" discards it.
+	Reasoning string `json:"reasoning,omitempty"`
+	// EmbeddingModel enables embeddings (used by the notes tool), e.g.
+	// "text-embedding-3-small". Without it notes use keyword search.
+	EmbeddingModel string `json:"embeddingModel,omitempty"`
+}
+
+// HTTPTransportConfig tunes connection reuse for a provider's HTTP client.
+// Zero values keep Go's defaults.
+type HTTPTransportConfig struct {
+	MaxIdleConns        int `json:"maxIdleConns,omitempty"`
+	MaxIdleConnsPerHost int `json:"maxIdleConnsPerHost,omitempty"`
+	MaxConnsPerHost     int `json:"maxConnsPerHost,omitempty"`
+	IdleConnTimeoutS    int `json:"idleConnTimeoutS,omitempty"`
+	KeepAliveS          int `json:"keepAliveS,omitempty"`
 }
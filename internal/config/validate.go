@@ -0,0 +1,147 @@
+package config
+
+import (
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+	"text/template"
+)
+
+// ValidationError lists every problem Validate found in a config.
+type ValidationError struct {
+	Problems []string
+}
+
+func (e *ValidationError) Error() string {
+	return "invalid config:\n  - " + strings.Join(e.Problems, "\n  - ")
+}
+
+// Validate checks cfg for values that would only fail later: missing
+// credentials for enabled channels, out-of-range numbers and ambiguous MCP
+// server transports. It returns a *ValidationError listing all problems, or
+// nil.
+func (cfg Config) Validate() error {
+	var problems []string
+	add := func(format string, args ...interface{}) {
+		problems = append(problems, fmt.Sprintf(format, args...))
+	}
+	nonNegative := func(name string, v int) {
+		if v < 0 {
+			add("%s must not be negative (got %d)", name, v)
+		}
+	}
+
+	d := cfg.Agents.Defaults
+	if d.Model == "" {
+		add("agents.defaults.model is empty; set it to the model to use, e.g. \"gpt-4o-mini\"")
+	}
+	if d.Temperature < 0 || d.Temperature > 2 {
+		add("agents.defaults.temperature must be between 0 and 2 (got %g)", d.Temperature)
+	}
+	nonNegative("agents.defaults.maxTokens", d.MaxTokens)
+	nonNegative("agents.defaults.maxToolIterations", d.MaxToolIterations)
+	nonNegative("agents.defaults.heartbeatIntervalS", d.HeartbeatIntervalS)
+	nonNegative("agents.defaults.requestTimeoutS", d.RequestTimeoutS)
+	nonNegative("agents.defaults.turnTimeoutS", d.TurnTimeoutS)
+	nonNegative("agents.defaults.askUserTimeoutS", d.AskUserTimeoutS)
+	nonNegative("agents.defaults.maxUnknownToolCalls", d.MaxUnknownToolCalls)
+	nonNegative("agents.defaults.maxTools", d.MaxTools)
+	for ch, n := range d.ChannelMaxToolIterations {
+		nonNegative("agents.defaults.channelMaxToolIterations."+ch, n)
+	}
+	if sm := d.Summarize; sm != nil {
+		nonNegative("agents.defaults.summarize.everyTurns", sm.EveryTurns)
+		nonNegative("agents.defaults.summarize.maxHistoryTokens", sm.MaxHistoryTokens)
+	}
+	if dk := d.Docker; dk != nil {
+		nonNegative("agents.defaults.docker.timeoutS", dk.TimeoutS)
+	}
+	if su := d.StatusUpdates; su != nil && (su.Channel == "" || su.ChatID == "") {
+		add("agents.defaults.statusUpdates needs both channel and chatId")
+	}
+	if d.SystemPrompt != "" && d.SystemPromptFile != "" {
+		add("agents.defaults: set systemPrompt or systemPromptFile, not both")
+	}
+	if _, err := template.New("").Parse(d.SystemPrompt); err != nil {
+		add("agents.defaults.systemPrompt is not a valid template: %v", err)
+	}
+	if ev := d.Events; ev != nil {
+		if u, err := url.Parse(ev.Webhook); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			add("agents.defaults.events.webhook must be an http or https URL (got %q)", ev.Webhook)
+		}
+	}
+
+	ch := cfg.Channels
+	if ch.Telegram.Enabled && ch.Telegram.Token == "" {
+		add("channels.telegram is enabled but token is empty")
+	}
+	if ch.Discord.Enabled && ch.Discord.Token == "" {
+		add("channels.discord is enabled but token is empty")
+	}
+	if ch.Slack.Enabled {
+		if ch.Slack.AppToken == "" {
+			add("channels.slack is enabled but appToken (xapp-...) is empty")
+		}
+		if ch.Slack.BotToken == "" {
+			add("channels.slack is enabled but botToken (xoxb-...) is empty")
+		}
+	}
+	if e := ch.Email; e.Enabled {
+		for _, f := range []struct{ name, value string }{
+			{"imapServer", e.IMAPServer}, {"smtpServer", e.SMTPServer},
+			{"username", e.Username}, {"password", e.Password},
+		} {
+			if f.value == "" {
+				add("channels.email is enabled but %s is empty", f.name)
+			}
+		}
+	}
+	nonNegative("channels.email.pollIntervalS", ch.Email.PollIntervalS)
+	if m := ch.InboundMedia; m != nil {
+		if m.MaxBytes < 0 || m.InlineTextMaxBytes < 0 {
+			add("channels.inboundMedia sizes must not be negative")
+		}
+	}
+	if q := ch.Queue; q != nil {
+		nonNegative("channels.queue.timeoutS", q.TimeoutS)
+	}
+
+	names := make([]string, 0, len(cfg.MCPServers))
+	for name := range cfg.MCPServers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		s := cfg.MCPServers[name]
+		prefix := "mcpServers." + name
+		switch {
+		case s.Command != "" && s.URL != "":
+			add("%s sets both command and url; use command for a local server or url for a remote one, not both", prefix)
+		case s.Command == "" && s.URL == "":
+			add("%s needs either command or url", prefix)
+		}
+		if s.Command != "" && (len(s.Headers) > 0 || s.BearerToken != "") {
+			add("%s: headers and bearerToken only apply to url servers", prefix)
+		}
+		nonNegative(prefix+".initTimeoutS", s.InitTimeoutS)
+		nonNegative(prefix+".requestTimeoutS", s.RequestTimeoutS)
+		nonNegative(prefix+".refreshIntervalS", s.RefreshIntervalS)
+	}
+
+	for _, p := range []struct {
+		name string
+		cfg  *ProviderConfig
+	}{{"openai", cfg.Providers.OpenAI}, {"ollama", cfg.Providers.Ollama}} {
+		if p.cfg != nil {
+			nonNegative("providers."+p.name+".timeoutS", p.cfg.TimeoutS)
+			nonNegative("providers."+p.name+".connectTimeoutS", p.cfg.ConnectTimeoutS)
+			nonNegative("providers."+p.name+".maxAttempts", p.cfg.MaxAttempts)
+		}
+	}
+
+	if len(problems) > 0 {
+		return &ValidationError{Problems: problems}
+	}
+	return nil
+}
@@ -0,0 +1,108 @@
+package config
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestDefaultConfigIsValid(t *testing.T) {
+	if err := DefaultConfig().Validate(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestValidateReportsEachProblem(t *testing.T) {
+	tests := []struct {
+		name   string
+		modify func(*Config)
+		want   string
+	}{
+		{"empty model", func(c *Config) { c.Agents.Defaults.Model = "" }, "agents.defaults.model is empty"},
+		{"negative maxTokens", func(c *Config) { c.Agents.Defaults.MaxTokens = -1 }, "agents.defaults.maxTokens must not be negative"},
+		{"temperature out of range", func(c *Config) { c.Agents.Defaults.Temperature = 3 }, "temperature must be between 0 and 2"},
+		{"negative iterations", func(c *Config) { c.Agents.Defaults.MaxToolIterations = -5 }, "maxToolIterations must not be negative"},
+		{"negative channel iterations", func(c *Config) { c.Agents.Defaults.ChannelMaxToolIterations = map[string]int{"discord": -1} }, "channelMaxToolIterations.discord"},
+		{"status updates without chat", func(c *Config) { c.Agents.Defaults.StatusUpdates = &StatusUpdatesConfig{Channel: "slack"} }, "statusUpdates needs both"},
+		{"events webhook not a URL", func(c *Config) { c.Agents.Defaults.Events = &EventsConfig{Webhook: "hooks.example.com"} }, "events.webhook must be an http or https URL"},
+		{"system prompt and file", func(c *Config) {
+			c.Agents.Defaults.SystemPrompt, c.Agents.Defaults.SystemPromptFile = "You are Ada.", "prompt.md"
+		}, "set systemPrompt or systemPromptFile, not both"},
+		{"broken system prompt template", func(c *Config) { c.Agents.Defaults.SystemPrompt = "Hi {{.Channel" }, "systemPrompt is not a valid template"},
+		{"telegram without token", func(c *Config) { c.Channels.Telegram.Enabled = true }, "channels.telegram is enabled but token is empty"},
+		{"discord without token", func(c *Config) { c.Channels.Discord.Enabled = true }, "channels.discord is enabled but token is empty"},
+		{"slack without app token", func(c *Config) {
+			c.Channels.Slack = SlackConfig{Enabled: true, BotToken: "xoxb-1"}
+		}, "appToken"},
+		{"slack without bot token", func(c *Config) {
+			c.Channels.Slack = SlackConfig{Enabled: true, AppToken: "xapp-1"}
+		}, "botToken"},
+		{"email without password", func(c *Config) {
+			c.Channels.Email = EmailConfig{Enabled: true, IMAPServer: "imap:993", SMTPServer: "smtp:587", Username: "me"}
+		}, "channels.email is enabled but password is empty"},
+		{"negative media size", func(c *Config) { c.Channels.InboundMedia = &InboundMediaConfig{MaxBytes: -1} }, "inboundMedia sizes"},
+		{"negative queue timeout", func(c *Config) { c.Channels.Queue = &QueueConfig{TimeoutS: -1} }, "channels.queue.timeoutS"},
+		{"mcp command and url", func(c *Config) {
+			c.MCPServers = map[string]MCPServerConfig{"fs": {Command: "mcp-fs", URL: "https://example.com/mcp"}}
+		}, "mcpServers.fs sets both command and url"},
+		{"mcp without transport", func(c *Config) {
+			c.MCPServers = map[string]MCPServerConfig{"fs": {}}
+		}, "mcpServers.fs needs either command or url"},
+		{"mcp headers on command", func(c *Config) {
+			c.MCPServers = map[string]MCPServerConfig{"fs": {Command: "mcp-fs", BearerToken: "t"}}
+		}, "only apply to url servers"},
+		{"negative provider timeout", func(c *Config) { c.Providers.OpenAI.TimeoutS = -1 }, "providers.openai.timeoutS"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := DefaultConfig()
+			tt.modify(&cfg)
+			err := cfg.Validate()
+			var verr *ValidationError
+			if !errors.As(err, &verr) {
+				t.Fatalf("want a *ValidationError, got %v", err)
+			}
+			if len(verr.Problems) != 1 || !strings.Contains(verr.Problems[0], tt.want) {
+				t.Fatalf("want one problem containing %q, got %q", tt.want, verr.Problems)
+			}
+		})
+	}
+}
+
+func TestValidateListsAllProblems(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.Agents.Defaults.Model = ""
+	cfg.Agents.Defaults.MaxTokens = -1
+	cfg.Channels.Telegram.Enabled = true
+	err := cfg.Validate()
+	if err == nil {
+		t.Fatal("expected an error")
+	}
+	msg := err.Error()
+	for _, want := range []string{"invalid config:", "model is empty", "maxTokens", "telegram"} {
+		if !strings.Contains(msg, want) {
+			t.Errorf("error %q does not mention %q", msg, want)
+		}
+	}
+}
+
+func TestLoadConfigReturnsValidationError(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	os.MkdirAll(filepath.Join(home, ".picobot"), 0o755)
+	if err := os.WriteFile(filepath.Join(home, ".picobot", "config.json"), []byte(`{
+  "agents": {"defaults": {"model": "gpt-4o-mini", "maxTokens": -10}}
+}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := LoadConfig()
+	var verr *ValidationError
+	if !errors.As(err, &verr) {
+		t.Fatalf("want a *ValidationError, got %v", err)
+	}
+	if cfg.Agents.Defaults.Model != "gpt-4o-mini" {
+		t.Errorf("config should still be returned, got %+v", cfg.Agents.Defaults)
+	}
+}
@@ -0,0 +1,98 @@
+package config
+
+import (
+	"context"
+	"log"
+	"os"
+	"reflect"
+	"time"
+)
+
+// Watch polls the config file at path every interval until ctx is done.
+// When the file changes it is reloaded the way LoadConfig does and, if the
+// result differs from the previous config, onChange is called with both.
+// current is the config the caller started with.
+//
+// A file that doesn't decode or fails Validate (e.g. one caught half-written
+// by an editor) is skipped with a log line and read again on the next tick,
+// so onChange only ever sees a complete config.
+func Watch(ctx context.Context, path string, current Config, interval time.Duration, onChange func(old, cfg Config)) {
+	if interval <= 0 {
+		interval = 2 * time.Second
+	}
+	last, _ := os.Stat(path)
+	lastErr := ""
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		fi, err := os.Stat(path)
+		if err != nil || (last != nil && fi.ModTime().Equal(last.ModTime()) && fi.Size() == last.Size()) {
+			continue
+		}
+		cfg, err := loadFile(path)
+		if err != nil {
+			if err.Error() != lastErr {
+				log.Printf("config: not reloading %s: %v", path, err)
+				lastErr = err.Error()
+			}
+			continue
+		}
+		last, lastErr = fi, ""
+		if reflect.DeepEqual(cfg, current) {
+			continue
+		}
+		log.Printf("config: reloaded %s", path)
+		onChange(current, cfg)
+		current = cfg
+	}
+}
+
+// RestartRequired lists the config sections that differ between old and cfg
+// in ways that only take effect after a restart. The model, maxTokens,
+// fallback models, system prompt, the channels' allow lists and mcpServers
+// are applied live and ignored, as is temperature, which is not sent to the
+// provider and so has no effect.
+func RestartRequired(old, cfg Config) []string {
+	old, cfg = withoutLiveFields(old), withoutLiveFields(cfg)
+	sections := []struct {
+		name     string
+		old, cfg interface{}
+	}{
+		{"agents", old.Agents, cfg.Agents},
+		{"channels.telegram", old.Channels.Telegram, cfg.Channels.Telegram},
+		{"channels.discord", old.Channels.Discord, cfg.Channels.Discord},
+		{"channels.slack", old.Channels.Slack, cfg.Channels.Slack},
+		{"channels.whatsapp", old.Channels.WhatsApp, cfg.Channels.WhatsApp},
+		{"channels.email", old.Channels.Email, cfg.Channels.Email},
+		{"channels.inboundMedia", old.Channels.InboundMedia, cfg.Channels.InboundMedia},
+		{"channels.queue", old.Channels.Queue, cfg.Channels.Queue},
+		{"providers", old.Providers, cfg.Providers},
+	}
+	var changed []string
+	for _, s := range sections {
+		if !reflect.DeepEqual(s.old, s.cfg) {
+			changed = append(changed, s.name)
+		}
+	}
+	return changed
+}
+
+func withoutLiveFields(cfg Config) Config {
+	d := &cfg.Agents.Defaults
+	d.Model, d.Temperature, d.MaxTokens = "", 0, 0
+	d.FallbackModels, d.LongContextModel = nil, ""
+	d.SystemPrompt, d.SystemPromptFile = "", ""
+	ch := &cfg.Channels
+	ch.Telegram.AllowFrom = nil
+	ch.Discord.AllowFrom = nil
+	ch.Slack.AllowUsers, ch.Slack.AllowChannels = nil, nil
+	ch.WhatsApp.AllowFrom = nil
+	ch.Email.AllowFrom = nil
+	cfg.MCPServers = nil
+	return cfg
+}
@@ -0,0 +1,72 @@
+package config
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestWatchSkipsPartialWritesAndReportsChanges(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.json")
+	write := func(s string, age time.Duration) {
+		t.Helper()
+		if err := os.WriteFile(path, []byte(s), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		mt := time.Now().Add(-age)
+		os.Chtimes(path, mt, mt)
+	}
+	write(`{"agents": {"defaults": {"model": "old-model"}}}`, time.Minute)
+	current, err := loadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	changes := make(chan [2]Config, 4)
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		Watch(ctx, path, current, 10*time.Millisecond, func(old, cfg Config) { changes <- [2]Config{old, cfg} })
+		close(done)
+	}()
+	defer func() { cancel(); <-done }()
+
+	write(`{"agents": {"defaults": {"model": "new-mo`, 30*time.Second)
+	select {
+	case c := <-changes:
+		t.Fatalf("partial write should not be applied, got %+v", c[1].Agents.Defaults)
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	write(`{"agents": {"defaults": {"model": "new-model", "maxTokens": 512}}}`, 0)
+	select {
+	case c := <-changes:
+		if c[0].Agents.Defaults.Model != "old-model" || c[1].Agents.Defaults.Model != "new-model" || c[1].Agents.Defaults.MaxTokens != 512 {
+			t.Fatalf("unexpected change: old %+v new %+v", c[0].Agents.Defaults, c[1].Agents.Defaults)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("change was not reported")
+	}
+}
+
+func TestRestartRequiredIgnoresLiveFields(t *testing.T) {
+	old := DefaultConfig()
+	cfg := old
+	cfg.Agents.Defaults.Model = "other"
+	cfg.Agents.Defaults.MaxTokens = 99
+	cfg.Channels.Telegram.AllowFrom = []string{"1", "2"}
+	cfg.Channels.Slack.AllowChannels = []string{"C1"}
+	cfg.MCPServers = map[string]MCPServerConfig{"search": {URL: "http://localhost:9000/mcp"}}
+	if got := RestartRequired(old, cfg); len(got) != 0 {
+		t.Fatalf("live fields should not need a restart, got %v", got)
+	}
+
+	cfg.Channels.Discord.Enabled = !old.Channels.Discord.Enabled
+	cfg.Providers.OpenAI = &ProviderConfig{APIKey: "k"}
+	if got, want := RestartRequired(old, cfg), []string{"channels.discord", "providers"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,74 @@
+package cron
+
+import (
+	"fmt"
+	"time"
+)
+
+// QuietHours is a daily window during which scheduled jobs are held back.
+// The window may wrap past midnight (e.g. 22:00–07:00).
+type QuietHours struct {
+	startMin int // minutes after local midnight
+	endMin   int
+	loc      *time.Location
+	// Drop discards jobs that come due inside the window instead of
+	// deferring them until it ends.
+	Drop bool
+}
+
+// ParseQuietHours builds a QuietHours window from "HH:MM" start and end times
+// in the named IANA timezone. An empty timezone means the local timezone.
+func ParseQuietHours(start, end, timezone string) (*QuietHours, error) {
+	s, err := parseClock(start)
+	if err != nil {
+		return nil, fmt.Errorf("quiet hours start: %w", err)
+	}
+	e, err := parseClock(end)
+	if err != nil {
+		return nil, fmt.Errorf("quiet hours end: %w", err)
+	}
+	loc := time.Local
+	if timezone != "" {
+		if loc, err = time.LoadLocation(timezone); err != nil {
+			return nil, fmt.Errorf("quiet hours timezone: %w", err)
+		}
+	}
+	return &QuietHours{startMin: s, endMin: e, loc: loc}, nil
+}
+
+func parseClock(s string) (int, error) {
+	t, err := time.Parse("15:04", s)
+	if err != nil {
+		return 0, fmt.Errorf("invalid time %q, expected HH:MM", s)
+	}
+	return t.Hour()*60 + t.Minute(), nil
+}
+
+// Until reports whether t falls inside the quiet window and, if so, the time
+// at which the window ends.
+func (q *QuietHours) Until(t time.Time) (time.Time, bool) {
+	if q == nil || q.startMin == q.endMin {
+		return time.Time{}, false
+	}
+	lt := t.In(q.loc)
+	y, m, d := lt.Date()
+	now := lt.Hour()*60 + lt.Minute()
+	at := func(day, min int) time.Time {
+		return time.Date(y, m, day, min/60, min%60, 0, 0, q.loc)
+	}
+
+	if q.startMin < q.endMin {
+		if now >= q.startMin && now < q.endMin {
+			return at(d, q.endMin), true
+		}
+		return time.Time{}, false
+	}
+	// window wraps past midnight
+	switch {
+	case now >= q.startMin:
+		return at(d+1, q.endMin), true
+	case now < q.endMin:
+		return at(d, q.endMin), true
+	}
+	return time.Time{}, false
+}
@@ -7,11 +7,18 @@ import (
 	"time"
 )
 
+// KindDigest marks a job that fetches Source, summarizes it following
+// Message, and posts the summary to the job's chat. Jobs with an empty Kind
+// are plain reminders.
+const KindDigest = "digest"
+
 // Job represents a scheduled task.
 type Job struct {
 	ID        string
 	Name      string
 	Message   string
+	Kind      string // "" for a reminder, or KindDigest
+	Source    string // digest source: an http(s) URL or "chat:<channel>:<chatID>"
 	FireAt    time.Time
 	Channel   string // originating channel (e.g., "telegram")
 	ChatID    string // originating chat ID
@@ -30,6 +37,7 @@ type Scheduler struct {
 	callback FireCallback
 	nextID   int
 	running  bool
+	quiet    *QuietHours
 }
 
 // NewScheduler creates a new scheduler with the given fire callback.
@@ -40,6 +48,14 @@ func NewScheduler(callback FireCallback) *Scheduler {
 	}
 }
 
+// SetQuietHours sets a daily window during which due jobs are deferred until
+// the window ends (or dropped, if q.Drop is set). Pass nil to disable.
+func (s *Scheduler) SetQuietHours(q *QuietHours) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.quiet = q
+}
+
 // Add schedules a new job. Returns the job ID.
 func (s *Scheduler) Add(name, message string, delay time.Duration, channel, chatID string) string {
 	s.mu.Lock()
@@ -78,6 +94,29 @@ func (s *Scheduler) AddRecurring(name, message string, interval time.Duration, c
 	return id
 }
 
+// AddDigest schedules a recurring digest of source, summarized according to
+// instructions and posted to channel/chatID every interval. Returns the job ID.
+func (s *Scheduler) AddDigest(name, source, instructions string, interval time.Duration, channel, chatID string) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.nextID++
+	id := fmt.Sprintf("job-%d", s.nextID)
+	s.jobs[id] = &Job{
+		ID:        id,
+		Name:      name,
+		Message:   instructions,
+		Kind:      KindDigest,
+		Source:    source,
+		FireAt:    time.Now().Add(interval),
+		Channel:   channel,
+		ChatID:    chatID,
+		Recurring: true,
+		Interval:  interval,
+	}
+	log.Printf("cron: scheduled digest %q (%s) of %s every %v", name, id, source, interval)
+	return id
+}
+
 // Cancel removes a job by ID. Returns true if found.
 func (s *Scheduler) Cancel(id string) bool {
 	s.mu.Lock()
@@ -139,10 +178,16 @@ func (s *Scheduler) tick(now time.Time) {
 	s.mu.Lock()
 	// collect jobs to fire
 	var toFire []*Job
+	quietEnd, quiet := s.quiet.Until(now)
 	for _, j := range s.jobs {
-		if !j.fired && now.After(j.FireAt) {
-			toFire = append(toFire, j)
+		if j.fired || !now.After(j.FireAt) {
+			continue
+		}
+		if quiet {
+			s.holdForQuietHours(j, now, quietEnd)
+			continue
 		}
+		toFire = append(toFire, j)
 	}
 	// handle fired jobs while still holding lock
 	for _, j := range toFire {
@@ -163,3 +208,20 @@ func (s *Scheduler) tick(now time.Time) {
 		}
 	}
 }
+
+// holdForQuietHours defers or drops a due job during quiet hours.
+// Must be called with s.mu held.
+func (s *Scheduler) holdForQuietHours(j *Job, now, quietEnd time.Time) {
+	if !s.quiet.Drop {
+		j.FireAt = quietEnd
+		log.Printf("cron: quiet hours, deferring job %q (%s) until %s", j.Name, j.ID, quietEnd.Format(time.RFC3339))
+		return
+	}
+	if j.Recurring {
+		j.FireAt = now.Add(j.Interval)
+		log.Printf("cron: quiet hours, skipping run of recurring job %q (%s)", j.Name, j.ID)
+		return
+	}
+	delete(s.jobs, j.ID)
+	log.Printf("cron: quiet hours, dropping job %q (%s)", j.Name, j.ID)
+}
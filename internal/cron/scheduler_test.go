@@ -88,3 +88,76 @@ func TestSchedulerDoesNotFireCancelled(t *testing.T) {
 		t.Errorf("expected 0 fired jobs after cancel, got %d", len(fired))
 	}
 }
+
+func TestSchedulerQuietHoursDefersJob(t *testing.T) {
+	var fired []Job
+	s := NewScheduler(func(job Job) { fired = append(fired, job) })
+	q, err := ParseQuietHours("22:00", "07:00", "UTC")
+	if err != nil {
+		t.Fatalf("ParseQuietHours: %v", err)
+	}
+	s.SetQuietHours(q)
+
+	id := s.Add("late-reminder", "take out the bins", time.Minute, "telegram", "1")
+	night := time.Date(2026, 3, 2, 3, 0, 0, 0, time.UTC)
+	s.jobs[id].FireAt = night.Add(-time.Second)
+
+	s.tick(night)
+	if len(fired) != 0 {
+		t.Fatalf("expected job to be held during quiet hours, fired %d", len(fired))
+	}
+	wantEnd := time.Date(2026, 3, 2, 7, 0, 0, 0, time.UTC)
+	if got := s.jobs[id].FireAt; !got.Equal(wantEnd) {
+		t.Fatalf("expected job deferred to %v, got %v", wantEnd, got)
+	}
+
+	s.tick(wantEnd.Add(time.Second))
+	if len(fired) != 1 || fired[0].Name != "late-reminder" {
+		t.Fatalf("expected deferred job to fire after quiet hours, got %+v", fired)
+	}
+}
+
+func TestSchedulerQuietHoursDrop(t *testing.T) {
+	var fired []Job
+	s := NewScheduler(func(job Job) { fired = append(fired, job) })
+	q, _ := ParseQuietHours("22:00", "07:00", "UTC")
+	q.Drop = true
+	s.SetQuietHours(q)
+
+	id := s.Add("late-reminder", "take out the bins", time.Minute, "telegram", "1")
+	night := time.Date(2026, 3, 2, 23, 30, 0, 0, time.UTC)
+	s.jobs[id].FireAt = night.Add(-time.Second)
+
+	s.tick(night)
+	s.tick(night.Add(12 * time.Hour))
+	if len(fired) != 0 {
+		t.Fatalf("expected job to be dropped, fired %d", len(fired))
+	}
+	if len(s.List()) != 0 {
+		t.Fatalf("expected dropped job to be removed")
+	}
+}
+
+func TestQuietHoursUntil(t *testing.T) {
+	q, err := ParseQuietHours("22:00", "07:00", "America/New_York")
+	if err != nil {
+		t.Fatalf("ParseQuietHours: %v", err)
+	}
+	ny, _ := time.LoadLocation("America/New_York")
+
+	end, ok := q.Until(time.Date(2026, 3, 2, 23, 15, 0, 0, ny))
+	if !ok || !end.Equal(time.Date(2026, 3, 3, 7, 0, 0, 0, ny)) {
+		t.Fatalf("23:15 should be quiet until 07:00 next day, got %v %v", end, ok)
+	}
+	if _, ok := q.Until(time.Date(2026, 3, 2, 12, 0, 0, 0, ny)); ok {
+		t.Fatal("noon should not be quiet")
+	}
+	// 04:00 UTC is 23:00 the previous evening in New York.
+	if _, ok := q.Until(time.Date(2026, 3, 3, 4, 0, 0, 0, time.UTC)); !ok {
+		t.Fatal("expected timezone to be honoured")
+	}
+
+	if _, err := ParseQuietHours("25:00", "07:00", ""); err == nil {
+		t.Fatal("expected error for invalid start time")
+	}
+}
@@ -37,11 +37,13 @@ func StartHeartbeat(ctx context.Context, workspace string, interval time.Duratio
 
 				// Push heartbeat content into the agent loop for processing
 				log.Println("heartbeat: sending tasks to agent")
-				hub.In <- chat.Inbound{
+				if err := hub.Receive(ctx, chat.Inbound{
 					Channel:  "heartbeat",
 					ChatID:   "system",
 					SenderID: "heartbeat",
 					Content:  "[HEARTBEAT CHECK] Review and execute any pending tasks from HEARTBEAT.md:\n\n" + content,
+				}); err != nil {
+					log.Printf("heartbeat: dropping tasks: %v", err)
 				}
 			}
 		}
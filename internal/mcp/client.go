@@ -5,6 +5,7 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
@@ -14,6 +15,8 @@ import (
 	"sync"
 	"sync/atomic"
 	"time"
+
+	"github.com/local/picobot/internal/providers"
 )
 
 // Tool describes a tool exposed by an MCP server.
@@ -23,12 +26,60 @@ type Tool struct {
 	InputSchema map[string]interface{} `json:"inputSchema,omitempty"`
 }
 
+// Resource describes a resource exposed by an MCP server.
+type Resource struct {
+	URI         string `json:"uri"`
+	Name        string `json:"name,omitempty"`
+	Description string `json:"description,omitempty"`
+	MimeType    string `json:"mimeType,omitempty"`
+}
+
+// ResourceContent is one item returned by resources/read. Exactly one of
+// Text or Blob (base64) is set.
+type ResourceContent struct {
+	URI      string `json:"uri"`
+	MimeType string `json:"mimeType,omitempty"`
+	Text     string `json:"text,omitempty"`
+	Blob     string `json:"blob,omitempty"`
+}
+
+// Prompt describes a prompt template exposed by an MCP server.
+type Prompt struct {
+	Name        string           `json:"name"`
+	Description string           `json:"description,omitempty"`
+	Arguments   []PromptArgument `json:"arguments,omitempty"`
+}
+
+// PromptArgument describes one argument accepted by a prompt template.
+type PromptArgument struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	Required    bool   `json:"required,omitempty"`
+}
+
+// Default timeouts, used when a server's config leaves them unset.
+const (
+	DefaultInitTimeout    = 30 * time.Second
+	DefaultRequestTimeout = 60 * time.Second
+)
+
 // Client connects to a single MCP server and exposes its tools.
 type Client struct {
 	name      string
 	transport transport
 	nextID    atomic.Int64
-	tools     []Tool
+	// toolsMu guards tools and capabilities, which a refresh or a stale
+	// session's re-initialize may replace while other calls read them.
+	toolsMu        sync.RWMutex
+	tools          []Tool
+	capabilities   map[string]json.RawMessage
+	requestTimeout time.Duration
+	// stale is set when an HTTP session stops answering pings; the next
+	// request re-runs the initialize handshake first.
+	stale atomic.Bool
+	// closed is closed by Close, stopping any periodic tool refresh.
+	closed    chan struct{}
+	closeOnce sync.Once
 }
 
 // NewStdioClient creates a client that spawns a child process and communicates via stdin/stdout.
@@ -37,27 +88,29 @@ func NewStdioClient(name, command string, args []string) (*Client, error) {
 	if err != nil {
 		return nil, fmt.Errorf("mcp %s: %w", name, err)
 	}
-	c := &Client{name: name, transport: t}
-	if err := c.initialize(); err != nil {
-		_ = t.close()
-		return nil, fmt.Errorf("mcp %s: %w", name, err)
-	}
-	if err := c.loadTools(); err != nil {
-		_ = t.close()
-		return nil, fmt.Errorf("mcp %s: %w", name, err)
-	}
-	return c, nil
+	return connect(name, t, DefaultInitTimeout, DefaultRequestTimeout)
 }
 
 // NewHTTPClient creates a client that communicates via Streamable HTTP.
-func NewHTTPClient(name, url string, headers map[string]string) (*Client, error) {
+func NewHTTPClient(name, url string, headers map[string]string, opts ...HTTPOption) (*Client, error) {
 	t := newHTTPTransport(url, headers)
-	c := &Client{name: name, transport: t}
-	if err := c.initialize(); err != nil {
+	for _, opt := range opts {
+		opt(t)
+	}
+	return connect(name, t, DefaultInitTimeout, DefaultRequestTimeout)
+}
+
+// connect performs the initialize handshake and tool discovery over t,
+// closing t if either fails or takes longer than initTimeout.
+func connect(name string, t transport, initTimeout, requestTimeout time.Duration) (*Client, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), initTimeout)
+	defer cancel()
+	c := &Client{name: name, transport: t, requestTimeout: requestTimeout, closed: make(chan struct{})}
+	if err := c.initialize(ctx); err != nil {
 		_ = t.close()
 		return nil, fmt.Errorf("mcp %s: %w", name, err)
 	}
-	if err := c.loadTools(); err != nil {
+	if err := c.loadTools(ctx); err != nil {
 		_ = t.close()
 		return nil, fmt.Errorf("mcp %s: %w", name, err)
 	}
@@ -68,58 +121,260 @@ func NewHTTPClient(name, url string, headers map[string]string) (*Client, error)
 func (c *Client) Name() string { return c.name }
 
 // Tools: returns the tools discovered from this server.
-func (c *Client) Tools() []Tool { return c.tools }
+func (c *Client) Tools() []Tool {
+	c.toolsMu.RLock()
+	defer c.toolsMu.RUnlock()
+	return c.tools
+}
+
+// ContentItem is one item of a tools/call result. Text is set for "text"
+// items; Data (base64) and MimeType are set for "image" and "audio" items.
+type ContentItem struct {
+	Type     string `json:"type"`
+	Text     string `json:"text,omitempty"`
+	Data     string `json:"data,omitempty"`
+	MimeType string `json:"mimeType,omitempty"`
+}
 
 // CallTool: invokes a tool on the MCP server and returns the text result.
-func (c *Client) CallTool(_ context.Context, toolName string, arguments map[string]interface{}) (string, error) {
+// Non-text items are represented by a short placeholder; use CallToolContent
+// to get their data.
+func (c *Client) CallTool(ctx context.Context, toolName string, arguments map[string]interface{}) (string, error) {
+	items, err := c.CallToolContent(ctx, toolName, arguments)
+	if err != nil {
+		return "", err
+	}
+	return ContentText(items), nil
+}
+
+// CallToolContent invokes a tool and returns its content items unchanged.
+func (c *Client) CallToolContent(ctx context.Context, toolName string, arguments map[string]interface{}) ([]ContentItem, error) {
 	params := map[string]interface{}{
 		"name":      toolName,
 		"arguments": arguments,
 	}
-	result, err := c.request("tools/call", params)
+	result, err := c.request(ctx, "tools/call", params)
 	if err != nil {
-		return "", err
+		return nil, err
 	}
 	var resp struct {
-		Content []struct {
-			Type string `json:"type"`
-			Text string `json:"text,omitempty"`
-		} `json:"content"`
-		IsError bool `json:"isError,omitempty"`
+		Content []ContentItem `json:"content"`
+		IsError bool          `json:"isError,omitempty"`
 	}
 	if err := json.Unmarshal(result, &resp); err != nil {
-		return "", fmt.Errorf("parse tools/call: %w", err)
+		return nil, fmt.Errorf("parse tools/call: %w", err)
 	}
+	if resp.IsError {
+		return nil, fmt.Errorf("tool error: %s", ContentText(resp.Content))
+	}
+	return resp.Content, nil
+}
+
+// ContentText joins the text items of a tool result, one per line. Image and
+// audio items become placeholders such as "[image: image/png]".
+func ContentText(items []ContentItem) string {
 	var sb strings.Builder
-	for _, item := range resp.Content {
-		if item.Type == "text" {
-			if sb.Len() > 0 {
-				sb.WriteString("\n")
+	for _, item := range items {
+		var line string
+		switch item.Type {
+		case "text":
+			line = item.Text
+		case "image", "audio":
+			line = fmt.Sprintf("[%s: %s]", item.Type, item.MimeType)
+		default:
+			continue
+		}
+		if sb.Len() > 0 {
+			sb.WriteString("\n")
+		}
+		sb.WriteString(line)
+	}
+	return sb.String()
+}
+
+// HasCapability reports whether the server advertised the named capability
+// (e.g. "tools", "resources", "prompts") during initialize.
+func (c *Client) HasCapability(name string) bool {
+	c.toolsMu.RLock()
+	defer c.toolsMu.RUnlock()
+	_, ok := c.capabilities[name]
+	return ok
+}
+
+// ListResources returns every resource the server exposes, following
+// pagination cursors.
+func (c *Client) ListResources(ctx context.Context) ([]Resource, error) {
+	var all []Resource
+	cursor := ""
+	for {
+		var params interface{}
+		if cursor != "" {
+			params = map[string]interface{}{"cursor": cursor}
+		}
+		result, err := c.request(ctx, "resources/list", params)
+		if err != nil {
+			return nil, fmt.Errorf("resources/list: %w", err)
+		}
+		var resp struct {
+			Resources  []Resource `json:"resources"`
+			NextCursor string     `json:"nextCursor,omitempty"`
+		}
+		if err := json.Unmarshal(result, &resp); err != nil {
+			return nil, fmt.Errorf("parse resources/list: %w", err)
+		}
+		all = append(all, resp.Resources...)
+		if resp.NextCursor == "" {
+			return all, nil
+		}
+		cursor = resp.NextCursor
+	}
+}
+
+// ReadResource fetches the contents of the resource at uri.
+func (c *Client) ReadResource(ctx context.Context, uri string) ([]ResourceContent, error) {
+	result, err := c.request(ctx, "resources/read", map[string]interface{}{"uri": uri})
+	if err != nil {
+		return nil, fmt.Errorf("resources/read: %w", err)
+	}
+	var resp struct {
+		Contents []ResourceContent `json:"contents"`
+	}
+	if err := json.Unmarshal(result, &resp); err != nil {
+		return nil, fmt.Errorf("parse resources/read: %w", err)
+	}
+	return resp.Contents, nil
+}
+
+// ListPrompts returns every prompt template the server exposes, following
+// pagination cursors.
+func (c *Client) ListPrompts(ctx context.Context) ([]Prompt, error) {
+	var all []Prompt
+	cursor := ""
+	for {
+		var params interface{}
+		if cursor != "" {
+			params = map[string]interface{}{"cursor": cursor}
+		}
+		result, err := c.request(ctx, "prompts/list", params)
+		if err != nil {
+			return nil, fmt.Errorf("prompts/list: %w", err)
+		}
+		var resp struct {
+			Prompts    []Prompt `json:"prompts"`
+			NextCursor string   `json:"nextCursor,omitempty"`
+		}
+		if err := json.Unmarshal(result, &resp); err != nil {
+			return nil, fmt.Errorf("parse prompts/list: %w", err)
+		}
+		all = append(all, resp.Prompts...)
+		if resp.NextCursor == "" {
+			return all, nil
+		}
+		cursor = resp.NextCursor
+	}
+}
+
+// GetPrompt renders the named prompt with args and returns its messages,
+// ready to be placed into a provider conversation.
+func (c *Client) GetPrompt(ctx context.Context, name string, args map[string]string) ([]providers.Message, error) {
+	params := map[string]interface{}{"name": name}
+	if len(args) > 0 {
+		params["arguments"] = args
+	}
+	result, err := c.request(ctx, "prompts/get", params)
+	if err != nil {
+		return nil, fmt.Errorf("prompts/get: %w", err)
+	}
+	var resp struct {
+		Messages []struct {
+			Role    string `json:"role"`
+			Content struct {
+				Type     string `json:"type"`
+				Text     string `json:"text,omitempty"`
+				MimeType string `json:"mimeType,omitempty"`
+				Resource *struct {
+					URI  string `json:"uri"`
+					Text string `json:"text,omitempty"`
+				} `json:"resource,omitempty"`
+			} `json:"content"`
+		} `json:"messages"`
+	}
+	if err := json.Unmarshal(result, &resp); err != nil {
+		return nil, fmt.Errorf("parse prompts/get: %w", err)
+	}
+	msgs := make([]providers.Message, 0, len(resp.Messages))
+	for _, m := range resp.Messages {
+		role := m.Role
+		if role != "assistant" {
+			role = "user"
+		}
+		var text string
+		switch m.Content.Type {
+		case "text":
+			text = m.Content.Text
+		case "resource":
+			if m.Content.Resource != nil {
+				text = m.Content.Resource.Text
+				if text == "" {
+					text = fmt.Sprintf("[resource: %s]", m.Content.Resource.URI)
+				}
 			}
-			sb.WriteString(item.Text)
+		default:
+			text = fmt.Sprintf("[%s content: %s]", m.Content.Type, m.Content.MimeType)
 		}
+		msgs = append(msgs, providers.Message{Role: role, Content: text})
 	}
-	text := sb.String()
-	if resp.IsError {
-		return "", fmt.Errorf("tool error: %s", text)
+	return msgs, nil
+}
+
+// Ping checks that the server is still responsive. If an HTTP server fails to
+// answer, its session is dropped and re-initialized on the next request.
+func (c *Client) Ping(ctx context.Context) error {
+	if _, err := c.request(ctx, "ping", nil); err != nil {
+		if ht, ok := c.transport.(*httpTransport); ok {
+			ht.mu.Lock()
+			ht.sessionID = ""
+			ht.mu.Unlock()
+			c.stale.Store(true)
+		}
+		return fmt.Errorf("ping: %w", err)
 	}
-	return text, nil
+	return nil
 }
 
 // Close shuts down the MCP server connection.
-func (c *Client) Close() error { return c.transport.close() }
+func (c *Client) Close() error {
+	c.closeOnce.Do(func() { close(c.closed) })
+	return c.transport.close()
+}
 
 /*** internal helpers ***/
 
-func (c *Client) request(method string, params interface{}) (json.RawMessage, error) {
+// request sends a JSON-RPC request and waits for its response, bounded by the
+// client's request timeout as well as ctx.
+func (c *Client) request(ctx context.Context, method string, params interface{}) (json.RawMessage, error) {
+	if method != "initialize" && c.stale.CompareAndSwap(true, false) {
+		if err := c.initialize(ctx); err != nil {
+			c.stale.Store(true)
+			return nil, fmt.Errorf("re-initialize: %w", err)
+		}
+	}
+	if c.requestTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.requestTimeout)
+		defer cancel()
+	}
 	id := c.nextID.Add(1)
 	req := rpcRequest{JSONRPC: "2.0", ID: &id, Method: method, Params: params}
 	b, err := json.Marshal(req)
 	if err != nil {
 		return nil, err
 	}
-	resp, err := c.transport.roundTrip(b)
+	resp, err := c.transport.roundTrip(ctx, b)
 	if err != nil {
+		if ctx.Err() != nil && method != "initialize" {
+			c.cancelRequest(id, ctx.Err())
+		}
 		return nil, err
 	}
 	var rr rpcResponse
@@ -132,37 +387,85 @@ func (c *Client) request(method string, params interface{}) (json.RawMessage, er
 	return rr.Result, nil
 }
 
-func (c *Client) initialize() error {
+// cancelRequest tells the server to stop working on request id, which the
+// caller has abandoned. It is best-effort: the notification is sent in the
+// background so a slow or wedged server can't hold up the caller.
+func (c *Client) cancelRequest(id int64, reason error) {
+	notif := rpcRequest{JSONRPC: "2.0", Method: "notifications/cancelled", Params: map[string]interface{}{
+		"requestId": id,
+		"reason":    reason.Error(),
+	}}
+	b, err := json.Marshal(notif)
+	if err != nil {
+		return
+	}
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		_ = c.transport.notify(ctx, b)
+	}()
+}
+
+// protocolVersion is the MCP revision picobot requests during initialize.
+const protocolVersion = "2025-03-26"
+
+func (c *Client) initialize(ctx context.Context) error {
 	params := map[string]interface{}{
-		"protocolVersion": "2025-03-26",
+		"protocolVersion": protocolVersion,
 		"clientInfo": map[string]interface{}{
 			"name":    "picobot",
 			"version": "0.1.10",
 		},
 		"capabilities": map[string]interface{}{},
 	}
-	if _, err := c.request("initialize", params); err != nil {
+	result, err := c.request(ctx, "initialize", params)
+	if err != nil {
 		return fmt.Errorf("initialize: %w", err)
 	}
+	var init struct {
+		ProtocolVersion string                     `json:"protocolVersion"`
+		Capabilities    map[string]json.RawMessage `json:"capabilities"`
+	}
+	_ = json.Unmarshal(result, &init)
+	c.toolsMu.Lock()
+	c.capabilities = init.Capabilities
+	c.toolsMu.Unlock()
+	// HTTP transports echo the negotiated version on every later request.
+	if ht, ok := c.transport.(*httpTransport); ok {
+		if init.ProtocolVersion == "" {
+			init.ProtocolVersion = protocolVersion
+		}
+		ht.mu.Lock()
+		ht.protocolVersion = init.ProtocolVersion
+		ht.mu.Unlock()
+	}
 	// Send the required initialized notification (fire-and-forget).
 	notif := rpcRequest{JSONRPC: "2.0", Method: "notifications/initialized"}
 	b, _ := json.Marshal(notif)
-	return c.transport.notify(b)
+	return c.transport.notify(ctx, b)
+}
+
+func (c *Client) loadTools(ctx context.Context) error {
+	tools, err := c.listTools(ctx)
+	if err != nil {
+		return err
+	}
+	c.tools = tools
+	return nil
 }
 
-func (c *Client) loadTools() error {
-	result, err := c.request("tools/list", nil)
+func (c *Client) listTools(ctx context.Context) ([]Tool, error) {
+	result, err := c.request(ctx, "tools/list", nil)
 	if err != nil {
-		return fmt.Errorf("tools/list: %w", err)
+		return nil, fmt.Errorf("tools/list: %w", err)
 	}
 	var resp struct {
 		Tools []Tool `json:"tools"`
 	}
 	if err := json.Unmarshal(result, &resp); err != nil {
-		return fmt.Errorf("parse tools/list: %w", err)
+		return nil, fmt.Errorf("parse tools/list: %w", err)
 	}
-	c.tools = resp.Tools
-	return nil
+	return resp.Tools, nil
 }
 
 /*** JSON-RPC 2.0 types ***/
@@ -190,21 +493,154 @@ func (e *rpcError) Error() string {
 	return fmt.Sprintf("jsonrpc error %d: %s", e.Code, e.Message)
 }
 
+// Is lets callers match server errors against the typed sentinels below,
+// e.g. errors.Is(err, mcp.ErrMethodNotFound).
+func (e *rpcError) Is(target error) bool {
+	sentinel, ok := rpcErrorCodes[e.Code]
+	return ok && sentinel == target
+}
+
+// Standard JSON-RPC 2.0 error codes.
+const (
+	CodeParseError     = -32700
+	CodeInvalidRequest = -32600
+	CodeMethodNotFound = -32601
+	CodeInvalidParams  = -32602
+	CodeInternalError  = -32603
+)
+
+// Typed errors for the standard JSON-RPC error codes. A server error with one
+// of these codes satisfies errors.Is against the matching sentinel.
+var (
+	ErrParseError     = errors.New("mcp: parse error")
+	ErrInvalidRequest = errors.New("mcp: invalid request")
+	ErrMethodNotFound = errors.New("mcp: method not supported")
+	ErrInvalidParams  = errors.New("mcp: invalid params")
+	ErrInternalError  = errors.New("mcp: internal server error")
+)
+
+var rpcErrorCodes = map[int]error{
+	CodeParseError:     ErrParseError,
+	CodeInvalidRequest: ErrInvalidRequest,
+	CodeMethodNotFound: ErrMethodNotFound,
+	CodeInvalidParams:  ErrInvalidParams,
+	CodeInternalError:  ErrInternalError,
+}
+
 /*** transport interface ***/
 
 type transport interface {
-	roundTrip(req []byte) ([]byte, error) // send request, read response
-	notify(req []byte) error              // fire-and-forget notification
+	roundTrip(ctx context.Context, req []byte) ([]byte, error) // send request, read response
+	notify(ctx context.Context, req []byte) error              // fire-and-forget notification
 	close() error
 }
 
+/*** Response routing ***/
+
+// NotificationHandler receives notifications sent by a server, such as
+// "notifications/tools/list_changed" or progress updates.
+type NotificationHandler func(method string, params json.RawMessage)
+
+// rpcMux routes the messages read by a transport's single reader goroutine:
+// responses go to the request waiting on their id, notifications to the
+// handler. A request that times out doesn't leave its late response to be
+// mistaken for the next one.
+type rpcMux struct {
+	onNotify NotificationHandler
+
+	mu      sync.Mutex
+	pending map[string]chan []byte // keyed by raw JSON id
+	done    chan struct{}          // closed when the reader exits
+	readErr error
+}
+
+func newRPCMux(onNotify NotificationHandler) *rpcMux {
+	return &rpcMux{onNotify: onNotify, pending: make(map[string]chan []byte), done: make(chan struct{})}
+}
+
+// deliver routes one incoming message. Server-initiated requests and
+// responses nobody is waiting for are dropped.
+func (m *rpcMux) deliver(msg []byte) {
+	var probe struct {
+		ID     json.RawMessage `json:"id"`
+		Method string          `json:"method"`
+		Params json.RawMessage `json:"params"`
+	}
+	if json.Unmarshal(msg, &probe) != nil {
+		return
+	}
+	if probe.Method != "" {
+		if probe.ID == nil && m.onNotify != nil {
+			m.onNotify(probe.Method, probe.Params)
+		}
+		return
+	}
+	if probe.ID == nil {
+		return
+	}
+	m.mu.Lock()
+	ch, ok := m.pending[string(probe.ID)]
+	delete(m.pending, string(probe.ID))
+	m.mu.Unlock()
+	if ok {
+		ch <- append([]byte(nil), msg...)
+	}
+}
+
+// fail records why the reader stopped and releases all waiting requests.
+func (m *rpcMux) fail(err error) {
+	m.mu.Lock()
+	m.readErr = err
+	m.mu.Unlock()
+	close(m.done)
+}
+
+// call writes req and waits for the response with the same id.
+func (m *rpcMux) call(ctx context.Context, req []byte, write func([]byte) error) ([]byte, error) {
+	var probe struct {
+		ID json.RawMessage `json:"id"`
+	}
+	if err := json.Unmarshal(req, &probe); err != nil || probe.ID == nil {
+		return nil, fmt.Errorf("request has no id")
+	}
+	key := string(probe.ID)
+	ch := make(chan []byte, 1)
+	m.mu.Lock()
+	m.pending[key] = ch
+	m.mu.Unlock()
+	forget := func() {
+		m.mu.Lock()
+		delete(m.pending, key)
+		m.mu.Unlock()
+	}
+
+	if err := write(req); err != nil {
+		forget()
+		return nil, err
+	}
+	select {
+	case resp := <-ch:
+		return resp, nil
+	case <-ctx.Done():
+		forget()
+		return nil, ctx.Err()
+	case <-m.done:
+		forget()
+		m.mu.Lock()
+		defer m.mu.Unlock()
+		return nil, m.readErr
+	}
+}
+
 /*** Stdio transport ***/
 
+// stdioTransport speaks newline-delimited JSON-RPC over a child process's
+// stdin/stdout.
 type stdioTransport struct {
 	cmd     *exec.Cmd
 	stdin   io.WriteCloser
-	scanner *bufio.Scanner
-	mu      sync.Mutex
+	writeMu sync.Mutex
+	mux     *rpcMux
 }
 
 func newStdioTransport(command string, args []string) (*stdioTransport, error) {
@@ -226,96 +662,147 @@ func newStdioTransport(command string, args []string) (*stdioTransport, error) {
 	scanner := bufio.NewScanner(stdout)
 	scanner.Buffer(make([]byte, 1<<20), 1<<20) // 1 MB buffer
 
-	return &stdioTransport{cmd: cmd, stdin: stdin, scanner: scanner}, nil
+	t := &stdioTransport{cmd: cmd, stdin: stdin, mux: newRPCMux(nil)}
+	go t.readLoop(scanner)
+	return t, nil
 }
 
-func (t *stdioTransport) roundTrip(req []byte) ([]byte, error) {
-	t.mu.Lock()
-	defer t.mu.Unlock()
-
-	if _, err := t.stdin.Write(append(req, '\n')); err != nil {
-		return nil, fmt.Errorf("write: %w", err)
-	}
-
-	// Read lines until we get a JSON-RPC response (has an "id" field).
-	for t.scanner.Scan() {
-		line := t.scanner.Bytes()
-		if len(bytes.TrimSpace(line)) == 0 {
-			continue
-		}
-		var probe struct {
-			ID *json.RawMessage `json:"id"`
-		}
-		if json.Unmarshal(line, &probe) == nil && probe.ID != nil {
-			return append([]byte(nil), line...), nil
+func (t *stdioTransport) readLoop(scanner *bufio.Scanner) {
+	for scanner.Scan() {
+		if line := scanner.Bytes(); len(bytes.TrimSpace(line)) > 0 {
+			t.mux.deliver(line)
 		}
-		// Server notification — skip.
 	}
-	if err := t.scanner.Err(); err != nil {
-		return nil, err
+	err := scanner.Err()
+	if err == nil {
+		err = fmt.Errorf("unexpected EOF from MCP server")
 	}
-	return nil, fmt.Errorf("unexpected EOF from MCP server")
+	t.mux.fail(err)
 }
 
-func (t *stdioTransport) notify(req []byte) error {
-	t.mu.Lock()
-	defer t.mu.Unlock()
-	_, err := t.stdin.Write(append(req, '\n'))
-	return err
+func (t *stdioTransport) roundTrip(ctx context.Context, req []byte) ([]byte, error) {
+	return t.mux.call(ctx, req, t.write)
+}
+
+func (t *stdioTransport) notify(_ context.Context, req []byte) error {
+	return t.write(req)
+}
+
+func (t *stdioTransport) write(req []byte) error {
+	t.writeMu.Lock()
+	defer t.writeMu.Unlock()
+	if _, err := t.stdin.Write(append(req, '\n')); err != nil {
+		return fmt.Errorf("write: %w", err)
+	}
+	return nil
 }
 
 func (t *stdioTransport) close() error {
 	_ = t.stdin.Close()
-	if t.cmd.Process != nil {
-		return t.cmd.Process.Kill()
+	if t.cmd.Process == nil {
+		return nil
 	}
-	return nil
+	err := t.cmd.Process.Kill()
+	// Reap the child so it doesn't linger as a zombie.
+	_ = t.cmd.Wait()
+	return err
 }
 
 /*** HTTP transport (Streamable HTTP) ***/
 
+// TokenSource supplies bearer tokens for the HTTP transport. Token is called
+// before every request, so implementations may refresh expired credentials
+// (e.g. an OAuth 2.0 flow) transparently.
+type TokenSource interface {
+	Token(ctx context.Context) (string, error)
+}
+
+// StaticToken is a TokenSource that always returns the same token.
+type StaticToken string
+
+func (s StaticToken) Token(context.Context) (string, error) { return string(s), nil }
+
+// TokenFunc adapts an ordinary function (e.g. a refresh callback) to TokenSource.
+type TokenFunc func(ctx context.Context) (string, error)
+
+func (f TokenFunc) Token(ctx context.Context) (string, error) { return f(ctx) }
+
+// HTTPOption configures an HTTP client created by NewHTTPClient.
+type HTTPOption func(*httpTransport)
+
+// WithTokenSource attaches "Authorization: Bearer <token>" to every request,
+// including the session DELETE sent on Close.
+func WithTokenSource(ts TokenSource) HTTPOption {
+	return func(t *httpTransport) { t.tokens = ts }
+}
+
 type httpTransport struct {
 	url       string
 	headers   map[string]string
+	tokens    TokenSource
 	client    *http.Client
 	sessionID string
-	mu        sync.Mutex
+	// protocolVersion is set once initialize succeeds.
+	protocolVersion string
+	mu              sync.Mutex
 }
 
 func newHTTPTransport(url string, headers map[string]string) *httpTransport {
 	return &httpTransport{
 		url:     url,
 		headers: headers,
-		client:  &http.Client{Timeout: 60 * time.Second},
+		client:  &http.Client{}, // per-request deadlines come from the caller's context
 	}
 }
 
-func (t *httpTransport) roundTrip(req []byte) ([]byte, error) {
+func (t *httpTransport) roundTrip(ctx context.Context, req []byte) ([]byte, error) {
 	t.mu.Lock()
 	defer t.mu.Unlock()
-	return t.doPost(req)
+	return t.doPost(ctx, req)
 }
 
-func (t *httpTransport) notify(req []byte) error {
+func (t *httpTransport) notify(ctx context.Context, req []byte) error {
 	t.mu.Lock()
 	defer t.mu.Unlock()
-	_, err := t.doPost(req)
+	_, err := t.doPost(ctx, req)
 	return err
 }
 
-func (t *httpTransport) doPost(body []byte) ([]byte, error) {
-	httpReq, err := http.NewRequest("POST", t.url, bytes.NewReader(body))
+// setHeaders applies custom headers, bearer auth, and the protocol headers to
+// req. Protocol headers are set last so custom headers can never override them.
+func (t *httpTransport) setHeaders(req *http.Request) error {
+	for k, v := range t.headers {
+		req.Header.Set(k, v)
+	}
+	if t.tokens != nil {
+		token, err := t.tokens.Token(req.Context())
+		if err != nil {
+			return fmt.Errorf("token: %w", err)
+		}
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	for _, k := range []string{"Mcp-Session-Id", "Mcp-Protocol-Version"} {
+		req.Header.Del(k)
+	}
+	if t.sessionID != "" {
+		req.Header.Set("Mcp-Session-Id", t.sessionID)
+	}
+	if t.protocolVersion != "" {
+		req.Header.Set("Mcp-Protocol-Version", t.protocolVersion)
+	}
+	return nil
+}
+
+func (t *httpTransport) doPost(ctx context.Context, body []byte) ([]byte, error) {
+	httpReq, err := http.NewRequestWithContext(ctx, "POST", t.url, bytes.NewReader(body))
 	if err != nil {
 		return nil, err
 	}
+	if err := t.setHeaders(httpReq); err != nil {
+		return nil, err
+	}
 	httpReq.Header.Set("Content-Type", "application/json")
 	httpReq.Header.Set("Accept", "application/json, text/event-stream")
-	for k, v := range t.headers {
-		httpReq.Header.Set(k, v)
-	}
-	if t.sessionID != "" {
-		httpReq.Header.Set("Mcp-Session-Id", t.sessionID)
-	}
 
 	resp, err := t.client.Do(httpReq)
 	if err != nil {
@@ -342,7 +829,30 @@ func (t *httpTransport) doPost(body []byte) ([]byte, error) {
 	return io.ReadAll(resp.Body)
 }
 
-func (t *httpTransport) close() error { return nil }
+// close terminates the server-side session, if one was established.
+func (t *httpTransport) close() error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.sessionID == "" {
+		return nil
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, "DELETE", t.url, nil)
+	if err != nil {
+		return err
+	}
+	if err := t.setHeaders(req); err != nil {
+		return err
+	}
+	t.sessionID = ""
+	resp, err := t.client.Do(req)
+	if err != nil {
+		return err
+	}
+	_ = resp.Body.Close()
+	return nil
+}
 
 // parseSSE extracts the first JSON-RPC response from an SSE stream.
 func parseSSE(r io.Reader) ([]byte, error) {
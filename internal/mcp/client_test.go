@@ -1,11 +1,18 @@
 package mcp
 
 import (
+	"bufio"
 	"context"
 	"encoding/json"
+	"errors"
 	"net/http"
 	"net/http/httptest"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
 	"testing"
+	"time"
 )
 
 func TestHTTPClientInitializeAndListTools(t *testing.T) {
@@ -109,6 +116,99 @@ func TestHTTPClientCallTool(t *testing.T) {
 	}
 }
 
+func TestHTTPClientCallToolContentImage(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req rpcRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		switch req.Method {
+		case "initialize":
+			_ = json.NewEncoder(w).Encode(rpcResponse{JSONRPC: "2.0", ID: req.ID, Result: json.RawMessage(`{"capabilities":{}}`)})
+		case "notifications/initialized":
+			w.WriteHeader(http.StatusAccepted)
+		case "tools/list":
+			_ = json.NewEncoder(w).Encode(rpcResponse{JSONRPC: "2.0", ID: req.ID, Result: json.RawMessage(`{"tools":[{"name":"plot"}]}`)})
+		case "tools/call":
+			_ = json.NewEncoder(w).Encode(rpcResponse{JSONRPC: "2.0", ID: req.ID, Result: json.RawMessage(`{"content":[{"type":"text","text":"done"},{"type":"image","data":"AAAA","mimeType":"image/png"}]}`)})
+		}
+	}))
+	defer srv.Close()
+
+	client, err := NewHTTPClient("test", srv.URL, nil)
+	if err != nil {
+		t.Fatalf("NewHTTPClient: %v", err)
+	}
+	defer func() { _ = client.Close() }()
+
+	items, err := client.CallToolContent(context.TODO(), "plot", nil)
+	if err != nil {
+		t.Fatalf("CallToolContent: %v", err)
+	}
+	if len(items) != 2 || items[1].Type != "image" || items[1].Data != "AAAA" || items[1].MimeType != "image/png" {
+		t.Fatalf("image item not preserved: %+v", items)
+	}
+	if got := ContentText(items); got != "done\n[image: image/png]" {
+		t.Fatalf("unexpected ContentText %q", got)
+	}
+}
+
+func TestHTTPClientSendsCancelledNotification(t *testing.T) {
+	callID := make(chan int64, 1)
+	cancelled := make(chan map[string]interface{}, 1)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req rpcRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		switch req.Method {
+		case "initialize":
+			_ = json.NewEncoder(w).Encode(rpcResponse{JSONRPC: "2.0", ID: req.ID, Result: json.RawMessage(`{"capabilities":{}}`)})
+		case "notifications/initialized":
+			w.WriteHeader(http.StatusAccepted)
+		case "tools/list":
+			_ = json.NewEncoder(w).Encode(rpcResponse{JSONRPC: "2.0", ID: req.ID, Result: json.RawMessage(`{"tools":[{"name":"slow"}]}`)})
+		case "tools/call":
+			callID <- *req.ID
+			<-r.Context().Done() // never answers; the client gives up
+		case "notifications/cancelled":
+			params, _ := req.Params.(map[string]interface{})
+			cancelled <- params
+			w.WriteHeader(http.StatusAccepted)
+		}
+	}))
+	defer srv.Close()
+
+	client, err := NewHTTPClient("test", srv.URL, nil)
+	if err != nil {
+		t.Fatalf("NewHTTPClient: %v", err)
+	}
+	defer func() { _ = client.Close() }()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		<-callID
+		cancel()
+	}()
+	if _, err := client.CallTool(ctx, "slow", nil); !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected context.Canceled, got %v", err)
+	}
+
+	select {
+	case params := <-cancelled:
+		// The test server saw the call's id before the client cancelled.
+		if id, _ := params["requestId"].(float64); int64(id) != client.nextID.Load() {
+			t.Fatalf("cancelled wrong request: %v", params)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("no notifications/cancelled received")
+	}
+}
+
 func TestHTTPClientCallToolError(t *testing.T) {
 	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		var req rpcRequest
@@ -173,3 +273,270 @@ func TestHTTPClientSSEResponse(t *testing.T) {
 		t.Fatalf("expected 0 tools, got %d", len(client.Tools()))
 	}
 }
+
+func TestHTTPClientMethodNotFoundIsTyped(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req rpcRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		switch req.Method {
+		case "initialize":
+			_ = json.NewEncoder(w).Encode(rpcResponse{JSONRPC: "2.0", ID: req.ID, Result: json.RawMessage(`{"capabilities":{}}`)})
+		case "notifications/initialized":
+			w.WriteHeader(http.StatusAccepted)
+		case "tools/list":
+			_ = json.NewEncoder(w).Encode(rpcResponse{JSONRPC: "2.0", ID: req.ID, Result: json.RawMessage(`{"tools":[]}`)})
+		default:
+			_ = json.NewEncoder(w).Encode(rpcResponse{JSONRPC: "2.0", ID: req.ID, Error: &rpcError{Code: CodeMethodNotFound, Message: "Method not found"}})
+		}
+	}))
+	defer srv.Close()
+
+	client, err := NewHTTPClient("test", srv.URL, nil)
+	if err != nil {
+		t.Fatalf("NewHTTPClient: %v", err)
+	}
+	defer func() { _ = client.Close() }()
+
+	_, err = client.CallTool(context.TODO(), "anything", nil)
+	if err == nil {
+		t.Fatal("expected error for -32601 response")
+	}
+	if !errors.Is(err, ErrMethodNotFound) {
+		t.Fatalf("expected ErrMethodNotFound, got %v", err)
+	}
+	if errors.Is(err, ErrInvalidParams) {
+		t.Fatalf("-32601 must not match ErrInvalidParams")
+	}
+}
+
+func TestHTTPClientBearerToken(t *testing.T) {
+	var mu sync.Mutex
+	auth := map[string]string{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodDelete {
+			mu.Lock()
+			auth["DELETE"] = r.Header.Get("Authorization")
+			mu.Unlock()
+			return
+		}
+		var req rpcRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		mu.Lock()
+		auth[req.Method] = r.Header.Get("Authorization")
+		mu.Unlock()
+		w.Header().Set("Content-Type", "application/json")
+		switch req.Method {
+		case "initialize":
+			w.Header().Set("Mcp-Session-Id", "sess-1")
+			_ = json.NewEncoder(w).Encode(rpcResponse{JSONRPC: "2.0", ID: req.ID, Result: json.RawMessage(`{"capabilities":{}}`)})
+		case "notifications/initialized":
+			w.WriteHeader(http.StatusAccepted)
+		case "tools/list":
+			_ = json.NewEncoder(w).Encode(rpcResponse{JSONRPC: "2.0", ID: req.ID, Result: json.RawMessage(`{"tools":[]}`)})
+		case "tools/call":
+			_ = json.NewEncoder(w).Encode(rpcResponse{JSONRPC: "2.0", ID: req.ID, Result: json.RawMessage(`{"content":[{"type":"text","text":"ok"}]}`)})
+		}
+	}))
+	defer srv.Close()
+
+	calls := 0
+	ts := TokenFunc(func(context.Context) (string, error) {
+		calls++
+		return "tok-" + strconv.Itoa(calls), nil
+	})
+	client, err := NewHTTPClient("test", srv.URL, nil, WithTokenSource(ts))
+	if err != nil {
+		t.Fatalf("NewHTTPClient: %v", err)
+	}
+	if _, err := client.CallTool(context.TODO(), "echo", nil); err != nil {
+		t.Fatalf("CallTool: %v", err)
+	}
+	if err := client.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	for _, m := range []string{"initialize", "notifications/initialized", "tools/list", "tools/call", "DELETE"} {
+		if !strings.HasPrefix(auth[m], "Bearer tok-") {
+			t.Errorf("%s: expected bearer token, got %q", m, auth[m])
+		}
+	}
+	// The token source is consulted per request so refreshed tokens are picked up.
+	if auth["tools/call"] == auth["initialize"] {
+		t.Errorf("expected a fresh token per request, got %q twice", auth["initialize"])
+	}
+}
+
+func TestHTTPClientTokenSourceError(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Error("request should not be sent when the token source fails")
+	}))
+	defer srv.Close()
+
+	ts := TokenFunc(func(context.Context) (string, error) { return "", errors.New("expired") })
+	if _, err := NewHTTPClient("test", srv.URL, nil, WithTokenSource(ts)); err == nil || !strings.Contains(err.Error(), "expired") {
+		t.Fatalf("expected token error, got %v", err)
+	}
+}
+
+func TestHTTPClientCustomHeaders(t *testing.T) {
+	var mu sync.Mutex
+	seen := map[string]http.Header{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		key := r.Method
+		if r.Method == http.MethodPost {
+			var req rpcRequest
+			if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			key = req.Method
+			w.Header().Set("Content-Type", "application/json")
+			switch req.Method {
+			case "initialize":
+				w.Header().Set("Mcp-Session-Id", "sess-1")
+				_ = json.NewEncoder(w).Encode(rpcResponse{JSONRPC: "2.0", ID: req.ID, Result: json.RawMessage(`{"protocolVersion":"2025-03-26","capabilities":{}}`)})
+			case "notifications/initialized":
+				w.WriteHeader(http.StatusAccepted)
+			case "tools/list":
+				_ = json.NewEncoder(w).Encode(rpcResponse{JSONRPC: "2.0", ID: req.ID, Result: json.RawMessage(`{"tools":[]}`)})
+			}
+		}
+		mu.Lock()
+		seen[key] = r.Header.Clone()
+		mu.Unlock()
+	}))
+	defer srv.Close()
+
+	headers := map[string]string{
+		"X-Api-Key":            "key-123",
+		"X-Tenant":             "acme",
+		"Mcp-Session-Id":       "spoofed",
+		"Mcp-Protocol-Version": "1999-01-01",
+		"Content-Type":         "text/plain",
+	}
+	client, err := NewHTTPClient("test", srv.URL, headers)
+	if err != nil {
+		t.Fatalf("NewHTTPClient: %v", err)
+	}
+	if err := client.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	for _, m := range []string{"initialize", "notifications/initialized", "tools/list", "DELETE"} {
+		h := seen[m]
+		if h == nil {
+			t.Fatalf("%s: request not seen", m)
+		}
+		if h.Get("X-Api-Key") != "key-123" || h.Get("X-Tenant") != "acme" {
+			t.Errorf("%s: custom headers missing: %v", m, h)
+		}
+	}
+	if got := seen["initialize"].Get("Mcp-Session-Id"); got != "" {
+		t.Errorf("initialize: custom header must not set session id, got %q", got)
+	}
+	if got := seen["initialize"].Get("Content-Type"); got != "application/json" {
+		t.Errorf("initialize: Content-Type overridden, got %q", got)
+	}
+	for _, m := range []string{"notifications/initialized", "tools/list", "DELETE"} {
+		if got := seen[m].Get("Mcp-Session-Id"); got != "sess-1" {
+			t.Errorf("%s: expected session id sess-1, got %q", m, got)
+		}
+		if got := seen[m].Get("Mcp-Protocol-Version"); got != "2025-03-26" {
+			t.Errorf("%s: expected negotiated protocol version, got %q", m, got)
+		}
+	}
+}
+
+// TestHelperStdioServer is not a real test: when run as a subprocess with
+// MCP_TEST_STDIO_SERVER=1 it acts as a minimal stdio MCP server exposing the
+// comma-separated tools in MCP_TEST_STDIO_TOOLS (default "echo"). tools/call
+// responses are sent in reverse order of arrival to exercise id routing.
+func TestHelperStdioServer(t *testing.T) {
+	if os.Getenv("MCP_TEST_STDIO_SERVER") != "1" {
+		return
+	}
+	scanner := bufio.NewScanner(os.Stdin)
+	enc := json.NewEncoder(os.Stdout)
+	var held []rpcRequest
+	for scanner.Scan() {
+		var req struct {
+			rpcRequest
+			Params struct {
+				Name string `json:"name"`
+			} `json:"params"`
+		}
+		if json.Unmarshal(scanner.Bytes(), &req) != nil {
+			continue
+		}
+		switch req.Method {
+		case "initialize":
+			_ = enc.Encode(rpcResponse{JSONRPC: "2.0", ID: req.ID, Result: json.RawMessage(`{"capabilities":{"tools":{}}}`)})
+		case "tools/list":
+			// An unrelated server notification must be skipped by the client.
+			_ = enc.Encode(rpcRequest{JSONRPC: "2.0", Method: "notifications/message"})
+			names := []string{"echo"}
+			if env := os.Getenv("MCP_TEST_STDIO_TOOLS"); env != "" {
+				names = strings.Split(env, ",")
+			}
+			var tools []Tool
+			for _, n := range names {
+				tools = append(tools, Tool{Name: n})
+			}
+			b, _ := json.Marshal(map[string]interface{}{"tools": tools})
+			_ = enc.Encode(rpcResponse{JSONRPC: "2.0", ID: req.ID, Result: b})
+		case "tools/call":
+			r := req.rpcRequest
+			r.Method = req.Params.Name
+			held = append(held, r)
+			if len(held) == 2 {
+				for i := len(held) - 1; i >= 0; i-- {
+					text := `{"content":[{"type":"text","text":"` + held[i].Method + `"}]}`
+					_ = enc.Encode(rpcResponse{JSONRPC: "2.0", ID: held[i].ID, Result: json.RawMessage(text)})
+				}
+				held = nil
+			}
+		}
+	}
+	os.Exit(0)
+}
+
+func TestStdioClientRoutesResponsesByID(t *testing.T) {
+	t.Setenv("MCP_TEST_STDIO_SERVER", "1")
+	client, err := NewStdioClient("stdio", os.Args[0], []string{"-test.run=^TestHelperStdioServer$"})
+	if err != nil {
+		t.Fatalf("NewStdioClient: %v", err)
+	}
+	defer func() { _ = client.Close() }()
+
+	if len(client.Tools()) != 1 || client.Tools()[0].Name != "echo" {
+		t.Fatalf("unexpected tools: %+v", client.Tools())
+	}
+
+	var wg sync.WaitGroup
+	results := make([]string, 2)
+	errs := make([]error, 2)
+	for i, name := range []string{"first", "second"} {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			results[i], errs[i] = client.CallTool(context.Background(), name, nil)
+		}()
+	}
+	wg.Wait()
+	for i, want := range []string{"first", "second"} {
+		if errs[i] != nil || results[i] != want {
+			t.Errorf("call %d: got %q, %v; want %q", i, results[i], errs[i], want)
+		}
+	}
+}
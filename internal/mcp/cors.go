@@ -0,0 +1,85 @@
+package mcp
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"slices"
+	"strings"
+)
+
+// HTTPAccessConfig controls which browser origins and HTTP methods may reach
+// an HTTP endpoint that serves MCP. The zero value is the secure default:
+// only same-origin and non-browser clients, using POST, GET, and DELETE (the
+// methods of the Streamable HTTP transport).
+type HTTPAccessConfig struct {
+	// AllowedOrigins lists origins (e.g. "http://localhost:3000") whose
+	// browser requests are accepted. "*" allows any origin.
+	AllowedOrigins []string
+	// AllowedMethods overrides the default POST, GET, DELETE.
+	AllowedMethods []string
+	// BearerToken, if set, must be presented as "Authorization: Bearer
+	// <token>" on every request except CORS preflights. With a token set, a
+	// "*" origin is ignored: only the listed origins are allowed.
+	BearerToken string
+}
+
+var defaultMCPMethods = []string{http.MethodPost, http.MethodGet, http.MethodDelete}
+
+// WithHTTPAccess wraps next with origin, method and token checks. Requests
+// carrying an Origin header that isn't allowed get 403 (this also blocks DNS
+// rebinding attacks against local servers); disallowed methods get 405, and
+// requests without the configured bearer token 401. CORS preflight requests
+// from allowed origins are answered directly.
+func WithHTTPAccess(cfg HTTPAccessConfig, next http.Handler) http.Handler {
+	methods := slices.Clone(cfg.AllowedMethods)
+	if len(methods) == 0 {
+		methods = slices.Clone(defaultMCPMethods)
+	}
+	for i, m := range methods {
+		methods[i] = strings.ToUpper(m)
+	}
+	allowMethods := strings.Join(methods, ", ")
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		origin := r.Header.Get("Origin")
+		if origin != "" {
+			anyOrigin := cfg.BearerToken == "" && slices.Contains(cfg.AllowedOrigins, "*")
+			if !anyOrigin && !slices.Contains(cfg.AllowedOrigins, origin) {
+				http.Error(w, "origin not allowed", http.StatusForbidden)
+				return
+			}
+			w.Header().Set("Access-Control-Allow-Origin", origin)
+			w.Header().Add("Vary", "Origin")
+			w.Header().Set("Access-Control-Expose-Headers", "Mcp-Session-Id")
+		}
+
+		if r.Method == http.MethodOptions && origin != "" {
+			if want := r.Header.Get("Access-Control-Request-Method"); !slices.Contains(methods, want) {
+				http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+				return
+			}
+			w.Header().Set("Access-Control-Allow-Methods", allowMethods)
+			w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization, Mcp-Session-Id, Mcp-Protocol-Version")
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		if !slices.Contains(methods, r.Method) {
+			w.Header().Set("Allow", allowMethods)
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		if cfg.BearerToken != "" && !validBearer(r.Header.Get("Authorization"), cfg.BearerToken) {
+			w.Header().Set("WWW-Authenticate", "Bearer")
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// validBearer reports whether header is "Bearer <token>", comparing the
+// token in constant time.
+func validBearer(header, token string) bool {
+	got, ok := strings.CutPrefix(header, "Bearer ")
+	return ok && subtle.ConstantTimeCompare([]byte(got), []byte(token)) == 1
+}
@@ -0,0 +1,80 @@
+package mcp
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestWithHTTPAccess(t *testing.T) {
+	ok := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusOK) })
+	h := WithHTTPAccess(HTTPAccessConfig{AllowedOrigins: []string{"http://localhost:3000"}}, ok)
+
+	tests := []struct {
+		name, method, origin, preflight string
+		want                            int
+	}{
+		{"no origin (non-browser client)", "POST", "", "", http.StatusOK},
+		{"allowed origin", "POST", "http://localhost:3000", "", http.StatusOK},
+		{"disallowed origin", "POST", "https://evil.example", "", http.StatusForbidden},
+		{"disallowed method", "PUT", "", "", http.StatusMethodNotAllowed},
+		{"preflight from allowed origin", "OPTIONS", "http://localhost:3000", "POST", http.StatusNoContent},
+		{"preflight from disallowed origin", "OPTIONS", "https://evil.example", "POST", http.StatusForbidden},
+		{"preflight for disallowed method", "OPTIONS", "http://localhost:3000", "PUT", http.StatusMethodNotAllowed},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest(tt.method, "/mcp", nil)
+		if tt.origin != "" {
+			req.Header.Set("Origin", tt.origin)
+		}
+		if tt.preflight != "" {
+			req.Header.Set("Access-Control-Request-Method", tt.preflight)
+		}
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		if rec.Code != tt.want {
+			t.Errorf("%s: got %d, want %d", tt.name, rec.Code, tt.want)
+		}
+		if tt.want != http.StatusForbidden && tt.origin != "" && rec.Header().Get("Access-Control-Allow-Origin") != tt.origin {
+			t.Errorf("%s: missing Access-Control-Allow-Origin", tt.name)
+		}
+	}
+}
+
+func TestWithHTTPAccessBearerToken(t *testing.T) {
+	ok := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusOK) })
+	h := WithHTTPAccess(HTTPAccessConfig{AllowedOrigins: []string{"*", "https://app.example"}, BearerToken: "s3cret"}, ok)
+
+	tests := []struct {
+		name, method, origin, auth string
+		want                       int
+	}{
+		{"valid token", "POST", "", "Bearer s3cret", http.StatusOK},
+		{"missing token", "POST", "", "", http.StatusUnauthorized},
+		{"wrong token", "POST", "", "Bearer nope", http.StatusUnauthorized},
+		{"wrong scheme", "POST", "", "Basic s3cret", http.StatusUnauthorized},
+		{"listed origin with token", "POST", "https://app.example", "Bearer s3cret", http.StatusOK},
+		{"wildcard ignored with token", "POST", "https://other.example", "Bearer s3cret", http.StatusForbidden},
+		{"preflight needs no token", "OPTIONS", "https://app.example", "", http.StatusNoContent},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest(tt.method, "/mcp", nil)
+		if tt.origin != "" {
+			req.Header.Set("Origin", tt.origin)
+		}
+		if tt.method == "OPTIONS" {
+			req.Header.Set("Access-Control-Request-Method", "POST")
+		}
+		if tt.auth != "" {
+			req.Header.Set("Authorization", tt.auth)
+		}
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		if rec.Code != tt.want {
+			t.Errorf("%s: got %d, want %d", tt.name, rec.Code, tt.want)
+		}
+		if tt.want == http.StatusUnauthorized && rec.Header().Get("WWW-Authenticate") != "Bearer" {
+			t.Errorf("%s: missing WWW-Authenticate challenge", tt.name)
+		}
+	}
+}
@@ -0,0 +1,449 @@
+package mcp
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"path"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/local/picobot/internal/config"
+	"github.com/local/picobot/internal/providers"
+)
+
+// Manager owns the connections to all configured MCP servers.
+type Manager struct {
+	mu       sync.RWMutex
+	clients  map[string]*Client
+	failures map[string]error // servers whose last connection attempt failed
+	// onToolsChanged is called when a periodic refresh finds that a
+	// server's tool list changed.
+	onToolsChanged func()
+}
+
+// ServerFailure records why a configured server is unavailable.
+type ServerFailure struct {
+	Server string
+	Err    error
+}
+
+// NamedResource is a resource tagged with the server that exposes it.
+// ID is the namespaced identifier "{server}_{uri}" used by ReadResource.
+type NamedResource struct {
+	ID     string
+	Server string
+	Resource
+}
+
+// NamedPrompt is a prompt tagged with the server that exposes it.
+// ID is the namespaced identifier "{server}_{name}" used by GetPrompt.
+type NamedPrompt struct {
+	ID     string
+	Server string
+	Prompt
+}
+
+// NewManager creates an empty manager.
+func NewManager() *Manager {
+	return &Manager{clients: make(map[string]*Client), failures: make(map[string]error)}
+}
+
+// ConnectServer connects to the server described by cfg and registers it
+// under name. Command takes precedence over URL when both are set. If the
+// handshake fails or exceeds cfg.InitTimeoutS, the connection (and any
+// spawned process) is closed.
+//
+// It is safe to call at runtime. A server already connected under name is
+// replaced (and closed) only once the new connection succeeds.
+func (m *Manager) ConnectServer(name string, cfg config.MCPServerConfig) (*Client, error) {
+	client, err := m.connectServer(name, cfg)
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if err != nil {
+		if _, connected := m.clients[name]; !connected {
+			m.failures[name] = err
+		}
+		return nil, err
+	}
+	delete(m.failures, name)
+	if old, ok := m.clients[name]; ok {
+		_ = old.Close()
+	}
+	m.clients[name] = client
+	if cfg.RefreshIntervalS > 0 {
+		go m.watchTools(client, cfg, time.Duration(cfg.RefreshIntervalS)*time.Second)
+	}
+	return client, nil
+}
+
+// OnToolsChanged registers fn to be called whenever a periodic refresh
+// (see MCPServerConfig.RefreshIntervalS) finds a server's tools changed.
+func (m *Manager) OnToolsChanged(fn func()) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.onToolsChanged = fn
+}
+
+// watchTools re-lists c's tools every interval until c is closed.
+func (m *Manager) watchTools(c *Client, cfg config.MCPServerConfig, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-c.closed:
+			return
+		case <-ticker.C:
+		}
+		changed, err := m.refreshTools(c, cfg)
+		if err != nil {
+			log.Printf("MCP server %q: tool refresh failed: %v", c.name, err)
+			continue
+		}
+		if !changed {
+			continue
+		}
+		log.Printf("MCP server %q: tool list changed (%d tools)", c.name, len(c.Tools()))
+		m.mu.RLock()
+		fn := m.onToolsChanged
+		m.mu.RUnlock()
+		if fn != nil {
+			fn()
+		}
+	}
+}
+
+// refreshTools re-runs tools/list on c and replaces its tools, reporting
+// whether they differ from before.
+func (m *Manager) refreshTools(c *Client, cfg config.MCPServerConfig) (bool, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), c.requestTimeout)
+	defer cancel()
+	tools, err := c.listTools(ctx)
+	if err != nil {
+		return false, err
+	}
+	tools = prepareTools(c.name, tools, cfg)
+	c.toolsMu.Lock()
+	defer c.toolsMu.Unlock()
+	if reflect.DeepEqual(tools, c.tools) {
+		return false, nil
+	}
+	c.tools = tools
+	return true, nil
+}
+
+// DisconnectServer closes the connection to the named server and forgets
+// it, including any recorded connection failure.
+func (m *Manager) DisconnectServer(name string) error {
+	m.mu.Lock()
+	c, ok := m.clients[name]
+	_, failed := m.failures[name]
+	delete(m.clients, name)
+	delete(m.failures, name)
+	m.mu.Unlock()
+	if !ok {
+		if failed {
+			return nil
+		}
+		return fmt.Errorf("mcp: no server named %q", name)
+	}
+	return c.Close()
+}
+
+func (m *Manager) connectServer(name string, cfg config.MCPServerConfig) (*Client, error) {
+	var t transport
+	switch {
+	case cfg.Command != "":
+		st, err := newStdioTransport(cfg.Command, cfg.Args)
+		if err != nil {
+			return nil, fmt.Errorf("mcp %s: %w", name, err)
+		}
+		t = st
+	case strings.HasPrefix(cfg.URL, "ws://") || strings.HasPrefix(cfg.URL, "wss://"):
+		headers := cfg.Headers
+		if cfg.BearerToken != "" {
+			headers = make(map[string]string, len(cfg.Headers)+1)
+			for k, v := range cfg.Headers {
+				headers[k] = v
+			}
+			headers["Authorization"] = "Bearer " + cfg.BearerToken
+		}
+		initTimeout := DefaultInitTimeout
+		if cfg.InitTimeoutS > 0 {
+			initTimeout = time.Duration(cfg.InitTimeoutS) * time.Second
+		}
+		wt, err := dialWS(cfg.URL, headers, nil, initTimeout)
+		if err != nil {
+			return nil, fmt.Errorf("mcp %s: %w", name, err)
+		}
+		t = wt
+	case cfg.URL != "":
+		ht := newHTTPTransport(cfg.URL, cfg.Headers)
+		if cfg.BearerToken != "" {
+			WithTokenSource(StaticToken(cfg.BearerToken))(ht)
+		}
+		t = ht
+	default:
+		return nil, fmt.Errorf("mcp %s: no command or url configured", name)
+	}
+	initTimeout, requestTimeout := DefaultInitTimeout, DefaultRequestTimeout
+	if cfg.InitTimeoutS > 0 {
+		initTimeout = time.Duration(cfg.InitTimeoutS) * time.Second
+	}
+	if cfg.RequestTimeoutS > 0 {
+		requestTimeout = time.Duration(cfg.RequestTimeoutS) * time.Second
+	}
+	client, err := connect(name, t, initTimeout, requestTimeout)
+	if err != nil {
+		return nil, err
+	}
+	client.tools = prepareTools(name, client.tools, cfg)
+	return client, nil
+}
+
+// prepareTools applies the server's allow/deny filters and normalizes the
+// remaining tools' schemas.
+func prepareTools(name string, tools []Tool, cfg config.MCPServerConfig) []Tool {
+	tools = filterTools(tools, cfg.AllowTools, cfg.DenyTools)
+	for i, tool := range tools {
+		schema, changes := NormalizeSchema(tool.InputSchema)
+		if len(changes) > 0 {
+			log.Printf("MCP server %q: rewrote schema of tool %q for compatibility (%s)", name, tool.Name, strings.Join(changes, "; "))
+		}
+		tools[i].InputSchema = schema
+	}
+	return tools
+}
+
+// filterTools keeps tools matching any allow pattern (all tools if allow is
+// empty), then drops tools matching any deny pattern.
+func filterTools(tools []Tool, allow, deny []string) []Tool {
+	if len(allow) == 0 && len(deny) == 0 {
+		return tools
+	}
+	matches := func(patterns []string, name string) bool {
+		for _, p := range patterns {
+			if ok, _ := path.Match(p, name); ok {
+				return true
+			}
+		}
+		return false
+	}
+	var out []Tool
+	for _, t := range tools {
+		if len(allow) > 0 && !matches(allow, t.Name) {
+			continue
+		}
+		if matches(deny, t.Name) {
+			continue
+		}
+		out = append(out, t)
+	}
+	return out
+}
+
+// InitializeServers connects to every configured server. Servers that fail
+// to connect are logged and skipped.
+func (m *Manager) InitializeServers(servers map[string]config.MCPServerConfig) {
+	for name, cfg := range servers {
+		client, err := m.ConnectServer(name, cfg)
+		if err != nil {
+			log.Printf("MCP server %q: failed to connect: %v", name, err)
+			continue
+		}
+		if client.HasCapability("resources") {
+			log.Printf("MCP server %q: connected (%d tools, resources available)", name, len(client.Tools()))
+		} else {
+			log.Printf("MCP server %q: connected (%d tools)", name, len(client.Tools()))
+		}
+	}
+}
+
+// Clients returns the connected clients sorted by server name.
+func (m *Manager) Clients() []*Client {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	out := make([]*Client, 0, len(m.clients))
+	for _, c := range m.clients {
+		out = append(out, c)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].name < out[j].name })
+	return out
+}
+
+// NamedTool is a tool tagged with the client that serves it.
+type NamedTool struct {
+	Client *Client
+	Tool
+}
+
+// GetAllTools returns the registered tools of every connected server, after
+// allow/deny filtering, ordered by server name.
+func (m *Manager) GetAllTools() []NamedTool {
+	var all []NamedTool
+	for _, c := range m.Clients() {
+		for _, t := range c.Tools() {
+			all = append(all, NamedTool{Client: c, Tool: t})
+		}
+	}
+	return all
+}
+
+// Failures returns the servers that failed to connect, sorted by name.
+func (m *Manager) Failures() []ServerFailure {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	out := make([]ServerFailure, 0, len(m.failures))
+	for name, err := range m.failures {
+		out = append(out, ServerFailure{Server: name, Err: err})
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Server < out[j].Server })
+	return out
+}
+
+// UnavailableTool reports whether toolName ("mcp_{server}_{tool}") belongs to
+// a server that failed to connect, returning that server's failure.
+func (m *Manager) UnavailableTool(toolName string) (ServerFailure, bool) {
+	rest, ok := strings.CutPrefix(toolName, "mcp_")
+	if !ok {
+		return ServerFailure{}, false
+	}
+	var best ServerFailure
+	for _, f := range m.Failures() {
+		if strings.HasPrefix(rest, f.Server+"_") && len(f.Server) > len(best.Server) {
+			best = f
+		}
+	}
+	return best, best.Server != ""
+}
+
+// HealthCheck pings every connected server concurrently and returns each
+// server's status: nil if it answered, otherwise the error. Servers that
+// failed to connect are included with their connection error.
+func (m *Manager) HealthCheck(ctx context.Context) map[string]error {
+	status := make(map[string]error)
+	for _, f := range m.Failures() {
+		status[f.Server] = f.Err
+	}
+	var (
+		mu sync.Mutex
+		wg sync.WaitGroup
+	)
+	for _, c := range m.Clients() {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			err := c.Ping(ctx)
+			mu.Lock()
+			status[c.name] = err
+			mu.Unlock()
+		}()
+	}
+	wg.Wait()
+	return status
+}
+
+// HasResources reports whether any connected server advertises resources.
+func (m *Manager) HasResources() bool {
+	for _, c := range m.Clients() {
+		if c.HasCapability("resources") {
+			return true
+		}
+	}
+	return false
+}
+
+// GetAllResources lists resources across all servers that advertise the
+// resources capability. A server that fails to list is logged and skipped.
+func (m *Manager) GetAllResources(ctx context.Context) []NamedResource {
+	var all []NamedResource
+	for _, c := range m.Clients() {
+		if !c.HasCapability("resources") {
+			continue
+		}
+		resources, err := c.ListResources(ctx)
+		if err != nil {
+			log.Printf("MCP server %q: %v", c.name, err)
+			continue
+		}
+		for _, r := range resources {
+			all = append(all, NamedResource{ID: c.name + "_" + r.URI, Server: c.name, Resource: r})
+		}
+	}
+	return all
+}
+
+// ReadResource reads a resource by its namespaced ID ("{server}_{uri}").
+func (m *Manager) ReadResource(ctx context.Context, id string) ([]ResourceContent, error) {
+	c, uri, ok := m.resolve(id)
+	if !ok {
+		return nil, fmt.Errorf("mcp: no server for resource %q", id)
+	}
+	return c.ReadResource(ctx, uri)
+}
+
+// HasPrompts reports whether any connected server advertises prompts.
+func (m *Manager) HasPrompts() bool {
+	for _, c := range m.Clients() {
+		if c.HasCapability("prompts") {
+			return true
+		}
+	}
+	return false
+}
+
+// GetAllPrompts lists prompts across all servers that advertise the prompts
+// capability. A server that fails to list is logged and skipped.
+func (m *Manager) GetAllPrompts(ctx context.Context) []NamedPrompt {
+	var all []NamedPrompt
+	for _, c := range m.Clients() {
+		if !c.HasCapability("prompts") {
+			continue
+		}
+		prompts, err := c.ListPrompts(ctx)
+		if err != nil {
+			log.Printf("MCP server %q: %v", c.name, err)
+			continue
+		}
+		for _, p := range prompts {
+			all = append(all, NamedPrompt{ID: c.name + "_" + p.Name, Server: c.name, Prompt: p})
+		}
+	}
+	return all
+}
+
+// GetPrompt renders a prompt by its namespaced ID ("{server}_{name}").
+func (m *Manager) GetPrompt(ctx context.Context, id string, args map[string]string) ([]providers.Message, error) {
+	c, name, ok := m.resolve(id)
+	if !ok {
+		return nil, fmt.Errorf("mcp: no server for prompt %q", id)
+	}
+	return c.GetPrompt(ctx, name, args)
+}
+
+// resolve splits a namespaced "{server}_{rest}" ID into its client and the
+// server-local part.
+func (m *Manager) resolve(id string) (*Client, string, bool) {
+	clients := m.Clients()
+	// Longest name first so "a_b" wins over "a" for "a_b_x".
+	sort.Slice(clients, func(i, j int) bool { return len(clients[i].name) > len(clients[j].name) })
+	for _, c := range clients {
+		if rest, ok := strings.CutPrefix(id, c.name+"_"); ok {
+			return c, rest, true
+		}
+	}
+	return nil, "", false
+}
+
+// Close shuts down all server connections.
+func (m *Manager) Close() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for name, c := range m.clients {
+		_ = c.Close()
+		delete(m.clients, name)
+	}
+}
@@ -0,0 +1,473 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/local/picobot/internal/config"
+)
+
+// newResourceServer starts a fake MCP server. When withResources is false the
+// server does not advertise the resources capability.
+func newResourceServer(t *testing.T, withResources bool) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			rpcRequest
+			Params json.RawMessage `json:"params,omitempty"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		reply := func(result string) {
+			_ = json.NewEncoder(w).Encode(rpcResponse{JSONRPC: "2.0", ID: req.ID, Result: json.RawMessage(result)})
+		}
+		switch req.Method {
+		case "initialize":
+			if withResources {
+				reply(`{"capabilities":{"tools":{},"resources":{}}}`)
+			} else {
+				reply(`{"capabilities":{"tools":{}}}`)
+			}
+		case "notifications/initialized":
+			w.WriteHeader(http.StatusAccepted)
+		case "tools/list":
+			reply(`{"tools":[]}`)
+		case "resources/list":
+			if !withResources {
+				t.Error("resources/list sent to a server without the resources capability")
+			}
+			var p struct {
+				Cursor string `json:"cursor"`
+			}
+			_ = json.Unmarshal(req.Params, &p)
+			if p.Cursor == "" {
+				reply(`{"resources":[{"uri":"file:///notes.md","name":"notes","mimeType":"text/markdown"}],"nextCursor":"page2"}`)
+			} else {
+				reply(`{"resources":[{"uri":"file:///todo.txt","name":"todo"}]}`)
+			}
+		case "resources/read":
+			var p struct {
+				URI string `json:"uri"`
+			}
+			_ = json.Unmarshal(req.Params, &p)
+			reply(`{"contents":[{"uri":"` + p.URI + `","mimeType":"text/plain","text":"contents of ` + p.URI + `"}]}`)
+		}
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestManagerResources(t *testing.T) {
+	docs := newResourceServer(t, true)
+	plain := newResourceServer(t, false)
+
+	m := NewManager()
+	defer m.Close()
+	m.InitializeServers(map[string]config.MCPServerConfig{
+		"docs":  {URL: docs.URL},
+		"plain": {URL: plain.URL},
+	})
+	if len(m.Clients()) != 2 {
+		t.Fatalf("expected 2 clients, got %d", len(m.Clients()))
+	}
+	if !m.HasResources() {
+		t.Fatal("expected HasResources to be true")
+	}
+
+	resources := m.GetAllResources(context.Background())
+	if len(resources) != 2 {
+		t.Fatalf("expected 2 resources across pages, got %+v", resources)
+	}
+	if resources[0].ID != "docs_file:///notes.md" || resources[0].Server != "docs" {
+		t.Fatalf("unexpected namespacing: %+v", resources[0])
+	}
+
+	contents, err := m.ReadResource(context.Background(), "docs_file:///todo.txt")
+	if err != nil {
+		t.Fatalf("ReadResource: %v", err)
+	}
+	if len(contents) != 1 || contents[0].Text != "contents of file:///todo.txt" {
+		t.Fatalf("unexpected contents: %+v", contents)
+	}
+
+	if _, err := m.ReadResource(context.Background(), "missing_file:///x"); err == nil {
+		t.Fatal("expected error for unknown server")
+	}
+}
+
+func TestManagerInitializeSkipsUnconfigured(t *testing.T) {
+	m := NewManager()
+	defer m.Close()
+	m.InitializeServers(map[string]config.MCPServerConfig{"empty": {}})
+	if len(m.Clients()) != 0 {
+		t.Fatalf("expected no clients, got %d", len(m.Clients()))
+	}
+}
+
+func newPromptServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			rpcRequest
+			Params json.RawMessage `json:"params,omitempty"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		reply := func(result string) {
+			_ = json.NewEncoder(w).Encode(rpcResponse{JSONRPC: "2.0", ID: req.ID, Result: json.RawMessage(result)})
+		}
+		switch req.Method {
+		case "initialize":
+			reply(`{"capabilities":{"prompts":{}}}`)
+		case "notifications/initialized":
+			w.WriteHeader(http.StatusAccepted)
+		case "tools/list":
+			reply(`{"tools":[]}`)
+		case "prompts/list":
+			reply(`{"prompts":[{"name":"review","description":"Review code","arguments":[{"name":"lang","required":true}]}]}`)
+		case "prompts/get":
+			var p struct {
+				Name      string            `json:"name"`
+				Arguments map[string]string `json:"arguments"`
+			}
+			_ = json.Unmarshal(req.Params, &p)
+			if p.Name != "review" {
+				_ = json.NewEncoder(w).Encode(rpcResponse{JSONRPC: "2.0", ID: req.ID, Error: &rpcError{Code: CodeInvalidParams, Message: "unknown prompt"}})
+				return
+			}
+			reply(`{"messages":[
+				{"role":"user","content":{"type":"text","text":"Review this ` + p.Arguments["lang"] + ` code."}},
+				{"role":"assistant","content":{"type":"text","text":"Sure, paste it."}},
+				{"role":"user","content":{"type":"resource","resource":{"uri":"file:///style.md","text":"Use tabs."}}}
+			]}`)
+		}
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestManagerPrompts(t *testing.T) {
+	srv := newPromptServer(t)
+
+	m := NewManager()
+	defer m.Close()
+	m.InitializeServers(map[string]config.MCPServerConfig{"code": {URL: srv.URL}})
+	if !m.HasPrompts() {
+		t.Fatal("expected HasPrompts to be true")
+	}
+
+	prompts := m.GetAllPrompts(context.Background())
+	if len(prompts) != 1 || prompts[0].ID != "code_review" || len(prompts[0].Arguments) != 1 || !prompts[0].Arguments[0].Required {
+		t.Fatalf("unexpected prompts: %+v", prompts)
+	}
+
+	msgs, err := m.GetPrompt(context.Background(), "code_review", map[string]string{"lang": "Go"})
+	if err != nil {
+		t.Fatalf("GetPrompt: %v", err)
+	}
+	if len(msgs) != 3 {
+		t.Fatalf("expected 3 messages, got %+v", msgs)
+	}
+	if msgs[0].Role != "user" || msgs[0].Content != "Review this Go code." {
+		t.Fatalf("unexpected first message: %+v", msgs[0])
+	}
+	if msgs[1].Role != "assistant" || msgs[2].Content != "Use tabs." {
+		t.Fatalf("unexpected messages: %+v", msgs)
+	}
+
+	if _, err := m.GetPrompt(context.Background(), "code_missing", nil); !errors.Is(err, ErrInvalidParams) {
+		t.Fatalf("expected ErrInvalidParams, got %v", err)
+	}
+}
+
+func TestConnectServerInitTimeoutClosesProcess(t *testing.T) {
+	pidFile := filepath.Join(t.TempDir(), "pid")
+	m := NewManager()
+	defer m.Close()
+
+	start := time.Now()
+	_, err := m.ConnectServer("stalled", config.MCPServerConfig{
+		Command:      "sh",
+		Args:         []string{"-c", "echo $$ > " + pidFile + "; exec sleep 30"},
+		InitTimeoutS: 1,
+	})
+	if err == nil {
+		t.Fatal("expected ConnectServer to fail for a stalled server")
+	}
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected deadline exceeded, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Fatalf("ConnectServer took %v, expected ~1s", elapsed)
+	}
+	if len(m.Clients()) != 0 {
+		t.Fatal("stalled server must not be registered")
+	}
+
+	b, err := os.ReadFile(pidFile)
+	if err != nil {
+		t.Fatalf("read pid: %v", err)
+	}
+	pid, _ := strconv.Atoi(strings.TrimSpace(string(b)))
+	if err := syscall.Kill(pid, 0); err == nil {
+		t.Fatalf("server process %d still running after failed connect", pid)
+	}
+}
+
+func TestConnectServerRequestTimeout(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req rpcRequest
+		_ = json.NewDecoder(r.Body).Decode(&req)
+		w.Header().Set("Content-Type", "application/json")
+		switch req.Method {
+		case "initialize":
+			_ = json.NewEncoder(w).Encode(rpcResponse{JSONRPC: "2.0", ID: req.ID, Result: json.RawMessage(`{"capabilities":{}}`)})
+		case "notifications/initialized":
+			w.WriteHeader(http.StatusAccepted)
+		case "tools/list":
+			_ = json.NewEncoder(w).Encode(rpcResponse{JSONRPC: "2.0", ID: req.ID, Result: json.RawMessage(`{"tools":[]}`)})
+		case "tools/call":
+			select {
+			case <-r.Context().Done():
+			case <-time.After(5 * time.Second):
+			}
+		}
+	}))
+	defer srv.Close()
+
+	m := NewManager()
+	defer m.Close()
+	client, err := m.ConnectServer("slow", config.MCPServerConfig{URL: srv.URL, RequestTimeoutS: 1})
+	if err != nil {
+		t.Fatalf("ConnectServer: %v", err)
+	}
+	start := time.Now()
+	_, err = client.CallTool(context.Background(), "slow", nil)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected deadline exceeded, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 3*time.Second {
+		t.Fatalf("CallTool took %v, expected ~1s", elapsed)
+	}
+}
+
+func TestManagerRecordsFailures(t *testing.T) {
+	ok := newResourceServer(t, false)
+
+	m := NewManager()
+	defer m.Close()
+	m.InitializeServers(map[string]config.MCPServerConfig{
+		"good":   {URL: ok.URL},
+		"broken": {Command: "/nonexistent/mcp-server"},
+	})
+
+	failures := m.Failures()
+	if len(failures) != 1 || failures[0].Server != "broken" || failures[0].Err == nil {
+		t.Fatalf("unexpected failures: %+v", failures)
+	}
+	if f, found := m.UnavailableTool("mcp_broken_search"); !found || f.Server != "broken" {
+		t.Fatalf("expected mcp_broken_search to map to the broken server, got %+v %v", f, found)
+	}
+	if _, found := m.UnavailableTool("mcp_good_search"); found {
+		t.Fatal("tools of a connected server must not be reported unavailable")
+	}
+	if _, found := m.UnavailableTool("web"); found {
+		t.Fatal("non-MCP tools must not be reported unavailable")
+	}
+}
+
+func TestConnectServerFiltersTools(t *testing.T) {
+	t.Setenv("MCP_TEST_STDIO_SERVER", "1")
+	t.Setenv("MCP_TEST_STDIO_TOOLS", "read_file,read_dir,write_file,delete_file,search")
+	helper := []string{"-test.run=^TestHelperStdioServer$"}
+
+	tests := []struct {
+		name  string
+		allow []string
+		deny  []string
+		want  []string
+	}{
+		{"no filters", nil, nil, []string{"read_file", "read_dir", "write_file", "delete_file", "search"}},
+		{"allowlist", []string{"read_*", "search"}, nil, []string{"read_file", "read_dir", "search"}},
+		{"denylist", nil, []string{"delete_*", "write_file"}, []string{"read_file", "read_dir", "search"}},
+		{"allow then deny", []string{"read_*"}, []string{"read_dir"}, []string{"read_file"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := NewManager()
+			defer m.Close()
+			_, err := m.ConnectServer("fs", config.MCPServerConfig{
+				Command: os.Args[0], Args: helper, AllowTools: tt.allow, DenyTools: tt.deny,
+			})
+			if err != nil {
+				t.Fatalf("ConnectServer: %v", err)
+			}
+			var got []string
+			for _, nt := range m.GetAllTools() {
+				got = append(got, nt.Name)
+			}
+			if strings.Join(got, ",") != strings.Join(tt.want, ",") {
+				t.Fatalf("got tools %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// newPingServer starts a fake MCP server that answers ping only if
+// answerPing is set; otherwise ping requests hang. Other requests get an empty
+// result. It counts initialize calls.
+func newPingServer(t *testing.T, answerPing bool, inits *atomic.Int32) *httptest.Server {
+	t.Helper()
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req rpcRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		reply := func(result string) {
+			_ = json.NewEncoder(w).Encode(rpcResponse{JSONRPC: "2.0", ID: req.ID, Result: json.RawMessage(result)})
+		}
+		switch req.Method {
+		case "initialize":
+			inits.Add(1)
+			reply(`{"capabilities":{}}`)
+		case "notifications/initialized":
+			w.WriteHeader(http.StatusAccepted)
+		case "tools/list":
+			reply(`{"tools":[]}`)
+		case "ping":
+			if !answerPing {
+				<-r.Context().Done()
+				return
+			}
+			reply(`{}`)
+		default:
+			reply(`{}`)
+		}
+	}))
+}
+
+func TestManagerHealthCheck(t *testing.T) {
+	var upInits, staleInits atomic.Int32
+	up := newPingServer(t, true, &upInits)
+	defer up.Close()
+	stale := newPingServer(t, false, &staleInits)
+	defer stale.Close()
+
+	m := NewManager()
+	defer m.Close()
+	m.InitializeServers(map[string]config.MCPServerConfig{
+		"up":    {URL: up.URL},
+		"stale": {URL: stale.URL},
+		"down":  {},
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 300*time.Millisecond)
+	defer cancel()
+	status := m.HealthCheck(ctx)
+	if len(status) != 3 {
+		t.Fatalf("expected status for 3 servers, got %v", status)
+	}
+	if status["up"] != nil {
+		t.Fatalf("up: expected healthy, got %v", status["up"])
+	}
+	if !errors.Is(status["stale"], context.DeadlineExceeded) {
+		t.Fatalf("stale: expected deadline exceeded, got %v", status["stale"])
+	}
+	if status["down"] == nil {
+		t.Fatal("down: expected its connection error")
+	}
+
+	// The stale HTTP session is re-initialized before the next request.
+	var client *Client
+	for _, c := range m.Clients() {
+		if c.Name() == "stale" {
+			client = c
+		}
+	}
+	if _, err := client.ListResources(context.Background()); err != nil {
+		t.Fatalf("ListResources: %v", err)
+	}
+	if n := staleInits.Load(); n != 2 {
+		t.Fatalf("expected stale server to be initialized twice, got %d", n)
+	}
+	if n := upInits.Load(); n != 1 {
+		t.Fatalf("healthy server should not be re-initialized, got %d inits", n)
+	}
+}
+
+func TestManagerRefreshesTools(t *testing.T) {
+	var second atomic.Bool
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req rpcRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		reply := func(result string) {
+			_ = json.NewEncoder(w).Encode(rpcResponse{JSONRPC: "2.0", ID: req.ID, Result: json.RawMessage(result)})
+		}
+		switch req.Method {
+		case "initialize":
+			reply(`{"capabilities":{"tools":{}}}`)
+		case "notifications/initialized":
+			w.WriteHeader(http.StatusAccepted)
+		case "tools/list":
+			if second.Load() {
+				reply(`{"tools":[{"name":"alpha"},{"name":"beta"}]}`)
+			} else {
+				reply(`{"tools":[{"name":"alpha"}]}`)
+			}
+		}
+	}))
+	defer srv.Close()
+
+	m := NewManager()
+	defer m.Close()
+	changed := make(chan struct{}, 1)
+	m.OnToolsChanged(func() {
+		select {
+		case changed <- struct{}{}:
+		default:
+		}
+	})
+	if _, err := m.ConnectServer("s", config.MCPServerConfig{URL: srv.URL, RefreshIntervalS: 1}); err != nil {
+		t.Fatalf("ConnectServer: %v", err)
+	}
+	if got := len(m.GetAllTools()); got != 1 {
+		t.Fatalf("got %d tools before refresh, want 1", got)
+	}
+
+	second.Store(true)
+	select {
+	case <-changed:
+	case <-time.After(5 * time.Second):
+		t.Fatal("tools were not refreshed")
+	}
+	var names []string
+	for _, nt := range m.GetAllTools() {
+		names = append(names, nt.Name)
+	}
+	if strings.Join(names, ",") != "alpha,beta" {
+		t.Fatalf("got tools %v after refresh, want alpha,beta", names)
+	}
+}
@@ -0,0 +1,248 @@
+package mcp
+
+import (
+	"slices"
+	"sort"
+	"strings"
+)
+
+// NormalizeSchema rewrites a tool's input schema into the subset of JSON
+// Schema that OpenAI-style function calling accepts. It returns a new schema
+// (the input is not modified) and a sorted list describing what was changed,
+// empty if the schema was already acceptable.
+//
+// The rewrite:
+//   - ensures the top level is {"type": "object", "properties": {...}}
+//   - inlines local "$ref"s ("#/$defs/X", "#/definitions/X") and drops
+//     remote or recursive ones
+//   - merges "allOf" subschemas into their parent
+//   - turns "oneOf" into the equivalent-for-our-purposes "anyOf"
+//   - infers a missing "type" from "properties", "items", or "enum"
+//   - gives arrays without "items" an unconstrained item schema
+//   - drops "$schema", "$id", "$defs", and "definitions"
+func NormalizeSchema(schema map[string]interface{}) (map[string]interface{}, []string) {
+	if len(schema) == 0 {
+		// No parameters: nothing was wrong, just fill in the minimal form.
+		return map[string]interface{}{"type": "object", "properties": map[string]interface{}{}}, nil
+	}
+	n := &schemaNormalizer{root: schema, changes: make(map[string]bool)}
+	out, _ := n.node(schema, nil).(map[string]interface{})
+	if out == nil {
+		out = map[string]interface{}{}
+	}
+	if t, _ := out["type"].(string); t != "object" {
+		if t == "" {
+			n.note("added top-level type object")
+		} else {
+			n.note("replaced top-level type " + t + " with object")
+		}
+		out["type"] = "object"
+	}
+	if _, ok := out["properties"].(map[string]interface{}); !ok {
+		out["properties"] = map[string]interface{}{}
+		n.note("added empty properties")
+	}
+	changes := make([]string, 0, len(n.changes))
+	for c := range n.changes {
+		changes = append(changes, c)
+	}
+	sort.Strings(changes)
+	return out, changes
+}
+
+type schemaNormalizer struct {
+	root    map[string]interface{}
+	changes map[string]bool
+}
+
+func (n *schemaNormalizer) note(change string) { n.changes[change] = true }
+
+// node normalizes one schema value. refs holds the $refs being expanded on
+// the current path, to cut cycles.
+func (n *schemaNormalizer) node(v interface{}, refs []string) interface{} {
+	m, ok := v.(map[string]interface{})
+	if !ok {
+		return v
+	}
+
+	if ref, ok := m["$ref"].(string); ok {
+		target, found := n.resolve(ref)
+		switch {
+		case !found:
+			n.note("dropped unresolvable $ref")
+			target = map[string]interface{}{}
+		case slices.Contains(refs, ref):
+			n.note("dropped recursive $ref")
+			target = map[string]interface{}{}
+		default:
+			n.note("inlined $ref")
+		}
+		// Sibling keywords (e.g. a description) override the referenced schema.
+		merged := make(map[string]interface{}, len(target)+len(m))
+		for k, val := range target {
+			merged[k] = val
+		}
+		for k, val := range m {
+			if k != "$ref" {
+				merged[k] = val
+			}
+		}
+		if found && !slices.Contains(refs, ref) {
+			refs = append(refs[:len(refs):len(refs)], ref)
+		}
+		m = merged
+	}
+
+	out := make(map[string]interface{}, len(m))
+	for k, val := range m {
+		switch k {
+		case "$schema", "$id", "$defs", "definitions":
+			n.note("dropped " + k)
+		case "properties":
+			props, _ := val.(map[string]interface{})
+			np := make(map[string]interface{}, len(props))
+			for name, p := range props {
+				np[name] = n.node(p, refs)
+			}
+			out[k] = np
+		case "items", "additionalProperties", "not":
+			out[k] = n.node(val, refs)
+		case "anyOf", "oneOf", "allOf":
+			list, _ := val.([]interface{})
+			nl := make([]interface{}, 0, len(list))
+			for _, s := range list {
+				nl = append(nl, n.node(s, refs))
+			}
+			out[k] = nl
+		default:
+			out[k] = val
+		}
+	}
+
+	if all, ok := out["allOf"].([]interface{}); ok {
+		delete(out, "allOf")
+		for _, s := range all {
+			if sm, ok := s.(map[string]interface{}); ok {
+				mergeSchema(out, sm)
+			}
+		}
+		n.note("merged allOf")
+	}
+	if one, ok := out["oneOf"]; ok {
+		delete(out, "oneOf")
+		if _, exists := out["anyOf"]; !exists {
+			out["anyOf"] = one
+		}
+		n.note("replaced oneOf with anyOf")
+	}
+
+	if _, ok := out["type"]; !ok {
+		if t := inferType(out); t != "" {
+			out["type"] = t
+			n.note("inferred missing type")
+		}
+	}
+	if out["type"] == "array" {
+		if _, ok := out["items"]; !ok {
+			out["items"] = map[string]interface{}{}
+			n.note("added missing array items")
+		}
+	}
+	return out
+}
+
+// resolve looks up a local JSON pointer such as "#/$defs/Address".
+func (n *schemaNormalizer) resolve(ref string) (map[string]interface{}, bool) {
+	path, ok := strings.CutPrefix(ref, "#/")
+	if !ok {
+		return nil, false
+	}
+	var cur interface{} = n.root
+	for _, part := range strings.Split(path, "/") {
+		part = strings.ReplaceAll(strings.ReplaceAll(part, "~1", "/"), "~0", "~")
+		m, ok := cur.(map[string]interface{})
+		if !ok {
+			return nil, false
+		}
+		if cur, ok = m[part]; !ok {
+			return nil, false
+		}
+	}
+	m, ok := cur.(map[string]interface{})
+	return m, ok
+}
+
+// mergeSchema folds src into dst: properties and required are combined,
+// other keywords already set in dst win.
+func mergeSchema(dst, src map[string]interface{}) {
+	for k, v := range src {
+		switch k {
+		case "properties":
+			sp, _ := v.(map[string]interface{})
+			dp, _ := dst["properties"].(map[string]interface{})
+			if dp == nil {
+				dp = make(map[string]interface{}, len(sp))
+			}
+			for name, p := range sp {
+				if _, exists := dp[name]; !exists {
+					dp[name] = p
+				}
+			}
+			dst["properties"] = dp
+		case "required":
+			dst["required"] = appendUnique(toStrings(dst["required"]), toStrings(v)...)
+		default:
+			if _, exists := dst[k]; !exists {
+				dst[k] = v
+			}
+		}
+	}
+}
+
+func inferType(s map[string]interface{}) string {
+	if _, ok := s["properties"]; ok {
+		return "object"
+	}
+	if _, ok := s["items"]; ok {
+		return "array"
+	}
+	if enum, ok := s["enum"].([]interface{}); ok && len(enum) > 0 {
+		switch enum[0].(type) {
+		case string:
+			return "string"
+		case float64:
+			return "number"
+		case bool:
+			return "boolean"
+		}
+	}
+	return ""
+}
+
+func toStrings(v interface{}) []string {
+	switch vv := v.(type) {
+	case []string:
+		return vv
+	case []interface{}:
+		out := make([]string, 0, len(vv))
+		for _, x := range vv {
+			if s, ok := x.(string); ok {
+				out = append(out, s)
+			}
+		}
+		return out
+	}
+	return nil
+}
+
+func appendUnique(list []string, items ...string) []interface{} {
+	seen := make(map[string]bool)
+	var out []interface{}
+	for _, s := range append(slices.Clone(list), items...) {
+		if !seen[s] {
+			seen[s] = true
+			out = append(out, s)
+		}
+	}
+	return out
+}
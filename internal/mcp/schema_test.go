@@ -0,0 +1,92 @@
+package mcp
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func parseSchema(t *testing.T, s string) map[string]interface{} {
+	t.Helper()
+	var m map[string]interface{}
+	if err := json.Unmarshal([]byte(s), &m); err != nil {
+		t.Fatalf("bad test schema: %v", err)
+	}
+	return m
+}
+
+func TestNormalizeSchema(t *testing.T) {
+	tests := []struct {
+		name        string
+		in, want    string
+		wantChanged bool
+	}{
+		{
+			name:        "already fine",
+			in:          `{"type":"object","properties":{"q":{"type":"string"}},"required":["q"]}`,
+			want:        `{"type":"object","properties":{"q":{"type":"string"}},"required":["q"]}`,
+			wantChanged: false,
+		},
+		{
+			name: "pydantic $defs with allOf wrapper",
+			in: `{"$defs":{"Unit":{"enum":["c","f"],"title":"Unit"}},
+				"properties":{"city":{"type":"string"},"unit":{"allOf":[{"$ref":"#/$defs/Unit"}],"default":"c"}},
+				"required":["city"],"title":"weatherArguments"}`,
+			want: `{"type":"object","title":"weatherArguments","required":["city"],
+				"properties":{"city":{"type":"string"},"unit":{"type":"string","enum":["c","f"],"title":"Unit","default":"c"}}}`,
+			wantChanged: true,
+		},
+		{
+			name: "zod-to-json-schema definitions and $schema",
+			in: `{"$schema":"http://json-schema.org/draft-07/schema#","$ref":"#/definitions/args",
+				"definitions":{"args":{"type":"object","properties":{"tags":{"type":"array"}}}}}`,
+			want:        `{"type":"object","properties":{"tags":{"type":"array","items":{}}}}`,
+			wantChanged: true,
+		},
+		{
+			name: "allOf merging properties and required",
+			in: `{"allOf":[{"properties":{"a":{"type":"string"}},"required":["a"]},
+				{"properties":{"b":{"type":"number"}},"required":["b"]}]}`,
+			want:        `{"type":"object","properties":{"a":{"type":"string"},"b":{"type":"number"}},"required":["a","b"]}`,
+			wantChanged: true,
+		},
+		{
+			name: "recursive and remote refs",
+			in: `{"type":"object","$defs":{"Node":{"type":"object","properties":{"child":{"$ref":"#/$defs/Node"}}}},
+				"properties":{"tree":{"$ref":"#/$defs/Node"},"ext":{"$ref":"https://example.com/s.json","description":"external"}}}`,
+			want: `{"type":"object","properties":{
+				"tree":{"type":"object","properties":{"child":{}}},
+				"ext":{"description":"external"}}}`,
+			wantChanged: true,
+		},
+		{
+			name:        "oneOf becomes anyOf",
+			in:          `{"type":"object","properties":{"id":{"oneOf":[{"type":"string"},{"type":"integer"}]}}}`,
+			want:        `{"type":"object","properties":{"id":{"anyOf":[{"type":"string"},{"type":"integer"}]}}}`,
+			wantChanged: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			in := parseSchema(t, tt.in)
+			got, changes := NormalizeSchema(in)
+			if want := parseSchema(t, tt.want); !reflect.DeepEqual(got, want) {
+				gb, _ := json.Marshal(got)
+				t.Fatalf("got  %s\nwant %s", gb, tt.want)
+			}
+			if (len(changes) > 0) != tt.wantChanged {
+				t.Fatalf("changes = %v, wantChanged %v", changes, tt.wantChanged)
+			}
+			if !reflect.DeepEqual(in, parseSchema(t, tt.in)) {
+				t.Fatal("input schema was modified")
+			}
+		})
+	}
+}
+
+func TestNormalizeSchemaEmpty(t *testing.T) {
+	got, changes := NormalizeSchema(nil)
+	if got["type"] != "object" || got["properties"] == nil || len(changes) != 0 {
+		t.Fatalf("unexpected result for empty schema: %v %v", got, changes)
+	}
+}
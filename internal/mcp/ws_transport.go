@@ -0,0 +1,87 @@
+package mcp
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// NewWSClient creates a client that speaks JSON-RPC over a WebSocket
+// (ws:// or wss://) connection. onNotify, if non-nil, receives notifications
+// the server sends on its own initiative.
+func NewWSClient(name, url string, headers map[string]string, onNotify NotificationHandler) (*Client, error) {
+	t, err := dialWS(url, headers, onNotify, DefaultInitTimeout)
+	if err != nil {
+		return nil, fmt.Errorf("mcp %s: %w", name, err)
+	}
+	return connect(name, t, DefaultInitTimeout, DefaultRequestTimeout)
+}
+
+// wsTransport sends one JSON-RPC message per WebSocket text frame. A single
+// reader goroutine demultiplexes responses by id, as for stdio.
+type wsTransport struct {
+	conn    *websocket.Conn
+	writeMu sync.Mutex // gorilla allows only one concurrent writer
+	mux     *rpcMux
+}
+
+func dialWS(url string, headers map[string]string, onNotify NotificationHandler, timeout time.Duration) (*wsTransport, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	h := http.Header{}
+	for k, v := range headers {
+		h.Set(k, v)
+	}
+	conn, resp, err := websocket.DefaultDialer.DialContext(ctx, url, h)
+	if err != nil {
+		if resp != nil {
+			return nil, fmt.Errorf("dial %s: %w (HTTP %d)", url, err, resp.StatusCode)
+		}
+		return nil, fmt.Errorf("dial %s: %w", url, err)
+	}
+	conn.SetReadLimit(1 << 20) // 1 MB, matching the stdio line limit
+	t := &wsTransport{conn: conn, mux: newRPCMux(onNotify)}
+	go t.readLoop()
+	return t, nil
+}
+
+func (t *wsTransport) readLoop() {
+	for {
+		_, msg, err := t.conn.ReadMessage()
+		if err != nil {
+			t.mux.fail(fmt.Errorf("websocket read: %w", err))
+			return
+		}
+		t.mux.deliver(msg)
+	}
+}
+
+func (t *wsTransport) roundTrip(ctx context.Context, req []byte) ([]byte, error) {
+	return t.mux.call(ctx, req, t.write)
+}
+
+func (t *wsTransport) notify(_ context.Context, req []byte) error {
+	return t.write(req)
+}
+
+func (t *wsTransport) write(req []byte) error {
+	t.writeMu.Lock()
+	defer t.writeMu.Unlock()
+	if err := t.conn.WriteMessage(websocket.TextMessage, req); err != nil {
+		return fmt.Errorf("write: %w", err)
+	}
+	return nil
+}
+
+// close sends a close frame (best effort) and drops the connection.
+func (t *wsTransport) close() error {
+	t.writeMu.Lock()
+	_ = t.conn.WriteControl(websocket.CloseMessage,
+		websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""), time.Now().Add(time.Second))
+	t.writeMu.Unlock()
+	return t.conn.Close()
+}
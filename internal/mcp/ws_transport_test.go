@@ -0,0 +1,104 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+
+	"github.com/local/picobot/internal/config"
+)
+
+// newTestWSServer serves a minimal MCP server over WebSocket. Before each
+// tools/call response it sends a progress notification.
+func newTestWSServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	upgrader := websocket.Upgrader{}
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer secret" {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		for {
+			_, msg, err := conn.ReadMessage()
+			if err != nil {
+				return
+			}
+			var req rpcRequest
+			if json.Unmarshal(msg, &req) != nil || req.ID == nil {
+				continue // notifications
+			}
+			var result string
+			switch req.Method {
+			case "initialize":
+				result = `{"capabilities":{}}`
+			case "tools/list":
+				result = `{"tools":[{"name":"echo"}]}`
+			case "tools/call":
+				_ = conn.WriteMessage(websocket.TextMessage, []byte(`{"jsonrpc":"2.0","method":"notifications/progress","params":{"progress":1}}`))
+				result = `{"content":[{"type":"text","text":"pong"}]}`
+			}
+			b, _ := json.Marshal(rpcResponse{JSONRPC: "2.0", ID: req.ID, Result: json.RawMessage(result)})
+			_ = conn.WriteMessage(websocket.TextMessage, b)
+		}
+	}))
+}
+
+func TestWSClientCallToolAndNotifications(t *testing.T) {
+	srv := newTestWSServer(t)
+	defer srv.Close()
+	url := "ws" + strings.TrimPrefix(srv.URL, "http")
+
+	notes := make(chan string, 1)
+	client, err := NewWSClient("ws", url, map[string]string{"Authorization": "Bearer secret"}, func(method string, params json.RawMessage) {
+		notes <- method
+	})
+	if err != nil {
+		t.Fatalf("NewWSClient: %v", err)
+	}
+	defer func() { _ = client.Close() }()
+
+	if tools := client.Tools(); len(tools) != 1 || tools[0].Name != "echo" {
+		t.Fatalf("unexpected tools: %+v", tools)
+	}
+	got, err := client.CallTool(context.Background(), "echo", nil)
+	if err != nil {
+		t.Fatalf("CallTool: %v", err)
+	}
+	if got != "pong" {
+		t.Fatalf("expected pong, got %q", got)
+	}
+	select {
+	case m := <-notes:
+		if m != "notifications/progress" {
+			t.Fatalf("unexpected notification %q", m)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("notification handler not called")
+	}
+}
+
+func TestManagerSelectsWSTransport(t *testing.T) {
+	srv := newTestWSServer(t)
+	defer srv.Close()
+	url := "ws" + strings.TrimPrefix(srv.URL, "http")
+
+	m := NewManager()
+	defer m.Close()
+	if _, err := m.ConnectServer("ws", config.MCPServerConfig{URL: url, BearerToken: "secret"}); err != nil {
+		t.Fatalf("ConnectServer: %v", err)
+	}
+	if tools := m.GetAllTools(); len(tools) != 1 {
+		t.Fatalf("expected 1 tool, got %d", len(tools))
+	}
+}
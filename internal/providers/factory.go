@@ -1,19 +1,86 @@
 package providers
 
-import "github.com/local/picobot/internal/config"
+import (
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/local/picobot/internal/config"
+)
 
 // NewProviderFromConfig creates a provider based on the configuration.
 // Simple rules (v0):
-//   - if OpenAI API key present or API base is set (for Ollama) -> OpenAI
+//   - if an Ollama provider is configured -> Ollama
+//   - if OpenAI API key present or API base is set -> OpenAI
 //   - else fallback to stub
 func NewProviderFromConfig(cfg config.Config) LLMProvider {
+	if pc := cfg.Providers.Ollama; pc != nil {
+		timeoutS := cfg.Agents.Defaults.RequestTimeoutS
+		if pc.TimeoutS > 0 {
+			timeoutS = pc.TimeoutS
+		}
+		p := NewOllamaProvider(pc.APIBase, cfg.Agents.Defaults.Model, timeoutS, cfg.Agents.Defaults.MaxTokens)
+		p.ReasoningPolicy = pc.Reasoning
+		if pc.Transport != nil || pc.ConnectTimeoutS > 0 {
+			var tc config.HTTPTransportConfig
+			if pc.Transport != nil {
+				tc = *pc.Transport
+			}
+			p.Client.Transport = newHTTPTransport(tc, time.Duration(pc.ConnectTimeoutS)*time.Second)
+		}
+		return p
+	}
 	if cfg.Providers.OpenAI != nil && (cfg.Providers.OpenAI.APIKey != "" || cfg.Providers.OpenAI.APIBase != "") {
-		return NewOpenAIProvider(
-			cfg.Providers.OpenAI.APIKey,
-			cfg.Providers.OpenAI.APIBase,
-			cfg.Agents.Defaults.RequestTimeoutS,
-			cfg.Agents.Defaults.MaxTokens,
-		)
+		pc := cfg.Providers.OpenAI
+		timeoutS := cfg.Agents.Defaults.RequestTimeoutS
+		if pc.TimeoutS > 0 {
+			timeoutS = pc.TimeoutS
+		}
+		p := NewOpenAIProvider(pc.APIKey, pc.APIBase, timeoutS, cfg.Agents.Defaults.MaxTokens)
+		p.ReasoningPolicy = pc.Reasoning
+		p.EmbeddingModel = pc.EmbeddingModel
+		if pc.MaxAttempts > 0 {
+			p.MaxAttempts = pc.MaxAttempts
+		}
+		if pc.Transport != nil || pc.ConnectTimeoutS > 0 {
+			var tc config.HTTPTransportConfig
+			if pc.Transport != nil {
+				tc = *pc.Transport
+			}
+			p.Client.Transport = newHTTPTransport(tc, time.Duration(pc.ConnectTimeoutS)*time.Second)
+		}
+		return p
 	}
 	return NewStubProvider()
 }
+
+// newHTTPTransport clones Go's default transport and applies any non-zero
+// pooling and keep-alive settings from tc, and connect, if set, as the dial
+// and TLS handshake timeout.
+func newHTTPTransport(tc config.HTTPTransportConfig, connect time.Duration) *http.Transport {
+	t := http.DefaultTransport.(*http.Transport).Clone()
+	if tc.MaxIdleConns > 0 {
+		t.MaxIdleConns = tc.MaxIdleConns
+	}
+	if tc.MaxIdleConnsPerHost > 0 {
+		t.MaxIdleConnsPerHost = tc.MaxIdleConnsPerHost
+	}
+	if tc.MaxConnsPerHost > 0 {
+		t.MaxConnsPerHost = tc.MaxConnsPerHost
+	}
+	if tc.IdleConnTimeoutS > 0 {
+		t.IdleConnTimeout = time.Duration(tc.IdleConnTimeoutS) * time.Second
+	}
+	if tc.KeepAliveS > 0 || connect > 0 {
+		dialer := &net.Dialer{Timeout: 30 * time.Second, KeepAlive: 30 * time.Second}
+		if tc.KeepAliveS > 0 {
+			dialer.KeepAlive = time.Duration(tc.KeepAliveS) * time.Second
+		}
+		if connect > 0 {
+			dialer.Timeout = connect
+			t.TLSHandshakeTimeout = connect
+		}
+		t.DialContext = dialer.DialContext
+	}
+	return t
+}
@@ -1,7 +1,11 @@
 package providers
 
 import (
+	"context"
+	"net"
+	"net/http"
 	"testing"
+	"time"
 
 	"github.com/local/picobot/internal/config"
 )
@@ -24,3 +28,97 @@ func TestNewProviderFromConfig_FallbacksToStub(t *testing.T) {
 		t.Fatalf("expected StubProvider, got %T", p)
 	}
 }
+
+func TestNewProviderFromConfig_AppliesTransportTuning(t *testing.T) {
+	cfg := config.Config{}
+	cfg.Providers.OpenAI = &config.ProviderConfig{
+		APIKey: "test",
+		Transport: &config.HTTPTransportConfig{
+			MaxIdleConns:        200,
+			MaxIdleConnsPerHost: 50,
+			IdleConnTimeoutS:    120,
+			KeepAliveS:          15,
+		},
+	}
+	p := NewProviderFromConfig(cfg).(*OpenAIProvider)
+	tr, ok := p.Client.Transport.(*http.Transport)
+	if !ok {
+		t.Fatalf("expected *http.Transport, got %T", p.Client.Transport)
+	}
+	if tr.MaxIdleConns != 200 || tr.MaxIdleConnsPerHost != 50 {
+		t.Fatalf("pool sizes not applied: %d/%d", tr.MaxIdleConns, tr.MaxIdleConnsPerHost)
+	}
+	if tr.IdleConnTimeout != 120*time.Second {
+		t.Fatalf("idle timeout not applied: %v", tr.IdleConnTimeout)
+	}
+	if tr.DialContext == nil {
+		t.Fatal("expected custom dialer for keep-alive")
+	}
+	// Unset fields keep Go's defaults.
+	if tr.TLSHandshakeTimeout != http.DefaultTransport.(*http.Transport).TLSHandshakeTimeout {
+		t.Fatalf("unexpected TLS handshake timeout: %v", tr.TLSHandshakeTimeout)
+	}
+}
+
+func TestNewProviderFromConfig_DefaultTransport(t *testing.T) {
+	cfg := config.Config{}
+	cfg.Providers.OpenAI = &config.ProviderConfig{APIKey: "test"}
+	p := NewProviderFromConfig(cfg).(*OpenAIProvider)
+	if p.Client.Transport != nil {
+		t.Fatalf("expected default transport when unset, got %T", p.Client.Transport)
+	}
+}
+
+func TestNewProviderFromConfig_AppliesProviderTimeouts(t *testing.T) {
+	cfg := config.Config{}
+	cfg.Agents.Defaults.RequestTimeoutS = 60
+	cfg.Providers.OpenAI = &config.ProviderConfig{APIKey: "test", TimeoutS: 300, ConnectTimeoutS: 5}
+	p := NewProviderFromConfig(cfg).(*OpenAIProvider)
+	if p.Client.Timeout != 300*time.Second {
+		t.Fatalf("expected the provider's total timeout, got %v", p.Client.Timeout)
+	}
+	tr, ok := p.Client.Transport.(*http.Transport)
+	if !ok {
+		t.Fatalf("expected *http.Transport, got %T", p.Client.Transport)
+	}
+	if tr.TLSHandshakeTimeout != 5*time.Second || tr.DialContext == nil {
+		t.Fatalf("connect timeout not applied: %v", tr.TLSHandshakeTimeout)
+	}
+
+	// Without provider timeouts the global request timeout applies.
+	cfg.Providers.OpenAI = &config.ProviderConfig{APIKey: "test"}
+	p = NewProviderFromConfig(cfg).(*OpenAIProvider)
+	if p.Client.Timeout != 60*time.Second {
+		t.Fatalf("expected the global timeout, got %v", p.Client.Timeout)
+	}
+}
+
+func TestConnectTimeoutFailsFast(t *testing.T) {
+	// A server that accepts connections but never completes the TLS handshake.
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+	go func() {
+		for {
+			c, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			defer c.Close()
+		}
+	}()
+
+	cfg := config.Config{}
+	cfg.Providers.OpenAI = &config.ProviderConfig{APIKey: "test", APIBase: "https://" + ln.Addr().String(), TimeoutS: 30, ConnectTimeoutS: 1}
+	p := NewProviderFromConfig(cfg).(*OpenAIProvider)
+	p.IncompleteRetries = 0
+	start := time.Now()
+	if _, err := p.Chat(context.Background(), []Message{{Role: "user", Content: "hi"}}, nil, "m"); err == nil {
+		t.Fatal("expected a connect error")
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Fatalf("connect timeout not honoured, took %v", elapsed)
+	}
+}
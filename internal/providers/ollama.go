@@ -0,0 +1,324 @@
+package providers
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// OllamaProvider calls Ollama's native /api/chat endpoint. Unlike going
+// through its OpenAI-compatible API, it copes with models that don't
+// support tools: when Ollama rejects a request for that reason, the request
+// is repeated without tools, and later requests for that model skip them.
+type OllamaProvider struct {
+	APIBase      string // e.g. http://localhost:11434
+	DefaultModel string
+	MaxTokens    int // 0 means the model's default
+	Client       *http.Client
+	// ReasoningPolicy is ReasoningSeparate (default) or ReasoningStrip, and
+	// applies to the "thinking" field of reasoning models.
+	ReasoningPolicy string
+
+	mu      sync.Mutex
+	noTools map[string]bool // models known to reject tools
+}
+
+// defaultOllamaModel is used when neither the request nor the config names a
+// model.
+const defaultOllamaModel = "llama3.2"
+
+func NewOllamaProvider(apiBase, defaultModel string, timeoutSecs, maxTokens int) *OllamaProvider {
+	if apiBase == "" {
+		apiBase = "http://localhost:11434"
+	}
+	// Accept the base URL of the OpenAI-compatible API too.
+	apiBase = strings.TrimRight(apiBase, "/")
+	apiBase = strings.TrimSuffix(strings.TrimSuffix(apiBase, "/v1"), "/api")
+	if timeoutSecs <= 1 {
+		timeoutSecs = 60
+	}
+	return &OllamaProvider{
+		APIBase:      apiBase,
+		DefaultModel: defaultModel,
+		MaxTokens:    maxTokens,
+		Client:       &http.Client{Timeout: time.Duration(timeoutSecs) * time.Second},
+		noTools:      make(map[string]bool),
+	}
+}
+
+// GetDefaultModel returns the configured model, or llama3.2.
+func (p *OllamaProvider) GetDefaultModel() string {
+	if p.DefaultModel != "" {
+		return p.DefaultModel
+	}
+	return defaultOllamaModel
+}
+
+type ollamaRequest struct {
+	Model    string          `json:"model"`
+	Messages []ollamaMessage `json:"messages"`
+	Tools    []toolWrapper   `json:"tools,omitempty"`
+	Stream   bool            `json:"stream"`
+	Options  *ollamaOptions  `json:"options,omitempty"`
+}
+
+type ollamaOptions struct {
+	NumPredict int      `json:"num_predict,omitempty"`
+	Stop       []string `json:"stop,omitempty"`
+}
+
+type ollamaMessage struct {
+	Role      string           `json:"role"`
+	Content   string           `json:"content"`
+	Thinking  string           `json:"thinking,omitempty"`
+	ToolCalls []ollamaToolCall `json:"tool_calls,omitempty"`
+}
+
+type ollamaToolCall struct {
+	Function struct {
+		Name      string                 `json:"name"`
+		Arguments map[string]interface{} `json:"arguments"`
+	} `json:"function"`
+}
+
+// ollamaChunk is one line of a streamed /api/chat response.
+type ollamaChunk struct {
+	Message    ollamaMessage `json:"message"`
+	Done       bool          `json:"done"`
+	DoneReason string        `json:"done_reason"`
+	Error      string        `json:"error"`
+	// Token counts, sent with the final chunk.
+	PromptEvalCount int `json:"prompt_eval_count"`
+	EvalCount       int `json:"eval_count"`
+}
+
+// errToolsUnsupported is how Ollama answers a request with tools for a model
+// without tool support, e.g. "registry.ollama.ai/library/gemma2:2b does not
+// support tools".
+const errToolsUnsupported = "does not support tools"
+
+// Ping lists the installed models, which checks that Ollama is reachable.
+func (p *OllamaProvider) Ping(ctx context.Context) error {
+	req, err := http.NewRequestWithContext(ctx, "GET", p.APIBase+"/api/tags", nil)
+	if err != nil {
+		return err
+	}
+	resp, err := p.Client.Do(req)
+	if err != nil {
+		return fmt.Errorf("cannot reach Ollama at %s: %w", p.APIBase, err)
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, resp.Body)
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("Ollama at %s returned %s for /api/tags", p.APIBase, resp.Status)
+	}
+	return nil
+}
+
+// Chat calls /api/chat with streaming on and assembles the reply.
+func (p *OllamaProvider) Chat(ctx context.Context, messages []Message, tools []ToolDefinition, model string) (LLMResponse, error) {
+	return p.chatWithFallback(ctx, messages, tools, model, nil)
+}
+
+// ChatStream is Chat, sending the reply's text as it arrives.
+func (p *OllamaProvider) ChatStream(ctx context.Context, messages []Message, tools []ToolDefinition, model string) (<-chan Delta, error) {
+	ch := make(chan Delta, 16)
+	go func() {
+		defer close(ch)
+		resp, err := p.chatWithFallback(ctx, messages, tools, model, func(d Delta) {
+			select {
+			case ch <- d:
+			case <-ctx.Done():
+			}
+		})
+		ch <- Delta{Done: true, Response: resp, Err: err}
+	}()
+	return ch, nil
+}
+
+// chatWithFallback sends the request, without tools if the model can't
+// take them, passing streamed text to emit if it isn't nil.
+func (p *OllamaProvider) chatWithFallback(ctx context.Context, messages []Message, tools []ToolDefinition, model string, emit func(Delta)) (LLMResponse, error) {
+	if model == "" {
+		model = p.GetDefaultModel()
+	}
+	p.mu.Lock()
+	skipTools := p.noTools[model]
+	p.mu.Unlock()
+	if skipTools && len(tools) > 0 {
+		return p.chat(ctx, withoutTools(messages, tools, model), nil, model, emit)
+	}
+
+	resp, err := p.chat(ctx, messages, tools, model, emit)
+	if err != nil && len(tools) > 0 && strings.Contains(err.Error(), errToolsUnsupported) {
+		log.Printf("Ollama: model %s does not support tools; continuing without them", model)
+		p.mu.Lock()
+		p.noTools[model] = true
+		p.mu.Unlock()
+		return p.chat(ctx, withoutTools(messages, tools, model), nil, model, emit)
+	}
+	return resp, err
+}
+
+func (p *OllamaProvider) chat(ctx context.Context, messages []Message, tools []ToolDefinition, model string, emit func(Delta)) (LLMResponse, error) {
+	reqBody := ollamaRequest{Model: model, Messages: make([]ollamaMessage, 0, len(messages)), Stream: true}
+	opts := ollamaOptions{NumPredict: p.MaxTokens, Stop: StopFromContext(ctx)}
+	if n := MaxTokensFromContext(ctx); n > 0 {
+		opts.NumPredict = n
+	}
+	if opts.NumPredict > 0 || len(opts.Stop) > 0 {
+		reqBody.Options = &opts
+	}
+	for _, m := range messages {
+		om := ollamaMessage{Role: m.Role, Content: m.Content}
+		for _, tc := range m.ToolCalls {
+			var c ollamaToolCall
+			c.Function.Name = tc.Name
+			c.Function.Arguments = tc.Arguments
+			om.ToolCalls = append(om.ToolCalls, c)
+		}
+		reqBody.Messages = append(reqBody.Messages, om)
+	}
+	for _, t := range tools {
+		params := t.Parameters
+		if params == nil {
+			params = map[string]interface{}{"type": "object", "properties": map[string]interface{}{}}
+		}
+		reqBody.Tools = append(reqBody.Tools, toolWrapper{Type: "function", Function: functionDef{Name: t.Name, Description: t.Description, Parameters: params}})
+	}
+	b, err := json.Marshal(reqBody)
+	if err != nil {
+		return LLMResponse{}, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", p.APIBase+"/api/chat", bytes.NewReader(b))
+	if err != nil {
+		return LLMResponse{}, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := p.Client.Do(req)
+	if err != nil {
+		return LLMResponse{}, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		var e struct {
+			Error string `json:"error"`
+		}
+		msg := strings.TrimSpace(string(body))
+		if json.Unmarshal(body, &e) == nil && e.Error != "" {
+			msg = e.Error
+		}
+		return LLMResponse{}, &StatusError{Provider: "Ollama", StatusCode: resp.StatusCode, Status: resp.Status, Body: msg}
+	}
+
+	var content, thinking strings.Builder
+	var calls []ToolCall
+	var usage Usage
+	done := false
+	incomplete := false
+	sc := bufio.NewScanner(resp.Body)
+	sc.Buffer(make([]byte, 64<<10), 4<<20)
+	for sc.Scan() {
+		line := bytes.TrimSpace(sc.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+		var chunk ollamaChunk
+		if err := json.Unmarshal(line, &chunk); err != nil {
+			return LLMResponse{}, fmt.Errorf("Ollama API: bad stream line: %w", err)
+		}
+		if chunk.Error != "" {
+			return LLMResponse{}, fmt.Errorf("Ollama API error: %s", chunk.Error)
+		}
+		content.WriteString(chunk.Message.Content)
+		thinking.WriteString(chunk.Message.Thinking)
+		if emit != nil {
+			d := Delta{Content: chunk.Message.Content}
+			if p.ReasoningPolicy != ReasoningStrip {
+				d.Reasoning = chunk.Message.Thinking
+			}
+			if d.Content != "" || d.Reasoning != "" {
+				emit(d)
+			}
+		}
+		for _, tc := range chunk.Message.ToolCalls {
+			calls = append(calls, ToolCall{ID: fmt.Sprintf("call_%d", len(calls)+1), Name: tc.Function.Name, Arguments: tc.Function.Arguments})
+		}
+		if chunk.Done {
+			usage = Usage{PromptTokens: chunk.PromptEvalCount, CompletionTokens: chunk.EvalCount, TotalTokens: chunk.PromptEvalCount + chunk.EvalCount}
+			done = true
+			incomplete = chunk.DoneReason == "length"
+			break
+		}
+	}
+	if !done {
+		if ctx.Err() != nil {
+			return LLMResponse{}, ctx.Err()
+		}
+		if content.Len() == 0 && len(calls) == 0 {
+			if err := sc.Err(); err != nil {
+				return LLMResponse{}, fmt.Errorf("Ollama API: %w", err)
+			}
+			return LLMResponse{}, fmt.Errorf("Ollama API: stream ended without a reply")
+		}
+		log.Printf("Ollama API: stream cut off, using the %d bytes of content received", content.Len())
+		incomplete = true
+	}
+
+	reasoning := strings.TrimSpace(thinking.String())
+	if p.ReasoningPolicy == ReasoningStrip {
+		reasoning = ""
+	}
+	return LLMResponse{
+		Content:      strings.TrimSpace(content.String()),
+		Reasoning:    reasoning,
+		HasToolCalls: len(calls) > 0,
+		ToolCalls:    calls,
+		Incomplete:   incomplete,
+		Usage:        usage,
+	}, nil
+}
+
+// withoutTools prepares messages for a model that can't call tools: tool
+// calls and results in the history become plain text, and the system prompt
+// says which tools exist but can't be used, so the model doesn't pretend to
+// call them.
+func withoutTools(messages []Message, tools []ToolDefinition, model string) []Message {
+	var note strings.Builder
+	fmt.Fprintf(&note, "\n\nNote: the model %s cannot call tools, so none of your tools are available in this conversation. Answer from your own knowledge, and if a request needs a tool, say so. The tools you would otherwise have:\n", model)
+	for _, t := range tools {
+		fmt.Fprintf(&note, "- %s: %s\n", t.Name, t.Description)
+	}
+	out := make([]Message, 0, len(messages)+1)
+	noted := false
+	for _, m := range messages {
+		switch {
+		case m.Role == "system" && !noted:
+			m.Content += note.String()
+			noted = true
+		case m.Role == "tool":
+			m = Message{Role: "user", Content: "[tool result] " + m.Content}
+		case len(m.ToolCalls) > 0:
+			var names []string
+			for _, tc := range m.ToolCalls {
+				names = append(names, tc.Name)
+			}
+			m = Message{Role: m.Role, Content: strings.TrimSpace(m.Content + "\n[called " + strings.Join(names, ", ") + "]")}
+		}
+		out = append(out, m)
+	}
+	if !noted {
+		out = append([]Message{{Role: "system", Content: strings.TrimSpace(note.String())}}, out...)
+	}
+	return out
+}
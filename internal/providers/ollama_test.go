@@ -0,0 +1,102 @@
+package providers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/local/picobot/internal/config"
+)
+
+func TestOllamaFallsBackWhenModelRejectsTools(t *testing.T) {
+	var calls atomic.Int32
+	var lastReq ollamaRequest
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/chat" {
+			t.Errorf("unexpected path %s", r.URL.Path)
+		}
+		calls.Add(1)
+		var req ollamaRequest
+		json.NewDecoder(r.Body).Decode(&req)
+		lastReq = req
+		if len(req.Tools) > 0 {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"error":"registry.ollama.ai/library/gemma2:2b does not support tools"}`))
+			return
+		}
+		w.Header().Set("Content-Type", "application/x-ndjson")
+		w.Write([]byte(`{"message":{"role":"assistant","content":"Hel"},"done":false}
+{"message":{"role":"assistant","content":"lo!"},"done":false}
+{"message":{"role":"assistant","content":""},"done":true,"done_reason":"stop"}
+`))
+	}))
+	defer srv.Close()
+
+	p := NewOllamaProvider(srv.URL+"/v1", "gemma2:2b", 10, 0)
+	msgs := []Message{{Role: "system", Content: "You are picobot."}, {Role: "user", Content: "hi"}}
+	tools := []ToolDefinition{{Name: "web", Description: "Fetch a URL"}}
+
+	resp, err := p.Chat(context.Background(), msgs, tools, "")
+	if err != nil {
+		t.Fatalf("Chat should fall back, got %v", err)
+	}
+	if resp.Content != "Hello!" || resp.HasToolCalls {
+		t.Fatalf("unexpected response %+v", resp)
+	}
+	if calls.Load() != 2 {
+		t.Fatalf("expected the request to be retried once, got %d calls", calls.Load())
+	}
+	if lastReq.Model != "gemma2:2b" || !lastReq.Stream {
+		t.Fatalf("retry should stream with the default model, got %+v", lastReq)
+	}
+	if sys := lastReq.Messages[0].Content; !strings.HasPrefix(sys, "You are picobot.") || !strings.Contains(sys, "- web: Fetch a URL") {
+		t.Fatalf("system prompt should describe the unavailable tools, got %q", sys)
+	}
+
+	// The model is remembered, so the next call skips tools straight away.
+	if _, err := p.Chat(context.Background(), msgs, tools, ""); err != nil {
+		t.Fatal(err)
+	}
+	if calls.Load() != 3 {
+		t.Fatalf("expected one more call, got %d total", calls.Load())
+	}
+}
+
+func TestOllamaParsesStreamedToolCalls(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"message":{"role":"assistant","content":"","thinking":"need the weather","tool_calls":[{"function":{"name":"web","arguments":{"url":"https://wttr.in"}}}]},"done":false}
+{"message":{"role":"assistant","content":""},"done":true,"done_reason":"stop"}
+`))
+	}))
+	defer srv.Close()
+
+	p := NewOllamaProvider(srv.URL, "qwen3", 10, 0)
+	resp, err := p.Chat(context.Background(), []Message{{Role: "user", Content: "weather?"}}, []ToolDefinition{{Name: "web"}}, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !resp.HasToolCalls || resp.ToolCalls[0].Name != "web" || resp.ToolCalls[0].Arguments["url"] != "https://wttr.in" || resp.ToolCalls[0].ID == "" {
+		t.Fatalf("unexpected tool calls %+v", resp.ToolCalls)
+	}
+	if resp.Reasoning != "need the weather" {
+		t.Fatalf("reasoning = %q", resp.Reasoning)
+	}
+}
+
+func TestNewProviderFromConfig_PicksOllama(t *testing.T) {
+	cfg := config.Config{}
+	cfg.Agents.Defaults.Model = "llama3.1:8b"
+	cfg.Providers.OpenAI = &config.ProviderConfig{APIKey: "test"}
+	cfg.Providers.Ollama = &config.ProviderConfig{APIBase: "http://gpu-box:11434/"}
+	p, ok := NewProviderFromConfig(cfg).(*OllamaProvider)
+	if !ok {
+		t.Fatalf("expected OllamaProvider")
+	}
+	if p.APIBase != "http://gpu-box:11434" || p.GetDefaultModel() != "llama3.1:8b" {
+		t.Fatalf("unexpected provider %+v", p)
+	}
+}
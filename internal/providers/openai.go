@@ -1,6 +1,7 @@
 package providers
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
 	"errors"
@@ -18,8 +19,26 @@ type OpenAIProvider struct {
 	APIBase   string // e.g. https://api.openai.com/v1 or https://openrouter.ai/api/v1
 	MaxTokens int    // 0 means "let the API decide"
 	Client    *http.Client
+	// ReasoningPolicy is ReasoningSeparate (default) or ReasoningStrip, and
+	// applies to the "reasoning"/"reasoning_content" fields some
+	// OpenAI-compatible servers return alongside the content.
+	ReasoningPolicy string
+	// EmbeddingModel enables Embed (e.g. "text-embedding-3-small").
+	EmbeddingModel string
+	// IncompleteRetries is how many times a response whose body was cut off
+	// mid-transfer is requested again before the partial content is used.
+	IncompleteRetries int
+	// MaxAttempts is how many times in all a request is tried while the API
+	// answers 429 or 5xx; 1 disables retries. RetryBaseDelay is the wait
+	// before the first retry, doubled for each later one, unless the API
+	// sends Retry-After.
+	MaxAttempts    int
+	RetryBaseDelay time.Duration
 }
 
+// defaultMaxAttempts is how many times a request is tried by default.
+const defaultMaxAttempts = 3
+
 func NewOpenAIProvider(apiKey, apiBase string, timeoutSecs, maxTokens int) *OpenAIProvider {
 	if apiBase == "" {
 		apiBase = "https://api.openai.com/v1" // sensible default; can be overridden
@@ -34,6 +53,9 @@ func NewOpenAIProvider(apiKey, apiBase string, timeoutSecs, maxTokens int) *Open
 		Client: &http.Client{
 			Timeout: time.Duration(timeoutSecs) * time.Second,
 		},
+		IncompleteRetries: 1,
+		MaxAttempts:       defaultMaxAttempts,
+		RetryBaseDelay:    time.Second,
 	}
 }
 
@@ -45,6 +67,9 @@ type chatRequest struct {
 	Messages  []messageJSON `json:"messages"`
 	Tools     []toolWrapper `json:"tools,omitempty"`
 	MaxTokens int           `json:"max_tokens,omitempty"`
+	User      string        `json:"user,omitempty"`
+	Stop      []string      `json:"stop,omitempty"`
+	Stream    bool          `json:"stream,omitempty"`
 }
 
 // toolWrapper is the OpenAI tools array element: {"type": "function", "function": {...}}
@@ -78,24 +103,148 @@ type toolCallFunctionJSON struct {
 }
 
 type messageResponseJSON struct {
-	Role      string         `json:"role"`
-	Content   string         `json:"content"`
-	ToolCalls []toolCallJSON `json:"tool_calls,omitempty"`
+	Role             string         `json:"role"`
+	Content          string         `json:"content"`
+	Reasoning        string         `json:"reasoning,omitempty"`         // OpenRouter, Ollama
+	ReasoningContent string         `json:"reasoning_content,omitempty"` // DeepSeek, vLLM
+	ToolCalls        []toolCallJSON `json:"tool_calls,omitempty"`
 }
 
 type chatResponse struct {
 	Choices []struct {
-		Message messageResponseJSON `json:"message"`
+		Message      messageResponseJSON `json:"message"`
+		FinishReason string              `json:"finish_reason"`
 	} `json:"choices"`
+	Usage *usageJSON `json:"usage"`
+}
+
+type usageJSON struct {
+	PromptTokens     int `json:"prompt_tokens"`
+	CompletionTokens int `json:"completion_tokens"`
+	TotalTokens      int `json:"total_tokens"`
+}
+
+// usage converts a usage block, which servers may leave out, to a Usage.
+func (u *usageJSON) usage() Usage {
+	if u == nil {
+		return Usage{}
+	}
+	total := u.TotalTokens
+	if total == 0 {
+		total = u.PromptTokens + u.CompletionTokens
+	}
+	return Usage{PromptTokens: u.PromptTokens, CompletionTokens: u.CompletionTokens, TotalTokens: total}
+}
+
+// Ping lists the available models, which checks both that the API base is
+// reachable and that the API key is accepted.
+func (p *OpenAIProvider) Ping(ctx context.Context) error {
+	req, err := http.NewRequestWithContext(ctx, "GET", p.APIBase+"/models", nil)
+	if err != nil {
+		return err
+	}
+	if p.APIKey != "" {
+		req.Header.Set("Authorization", "Bearer "+p.APIKey)
+	}
+	resp, err := p.Client.Do(req)
+	if err != nil {
+		return fmt.Errorf("cannot reach %s: %w", p.APIBase, err)
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, resp.Body)
+	switch {
+	case resp.StatusCode == http.StatusUnauthorized || resp.StatusCode == http.StatusForbidden:
+		return fmt.Errorf("%s rejected the API key (%s); check providers.openai.apiKey", p.APIBase, resp.Status)
+	case resp.StatusCode < 200 || resp.StatusCode >= 300:
+		return fmt.Errorf("%s returned %s for /models", p.APIBase, resp.Status)
+	}
+	return nil
 }
 
 // Chat calls an OpenAI-compatible chat completion endpoint and returns a simplified response.
 func (p *OpenAIProvider) Chat(ctx context.Context, messages []Message, tools []ToolDefinition, model string) (LLMResponse, error) {
+	b, err := json.Marshal(p.chatRequest(ctx, messages, tools, model))
+	if err != nil {
+		return LLMResponse{}, err
+	}
+
+	body, complete, err := p.postChat(ctx, b)
+	if err != nil {
+		return LLMResponse{}, err
+	}
+	for try := 0; !complete && try < p.IncompleteRetries && ctx.Err() == nil; try++ {
+		log.Printf("OpenAI API: response cut off after %d bytes, retrying", len(body))
+		retryBody, retryComplete, err := p.postChat(ctx, b)
+		if err != nil {
+			log.Printf("OpenAI API: retry failed: %v", err)
+			break
+		}
+		body, complete = retryBody, retryComplete
+	}
+	if !complete {
+		content, ok := salvageContent(body)
+		if !ok {
+			return LLMResponse{}, fmt.Errorf("OpenAI API: response cut off after %d bytes", len(body))
+		}
+		log.Printf("OpenAI API: response cut off, using the %d bytes of content received", len(content))
+		return LLMResponse{Content: strings.TrimSpace(content), Incomplete: true}, nil
+	}
+
+	var out chatResponse
+	if err := json.Unmarshal(body, &out); err != nil {
+		return LLMResponse{}, err
+	}
+
+	if len(out.Choices) == 0 {
+		return LLMResponse{}, errors.New("OpenAI API returned no choices")
+	}
+
+	choice := out.Choices[0]
+	resp := p.response(choice.Message, choice.FinishReason)
+	resp.Usage = out.Usage.usage()
+	return resp, nil
+}
+
+// response converts the assistant message of a completion to an LLMResponse.
+func (p *OpenAIProvider) response(msg messageResponseJSON, finishReason string) LLMResponse {
+	incomplete := finishReason == "length"
+	reasoning := strings.TrimSpace(msg.ReasoningContent)
+	if reasoning == "" {
+		reasoning = strings.TrimSpace(msg.Reasoning)
+	}
+	if p.ReasoningPolicy == ReasoningStrip {
+		reasoning = ""
+	}
+	// If the model requested tool calls, parse them
+	if len(msg.ToolCalls) > 0 {
+		var tcs []ToolCall
+		for _, tc := range msg.ToolCalls {
+			var parsed map[string]interface{}
+			if err := json.Unmarshal([]byte(tc.Function.Arguments), &parsed); err != nil {
+				// skip unparseable tool calls
+				continue
+			}
+			tcs = append(tcs, ToolCall{ID: tc.ID, Name: tc.Function.Name, Arguments: parsed})
+		}
+		if len(tcs) > 0 {
+			return LLMResponse{Content: strings.TrimSpace(msg.Content), Reasoning: reasoning, HasToolCalls: true, ToolCalls: tcs, Incomplete: incomplete}
+		}
+	}
+
+	// No tool calls
+	return LLMResponse{Content: strings.TrimSpace(msg.Content), Reasoning: reasoning, HasToolCalls: false, Incomplete: incomplete}
+}
+
+// chatRequest builds the request body for a chat completion.
+func (p *OpenAIProvider) chatRequest(ctx context.Context, messages []Message, tools []ToolDefinition, model string) chatRequest {
 	if model == "" {
 		model = p.GetDefaultModel()
 	}
 
-	reqBody := chatRequest{Model: model, Messages: make([]messageJSON, 0, len(messages)), MaxTokens: p.MaxTokens}
+	reqBody := chatRequest{Model: model, Messages: make([]messageJSON, 0, len(messages)), MaxTokens: p.MaxTokens, User: UserFromContext(ctx), Stop: StopFromContext(ctx)}
+	if n := MaxTokensFromContext(ctx); n > 0 {
+		reqBody.MaxTokens = n
+	}
 	for _, m := range messages {
 		mj := messageJSON{Role: m.Role, ToolCallID: m.ToolCallID}
 		if len(m.ToolCalls) > 0 && m.Content == "" {
@@ -138,15 +287,66 @@ func (p *OpenAIProvider) Chat(ctx context.Context, messages []Message, tools []T
 		}
 	}
 
-	b, err := json.Marshal(reqBody)
+	return reqBody
+}
+
+// postChat sends a chat completion request and reads the response body.
+// complete is false if the connection dropped while the body was being
+// read; body then holds what arrived.
+func (p *OpenAIProvider) postChat(ctx context.Context, b []byte) (body []byte, complete bool, err error) {
+	resp, err := p.post(ctx, b)
 	if err != nil {
-		return LLMResponse{}, err
+		return nil, false, err
 	}
+	defer resp.Body.Close()
 
+	body, err = io.ReadAll(resp.Body)
+	if err != nil {
+		if ctx.Err() != nil {
+			return nil, false, ctx.Err()
+		}
+		return body, false, nil
+	}
+	return body, true, nil
+}
+
+// post sends a chat completion request and returns the response once its
+// status is known to be 2xx, retrying temporary failures up to MaxAttempts
+// times in all. The caller closes the body.
+func (p *OpenAIProvider) post(ctx context.Context, b []byte) (*http.Response, error) {
+	attempts := max(p.MaxAttempts, 1)
+	for attempt := 1; ; attempt++ {
+		resp, err := p.postOnce(ctx, b)
+		var se *StatusError
+		if err == nil || !errors.As(err, &se) || !se.Temporary() {
+			if err != nil && attempt > 1 {
+				err = &RetryError{Attempts: attempt, Err: err}
+			}
+			return resp, err
+		}
+		delay, ok := retryDelay(p.RetryBaseDelay, attempt, se.RetryAfter)
+		if attempt >= attempts || !ok {
+			if attempt == 1 {
+				return nil, err
+			}
+			return nil, &RetryError{Attempts: attempt, Err: err}
+		}
+		log.Printf("OpenAI API: %s, retrying in %s (attempt %d of %d)", se.Status, delay, attempt+1, attempts)
+		select {
+		case <-time.After(delay):
+		case <-ctx.Done():
+			return nil, &RetryError{Attempts: attempt, Err: err}
+		}
+	}
+}
+
+// postOnce sends a chat completion request once; a non-2xx response is
+// returned as a *StatusError.
+func (p *OpenAIProvider) postOnce(ctx context.Context, b []byte) (*http.Response, error) {
 	url := fmt.Sprintf("%s/chat/completions", p.APIBase)
-	req, err := http.NewRequestWithContext(ctx, "POST", url, strings.NewReader(string(b)))
+	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewReader(b))
 	if err != nil {
-		return LLMResponse{}, err
+		return nil, err
 	}
 	req.Header.Set("Content-Type", "application/json")
 	if p.APIKey != "" {
@@ -155,47 +355,119 @@ func (p *OpenAIProvider) Chat(ctx context.Context, messages []Message, tools []T
 
 	resp, err := p.Client.Do(req)
 	if err != nil {
-		return LLMResponse{}, err
+		return nil, err
 	}
-	defer resp.Body.Close()
-
 	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		defer resp.Body.Close()
 		// attempt to read response body for more details (do not expose API key)
 		bodyBytes, _ := io.ReadAll(resp.Body)
 		body := strings.TrimSpace(string(bodyBytes))
 		log.Printf("OpenAI API non-2xx: %s body=%q", resp.Status, body)
-		if body == "" {
-			return LLMResponse{}, fmt.Errorf("OpenAI API error: %s", resp.Status)
-		}
-		return LLMResponse{}, fmt.Errorf("OpenAI API error: %s - %s", resp.Status, body)
+		return nil, &StatusError{Provider: "OpenAI", StatusCode: resp.StatusCode, Status: resp.Status, Body: body, RetryAfter: parseRetryAfter(resp.Header.Get("Retry-After"))}
 	}
+	return resp, nil
+}
 
-	var out chatResponse
-	if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
-		return LLMResponse{}, err
+// salvageContent extracts the assistant message content from a response
+// body that was cut off, decoding the JSON string as far as it got. ok is
+// false if the content never started.
+func salvageContent(body []byte) (content string, ok bool) {
+	msg := bytes.Index(body, []byte(`"message"`))
+	if msg < 0 {
+		return "", false
 	}
-
-	if len(out.Choices) == 0 {
-		return LLMResponse{}, errors.New("OpenAI API returned no choices")
+	rest := body[msg:]
+	key := bytes.Index(rest, []byte(`"content"`))
+	if key < 0 {
+		return "", false
 	}
-
-	msg := out.Choices[0].Message
-	// If the model requested tool calls, parse them
-	if len(msg.ToolCalls) > 0 {
-		var tcs []ToolCall
-		for _, tc := range msg.ToolCalls {
-			var parsed map[string]interface{}
-			if err := json.Unmarshal([]byte(tc.Function.Arguments), &parsed); err != nil {
-				// skip unparseable tool calls
-				continue
-			}
-			tcs = append(tcs, ToolCall{ID: tc.ID, Name: tc.Function.Name, Arguments: parsed})
+	rest = bytes.TrimLeft(rest[key+len(`"content"`):], " \t\r\n")
+	rest, found := bytes.CutPrefix(rest, []byte(":"))
+	rest = bytes.TrimLeft(rest, " \t\r\n")
+	if !found || len(rest) == 0 || rest[0] != '"' {
+		return "", false
+	}
+	// Find the closing quote. If the string was cut, drop any partial escape
+	// sequence and close it.
+	lit, closed := rest, false
+	for i := 1; i < len(rest); i++ {
+		if rest[i] == '"' {
+			lit, closed = rest[:i+1], true
+			break
 		}
-		if len(tcs) > 0 {
-			return LLMResponse{Content: strings.TrimSpace(msg.Content), HasToolCalls: true, ToolCalls: tcs}, nil
+		if rest[i] == '\\' {
+			n := 2
+			if i+1 < len(rest) && rest[i+1] == 'u' {
+				n = 6
+			}
+			if i+n > len(rest) {
+				lit = rest[:i]
+				break
+			}
+			i += n - 1
 		}
 	}
+	if !closed {
+		lit = append(append([]byte(nil), lit...), '"')
+	}
+	if err := json.Unmarshal(lit, &content); err != nil {
+		return "", false
+	}
+	return content, true
+}
 
-	// No tool calls
-	return LLMResponse{Content: strings.TrimSpace(msg.Content), HasToolCalls: false}, nil
+type embeddingRequest struct {
+	Model string   `json:"model"`
+	Input []string `json:"input"`
+}
+
+type embeddingResponse struct {
+	Data []struct {
+		Index     int       `json:"index"`
+		Embedding []float64 `json:"embedding"`
+	} `json:"data"`
+}
+
+// Embed calls the OpenAI-compatible embeddings endpoint with EmbeddingModel.
+func (p *OpenAIProvider) Embed(ctx context.Context, texts []string) ([][]float64, error) {
+	if p.EmbeddingModel == "" {
+		return nil, ErrNoEmbeddings
+	}
+	b, err := json.Marshal(embeddingRequest{Model: p.EmbeddingModel, Input: texts})
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, "POST", p.APIBase+"/embeddings", strings.NewReader(string(b)))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if p.APIKey != "" {
+		req.Header.Set("Authorization", "Bearer "+p.APIKey)
+	}
+	resp, err := p.Client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return nil, fmt.Errorf("OpenAI embeddings error: %s - %s", resp.Status, strings.TrimSpace(string(body)))
+	}
+	var out embeddingResponse
+	if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
+		return nil, err
+	}
+	vecs := make([][]float64, len(texts))
+	for _, d := range out.Data {
+		if d.Index >= 0 && d.Index < len(vecs) {
+			vecs[d.Index] = d.Embedding
+		}
+	}
+	for i, v := range vecs {
+		if v == nil {
+			return nil, fmt.Errorf("OpenAI embeddings response is missing input %d", i)
+		}
+	}
+	return vecs, nil
 }
@@ -2,8 +2,13 @@ package providers
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"net/http"
 	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync/atomic"
 	"testing"
 	"time"
 )
@@ -57,3 +62,296 @@ func TestOpenAIFunctionCallParsing(t *testing.T) {
 		t.Fatalf("unexpected argument content: %v", resp.ToolCalls[0].Arguments)
 	}
 }
+
+func TestOpenAISendsHashedUser(t *testing.T) {
+	var got []string
+	h := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			User string `json:"user"`
+		}
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		got = append(got, body.User)
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"choices":[{"message":{"role":"assistant","content":"ok"}}]}`))
+	}))
+	defer h.Close()
+
+	p := NewOpenAIProvider("test-key", h.URL, 60, 0)
+	msgs := []Message{{Role: "user", Content: "hi"}}
+	for _, sender := range []string{"12345", "12345", "67890"} {
+		ctx := WithUser(context.Background(), HashUser("telegram", sender))
+		if _, err := p.Chat(ctx, msgs, nil, "model-x"); err != nil {
+			t.Fatalf("Chat: %v", err)
+		}
+	}
+	if _, err := p.Chat(context.Background(), msgs, nil, "model-x"); err != nil {
+		t.Fatalf("Chat: %v", err)
+	}
+
+	if got[0] == "" || got[0] != got[1] {
+		t.Fatalf("expected a stable user id for the same sender, got %q and %q", got[0], got[1])
+	}
+	if got[0] == got[2] {
+		t.Fatal("different senders must get different user ids")
+	}
+	if strings.Contains(got[0], "12345") {
+		t.Fatalf("user id must not expose the raw sender id: %q", got[0])
+	}
+	if got[3] != "" {
+		t.Fatalf("expected no user field without WithUser, got %q", got[3])
+	}
+	if HashUser("telegram", "1") == HashUser("discord", "1") {
+		t.Fatal("hash must depend on the channel")
+	}
+}
+
+func TestOpenAIReasoningPolicy(t *testing.T) {
+	h := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"choices":[{"message":{"role":"assistant","content":"42","reasoning_content":"6 times 7"}}]}`))
+	}))
+	defer h.Close()
+
+	msgs := []Message{{Role: "user", Content: "6*7?"}}
+	for policy, want := range map[string]string{"": "6 times 7", ReasoningSeparate: "6 times 7", ReasoningStrip: ""} {
+		p := NewOpenAIProvider("test-key", h.URL, 60, 0)
+		p.ReasoningPolicy = policy
+		resp, err := p.Chat(context.Background(), msgs, nil, "model-x")
+		if err != nil {
+			t.Fatalf("Chat: %v", err)
+		}
+		if resp.Content != "42" || resp.Reasoning != want {
+			t.Errorf("policy %q: got content %q reasoning %q, want reasoning %q", policy, resp.Content, resp.Reasoning, want)
+		}
+	}
+}
+
+func TestOpenAIPing(t *testing.T) {
+	h := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/models" {
+			t.Errorf("unexpected path %s", r.URL.Path)
+		}
+		if r.Header.Get("Authorization") != "Bearer good-key" {
+			http.Error(w, `{"error":{"message":"Incorrect API key provided"}}`, http.StatusUnauthorized)
+			return
+		}
+		w.Write([]byte(`{"data":[{"id":"gpt-4o-mini"}]}`))
+	}))
+	defer h.Close()
+
+	good := NewOpenAIProvider("good-key", h.URL, 5, 0)
+	if err := CheckConnectivity(context.Background(), good); err != nil {
+		t.Fatalf("expected good key to pass, got %v", err)
+	}
+
+	bad := NewOpenAIProvider("bad-key", h.URL, 5, 0)
+	err := CheckConnectivity(context.Background(), bad)
+	if err == nil || !strings.Contains(err.Error(), "rejected the API key") || !strings.Contains(err.Error(), "401") {
+		t.Fatalf("expected a clear API key error, got %v", err)
+	}
+}
+
+func TestOpenAISendsStopSequences(t *testing.T) {
+	var got [][]string
+	h := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			Stop []string `json:"stop"`
+		}
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		got = append(got, body.Stop)
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"choices":[{"message":{"role":"assistant","content":"ok"}}]}`))
+	}))
+	defer h.Close()
+
+	p := NewOpenAIProvider("test-key", h.URL, 60, 0)
+	msgs := []Message{{Role: "user", Content: "hi"}}
+	if _, err := p.Chat(WithStop(context.Background(), []string{"User:"}), msgs, nil, "model-x"); err != nil {
+		t.Fatalf("Chat: %v", err)
+	}
+	if _, err := p.Chat(context.Background(), msgs, nil, "model-x"); err != nil {
+		t.Fatalf("Chat: %v", err)
+	}
+	if len(got[0]) != 1 || got[0][0] != "User:" {
+		t.Fatalf("expected stop sequences in the request, got %q", got[0])
+	}
+	if got[1] != nil {
+		t.Fatalf("expected no stop field without stop sequences, got %q", got[1])
+	}
+}
+
+func TestOpenAIEmbed(t *testing.T) {
+	h := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			Model string   `json:"model"`
+			Input []string `json:"input"`
+		}
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		if r.URL.Path != "/embeddings" || body.Model != "embed-x" || len(body.Input) != 2 {
+			t.Errorf("unexpected request %s %+v", r.URL.Path, body)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		// Out of order on purpose; index decides the position.
+		w.Write([]byte(`{"data":[{"index":1,"embedding":[0,1]},{"index":0,"embedding":[1,0]}]}`))
+	}))
+	defer h.Close()
+
+	p := NewOpenAIProvider("test-key", h.URL, 60, 0)
+	if _, err := p.Embed(context.Background(), []string{"a"}); !errors.Is(err, ErrNoEmbeddings) {
+		t.Fatalf("expected ErrNoEmbeddings without a model, got %v", err)
+	}
+	p.EmbeddingModel = "embed-x"
+	vecs, err := p.Embed(context.Background(), []string{"a", "b"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if vecs[0][0] != 1 || vecs[1][1] != 1 {
+		t.Fatalf("unexpected vectors %v", vecs)
+	}
+}
+
+// cutOffHandler answers with full, or with the first half of full and then
+// drops the connection, as a network failure mid-response would.
+func cutOffHandler(full string, cut func() bool) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if !cut() {
+			w.Write([]byte(full))
+			return
+		}
+		w.Header().Set("Content-Length", strconv.Itoa(len(full)))
+		w.Write([]byte(full[:len(full)/2]))
+	}
+}
+
+func TestOpenAISalvagesCutOffResponse(t *testing.T) {
+	full := `{"choices":[{"message":{"role":"assistant","content":"The first step is to preheat the oven.\nThen mix the flour and sugar, and bake for forty minutes."},"finish_reason":"stop"}]}`
+	var requests atomic.Int32
+	h := httptest.NewServer(cutOffHandler(full, func() bool { requests.Add(1); return true }))
+	defer h.Close()
+
+	p := NewOpenAIProvider("test-key", h.URL, 60, 0)
+	resp, err := p.Chat(context.Background(), []Message{{Role: "user", Content: "recipe?"}}, nil, "model-x")
+	if err != nil {
+		t.Fatalf("Chat: %v", err)
+	}
+	if !resp.Incomplete || !strings.HasPrefix(resp.Content, "The first step") || strings.Contains(resp.Content, "forty") {
+		t.Fatalf("want partial content marked incomplete, got %+v", resp)
+	}
+	if n := requests.Load(); n != 2 {
+		t.Fatalf("want 1 retry (2 requests), got %d", n)
+	}
+}
+
+func TestOpenAIRetriesCutOffResponse(t *testing.T) {
+	full := `{"choices":[{"message":{"role":"assistant","content":"all of it"},"finish_reason":"stop"}]}`
+	var requests atomic.Int32
+	h := httptest.NewServer(cutOffHandler(full, func() bool { return requests.Add(1) == 1 }))
+	defer h.Close()
+
+	p := NewOpenAIProvider("test-key", h.URL, 60, 0)
+	resp, err := p.Chat(context.Background(), []Message{{Role: "user", Content: "hi"}}, nil, "model-x")
+	if err != nil || resp.Content != "all of it" || resp.Incomplete {
+		t.Fatalf("want the retried full response, got %+v, %v", resp, err)
+	}
+}
+
+func TestOpenAIMarksLengthFinishIncomplete(t *testing.T) {
+	h := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"choices":[{"message":{"role":"assistant","content":"Once upon a"},"finish_reason":"length"}]}`))
+	}))
+	defer h.Close()
+
+	resp, err := NewOpenAIProvider("test-key", h.URL, 60, 0).Chat(context.Background(), []Message{{Role: "user", Content: "story"}}, nil, "model-x")
+	if err != nil || !resp.Incomplete || resp.Content != "Once upon a" {
+		t.Fatalf("want incomplete response, got %+v, %v", resp, err)
+	}
+}
+
+func TestSalvageContent(t *testing.T) {
+	for body, want := range map[string]string{
+		`{"choices":[{"message":{"content":"line\nnext ét`:   "line\nnext ét",
+		`{"choices":[{"message":{"content":"say \"hi\" \u00`: `say "hi" `,
+		`{"choices":[{"message":{"content":"ends \`:          "ends ",
+		`{"choices":[{"message":{"content":"done"},"finish`:  "done",
+	} {
+		got, ok := salvageContent([]byte(body))
+		if !ok || got != want {
+			t.Errorf("salvageContent(%s) = %q, %v; want %q", body, got, ok, want)
+		}
+	}
+	if _, ok := salvageContent([]byte(`{"choices":[{"mess`)); ok {
+		t.Error("nothing to salvage before the content starts")
+	}
+}
+
+func TestOpenAIRetriesTemporaryErrors(t *testing.T) {
+	var requests atomic.Int32
+	h := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if requests.Add(1) <= 2 {
+			w.Header().Set("Retry-After", "0")
+			http.Error(w, "overloaded", http.StatusServiceUnavailable)
+			return
+		}
+		w.Write([]byte(`{"choices":[{"message":{"role":"assistant","content":"ok"},"finish_reason":"stop"}]}`))
+	}))
+	defer h.Close()
+
+	p := NewOpenAIProvider("test-key", h.URL, 60, 0)
+	p.RetryBaseDelay = time.Millisecond
+	resp, err := p.Chat(context.Background(), []Message{{Role: "user", Content: "hi"}}, nil, "model-x")
+	if err != nil || resp.Content != "ok" {
+		t.Fatalf("want the third attempt's reply, got %+v, %v", resp, err)
+	}
+	if n := requests.Load(); n != 3 {
+		t.Fatalf("want 3 requests, got %d", n)
+	}
+}
+
+func TestOpenAIRetryLimits(t *testing.T) {
+	var requests atomic.Int32
+	status := http.StatusTooManyRequests
+	h := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		http.Error(w, "nope", status)
+	}))
+	defer h.Close()
+
+	p := NewOpenAIProvider("test-key", h.URL, 60, 0)
+	p.RetryBaseDelay = time.Millisecond
+	_, err := p.Chat(context.Background(), []Message{{Role: "user", Content: "hi"}}, nil, "model-x")
+	var re *RetryError
+	var se *StatusError
+	if !errors.As(err, &re) || re.Attempts != 3 || !errors.As(err, &se) || se.StatusCode != status {
+		t.Fatalf("want a RetryError after 3 attempts wrapping the 429, got %v", err)
+	}
+	if !strings.Contains(err.Error(), "gave up after 3 attempts") || requests.Load() != 3 {
+		t.Fatalf("unexpected error %q after %d requests", err, requests.Load())
+	}
+
+	// Bad requests and auth failures fail straight away.
+	for _, status = range []int{http.StatusBadRequest, http.StatusUnauthorized} {
+		requests.Store(0)
+		_, err := p.Chat(context.Background(), []Message{{Role: "user", Content: "hi"}}, nil, "model-x")
+		if !errors.As(err, &se) || errors.As(err, &re) || requests.Load() != 1 {
+			t.Fatalf("status %d: want one attempt, got %d requests and %v", status, requests.Load(), err)
+		}
+	}
+}
+
+func TestOpenAIParsesUsage(t *testing.T) {
+	h := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"choices":[{"message":{"role":"assistant","content":"hi"},"finish_reason":"stop"}],
+		  "usage":{"prompt_tokens":120,"completion_tokens":8,"total_tokens":128}}`))
+	}))
+	defer h.Close()
+
+	p := NewOpenAIProvider("test-key", h.URL, 60, 0)
+	resp, err := p.Chat(context.Background(), []Message{{Role: "user", Content: "hi"}}, nil, "model-x")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := (Usage{PromptTokens: 120, CompletionTokens: 8, TotalTokens: 128}); resp.Usage != want {
+		t.Fatalf("usage = %+v, want %+v", resp.Usage, want)
+	}
+}
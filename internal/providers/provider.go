@@ -1,6 +1,11 @@
 package providers
 
-import "context"
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+)
 
 // Message represents a chat message to/from the LLM.
 type Message struct {
@@ -26,9 +31,34 @@ type ToolCall struct {
 
 // LLMResponse is a normalized response from a provider.
 type LLMResponse struct {
-	Content      string     `json:"content"`
+	Content string `json:"content"`
+	// Reasoning is the model's reasoning when the provider returns it apart
+	// from Content (rather than inline in <think> tags).
+	Reasoning    string     `json:"reasoning,omitempty"`
 	HasToolCalls bool       `json:"hasToolCalls"`
 	ToolCalls    []ToolCall `json:"toolCalls,omitempty"`
+	// Incomplete is set when the reply was cut off before the model
+	// finished: the connection dropped mid-response (Content holds what
+	// arrived) or the token limit was reached.
+	Incomplete bool `json:"incomplete,omitempty"`
+	// Usage is the tokens the request used, if the provider reported them.
+	Usage Usage `json:"usage,omitzero"`
+}
+
+// Usage counts the tokens of one or more requests.
+type Usage struct {
+	PromptTokens     int `json:"promptTokens"`
+	CompletionTokens int `json:"completionTokens"`
+	TotalTokens      int `json:"totalTokens"`
+}
+
+// Add returns the sum of u and o.
+func (u Usage) Add(o Usage) Usage {
+	return Usage{
+		PromptTokens:     u.PromptTokens + o.PromptTokens,
+		CompletionTokens: u.CompletionTokens + o.CompletionTokens,
+		TotalTokens:      u.TotalTokens + o.TotalTokens,
+	}
 }
 
 // LLMProvider is the interface used by the agent loop to call LLMs.
@@ -39,3 +69,94 @@ type LLMProvider interface {
 	// GetDefaultModel returns the provider's default model string.
 	GetDefaultModel() string
 }
+
+// Embedder is implemented by providers that can compute text embeddings.
+// Embed returns one vector per input text, or ErrNoEmbeddings if embeddings
+// are not configured.
+type Embedder interface {
+	Embed(ctx context.Context, texts []string) ([][]float64, error)
+}
+
+// ErrNoEmbeddings is returned by Embed when no embedding model is configured.
+var ErrNoEmbeddings = errors.New("no embedding model configured")
+
+// Reasoning policies for providers that return reasoning in a separate field.
+const (
+	// ReasoningSeparate returns it in LLMResponse.Reasoning, where the agent
+	// handles it like <think> blocks: kept out of the reply and history, and
+	// offered to UIs as a thinking event. This is the default.
+	ReasoningSeparate = "separate"
+	// ReasoningStrip discards it.
+	ReasoningStrip = "strip"
+)
+
+// Pinger is implemented by providers that can cheaply verify their
+// credentials and connectivity without running a completion.
+type Pinger interface {
+	Ping(ctx context.Context) error
+}
+
+// CheckConnectivity pings p if it supports it. Providers that don't (such as
+// the stub) are assumed reachable.
+func CheckConnectivity(ctx context.Context, p LLMProvider) error {
+	if pinger, ok := p.(Pinger); ok {
+		return pinger.Ping(ctx)
+	}
+	return nil
+}
+
+type userKey struct{}
+
+// WithUser attaches an end-user identifier to ctx. Providers that support it
+// forward the identifier upstream (e.g. OpenAI's "user" field) for abuse
+// monitoring.
+func WithUser(ctx context.Context, user string) context.Context {
+	return context.WithValue(ctx, userKey{}, user)
+}
+
+// UserFromContext returns the identifier set by WithUser, if any.
+func UserFromContext(ctx context.Context) string {
+	u, _ := ctx.Value(userKey{}).(string)
+	return u
+}
+
+type stopKey struct{}
+
+// WithStop attaches stop sequences to ctx. Providers that support them stop
+// generating when the model produces one (e.g. a "User:" turn marker).
+func WithStop(ctx context.Context, stop []string) context.Context {
+	if len(stop) == 0 {
+		return ctx
+	}
+	return context.WithValue(ctx, stopKey{}, stop)
+}
+
+// StopFromContext returns the stop sequences set by WithStop, if any.
+func StopFromContext(ctx context.Context) []string {
+	s, _ := ctx.Value(stopKey{}).([]string)
+	return s
+}
+
+type maxTokensKey struct{}
+
+// WithMaxTokens overrides the provider's configured reply length limit for
+// requests made with ctx. n <= 0 leaves the provider's own setting.
+func WithMaxTokens(ctx context.Context, n int) context.Context {
+	if n <= 0 {
+		return ctx
+	}
+	return context.WithValue(ctx, maxTokensKey{}, n)
+}
+
+// MaxTokensFromContext returns the limit set by WithMaxTokens, or 0.
+func MaxTokensFromContext(ctx context.Context) int {
+	n, _ := ctx.Value(maxTokensKey{}).(int)
+	return n
+}
+
+// HashUser derives a stable, opaque identifier for a sender so raw chat IDs
+// are never sent to the provider.
+func HashUser(channel, senderID string) string {
+	sum := sha256.Sum256([]byte("picobot:" + channel + ":" + senderID))
+	return hex.EncodeToString(sum[:16])
+}
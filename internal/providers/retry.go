@@ -0,0 +1,110 @@
+package providers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// maxRetryDelay is the longest a request waits before it is retried. A
+// server asking for a longer wait with Retry-After is not retried, so the
+// turn fails quickly instead of hanging.
+const maxRetryDelay = 30 * time.Second
+
+// StatusError is a non-2xx response from a provider's API.
+type StatusError struct {
+	Provider   string // "OpenAI" or "Ollama"
+	StatusCode int
+	Status     string // e.g. "503 Service Unavailable"
+	Body       string
+	// RetryAfter is the wait the server asked for, or 0.
+	RetryAfter time.Duration
+}
+
+func (e *StatusError) Error() string {
+	if e.Body == "" {
+		return fmt.Sprintf("%s API error: %s", e.Provider, e.Status)
+	}
+	return fmt.Sprintf("%s API error: %s - %s", e.Provider, e.Status, e.Body)
+}
+
+// Temporary reports whether the request may succeed if repeated: the API
+// was rate limited (429) or failed on its side (5xx). Bad requests and
+// authentication failures are not temporary.
+func (e *StatusError) Temporary() bool {
+	return e.StatusCode == http.StatusTooManyRequests || e.StatusCode >= 500
+}
+
+// contextTooLongMarkers appear in the errors APIs return for a request
+// longer than the model's context window.
+var contextTooLongMarkers = []string{"context_length_exceeded", "maximum context length", "context length", "context window", "too many tokens"}
+
+// IsOverloaded reports whether err means the model is unavailable for now
+// (rate limited, overloaded or down), so another model may serve the
+// request.
+func IsOverloaded(err error) bool {
+	var se *StatusError
+	return errors.As(err, &se) && se.Temporary() && !IsContextTooLong(err)
+}
+
+// IsContextTooLong reports whether err means the request didn't fit the
+// model's context window.
+func IsContextTooLong(err error) bool {
+	var se *StatusError
+	if !errors.As(err, &se) || (se.StatusCode != http.StatusBadRequest && se.StatusCode != http.StatusRequestEntityTooLarge) {
+		return false
+	}
+	body := strings.ToLower(se.Body)
+	for _, m := range contextTooLongMarkers {
+		if strings.Contains(body, m) {
+			return true
+		}
+	}
+	return false
+}
+
+// RetryError is returned when a request still failed after being retried.
+type RetryError struct {
+	Attempts int
+	Err      error // the last attempt's error
+}
+
+func (e *RetryError) Error() string {
+	return fmt.Sprintf("%v (gave up after %d attempts)", e.Err, e.Attempts)
+}
+
+func (e *RetryError) Unwrap() error { return e.Err }
+
+// retryDelay is the wait before retrying after the given attempt (1 for the
+// first): the server's Retry-After if it sent one, otherwise base doubled for
+// each attempt so far. ok is false if the wait would exceed maxRetryDelay.
+func retryDelay(base time.Duration, attempt int, retryAfter time.Duration) (d time.Duration, ok bool) {
+	if retryAfter > 0 {
+		return retryAfter, retryAfter <= maxRetryDelay
+	}
+	d = base << (attempt - 1)
+	if d <= 0 || d > maxRetryDelay {
+		d = maxRetryDelay
+	}
+	return d, true
+}
+
+// parseRetryAfter reads a Retry-After header, given either in seconds or as
+// an HTTP date. It returns 0 if the header is missing or invalid.
+func parseRetryAfter(h string) time.Duration {
+	if h == "" {
+		return 0
+	}
+	if secs, err := strconv.Atoi(h); err == nil && secs >= 0 {
+		return time.Duration(secs) * time.Second
+	}
+	if t, err := http.ParseTime(h); err == nil {
+		if d := time.Until(t); d > 0 {
+			return d
+		}
+	}
+	return 0
+}
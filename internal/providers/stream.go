@@ -0,0 +1,172 @@
+package providers
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+)
+
+// Delta is one piece of a streamed reply. The last delta a stream sends has
+// Done set and carries the assembled Response, or Err if the stream failed.
+type Delta struct {
+	Content   string
+	Reasoning string
+	Done      bool
+	Response  LLMResponse
+	Err       error
+}
+
+// Streamer is implemented by providers that can stream replies as they are
+// generated. ChatStream returns an error if the request could not be
+// started; later failures arrive as the Err of the final delta, after which
+// the channel is closed. Callers that want the whole reply use Chat.
+type Streamer interface {
+	ChatStream(ctx context.Context, messages []Message, tools []ToolDefinition, model string) (<-chan Delta, error)
+}
+
+// CollectStream reads a stream to its end, passing each piece of text to
+// onDelta (which may be nil), and returns the final response.
+func CollectStream(ch <-chan Delta, onDelta func(Delta)) (LLMResponse, error) {
+	for d := range ch {
+		if d.Done {
+			return d.Response, d.Err
+		}
+		if onDelta != nil {
+			onDelta(d)
+		}
+	}
+	return LLMResponse{}, errors.New("stream closed without a final response")
+}
+
+// streamChunk is one server-sent event of a streamed chat completion.
+type streamChunk struct {
+	Choices []struct {
+		Delta struct {
+			Content          string `json:"content"`
+			Reasoning        string `json:"reasoning"`
+			ReasoningContent string `json:"reasoning_content"`
+			ToolCalls        []struct {
+				Index    int                  `json:"index"`
+				ID       string               `json:"id"`
+				Function toolCallFunctionJSON `json:"function"`
+			} `json:"tool_calls"`
+		} `json:"delta"`
+		FinishReason string `json:"finish_reason"`
+	} `json:"choices"`
+	// Usage is sent by some servers, in the last event before [DONE].
+	Usage *usageJSON `json:"usage"`
+}
+
+// ChatStream calls the chat completion endpoint with "stream": true and
+// sends the reply's text as it arrives over server-sent events. Tool calls,
+// which arrive in fragments, are only reported in the final response.
+func (p *OpenAIProvider) ChatStream(ctx context.Context, messages []Message, tools []ToolDefinition, model string) (<-chan Delta, error) {
+	req := p.chatRequest(ctx, messages, tools, model)
+	req.Stream = true
+	b, err := json.Marshal(req)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := p.post(ctx, b)
+	if err != nil {
+		return nil, err
+	}
+
+	ch := make(chan Delta, 16)
+	go func() {
+		defer close(ch)
+		defer resp.Body.Close()
+		var msg messageResponseJSON
+		var usage *usageJSON
+		var calls = map[int]*toolCallJSON{}
+		finish, done := "", false
+		sc := bufio.NewScanner(resp.Body)
+		sc.Buffer(make([]byte, 64<<10), 4<<20)
+		for sc.Scan() {
+			data, ok := bytes.CutPrefix(bytes.TrimSpace(sc.Bytes()), []byte("data:"))
+			if !ok {
+				continue // blank separators, comments, other fields
+			}
+			data = bytes.TrimSpace(data)
+			if string(data) == "[DONE]" {
+				done = true
+				break
+			}
+			var chunk streamChunk
+			if err := json.Unmarshal(data, &chunk); err != nil {
+				ch <- Delta{Done: true, Err: fmt.Errorf("OpenAI API: bad stream event: %w", err)}
+				return
+			}
+			if chunk.Usage != nil {
+				usage = chunk.Usage
+			}
+			if len(chunk.Choices) == 0 {
+				continue
+			}
+			c := chunk.Choices[0]
+			msg.Content += c.Delta.Content
+			msg.Reasoning += c.Delta.Reasoning
+			msg.ReasoningContent += c.Delta.ReasoningContent
+			for _, tc := range c.Delta.ToolCalls {
+				call := calls[tc.Index]
+				if call == nil {
+					call = &toolCallJSON{Type: "function"}
+					calls[tc.Index] = call
+				}
+				if tc.ID != "" {
+					call.ID = tc.ID
+				}
+				call.Function.Name += tc.Function.Name
+				call.Function.Arguments += tc.Function.Arguments
+			}
+			if c.FinishReason != "" {
+				finish = c.FinishReason
+			}
+			reasoning := c.Delta.ReasoningContent + c.Delta.Reasoning
+			if p.ReasoningPolicy == ReasoningStrip {
+				reasoning = ""
+			}
+			if c.Delta.Content != "" || reasoning != "" {
+				select {
+				case ch <- Delta{Content: c.Delta.Content, Reasoning: reasoning}:
+				case <-ctx.Done():
+					ch <- Delta{Done: true, Err: ctx.Err()}
+					return
+				}
+			}
+		}
+		if ctx.Err() != nil {
+			ch <- Delta{Done: true, Err: ctx.Err()}
+			return
+		}
+
+		indexes := make([]int, 0, len(calls))
+		for i := range calls {
+			indexes = append(indexes, i)
+		}
+		sort.Ints(indexes)
+		for _, i := range indexes {
+			msg.ToolCalls = append(msg.ToolCalls, *calls[i])
+		}
+		if !done && finish == "" {
+			// The connection dropped mid-reply: use what arrived, as Chat does.
+			if strings.TrimSpace(msg.Content) == "" {
+				ch <- Delta{Done: true, Err: fmt.Errorf("OpenAI API: stream cut off before any content: %v", sc.Err())}
+				return
+			}
+			log.Printf("OpenAI API: stream cut off, using the %d bytes of content received", len(msg.Content))
+			ch <- Delta{Done: true, Response: LLMResponse{Content: strings.TrimSpace(msg.Content), Incomplete: true}}
+			return
+		}
+		resp := p.response(msg, finish)
+		resp.Usage = usage.usage()
+		ch <- Delta{Done: true, Response: resp}
+	}()
+	return ch, nil
+}
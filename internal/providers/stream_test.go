@@ -0,0 +1,100 @@
+package providers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func sseHandler(t *testing.T, events ...string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req chatRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil || !req.Stream {
+			t.Errorf("want a streaming request, got %+v (%v)", req, err)
+		}
+		w.Header().Set("Content-Type", "text/event-stream")
+		for _, e := range events {
+			w.Write([]byte("data: " + e + "\n\n"))
+			w.(http.Flusher).Flush()
+		}
+	}
+}
+
+func TestOpenAIChatStream(t *testing.T) {
+	h := httptest.NewServer(sseHandler(t,
+		`{"choices":[{"delta":{"role":"assistant","content":"Let me "}}]}`,
+		`{"choices":[{"delta":{"content":"check."}}]}`,
+		`{"choices":[{"delta":{"tool_calls":[{"index":0,"id":"call_1","function":{"name":"web","arguments":"{\"url\":"}}]}}]}`,
+		`{"choices":[{"delta":{"tool_calls":[{"index":0,"function":{"arguments":"\"https://wttr.in\"}"}}]}}]}`,
+		`{"choices":[{"delta":{},"finish_reason":"tool_calls"}]}`,
+		`[DONE]`,
+	))
+	defer h.Close()
+
+	p := NewOpenAIProvider("test-key", h.URL, 60, 0)
+	ch, err := p.ChatStream(context.Background(), []Message{{Role: "user", Content: "weather?"}}, []ToolDefinition{{Name: "web"}}, "model-x")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var pieces []string
+	resp, err := CollectStream(ch, func(d Delta) { pieces = append(pieces, d.Content) })
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(pieces) != 2 || pieces[0] != "Let me " || pieces[1] != "check." {
+		t.Fatalf("unexpected deltas %q", pieces)
+	}
+	if resp.Content != "Let me check." || !resp.HasToolCalls || len(resp.ToolCalls) != 1 {
+		t.Fatalf("unexpected response %+v", resp)
+	}
+	if tc := resp.ToolCalls[0]; tc.ID != "call_1" || tc.Name != "web" || tc.Arguments["url"] != "https://wttr.in" {
+		t.Fatalf("tool call fragments not joined: %+v", tc)
+	}
+}
+
+func TestOpenAIChatStreamCutOff(t *testing.T) {
+	h := httptest.NewServer(sseHandler(t,
+		`{"choices":[{"delta":{"content":"The first step"}}]}`,
+		`{"choices":[{"delta":{"content":" is to preheat"}}]}`,
+	))
+	defer h.Close()
+
+	p := NewOpenAIProvider("test-key", h.URL, 60, 0)
+	ch, err := p.ChatStream(context.Background(), []Message{{Role: "user", Content: "recipe?"}}, nil, "model-x")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := CollectStream(ch, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !resp.Incomplete || resp.Content != "The first step is to preheat" {
+		t.Fatalf("want the received content marked incomplete, got %+v", resp)
+	}
+}
+
+func TestOllamaChatStream(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"message":{"role":"assistant","content":"Hel"},"done":false}
+{"message":{"role":"assistant","content":"lo!"},"done":false}
+{"message":{"role":"assistant","content":""},"done":true,"done_reason":"stop"}
+`))
+	}))
+	defer srv.Close()
+
+	p := NewOllamaProvider(srv.URL, "llama3.2", 10, 0)
+	ch, err := p.ChatStream(context.Background(), []Message{{Role: "user", Content: "hi"}}, nil, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got string
+	resp, err := CollectStream(ch, func(d Delta) { got += d.Content + "|" })
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != "Hel|lo!|" || resp.Content != "Hello!" {
+		t.Fatalf("deltas %q, response %+v", got, resp)
+	}
+}
@@ -0,0 +1,93 @@
+package session
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Turn is one message of a session's history.
+type Turn struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+// Turns splits the stored "role: content" history into turns, oldest first.
+func (s *Session) Turns() []Turn {
+	turns := make([]Turn, 0, len(s.History))
+	for _, h := range s.History {
+		role, content, ok := strings.Cut(h, ": ")
+		if !ok {
+			role, content = "", h
+		}
+		turns = append(turns, Turn{Role: role, Content: content})
+	}
+	return turns
+}
+
+var redactions = []struct {
+	re   *regexp.Regexp
+	with string
+}{
+	{regexp.MustCompile(`[A-Za-z0-9._%+-]+@[A-Za-z0-9.-]+\.[A-Za-z]{2,}`), "[email]"},
+	{regexp.MustCompile(`\b(?:sk|pk|ghp|gho|xox[abp])[-_][A-Za-z0-9_-]{10,}`), "[secret]"},
+	{regexp.MustCompile(`\b[A-Za-z0-9_-]{32,}\b`), "[secret]"},
+}
+
+// numberRE matches digit runs with common separators; only those with
+// enough digits to be a phone or account number are redacted.
+var numberRE = regexp.MustCompile(`\+?\d[\d ().-]{6,}\d`)
+
+// Redact masks email addresses, API-key-like tokens and phone or account
+// numbers in text.
+func Redact(text string) string {
+	for _, r := range redactions {
+		text = r.re.ReplaceAllString(text, r.with)
+	}
+	return numberRE.ReplaceAllStringFunc(text, func(m string) string {
+		digits := 0
+		for _, c := range m {
+			if c >= '0' && c <= '9' {
+				digits++
+			}
+		}
+		if digits < 9 {
+			return m
+		}
+		return "[number]"
+	})
+}
+
+// Export renders the session as "markdown" or "json", optionally redacted.
+func Export(s *Session, format string, redact bool) (string, error) {
+	turns := s.Turns()
+	if redact {
+		for i := range turns {
+			turns[i].Content = Redact(turns[i].Content)
+		}
+	}
+	switch format {
+	case "", "markdown":
+		var sb strings.Builder
+		fmt.Fprintf(&sb, "# Transcript: %s\n", s.Key)
+		for _, t := range turns {
+			role := t.Role
+			if role == "" {
+				role = "note"
+			}
+			fmt.Fprintf(&sb, "\n**%s:** %s\n", role, t.Content)
+		}
+		return sb.String(), nil
+	case "json":
+		b, err := json.MarshalIndent(struct {
+			Key   string `json:"key"`
+			Turns []Turn `json:"turns"`
+		}{s.Key, turns}, "", "  ")
+		if err != nil {
+			return "", err
+		}
+		return string(b), nil
+	}
+	return "", fmt.Errorf("unsupported transcript format %q (use markdown or json)", format)
+}
@@ -41,6 +41,19 @@ func (sm *SessionManager) GetOrCreate(key string) *Session {
 	return s
 }
 
+// Known reports whether the conversation key has any history, in memory or
+// saved on disk from an earlier run.
+func (sm *SessionManager) Known(key string) bool {
+	sm.mu.RLock()
+	s, ok := sm.sessions[key]
+	sm.mu.RUnlock()
+	if ok && len(s.History) > 0 {
+		return true
+	}
+	_, err := os.Stat(filepath.Join(sm.workspace, "sessions", key+".json"))
+	return err == nil
+}
+
 func (sm *SessionManager) Save(s *Session) error {
 	sm.mu.Lock()
 	defer sm.mu.Unlock()
@@ -99,3 +112,14 @@ func (s *Session) trim() {
 		s.History = s.History[len(s.History)-MaxHistorySize:]
 	}
 }
+
+// KeepLast drops all but the last n messages, e.g. once older ones have been
+// summarized.
+func (s *Session) KeepLast(n int) {
+	if n < 0 {
+		n = 0
+	}
+	if len(s.History) > n {
+		s.History = append([]string(nil), s.History[len(s.History)-n:]...)
+	}
+}